@@ -1,13 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
-	"os"
-	"strings"
 
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"github.com/joho/godotenv"
+
+	"lib/failure"
+	"lib/operator"
+	"lib/output"
 )
 
 type AccountTokenBalanceResponse struct {
@@ -17,27 +18,38 @@ type AccountTokenBalanceResponse struct {
 }
 
 func main() {
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env")
+	accountOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "06-hcs-topic")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
 	}
 
-	accountIdStr := os.Getenv("ACCOUNT_ID")
-	accountKeyStr := os.Getenv("ACCOUNT_PRIVATE_KEY")
-	accountKeyStr = strings.TrimPrefix(accountKeyStr, "0x")
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*accountOpts, report)
+	report.Finish(err)
+	if err != nil {
+		failure.Exit(err)
+	}
+}
 
-	// Configure client using environment variables
-	accountId, err := hedera.AccountIDFromString(accountIdStr)
+func run(accountOpts operator.Options, report *output.Report) error {
+	// Configure client using flags, environment variables, or the .env file
+	accountConfig, err := operator.Load(accountOpts)
 	if err != nil {
-		log.Fatalf("Error parsing account ID: %v", err)
+		return fmt.Errorf("loading account config: %w", err)
 	}
-	accountKey, err := hedera.PrivateKeyFromStringECDSA(accountKeyStr)
+	report.SetOperator(accountConfig)
+	accountId := accountConfig.AccountID
+	accountKey := accountConfig.PrivateKey
+	client, err := accountConfig.NewClient()
 	if err != nil {
-		log.Fatalf("Error parsing account private key: %v", err)
+		return fmt.Errorf("creating client: %w", err)
 	}
-	client := hedera.ClientForTestnet()
-	client.SetOperator(accountId, accountKey)
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
 
 	// NOTE: Create a topic
 	// Step (1) in the accompanying tutorial
@@ -47,25 +59,27 @@ func main() {
 	topicCreateTx, err := hedera.NewTopicCreateTransaction().
 		FreezeWith(client)
 	if err != nil {
-		log.Fatalf("Error in TopicCreateTransaction: %v\n", err)
+		return fmt.Errorf("freezing TopicCreateTransaction: %w", err)
 	}
 
 	topicCreateTxSigned := topicCreateTx.Sign(accountKey)
 	topicCreateTxResponse, err := topicCreateTxSigned.Execute(client)
 	if err != nil {
-		log.Fatalf("Error executing TopicCreateTransaction: %v\n", err)
+		return fmt.Errorf("executing TopicCreateTransaction: %w", err)
 	}
 
 	topicCreateTxReceipt, err := topicCreateTxResponse.
 		SetValidateStatus(true).
 		GetReceipt(client)
 	if err != nil {
-		log.Fatalf("Error getting receipt for TopicCreateTransaction: %v\n", err)
+		return fmt.Errorf("getting receipt for TopicCreateTransaction: %w", err)
 	}
 
 	topicCreateTxId := topicCreateTxReceipt.TransactionID
 	topicId := topicCreateTxReceipt.TopicID
-	topicExplorerUrl := fmt.Sprintf("https://hashscan.io/testnet/topic/%s", topicId)
+	topicExplorerUrl := accountConfig.Network.ExplorerEntityURL("topic", topicId)
+	report.AddTransaction("topicCreate", topicCreateTxResponse.TransactionID, topicCreateTxReceipt.Status)
+	report.AddEntity("topic", topicId)
 
 	// NOTE: Publish message to topic
 	// Step (2) in the accompanying tutorial
@@ -77,13 +91,13 @@ func main() {
 		SetMessage([]byte("Hello HCS - bguiz")).
 		FreezeWith(client)
 	if err != nil {
-		log.Fatalf("Error in TopicMessageSubmitTransaction: %v\n", err)
+		return fmt.Errorf("freezing TopicMessageSubmitTransaction: %w", err)
 	}
 
 	topicMsgSubmitTxSigned := topicMsgSubmitTx.Sign(accountKey)
 	topicMsgSubmitTxResponse, err := topicMsgSubmitTxSigned.Execute(client)
 	if err != nil {
-		log.Fatalf("Error executing TopicMessageSubmitTransaction: %v\n", err)
+		return fmt.Errorf("executing TopicMessageSubmitTransaction: %w", err)
 	}
 
 	topicMsgSubmitTxId := topicMsgSubmitTxResponse.TransactionID
@@ -91,16 +105,16 @@ func main() {
 		SetValidateStatus(true).
 		GetReceipt(client)
 	if err != nil {
-		log.Fatalf("Error getting receipt for TopicMessageSubmitTransaction: %v\n", err)
+		return fmt.Errorf("getting receipt for TopicMessageSubmitTransaction: %w", err)
 	}
 
-	topicMessageMirrorUrl := fmt.Sprintf(
-		"https://testnet.mirrornode.hedera.com/api/v1/topics/%s/messages/%d",
+	topicMessageMirrorUrl := accountConfig.Network.MirrorNodeAPIURL(
+		"topics/%s/messages/%d",
 		topicId,
 		topicMsgSubmitTxReceipt.TopicSequenceNumber,
 	)
-
-	client.Close()
+	report.AddTransaction("topicMessageSubmit", topicMsgSubmitTxId, topicMsgSubmitTxReceipt.Status)
+	report.Set("topicMessageMirrorUrl", topicMessageMirrorUrl)
 
 	// output results
 	fmt.Printf("accountId: %v\n", accountId)
@@ -109,4 +123,5 @@ func main() {
 	fmt.Printf("topicCreateTxId: %v\n", topicCreateTxId)
 	fmt.Printf("topicMsgSubmitTxId: %v\n", topicMsgSubmitTxId)
 	fmt.Printf("topicMessageMirrorUrl: %v\n", topicMessageMirrorUrl)
+	return nil
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+func accountBalanceCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	account := fs.String("account", "", "account ID (default: the operator account)")
+	return func(ctx context.Context, e *env) error {
+		accountId := e.operator.AccountID
+		if *account != "" {
+			var err error
+			accountId, err = hedera.AccountIDFromString(*account)
+			if err != nil {
+				return flagError("account", err)
+			}
+		}
+		e.report.AddEntity("account", accountId)
+
+		// The balance query is free, so it does not need to be signed
+		accountBalance, err := hedera.NewAccountBalanceQuery().
+			SetAccountID(accountId).
+			Execute(e.client)
+		if err != nil {
+			return fmt.Errorf("executing AccountBalanceQuery: %w", err)
+		}
+		e.report.Set("balance", accountBalance.Hbars.AsTinybar())
+		fmt.Printf("The balance of %s: %s\n", accountId.String(), accountBalance.Hbars.String())
+		return nil
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/auditlog"
+	"lib/chunks"
+	"lib/keys"
+	"lib/mirror"
+	"lib/operator"
+)
+
+func auditAppendCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID of the audit log (required)")
+	record := fs.String("record", "", "JSON document to log (required, unless -file is set)")
+	file := fs.String("file", "", "file holding the JSON document to log")
+	var signerKeys operator.KeyList
+	fs.Var(&signerKeys, "key", "private key of the submit key of the topic, when it is not the operator key, may be repeated")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		if *record != "" && *file != "" {
+			return flagError("file", fmt.Errorf("only one of -record and -file can be set"))
+		}
+		document := []byte(*record)
+		if *file != "" {
+			document, err = os.ReadFile(*file)
+			if err != nil {
+				return flagError("file", err)
+			}
+		}
+		if len(document) == 0 {
+			return requireFlag("record or -file", "")
+		}
+		if !json.Valid(document) {
+			return flagError("record", fmt.Errorf("the record needs to be a JSON document"))
+		}
+		signers := keys.PublicKeys(append([]hedera.PrivateKey{e.operator.PrivateKey}, signerKeys...)...)
+		if err := checkSubmitKey(ctx, e, topicId, signers); err != nil {
+			return err
+		}
+		e.report.AddEntity("topic", topicId)
+
+		// The new entry links to the last entry on the chain, so the chain is rebuilt first
+		index, err := buildAuditLog(ctx, e, topicId)
+		if err != nil {
+			return err
+		}
+		message, head, err := auditlog.Append(index.Head(), json.RawMessage(document))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🟣 Append entry %d to the audit log of topic %s\n", head.Index, topicId.String())
+		topicMsgSubmitTx, err := hedera.NewTopicMessageSubmitTransaction().
+			SetTopicID(topicId).
+			SetMessage(message).
+			SetMaxChunks(uint64(max(chunks.Count(len(message)), chunks.DefaultMaxChunks))).
+			// Freeze the transaction to prepare for signing
+			FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TopicMessageSubmitTransaction: %w", err)
+		}
+		for _, key := range signerKeys {
+			topicMsgSubmitTx.Sign(key)
+		}
+		topicMsgSubmitTxResponses, err := topicMsgSubmitTx.Sign(e.operator.PrivateKey).ExecuteAll(e.client)
+		if err != nil {
+			return fmt.Errorf("executing TopicMessageSubmitTransaction: %w", err)
+		}
+		var topicMsgSeqNum uint64
+		for i, topicMsgSubmitTxSubmitted := range topicMsgSubmitTxResponses {
+			topicMsgSubmitTxReceipt, err := topicMsgSubmitTxSubmitted.GetReceipt(e.client)
+			if err != nil {
+				return fmt.Errorf("getting receipt for TopicMessageSubmitTransaction chunk %d: %w", i+1, err)
+			}
+			e.report.AddTransaction("auditAppend", topicMsgSubmitTxSubmitted.TransactionID, topicMsgSubmitTxReceipt.Status)
+			topicMsgSeqNum = topicMsgSubmitTxReceipt.TopicSequenceNumber
+		}
+		fmt.Printf("Topic Message Sequence Number: %v\n", topicMsgSeqNum)
+		e.report.Set("topicMessageSequenceNumber", topicMsgSeqNum)
+		e.report.Set("head", head)
+
+		// Another writer may have appended an entry after the same head in the meantime,
+		// in which case whichever reached consensus first is on the chain, and the other is a fork
+		topicMessageMirrorNodeApiUrl :=
+			e.mirror.URL(fmt.Sprintf("topics/%s/messages/%d", topicId.String(), topicMsgSeqNum), nil)
+		topicMessage, err := e.mirror.WaitForTopicMessage(ctx, mirror.DefaultBackoff, topicId.String(), topicMsgSeqNum)
+		e.report.AddMirrorVerification("topicMessage", topicMessageMirrorNodeApiUrl, topicMessage, err)
+		if err != nil {
+			return fmt.Errorf("finding topic message on the mirror node: %w", err)
+		}
+		index, err = buildAuditLog(ctx, e, topicId)
+		if err != nil {
+			return err
+		}
+		if int(head.Index) >= len(index.Chain) || !bytes.Equal(index.Chain[head.Index].Hash, head.Hash) {
+			return fmt.Errorf("entry %d at #%d is not on the chain, another entry was appended after the same entry first", head.Index, topicMsgSeqNum)
+		}
+		fmt.Printf("The mirror node shows entry %d on the chain\n", head.Index)
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func auditVerifyCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID of the audit log (required)")
+	prove := fs.Uint64("prove", 0, "sequence number of an entry to prove is in the audit log")
+	proofOut := fs.String("proof-out", "", "file to write the proof to, with -prove (default: proof-<topic>-<sequence number>.json)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		e.report.AddEntity("topic", topicId)
+		index, err := buildAuditLog(ctx, e, topicId)
+		if err != nil {
+			return err
+		}
+		for _, record := range index.Chain {
+			fmt.Printf("#%d entry %d: %s\n", record.SequenceNumber, record.Index, record.Record)
+		}
+		if *prove > 0 {
+			proof, err := index.Prove(*prove)
+			if err != nil {
+				return flagError("prove", err)
+			}
+			if err := proof.Verify(); err != nil {
+				return err
+			}
+			if *proofOut == "" {
+				*proofOut = fmt.Sprintf("proof-%s-%d.json", topicId.String(), *prove)
+			}
+			if err := auditlog.WriteProof(*proofOut, proof); err != nil {
+				return flagError("proof-out", err)
+			}
+			e.report.Set("proof", *proofOut)
+			fmt.Printf("The proof of the entry at #%d, with a path of %d hashes to the root, has been written to: %s\n", *prove, len(proof.Path), *proofOut)
+		}
+		if len(index.Problems) > 0 {
+			return fmt.Errorf("the audit log of topic %s has %d problems", topicId.String(), len(index.Problems))
+		}
+		fmt.Println("Every entry on the topic is on the chain")
+		return nil
+	}
+}
+
+func auditCheckProofCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	proofFile := fs.String("proof", "", "proof file, from `audit verify -prove` (required)")
+	root := fs.String("root", "", "root that the proof needs to lead to, e.g. from another index of the audit log (default: the root in the proof)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("proof", *proofFile); err != nil {
+			return err
+		}
+		proof, err := auditlog.ReadProof(*proofFile)
+		if err != nil {
+			return flagError("proof", err)
+		}
+		if *root != "" {
+			var expected auditlog.Hash
+			if err := expected.UnmarshalText([]byte(*root)); err != nil {
+				return flagError("root", err)
+			}
+			if !bytes.Equal(expected, proof.Root) {
+				return flagError("root", fmt.Errorf("the proof is for root %s", proof.Root))
+			}
+		}
+		entry, err := proof.Entry()
+		if err != nil {
+			return flagError("proof", err)
+		}
+		fmt.Printf("🟣 Check the proof of entry %d of the audit log of topic %s\n", entry.Index, proof.TopicID)
+		fmt.Printf("#%d entry %d: %s\n", proof.SequenceNumber, entry.Index, entry.Record)
+		if err := proof.Verify(); err != nil {
+			return err
+		}
+		fmt.Printf("The entry is leaf %d of the %d leaves under root %s\n", proof.LeafIndex, proof.TreeSize, proof.Root)
+
+		topicMessageMirrorNodeApiUrl :=
+			e.mirror.URL(fmt.Sprintf("topics/%s/messages/%d", proof.TopicID, proof.SequenceNumber), nil)
+		err = proof.VerifyOnMirror(ctx, e.mirror)
+		e.report.AddMirrorVerification("topicMessage", topicMessageMirrorNodeApiUrl, proof.ConsensusTimestamp, err)
+		if err != nil {
+			return fmt.Errorf("verifying the entry on the mirror node: %w", err)
+		}
+		fmt.Printf("The mirror node shows the entry at #%d, at consensus timestamp %s, with running hash %s\n",
+			proof.SequenceNumber, proof.ConsensusTimestamp, proof.RunningHash)
+		e.report.Set("record", entry.Record)
+		return nil
+	}
+}
+
+// buildAuditLog rebuilds the audit log of a topic from the mirror node, and reports it, with any problems
+func buildAuditLog(ctx context.Context, e *env, topicId hedera.TopicID) (*auditlog.Index, error) {
+	fmt.Printf("🟣 Rebuild the audit log of topic %s from the Hedera Mirror Node\n", topicId.String())
+	index, err := auditlog.Build(ctx, e.mirror, topicId.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range index.Problems {
+		fmt.Printf("⚠️ #%d: %s: %s\n", problem.SequenceNumber, problem.Kind, problem.Detail)
+	}
+	if err := index.Incomplete(); err != nil {
+		fmt.Printf("⚠️ %v\n", err)
+	}
+	type auditLog struct {
+		Entries               int                `json:"entries"`
+		Head                  *auditlog.Head     `json:"head"`
+		Root                  auditlog.Hash      `json:"root"`
+		Problems              []auditlog.Problem `json:"problems"`
+		Skipped               int                `json:"skipped"`
+		RunningHashesVerified int                `json:"runningHashesVerified"`
+	}
+	e.report.Set("auditLog", auditLog{
+		Entries:               len(index.Chain),
+		Head:                  index.Head(),
+		Root:                  index.Root(),
+		Problems:              append([]auditlog.Problem{}, index.Problems...),
+		Skipped:               index.Skipped,
+		RunningHashesVerified: index.RunningHashesVerified,
+	})
+	fmt.Printf("The chain has %d entries, with root %s, and %d running hashes verified\n",
+		len(index.Chain), index.Root(), index.RunningHashesVerified)
+	return index, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/contract"
+	"lib/failure"
+)
+
+func contractDeployCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	bin := fs.String("bin", "", "path of the compiled contract bytecode, in hex (required)")
+	abiPath := fs.String("abi", "", "path of the compiled contract ABI, needed for constructor arguments")
+	var args listFlag
+	fs.Var(&args, "arg", "constructor argument, may be repeated, in order")
+	gas := fs.Int64("gas", 500_000, "maximum gas for the constructor")
+	memo := fs.String("memo", "", "contract memo")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("bin", *bin); err != nil {
+			return err
+		}
+		bytecodeFile, err := os.ReadFile(*bin)
+		if err != nil {
+			return flagError("bin", err)
+		}
+		// The file service stores the bytecode as hex text, which is what solc outputs
+		bytecode := []byte(strings.TrimSpace(string(bytecodeFile)))
+
+		var constructorParams []byte
+		if len(args) > 0 || *abiPath != "" {
+			contractABI, err := loadABI(*abiPath)
+			if err != nil {
+				return err
+			}
+			values, err := contractABI.ParseConstructorArgs(args)
+			if err != nil {
+				return flagError("arg", err)
+			}
+			constructorParams, err = contractABI.EncodeConstructor(values...)
+			if err != nil {
+				return flagError("arg", err)
+			}
+		}
+
+		fmt.Println("🟣 Deploying smart contract")
+		// ContractCreateFlow uploads the bytecode to a file, in chunks,
+		// then creates the contract from that file
+		contractCreateTxSubmitted, err := hedera.NewContractCreateFlow().
+			SetBytecode(bytecode).
+			SetMaxChunks(40).
+			SetGas(*gas).
+			SetConstructorParametersRaw(constructorParams).
+			SetContractMemo(*memo).
+			Execute(e.client)
+		if err != nil {
+			return fmt.Errorf("executing ContractCreateFlow: %w", err)
+		}
+		contractCreateTxReceipt, err := contractCreateTxSubmitted.GetReceipt(e.client)
+		if err != nil {
+			return fmt.Errorf("getting receipt for ContractCreateTransaction: %w", err)
+		}
+		e.report.AddTransaction("contractCreate", contractCreateTxSubmitted.TransactionID, contractCreateTxReceipt.Status)
+
+		contractId := *contractCreateTxReceipt.ContractID
+		e.report.AddEntity("contract", contractId)
+		fmt.Printf("The deployed contract ID: %s\n", contractId.String())
+		fmt.Printf("The deployed contract EVM address: 0x%s\n", contractId.ToSolidityAddress())
+		fmt.Printf("Contract Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("contract", contractId))
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func contractCallCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	contractIdStr := fs.String("contract", "", "contract ID (required)")
+	abiPath := fs.String("abi", "", "path of the compiled contract ABI (required)")
+	function := fs.String("function", "", "name of the function to call (required)")
+	var args listFlag
+	fs.Var(&args, "arg", "function argument, may be repeated, in order")
+	gas := fs.Uint64("gas", 100_000, "maximum gas for the function call")
+	query := fs.Bool("query", false, "call a view function with a ContractCallQuery, instead of a transaction")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("contract", *contractIdStr); err != nil {
+			return err
+		}
+		if err := requireFlag("function", *function); err != nil {
+			return err
+		}
+		contractId, err := hedera.ContractIDFromString(*contractIdStr)
+		if err != nil {
+			return flagError("contract", err)
+		}
+		e.report.AddEntity("contract", contractId)
+		contractABI, err := loadABI(*abiPath)
+		if err != nil {
+			return err
+		}
+		values, err := contractABI.ParseArgs(*function, args)
+		if err != nil {
+			return flagError("arg", err)
+		}
+		params, err := contractABI.EncodeCall(*function, values...)
+		if err != nil {
+			return flagError("arg", err)
+		}
+
+		var result hedera.ContractFunctionResult
+		if *query {
+			fmt.Printf("🟣 Querying the %s function of the smart contract\n", *function)
+			result, err = hedera.NewContractCallQuery().
+				SetContractID(contractId).
+				SetGas(*gas).
+				SetFunctionParameters(params).
+				Execute(e.client)
+			if err != nil {
+				return fmt.Errorf("executing ContractCallQuery: %w", err)
+			}
+		} else {
+			fmt.Printf("🟣 Calling the %s function of the smart contract\n", *function)
+			result, err = executeContract(e, contractId, *gas, params)
+			if err != nil {
+				return err
+			}
+		}
+
+		outputs, err := contractABI.DecodeResult(*function, result.ContractCallResult)
+		if err != nil {
+			return err
+		}
+		e.report.Set("result", outputs)
+		for i, value := range outputs {
+			fmt.Printf("Result %d: %v\n", i, value)
+		}
+		events := []*contract.Event{}
+		for _, log := range result.LogInfo {
+			event, err := contractABI.DecodeSDKLog(log)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Event %s: %v\n", event.Name, event.Args)
+			events = append(events, event)
+		}
+		e.report.Set("events", events)
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+// executeContract calls a state changing function,
+// and returns its result from the transaction record
+func executeContract(e *env, contractId hedera.ContractID, gas uint64, params []byte) (hedera.ContractFunctionResult, error) {
+	contractExecuteTx, err := hedera.NewContractExecuteTransaction().
+		SetContractID(contractId).
+		SetGas(gas).
+		SetFunctionParameters(params).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(e.client)
+	if err != nil {
+		return hedera.ContractFunctionResult{}, fmt.Errorf("freezing ContractExecuteTransaction: %w", err)
+	}
+	contractExecuteTxId := contractExecuteTx.GetTransactionID()
+	fmt.Printf("The contract execute transaction ID: %s\n", contractExecuteTxId.String())
+
+	// Sign with the operator key, submit, and wait for the receipt
+	contractExecuteTxSubmitted, err := contractExecuteTx.Sign(e.operator.PrivateKey).Execute(e.client)
+	if err != nil {
+		return hedera.ContractFunctionResult{}, fmt.Errorf("executing ContractExecuteTransaction: %w", err)
+	}
+	contractExecuteTxReceipt, err := contractExecuteTxSubmitted.GetReceipt(e.client)
+	if err != nil {
+		return hedera.ContractFunctionResult{}, fmt.Errorf("getting receipt for ContractExecuteTransaction: %w", err)
+	}
+	e.report.AddTransaction("contractExecute", contractExecuteTxId, contractExecuteTxReceipt.Status)
+
+	// The return values and events are in the record, which is a paid query
+	contractExecuteTxRecord, err := contractExecuteTxSubmitted.GetRecord(e.client)
+	if err != nil {
+		return hedera.ContractFunctionResult{}, fmt.Errorf("getting record for ContractExecuteTransaction: %w", err)
+	}
+	result, err := contractExecuteTxRecord.GetContractExecuteResult()
+	if err != nil {
+		return hedera.ContractFunctionResult{}, fmt.Errorf("getting result of ContractExecuteTransaction: %w", err)
+	}
+	return result, nil
+}
+
+func loadABI(path string) (*contract.ABI, error) {
+	if err := requireFlag("abi", path); err != nil {
+		return nil, err
+	}
+	contractABI, err := contract.Load(path)
+	if err != nil {
+		return nil, failure.Wrap(failure.KindConfig, err)
+	}
+	return contractABI, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/operator"
+	"lib/signers"
+	"lib/transferlist"
+)
+
+func hbarTransferCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	var to, transfers transferlist.List
+	fs.Var(&to, "to", "credit from the operator account, as `0.0.x=amount`, may be repeated")
+	fs.Var(&transfers, "transfer", "debit or credit, as `0.0.x=amount`, in any unit, e.g. 0.0.200=-1.5ℏ, may be repeated")
+	file := fs.String("file", "", "path of a .csv or .json file with debits and credits")
+	var keys operator.KeyList
+	fs.Var(&keys, "key", "private key of a debited account that is not configured in .env, may be repeated")
+	memo := fs.String("memo", "", "transaction memo")
+	freeze := registerFreezeFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		transferList := append(transferlist.List{}, transfers...)
+		if *file != "" {
+			fileTransfers, err := transferlist.ReadFile(*file)
+			if err != nil {
+				return flagError("file", err)
+			}
+			transferList = append(transferList, fileTransfers...)
+		}
+		if len(to) > 0 {
+			// The operator account is debited the total of the credits
+			total, err := to.Total()
+			if err != nil {
+				return flagError("to", err)
+			}
+			transferList = append(transferList, to...)
+			transferList = append(transferList, transferlist.Entry{
+				AccountID: e.operator.AccountID,
+				Amount:    total.Negated(),
+			})
+		}
+		if len(transferList) == 0 {
+			return requireFlag("to, -transfer, or -file", "")
+		}
+		// Validate before freezing, and split lists that are too long for a single transaction
+		batches, err := transferList.Batches(transferlist.MaxTransfers)
+		if err != nil {
+			return flagError("transfer", err)
+		}
+		if freeze.enabled() && len(batches) > 1 {
+			return flagError("freeze-to", fmt.Errorf("the transfer list needs %d transactions, only one can be frozen to a file", len(batches)))
+		}
+
+		// The operator account and the ACCOUNT_0, ACCOUNT_1, ... accounts from the .env file
+		// sign for the accounts in the transfer list that they debit
+		keyring := signers.New(e.operator, keys...)
+		for i, batch := range batches {
+			name := "transfer"
+			if len(batches) > 1 {
+				name = fmt.Sprintf("transfer%d", i+1)
+			}
+			if err := submitTransfer(ctx, e, name, batch, keyring, *memo, freeze); err != nil {
+				return err
+			}
+		}
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+// submitTransfer submits one batch of a transfer list, which nets to zero
+func submitTransfer(ctx context.Context, e *env, name string, batch transferlist.List, keyring *signers.Keyring, memo string, freeze *freezeFlags) error {
+	if freeze.enabled() {
+		fmt.Printf("🟣 Creating and freezing the %s transaction\n", name)
+	} else {
+		fmt.Printf("🟣 Creating, signing, and submitting the %s transaction\n", name)
+	}
+	for _, entry := range batch {
+		fmt.Printf("  %-15s %s\n", entry.AccountID.String(), entry.Amount.String())
+	}
+	transferTxId, err := freeze.transactionID(e)
+	if err != nil {
+		return err
+	}
+	transferTx, err := batch.Transaction().
+		SetTransactionID(transferTxId).
+		SetTransactionValidDuration(*freeze.validDuration).
+		SetTransactionMemo(memo).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(e.client)
+	if err != nil {
+		return fmt.Errorf("freezing TransferTransaction: %w", err)
+	}
+	fmt.Printf("The transfer transaction ID: %s\n", transferTxId.String())
+	if freeze.enabled() {
+		// Every debited account signs the file, including the operator account
+		fmt.Printf("Required signers: %s\n", accountList(append([]hedera.AccountID{*transferTxId.AccountID}, batch.Debited()...)))
+		return freeze.export(e, transferTx)
+	}
+
+	// Sign with the fee payer (operator account) and the accounts that are being debited,
+	// submit, and wait for the receipt
+	requiredSigners := append([]hedera.AccountID{e.operator.AccountID}, batch.Debited()...)
+	signingKeys, missingSigners := keyring.Keys(requiredSigners)
+	for _, accountId := range missingSigners {
+		fmt.Printf("⚠️ No key configured for the debited account %s, the transaction may fail with INVALID_SIGNATURE\n", accountId.String())
+	}
+	for _, key := range signingKeys {
+		transferTx = transferTx.Sign(key)
+	}
+	transferTxSubmitted, err := transferTx.Execute(e.client)
+	if err != nil {
+		err = keyring.Diagnose(ctx, e.mirror, err, requiredSigners)
+		return fmt.Errorf("executing TransferTransaction: %w", err)
+	}
+	transferTxReceipt, err := transferTxSubmitted.GetReceipt(e.client)
+	if err != nil {
+		err = keyring.Diagnose(ctx, e.mirror, err, requiredSigners)
+		return fmt.Errorf("getting receipt for TransferTransaction: %w", err)
+	}
+	e.report.AddTransaction(name, transferTxId, transferTxReceipt.Status)
+	fmt.Printf("The transfer transaction status is: %s\n", transferTxReceipt.Status.String())
+	fmt.Printf("Transaction Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("transaction", transferTxId))
+	return nil
+}
+
+// accountList formats account IDs for display, without repeats
+func accountList(accounts []hedera.AccountID) string {
+	// String leaves out the checksum, so 0.0.5 and 0.0.5-vfmkw are shown once
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, accountId := range accounts {
+		if id := accountId.String(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,201 @@
+// Command hfw runs the Hello Future World flows from a single binary,
+// with the values that the scripts hardcode taken from flags instead.
+//
+// Usage:
+//
+//	hfw <group> <command> [flags]
+//
+// Run `hfw help` for the list of commands,
+// and `hfw <group> <command> -h` for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/failure"
+	"lib/mirror"
+	"lib/operator"
+	"lib/output"
+)
+
+// command is a single subcommand, e.g. `topic create`
+type command struct {
+	group   string
+	name    string
+	summary string
+	// local commands run without the operator account and the network,
+	// e.g. to sign a transaction file on an air-gapped machine
+	local bool
+	// setup registers the flags of the command on fs,
+	// and returns the function that runs it once the flags are parsed
+	setup func(fs *flag.FlagSet) func(ctx context.Context, e *env) error
+}
+
+// commands are listed in the order that they are shown in the usage
+var commands = []command{
+	{"topic", "create", "Create an HCS topic", false, topicCreateCommand},
+	{"topic", "submit", "Submit a message to an HCS topic", false, topicSubmitCommand},
+	{"topic", "read", "Read the messages of an HCS topic from the mirror node", false, topicReadCommand},
+	{"topic", "subscribe", "Stream the messages of an HCS topic from the mirror node as they arrive", false, topicSubscribeCommand},
+	{"topic", "consume", "Process the messages of an HCS topic in order, once each, continuing from a checkpoint", false, topicConsumeCommand},
+	{"topic", "update", "Update the memo, keys, or auto renewal of an HCS topic with the admin key", false, topicUpdateCommand},
+	{"topic", "delete", "Delete an HCS topic with the admin key", false, topicDeleteCommand},
+	{"audit", "append", "Append a record to the hash-chained audit log on an HCS topic", false, auditAppendCommand},
+	{"audit", "verify", "Rebuild and verify the audit log of an HCS topic, and prove that an entry is in it", false, auditVerifyCommand},
+	{"audit", "check-proof", "Check a proof that an entry is in an audit log, and on the mirror node", false, auditCheckProofCommand},
+	{"token", "create", "Create an HTS fungible token", false, tokenCreateCommand},
+	{"token", "info", "Show an HTS token from the mirror node", false, tokenInfoCommand},
+	{"token", "associate", "Associate an account with an HTS token", false, tokenAssociateCommand},
+	{"token", "dissociate", "Dissociate an account from an HTS token", false, tokenDissociateCommand},
+	{"token", "transfer", "Transfer an HTS fungible token between accounts", false, tokenTransferCommand},
+	{"token", "mint", "Mint tokens to the treasury with the supply key", false, tokenMintCommand},
+	{"token", "burn", "Burn tokens from the treasury with the supply key", false, tokenBurnCommand},
+	{"token", "freeze", "Freeze an account for a token with the freeze key", false, tokenFreezeCommand},
+	{"token", "unfreeze", "Unfreeze an account for a token with the freeze key", false, tokenUnfreezeCommand},
+	{"token", "grant-kyc", "Grant KYC to an account with the KYC key", false, tokenGrantKycCommand},
+	{"token", "revoke-kyc", "Revoke KYC from an account with the KYC key", false, tokenRevokeKycCommand},
+	{"token", "wipe", "Wipe tokens from an account with the wipe key", false, tokenWipeCommand},
+	{"token", "pause", "Pause a token with the pause key", false, tokenPauseCommand},
+	{"token", "unpause", "Unpause a token with the pause key", false, tokenUnpauseCommand},
+	{"token", "update", "Update the name, symbol, or memo of a token with the admin key", false, tokenUpdateCommand},
+	{"token", "delete", "Delete a token with the admin key", false, tokenDeleteCommand},
+	{"token", "fee-schedule", "Replace the custom fees of a token with the fee schedule key", false, tokenFeeScheduleCommand},
+	{"nft", "create", "Create an HTS NFT collection", false, nftCreateCommand},
+	{"nft", "mint", "Mint NFT serials with HIP-412 metadata URIs", false, nftMintCommand},
+	{"nft", "transfer", "Transfer NFT serials between accounts", false, nftTransferCommand},
+	{"nft", "list", "List the serials of an NFT collection from the mirror node", false, nftListCommand},
+	{"hbar", "transfer", "Transfer HBAR from the operator account", false, hbarTransferCommand},
+	{"contract", "deploy", "Deploy a compiled smart contract", false, contractDeployCommand},
+	{"contract", "call", "Call a smart contract function", false, contractCallCommand},
+	{"account", "balance", "Show the HBAR balance of an account", false, accountBalanceCommand},
+	{"tx", "show", "Show a frozen transaction file, and who has signed it", true, txShowCommand},
+	{"tx", "sign", "Sign a frozen transaction file, without network access", true, txSignCommand},
+	{"tx", "merge", "Merge copies of a transaction file signed by different keys", true, txMergeCommand},
+	{"tx", "submit", "Submit a signed transaction file, and wait for the receipt", false, txSubmitCommand},
+}
+
+// env holds what every command needs, built from the common flags
+type env struct {
+	operator *operator.Config
+	client   *hedera.Client
+	mirror   *mirror.Client
+	report   *output.Report
+}
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage()
+		if len(os.Args) < 2 || os.Args[1] != "help" {
+			os.Exit(failure.ExitConfig)
+		}
+		return
+	}
+	cmd, ok := lookupCommand(os.Args[1], os.Args[2])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s %s\n\n", os.Args[1], os.Args[2])
+		usage()
+		os.Exit(failure.ExitConfig)
+	}
+
+	fs := flag.NewFlagSet("hfw "+cmd.group+" "+cmd.name, flag.ExitOnError)
+	operatorOpts := operator.RegisterFlags(fs)
+	report := output.RegisterFlags(fs, cmd.group+" "+cmd.name)
+	run := cmd.setup(fs)
+	// ExitOnError exits with the config exit code on invalid flags
+	_ = fs.Parse(os.Args[3:])
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	var err error
+	if cmd.local {
+		err = run(context.Background(), &env{report: report})
+	} else {
+		err = execute(*operatorOpts, report, run)
+	}
+	report.Finish(err)
+	if err != nil {
+		failure.Exit(err)
+	}
+}
+
+// execute loads the operator account, creates the clients, and runs the command
+func execute(operatorOpts operator.Options, report *output.Report, run func(ctx context.Context, e *env) error) error {
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	defer client.Close()
+
+	return run(context.Background(), &env{
+		operator: operatorConfig,
+		client:   client,
+		mirror:   mirror.NewClient(operatorConfig.Network.MirrorNodeURL),
+		report:   report,
+	})
+}
+
+func lookupCommand(group string, name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.group == group && cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: hfw <group> <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", cmd.group+" "+cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Every command accepts the operator flags, -network, and -output.")
+	fmt.Fprintln(os.Stderr, "Run `hfw <group> <command> -h` for the flags of a command.")
+}
+
+// requireFlag returns a config error when a required flag has not been set
+func requireFlag(name string, value string) error {
+	if value == "" {
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("-%s is required", name))
+	}
+	return nil
+}
+
+// flagError classifies an invalid flag value as a config error
+func flagError(name string, err error) error {
+	return failure.Wrap(failure.KindConfig, fmt.Errorf("-%s: %w", name, err))
+}
+
+// listFlag is a flag that may be repeated, collecting every value
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	if value == "" {
+		return errors.New("empty value")
+	}
+	*l = append(*l, value)
+	return nil
+}
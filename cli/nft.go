@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/fees"
+	"lib/mirror"
+	"lib/nft"
+)
+
+func nftCreateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	name := fs.String("name", "", "collection name (required)")
+	symbol := fs.String("symbol", "", "collection symbol (required)")
+	maxSupply := fs.Int64("max-supply", 0, "maximum number of serials (default: infinite)")
+	memo := fs.String("memo", "", "token memo")
+	var feeFlags listFlag
+	fs.Var(&feeFlags, "fee", feeFlagUsage)
+	withFeeScheduleKey := fs.Bool("with-fee-schedule-key", false, "set the fee schedule key to the operator key, so that the custom fees can be updated")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("name", *name); err != nil {
+			return err
+		}
+		if err := requireFlag("symbol", *symbol); err != nil {
+			return err
+		}
+		if *maxSupply < 0 {
+			return flagError("max-supply", fmt.Errorf("must not be negative, got %d", *maxSupply))
+		}
+		customFees, err := fees.ParseList(feeFlags, e.operator.AccountID, hedera.TokenTypeNonFungibleUnique, nil)
+		if err != nil {
+			return flagError("fee", err)
+		}
+
+		fmt.Println("🟣 Creating new HTS NFT collection")
+		tokenCreateTx := hedera.NewTokenCreateTransaction().
+			// HTS `TokenType.NonFungibleUnique` behaves similarly to ERC721
+			SetTokenType(hedera.TokenTypeNonFungibleUnique).
+			SetTokenName(*name).
+			SetTokenSymbol(*symbol).
+			SetTokenMemo(*memo).
+			SetDecimals(0).
+			SetInitialSupply(0).
+			// The operator account is the treasury, which receives the minted serials
+			SetTreasuryAccountID(e.operator.AccountID).
+			// The supply key (operator key) mints the serials
+			SetSupplyKey(e.operator.PrivateKey.PublicKey()).
+			SetCustomFees(customFees)
+		if *maxSupply > 0 {
+			tokenCreateTx.SetSupplyType(hedera.TokenSupplyTypeFinite).SetMaxSupply(*maxSupply)
+		}
+		if *withFeeScheduleKey {
+			tokenCreateTx.SetFeeScheduleKey(e.operator.PrivateKey.PublicKey())
+		}
+		tokenCreateTx, err = tokenCreateTx.FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TokenCreateTransaction: %w", err)
+		}
+		tokenCreateTxId := tokenCreateTx.GetTransactionID()
+		fmt.Printf("The token create transaction ID: %s\n", tokenCreateTxId.String())
+
+		tokenCreateTxSubmitted, err := tokenCreateTx.Sign(e.operator.PrivateKey).Execute(e.client)
+		if err != nil {
+			return fmt.Errorf("executing TokenCreateTransaction: %w", err)
+		}
+		tokenCreateTxReceipt, err := tokenCreateTxSubmitted.GetReceipt(e.client)
+		if err != nil {
+			return fmt.Errorf("getting receipt for TokenCreateTransaction: %w", err)
+		}
+		e.report.AddTransaction("tokenCreate", tokenCreateTxId, tokenCreateTxReceipt.Status)
+
+		tokenId := *tokenCreateTxReceipt.TokenID
+		e.report.AddEntity("token", tokenId)
+		fmt.Printf("Token ID: %s\n", tokenId.String())
+		fmt.Printf("Token Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("token", tokenId))
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func nftMintCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	var uris listFlag
+	fs.Var(&uris, "metadata", "metadata URI of a serial, e.g. ipfs://<cid>/1.json pointing at a HIP-412 document, may be repeated")
+	baseURI := fs.String("metadata-base-uri", "", "URI of an uploaded directory of HIP-412 documents, named 1.json, 2.json, and so on")
+	count := fs.Int("count", 0, "number of serials to mint with -metadata-base-uri")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		metadataURIs := append([]string{}, uris...)
+		if *baseURI != "" {
+			if *count <= 0 {
+				return flagError("count", fmt.Errorf("must be positive with -metadata-base-uri, got %d", *count))
+			}
+			metadataURIs = append(metadataURIs, nft.MetadataURIs(*baseURI, *count)...)
+		}
+		if len(metadataURIs) == 0 {
+			return requireFlag("metadata or -metadata-base-uri", "")
+		}
+		metadata, err := nft.Metadata(metadataURIs)
+		if err != nil {
+			return flagError("metadata", err)
+		}
+
+		// A single transaction mints at most nft.MaxMintBatch serials
+		serials := []int64{}
+		for i, batch := range nft.Batches(metadata, nft.MaxMintBatch) {
+			fmt.Printf("🟣 Minting %d serials with the supply key\n", len(batch))
+			tokenMintTx, err := hedera.NewTokenMintTransaction().
+				SetTokenID(tokenId).
+				SetMetadatas(batch).
+				FreezeWith(e.client)
+			if err != nil {
+				return fmt.Errorf("freezing TokenMintTransaction: %w", err)
+			}
+			tokenMintTxId := tokenMintTx.GetTransactionID()
+			fmt.Printf("The token mint transaction ID: %s\n", tokenMintTxId.String())
+			tokenMintTxSubmitted, err := tokenMintTx.Sign(e.operator.PrivateKey).Execute(e.client)
+			if err != nil {
+				return fmt.Errorf("executing TokenMintTransaction: %w", err)
+			}
+			tokenMintTxReceipt, err := tokenMintTxSubmitted.GetReceipt(e.client)
+			if err != nil {
+				return fmt.Errorf("getting receipt for TokenMintTransaction: %w", err)
+			}
+			e.report.AddTransaction(fmt.Sprintf("tokenMint%d", i+1), tokenMintTxId, tokenMintTxReceipt.Status)
+			fmt.Printf("The minted serials: %v\n", tokenMintTxReceipt.SerialNumbers)
+			serials = append(serials, tokenMintTxReceipt.SerialNumbers...)
+		}
+		e.report.Set("serials", serials)
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func nftTransferCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	var serialFlags listFlag
+	fs.Var(&serialFlags, "serial", "serial number to transfer, may be repeated (required)")
+	from := fs.String("from", "", "owner of the serials, which signs with -key (default: the operator account)")
+	to := fs.String("to", "", "account to transfer the serials to, which has to be associated with the token (required)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if len(serialFlags) == 0 {
+			return requireFlag("serial", "")
+		}
+		if err := requireFlag("to", *to); err != nil {
+			return err
+		}
+		serials := make([]int64, len(serialFlags))
+		for i, serialFlag := range serialFlags {
+			serials[i], err = strconv.ParseInt(serialFlag, 10, 64)
+			if err != nil || serials[i] <= 0 {
+				return flagError("serial", fmt.Errorf("invalid serial number %q", serialFlag))
+			}
+		}
+		fromId, err := accountFlag(e, "from", *from)
+		if err != nil {
+			return err
+		}
+		toId, err := accountFlag(e, "to", *to)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🟣 Transferring serials %v to %s\n", serials, toId.String())
+		nftTransferTx := hedera.NewTransferTransaction()
+		for _, serial := range serials {
+			nftTransferTx.AddNftTransfer(hedera.NftID{TokenID: tokenId, SerialNumber: serial}, fromId, toId)
+		}
+		nftTransferTx, err = nftTransferTx.FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TransferTransaction: %w", err)
+		}
+		if err := submitSigned(e, "nftTransfer", nftTransferTx, tf.keys); err != nil {
+			return err
+		}
+
+		fmt.Println("🟣 Verifying the new owner on the Hedera Mirror Node")
+		for _, serial := range serials {
+			nftMirrorNodeApiUrl := e.mirror.URL(fmt.Sprintf("tokens/%s/nfts/%d", tokenId.String(), serial), nil)
+			nftResp, err := e.mirror.WaitForNFTOwner(ctx, mirror.DefaultBackoff, tokenId.String(), serial, toId.String())
+			e.report.AddMirrorVerification(fmt.Sprintf("nft%d", serial), nftMirrorNodeApiUrl, nftResp, err)
+			if err != nil {
+				return fmt.Errorf("verifying NFT transfer: %w", err)
+			}
+			fmt.Printf("Serial #%d is owned by %s\n", serial, nftResp.AccountID)
+		}
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func nftListCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	token := fs.String("token", "", "token ID (required)")
+	account := fs.String("account", "", "only list the serials owned by this account")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("token", *token); err != nil {
+			return err
+		}
+		tokenId, err := hedera.TokenIDFromString(*token)
+		if err != nil {
+			return flagError("token", err)
+		}
+		e.report.AddEntity("token", tokenId)
+		opts := mirror.ListOptions{Limit: 100, Order: mirror.OrderAsc}
+		if *account != "" {
+			accountId, err := hedera.AccountIDFromString(*account)
+			if err != nil {
+				return flagError("account", err)
+			}
+			opts.AccountID = []mirror.Condition{mirror.Eq(accountId.String())}
+		}
+
+		fmt.Println("🟣 Get the collection's NFTs from the Hedera Mirror Node")
+		nftsMirrorNodeApiUrl := e.mirror.URL(fmt.Sprintf("tokens/%s/nfts", tokenId.String()), opts.Query())
+		fmt.Printf("The NFTs Hedera Mirror Node API URL: %s\n", nftsMirrorNodeApiUrl)
+
+		type nftEntry struct {
+			SerialNumber int64  `json:"serialNumber"`
+			Owner        string `json:"owner"`
+			Metadata     string `json:"metadata"`
+			Deleted      bool   `json:"deleted"`
+		}
+		nfts := []nftEntry{}
+		fmt.Printf("%-8s %-15s %s\n", "Serial", "Owner", "Metadata")
+		fmt.Println(strings.Repeat("-", 60))
+		err = e.mirror.EachTokenNFT(ctx, tokenId.String(), opts, func(entry mirror.NFT) error {
+			metadata, err := entry.Contents()
+			if err != nil {
+				return fmt.Errorf("decoding metadata of serial #%d: %w", entry.SerialNumber, err)
+			}
+			fmt.Printf("%-8d %-15s %s\n", entry.SerialNumber, entry.AccountID, metadata)
+			nfts = append(nfts, nftEntry{
+				SerialNumber: entry.SerialNumber,
+				Owner:        entry.AccountID,
+				Metadata:     string(metadata),
+				Deleted:      entry.Deleted,
+			})
+			return nil
+		})
+		e.report.AddMirrorVerification("nfts", nftsMirrorNodeApiUrl, nfts, err)
+		if err != nil {
+			return fmt.Errorf("fetching NFTs: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,799 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/fees"
+	"lib/mirror"
+	"lib/operator"
+	"lib/txid"
+)
+
+// tokenKeyRoles are the token keys that `token create -with-keys` can set to the operator key
+var tokenKeyRoles = []string{"admin", "supply", "freeze", "kyc", "wipe", "pause", "fee-schedule"}
+
+// feeFlagUsage describes the -fee flag of the commands that set custom fees
+const feeFlagUsage = "custom fee, collected by the operator account unless it sets collector=0.0.x, may be repeated, e.g. " +
+	"fixed:2ℏ, fixed:10@0.0.1234, fixed:10@self, fractional:1/100,min=1,max=500, or royalty:5/100,fallback=1ℏ"
+
+func tokenCreateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	name := fs.String("name", "", "token name (required)")
+	symbol := fs.String("symbol", "", "token symbol (required)")
+	decimals := fs.Uint("decimals", 2, "number of decimal places")
+	initialSupply := fs.Uint64("initial-supply", 1_000_000, "initial supply, in the smallest denomination")
+	memo := fs.String("memo", "", "token memo")
+	var withKeys listFlag
+	fs.Var(&withKeys, "with-keys", "comma separated token keys to set to the operator key, any of "+strings.Join(tokenKeyRoles, ", ")+" (default: none, so the token cannot be changed)")
+	var feeFlags listFlag
+	fs.Var(&feeFlags, "fee", feeFlagUsage)
+	freeze := registerFreezeFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("name", *name); err != nil {
+			return err
+		}
+		if err := requireFlag("symbol", *symbol); err != nil {
+			return err
+		}
+		customFees, err := fees.ParseList(feeFlags, e.operator.AccountID, hedera.TokenTypeFungibleCommon, nil)
+		if err != nil {
+			return flagError("fee", err)
+		}
+		roles := map[string]bool{}
+		for _, role := range strings.Split(strings.Join(withKeys, ","), ",") {
+			role = strings.ToLower(strings.TrimSpace(role))
+			if role == "" {
+				continue
+			}
+			if !slices.Contains(tokenKeyRoles, role) {
+				return flagError("with-keys", fmt.Errorf("unknown token key %q, expected one of %s", role, strings.Join(tokenKeyRoles, ", ")))
+			}
+			roles[role] = true
+		}
+
+		fmt.Println("🟣 Creating new HTS token")
+		tokenCreateTxId, err := freeze.transactionID(e)
+		if err != nil {
+			return err
+		}
+		tokenCreateTx := hedera.NewTokenCreateTransaction().
+			SetTransactionID(tokenCreateTxId).
+			SetTransactionValidDuration(*freeze.validDuration).
+			// HTS `TokenType.FungibleCommon` behaves similarly to ERC20
+			SetTokenType(hedera.TokenTypeFungibleCommon).
+			SetTokenName(*name).
+			SetTokenSymbol(*symbol).
+			SetTokenMemo(*memo).
+			SetDecimals(*decimals).
+			SetInitialSupply(*initialSupply).
+			// The operator account is the treasury, which receives the initial supply
+			SetTreasuryAccountID(e.operator.AccountID).
+			SetFreezeDefault(false).
+			SetCustomFees(customFees)
+		// Each key enables the operations that it signs for, e.g. the supply key enables minting and burning
+		operatorPublicKey := e.operator.PrivateKey.PublicKey()
+		if roles["admin"] {
+			tokenCreateTx.SetAdminKey(operatorPublicKey)
+		}
+		if roles["supply"] {
+			tokenCreateTx.SetSupplyKey(operatorPublicKey)
+		}
+		if roles["freeze"] {
+			tokenCreateTx.SetFreezeKey(operatorPublicKey)
+		}
+		if roles["kyc"] {
+			tokenCreateTx.SetKycKey(operatorPublicKey)
+		}
+		if roles["wipe"] {
+			tokenCreateTx.SetWipeKey(operatorPublicKey)
+		}
+		if roles["pause"] {
+			tokenCreateTx.SetPauseKey(operatorPublicKey)
+		}
+		if roles["fee-schedule"] {
+			tokenCreateTx.SetFeeScheduleKey(operatorPublicKey)
+		}
+		// Freeze the transaction to prepare for signing
+		tokenCreateTx, err = tokenCreateTx.FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TokenCreateTransaction: %w", err)
+		}
+		fmt.Printf("The token create transaction ID: %s\n", tokenCreateTxId.String())
+		if freeze.enabled() {
+			return freeze.export(e, tokenCreateTx)
+		}
+
+		// Sign with the treasury key (operator key), submit, and wait for the receipt
+		tokenCreateTxSubmitted, err := tokenCreateTx.Sign(e.operator.PrivateKey).Execute(e.client)
+		if err != nil {
+			return fmt.Errorf("executing TokenCreateTransaction: %w", err)
+		}
+		tokenCreateTxReceipt, err := tokenCreateTxSubmitted.GetReceipt(e.client)
+		if err != nil {
+			return fmt.Errorf("getting receipt for TokenCreateTransaction: %w", err)
+		}
+		e.report.AddTransaction("tokenCreate", tokenCreateTxId, tokenCreateTxReceipt.Status)
+
+		tokenId := *tokenCreateTxReceipt.TokenID
+		e.report.AddEntity("token", tokenId)
+		fmt.Printf("Token ID: %s\n", tokenId.String())
+		fmt.Printf("Token Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("token", tokenId))
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func tokenFeeScheduleCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	var feeFlags listFlag
+	fs.Var(&feeFlags, "fee", feeFlagUsage)
+	clearFees := fs.Bool("clear", false, "remove every custom fee of the token")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if len(feeFlags) == 0 && !*clearFees {
+			return requireFlag("fee or -clear", "")
+		}
+		if len(feeFlags) > 0 && *clearFees {
+			return flagError("clear", fmt.Errorf("cannot be combined with -fee"))
+		}
+		// Fractional fees only apply to fungible tokens, and royalty fees to NFTs
+		tokenResp, err := e.mirror.GetToken(ctx, tokenId.String())
+		if err != nil {
+			return fmt.Errorf("fetching token: %w", err)
+		}
+		tokenType := hedera.TokenTypeFungibleCommon
+		if tokenResp.Type == "NON_FUNGIBLE_UNIQUE" {
+			tokenType = hedera.TokenTypeNonFungibleUnique
+		}
+		customFees, err := fees.ParseList(feeFlags, e.operator.AccountID, tokenType, &tokenId)
+		if err != nil {
+			return flagError("fee", err)
+		}
+
+		// The new fee schedule replaces the previous one as a whole
+		fmt.Println("🟣 Updating the custom fees of the token with the fee schedule key")
+		tokenFeeScheduleUpdateTx := hedera.NewTokenFeeScheduleUpdateTransaction().
+			SetTokenID(tokenId).
+			SetCustomFees(customFees)
+		tokenFeeScheduleUpdateTx, err = freezeWith(e, freeze, tokenFeeScheduleUpdateTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenFeeScheduleUpdateTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenFeeScheduleUpdateTx)
+		}
+		if err := submitSigned(e, "tokenFeeScheduleUpdate", tokenFeeScheduleUpdateTx, tf.keys); err != nil {
+			return err
+		}
+		feeLines := fees.Describe(customFees, tokenId)
+		err = verifyTokenChange(ctx, e, "tokenFeeScheduleUpdate", tokenId, "with the new custom fees",
+			func(token *mirror.Token) bool { return fees.Equal(fees.DescribeMirror(token.CustomFees), feeLines) })
+		if err != nil {
+			return err
+		}
+		for _, line := range feeLines {
+			fmt.Printf("  %s\n", line)
+		}
+		return nil
+	}
+}
+
+// tokenFlags are the flags shared by the commands that operate on an existing token
+type tokenFlags struct {
+	token *string
+	keys  operator.KeyList
+}
+
+func registerTokenFlags(fs *flag.FlagSet) *tokenFlags {
+	f := &tokenFlags{token: fs.String("token", "", "token ID (required)")}
+	fs.Var(&f.keys, "key", "private key that needs to sign, when the token key or the account key is not the operator key, may be repeated")
+	return f
+}
+
+// tokenID parses the required `-token` flag
+func (f *tokenFlags) tokenID(e *env) (hedera.TokenID, error) {
+	if err := requireFlag("token", *f.token); err != nil {
+		return hedera.TokenID{}, err
+	}
+	tokenId, err := hedera.TokenIDFromString(*f.token)
+	if err != nil {
+		return hedera.TokenID{}, flagError("token", err)
+	}
+	e.report.AddEntity("token", tokenId)
+	return tokenId, nil
+}
+
+// accountFlag parses an account ID flag, which defaults to the operator account when empty
+func accountFlag(e *env, name string, value string) (hedera.AccountID, error) {
+	if value == "" {
+		return e.operator.AccountID, nil
+	}
+	accountId, err := hedera.AccountIDFromString(value)
+	if err != nil {
+		return hedera.AccountID{}, flagError(name, err)
+	}
+	return accountId, nil
+}
+
+// signedTransaction is a frozen transaction, e.g. *hedera.TokenMintTransaction.
+// The operator signs when it is executed, as the fee payer.
+type signedTransaction[T any] interface {
+	Sign(privateKey hedera.PrivateKey) T
+	Execute(client *hedera.Client) (hedera.TransactionResponse, error)
+	GetTransactionID() hedera.TransactionID
+}
+
+// submitSigned signs a frozen transaction with the `-key` keys, submits it, and waits for the receipt
+func submitSigned[T signedTransaction[T]](e *env, name string, tx T, keys []hedera.PrivateKey) error {
+	txType := strings.TrimPrefix(fmt.Sprintf("%T", tx), "*hedera.")
+	txId := tx.GetTransactionID()
+	fmt.Printf("The %s transaction ID: %s\n", name, txId.String())
+	for _, key := range keys {
+		tx = tx.Sign(key)
+	}
+	txSubmitted, err := tx.Execute(e.client)
+	if err != nil {
+		return fmt.Errorf("executing %s: %w", txType, err)
+	}
+	txReceipt, err := txSubmitted.GetReceipt(e.client)
+	if err != nil {
+		return fmt.Errorf("getting receipt for %s: %w", txType, err)
+	}
+	e.report.AddTransaction(name, txId, txReceipt.Status)
+	fmt.Printf("The %s transaction status is: %s\n", name, txReceipt.Status.String())
+	fmt.Printf("Transaction Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("transaction", txId))
+	return nil
+}
+
+// verifyTokenChange polls the token on the mirror node until done reports that it is what, e.g. paused
+func verifyTokenChange(ctx context.Context, e *env, name string, tokenId hedera.TokenID, what string, done func(*mirror.Token) bool) error {
+	fmt.Println("🟣 Verifying the token on the Hedera Mirror Node")
+	tokenMirrorNodeApiUrl := e.mirror.URL(fmt.Sprintf("tokens/%s", tokenId.String()), nil)
+	fmt.Printf("The token Hedera Mirror Node API URL: %s\n", tokenMirrorNodeApiUrl)
+	tokenResp, err := e.mirror.WaitForTokenChange(ctx, mirror.DefaultBackoff, tokenId.String(), done)
+	e.report.AddMirrorVerification(name, tokenMirrorNodeApiUrl, tokenResp, err)
+	if err != nil {
+		return fmt.Errorf("verifying token %s: %w", what, err)
+	}
+	fmt.Printf("The mirror node shows the token %s\n", what)
+	return e.report.FillFromMirror(ctx, e.mirror)
+}
+
+// verifyRelationship polls the account's relationship with the token on the mirror node
+// until done reports that it is what, e.g. frozen
+func verifyRelationship(ctx context.Context, e *env, name string, accountId hedera.AccountID, tokenId hedera.TokenID, what string, done func(*mirror.TokenRelationship) bool) error {
+	fmt.Println("🟣 Verifying the account on the Hedera Mirror Node")
+	relationshipMirrorNodeApiUrl :=
+		e.mirror.URL(fmt.Sprintf("accounts/%s/tokens", accountId.String()), url.Values{"token.id": {tokenId.String()}})
+	fmt.Printf("The account tokens Hedera Mirror Node API URL: %s\n", relationshipMirrorNodeApiUrl)
+	relationshipResp, err := e.mirror.WaitForTokenRelationship(ctx, mirror.DefaultBackoff, accountId.String(), tokenId.String(), done)
+	e.report.AddMirrorVerification(name, relationshipMirrorNodeApiUrl, relationshipResp, err)
+	if err != nil {
+		return fmt.Errorf("verifying account %s %s: %w", accountId.String(), what, err)
+	}
+	fmt.Printf("The mirror node shows the account %s %s\n", accountId.String(), what)
+	return e.report.FillFromMirror(ctx, e.mirror)
+}
+
+// tokenBalance returns the balance of the account from the mirror node, 0 if it is not associated
+func tokenBalance(ctx context.Context, e *env, accountId hedera.AccountID, tokenId hedera.TokenID) (int64, error) {
+	resp, err := e.mirror.GetAccountTokens(ctx, accountId.String(), url.Values{"token.id": {tokenId.String()}})
+	if err != nil {
+		return 0, fmt.Errorf("fetching token balance: %w", err)
+	}
+	for _, rel := range resp.Tokens {
+		if rel.TokenID == tokenId.String() {
+			return rel.Balance, nil
+		}
+	}
+	return 0, nil
+}
+
+// tokenCredit returns the amount of the token that the transaction credited to the account, net of custom fees
+func tokenCredit(ctx context.Context, e *env, transactionId hedera.TransactionID, accountId hedera.AccountID, tokenId hedera.TokenID) (int64, error) {
+	id, query, err := txid.ToMirror(transactionId)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.mirror.WaitForTransaction(ctx, mirror.DefaultBackoff, id, query)
+	if err != nil {
+		return 0, fmt.Errorf("fetching transaction: %w", err)
+	}
+	var credit int64
+	for _, transfer := range resp.Transactions[0].TokenTransfers {
+		if transfer.TokenID == tokenId.String() && transfer.Account == accountId.String() {
+			credit += transfer.Amount
+		}
+	}
+	return credit, nil
+}
+
+// tokenSupply returns the total supply of the token from the mirror node
+func tokenSupply(ctx context.Context, e *env, tokenId hedera.TokenID) (uint64, error) {
+	tokenResp, err := e.mirror.GetToken(ctx, tokenId.String())
+	if err != nil {
+		return 0, fmt.Errorf("fetching token: %w", err)
+	}
+	return uint64(tokenResp.TotalSupply), nil
+}
+
+func tokenAssociateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	account := fs.String("account", "", "account to associate, which signs with -key (default: the operator account)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		accountId, err := accountFlag(e, "account", *account)
+		if err != nil {
+			return err
+		}
+
+		// An account has to be associated with a token before it can hold it
+		fmt.Println("🟣 Associating the account with the token")
+		tokenAssociateTx := hedera.NewTokenAssociateTransaction().
+			SetAccountID(accountId).
+			SetTokenIDs(tokenId)
+		tokenAssociateTx, err = freezeWith(e, freeze, tokenAssociateTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenAssociateTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenAssociateTx)
+		}
+		if err := submitSigned(e, "tokenAssociate", tokenAssociateTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyRelationship(ctx, e, "tokenAssociate", accountId, tokenId, "associated",
+			func(rel *mirror.TokenRelationship) bool { return rel != nil })
+	}
+}
+
+func tokenDissociateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	account := fs.String("account", "", "account to dissociate, which signs with -key (default: the operator account)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		accountId, err := accountFlag(e, "account", *account)
+		if err != nil {
+			return err
+		}
+
+		// The balance has to be zero, unless the token has been deleted
+		fmt.Println("🟣 Dissociating the account from the token")
+		tokenDissociateTx := hedera.NewTokenDissociateTransaction().
+			SetAccountID(accountId).
+			SetTokenIDs(tokenId)
+		tokenDissociateTx, err = freezeWith(e, freeze, tokenDissociateTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenDissociateTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenDissociateTx)
+		}
+		if err := submitSigned(e, "tokenDissociate", tokenDissociateTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyRelationship(ctx, e, "tokenDissociate", accountId, tokenId, "dissociated",
+			func(rel *mirror.TokenRelationship) bool { return rel == nil })
+	}
+}
+
+func tokenTransferCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	from := fs.String("from", "", "account to debit, which signs with -key (default: the operator account)")
+	to := fs.String("to", "", "account to credit (required)")
+	amount := fs.Int64("amount", 0, "amount, in the smallest denomination (required)")
+	decimals := fs.Int("decimals", -1, "decimals of the token, checked by the network to guard against a wrong amount (default: not checked)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if err := requireFlag("to", *to); err != nil {
+			return err
+		}
+		if *amount <= 0 {
+			return flagError("amount", fmt.Errorf("must be positive, got %d", *amount))
+		}
+		fromId, err := accountFlag(e, "from", *from)
+		if err != nil {
+			return err
+		}
+		toId, err := accountFlag(e, "to", *to)
+		if err != nil {
+			return err
+		}
+		balanceBefore, err := tokenBalance(ctx, e, toId, tokenId)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("🟣 Transferring tokens")
+		tokenTransferTx := hedera.NewTransferTransaction()
+		if *decimals >= 0 {
+			// The network rejects the transfer if the token has a different number of decimals
+			tokenTransferTx.
+				AddTokenTransferWithDecimals(tokenId, fromId, -*amount, uint32(*decimals)).
+				AddTokenTransferWithDecimals(tokenId, toId, *amount, uint32(*decimals))
+		} else {
+			tokenTransferTx.
+				AddTokenTransfer(tokenId, fromId, -*amount).
+				AddTokenTransfer(tokenId, toId, *amount)
+		}
+		tokenTransferTx, err = freezeWith(e, freeze, tokenTransferTx)
+		if err != nil {
+			return fmt.Errorf("freezing TransferTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenTransferTx)
+		}
+		if err := submitSigned(e, "tokenTransfer", tokenTransferTx, tf.keys); err != nil {
+			return err
+		}
+		// Custom fees of the token may be taken out of the amount, so the credit is read from the transaction
+		credit, err := tokenCredit(ctx, e, tokenTransferTx.GetTransactionID(), toId, tokenId)
+		if err != nil {
+			return err
+		}
+		if credit != *amount {
+			fmt.Printf("%v received %d, after %d of custom fees\n", toId, credit, *amount-credit)
+		}
+		balance := balanceBefore + credit
+		return verifyRelationship(ctx, e, "tokenTransfer", toId, tokenId, fmt.Sprintf("with a balance of %d", balance),
+			func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.Balance == balance })
+	}
+}
+
+func tokenMintCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenSupplyCommand(fs, "mint")
+}
+
+func tokenBurnCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenSupplyCommand(fs, "burn")
+}
+
+// tokenSupplyCommand mints to, or burns from, the treasury account, signed by the supply key
+func tokenSupplyCommand(fs *flag.FlagSet, op string) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	amount := fs.Uint64("amount", 0, "amount, in the smallest denomination (required)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if *amount == 0 {
+			return requireFlag("amount", "")
+		}
+		supplyBefore, err := tokenSupply(ctx, e, tokenId)
+		if err != nil {
+			return err
+		}
+
+		var totalSupply uint64
+		if op == "mint" {
+			fmt.Println("🟣 Minting tokens with the supply key")
+			tokenMintTx := hedera.NewTokenMintTransaction().
+				SetTokenID(tokenId).
+				SetAmount(*amount)
+			tokenMintTx, err = freezeWith(e, freeze, tokenMintTx)
+			if err != nil {
+				return fmt.Errorf("freezing TokenMintTransaction: %w", err)
+			}
+			if freeze.enabled() {
+				return freeze.export(e, tokenMintTx)
+			}
+			if err := submitSigned(e, "tokenMint", tokenMintTx, tf.keys); err != nil {
+				return err
+			}
+			totalSupply = supplyBefore + *amount
+		} else {
+			fmt.Println("🟣 Burning tokens with the supply key")
+			tokenBurnTx := hedera.NewTokenBurnTransaction().
+				SetTokenID(tokenId).
+				SetAmount(*amount)
+			tokenBurnTx, err = freezeWith(e, freeze, tokenBurnTx)
+			if err != nil {
+				return fmt.Errorf("freezing TokenBurnTransaction: %w", err)
+			}
+			if freeze.enabled() {
+				return freeze.export(e, tokenBurnTx)
+			}
+			if err := submitSigned(e, "tokenBurn", tokenBurnTx, tf.keys); err != nil {
+				return err
+			}
+			totalSupply = supplyBefore - *amount
+		}
+		expected := strconv.FormatUint(totalSupply, 10)
+		return verifyTokenChange(ctx, e, "token"+strings.ToUpper(op[:1])+op[1:], tokenId, "with a total supply of "+expected,
+			func(token *mirror.Token) bool { return uint64(token.TotalSupply) == totalSupply })
+	}
+}
+
+func tokenWipeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	account := fs.String("account", "", "account to wipe tokens from, other than the treasury (required)")
+	amount := fs.Uint64("amount", 0, "amount, in the smallest denomination (required)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if err := requireFlag("account", *account); err != nil {
+			return err
+		}
+		if *amount == 0 {
+			return requireFlag("amount", "")
+		}
+		accountId, err := accountFlag(e, "account", *account)
+		if err != nil {
+			return err
+		}
+		balanceBefore, err := tokenBalance(ctx, e, accountId, tokenId)
+		if err != nil {
+			return err
+		}
+
+		// Wiping does not need the signature of the account
+		fmt.Println("🟣 Wiping tokens from the account with the wipe key")
+		tokenWipeTx := hedera.NewTokenWipeTransaction().
+			SetAccountID(accountId).
+			SetTokenID(tokenId).
+			SetAmount(*amount)
+		tokenWipeTx, err = freezeWith(e, freeze, tokenWipeTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenWipeTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenWipeTx)
+		}
+		if err := submitSigned(e, "tokenWipe", tokenWipeTx, tf.keys); err != nil {
+			return err
+		}
+		balance := balanceBefore - int64(*amount)
+		return verifyRelationship(ctx, e, "tokenWipe", accountId, tokenId, fmt.Sprintf("with a balance of %d", balance),
+			func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.Balance == balance })
+	}
+}
+
+func tokenFreezeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenAccountStatusCommand(fs, "freeze")
+}
+
+func tokenUnfreezeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenAccountStatusCommand(fs, "unfreeze")
+}
+
+func tokenGrantKycCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenAccountStatusCommand(fs, "grant-kyc")
+}
+
+func tokenRevokeKycCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	return tokenAccountStatusCommand(fs, "revoke-kyc")
+}
+
+// tokenAccountStatusCommand freezes or unfreezes an account, signed by the freeze key,
+// or grants or revokes its KYC, signed by the KYC key
+func tokenAccountStatusCommand(fs *flag.FlagSet, op string) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	account := fs.String("account", "", "account ID (required)")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if err := requireFlag("account", *account); err != nil {
+			return err
+		}
+		accountId, err := accountFlag(e, "account", *account)
+		if err != nil {
+			return err
+		}
+
+		var name, what string
+		var done func(*mirror.TokenRelationship) bool
+		switch op {
+		case "freeze":
+			fmt.Println("🟣 Freezing the account for the token")
+			tx, err := freezeWith(e, freeze, hedera.NewTokenFreezeTransaction().SetAccountID(accountId).SetTokenID(tokenId))
+			if err != nil {
+				return fmt.Errorf("freezing TokenFreezeTransaction: %w", err)
+			}
+			name, what = "tokenFreeze", "frozen"
+			if freeze.enabled() {
+				return freeze.export(e, tx)
+			}
+			err = submitSigned(e, name, tx, tf.keys)
+			if err != nil {
+				return err
+			}
+			done = func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.FreezeStatus == "FROZEN" }
+		case "unfreeze":
+			fmt.Println("🟣 Unfreezing the account for the token")
+			tx, err := freezeWith(e, freeze, hedera.NewTokenUnfreezeTransaction().SetAccountID(accountId).SetTokenID(tokenId))
+			if err != nil {
+				return fmt.Errorf("freezing TokenUnfreezeTransaction: %w", err)
+			}
+			name, what = "tokenUnfreeze", "unfrozen"
+			if freeze.enabled() {
+				return freeze.export(e, tx)
+			}
+			err = submitSigned(e, name, tx, tf.keys)
+			if err != nil {
+				return err
+			}
+			done = func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.FreezeStatus == "UNFROZEN" }
+		case "grant-kyc":
+			fmt.Println("🟣 Granting KYC to the account")
+			tx, err := freezeWith(e, freeze, hedera.NewTokenGrantKycTransaction().SetAccountID(accountId).SetTokenID(tokenId))
+			if err != nil {
+				return fmt.Errorf("freezing TokenGrantKycTransaction: %w", err)
+			}
+			name, what = "tokenGrantKyc", "KYC granted"
+			if freeze.enabled() {
+				return freeze.export(e, tx)
+			}
+			err = submitSigned(e, name, tx, tf.keys)
+			if err != nil {
+				return err
+			}
+			done = func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.KycStatus == "GRANTED" }
+		case "revoke-kyc":
+			fmt.Println("🟣 Revoking KYC from the account")
+			tx, err := freezeWith(e, freeze, hedera.NewTokenRevokeKycTransaction().SetAccountID(accountId).SetTokenID(tokenId))
+			if err != nil {
+				return fmt.Errorf("freezing TokenRevokeKycTransaction: %w", err)
+			}
+			name, what = "tokenRevokeKyc", "KYC revoked"
+			if freeze.enabled() {
+				return freeze.export(e, tx)
+			}
+			err = submitSigned(e, name, tx, tf.keys)
+			if err != nil {
+				return err
+			}
+			done = func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.KycStatus == "REVOKED" }
+		}
+		return verifyRelationship(ctx, e, name, accountId, tokenId, what, done)
+	}
+}
+
+func tokenPauseCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+
+		// A paused token cannot be part of any transaction, other than unpausing it
+		fmt.Println("🟣 Pausing the token with the pause key")
+		tokenPauseTx, err := hedera.NewTokenPauseTransaction().
+			SetTokenID(tokenId).
+			FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TokenPauseTransaction: %w", err)
+		}
+		if err := submitSigned(e, "tokenPause", tokenPauseTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyTokenChange(ctx, e, "tokenPause", tokenId, "paused",
+			func(token *mirror.Token) bool { return token.PauseStatus == "PAUSED" })
+	}
+}
+
+func tokenUnpauseCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("🟣 Unpausing the token with the pause key")
+		tokenUnpauseTx, err := hedera.NewTokenUnpauseTransaction().
+			SetTokenID(tokenId).
+			FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TokenUnpauseTransaction: %w", err)
+		}
+		if err := submitSigned(e, "tokenUnpause", tokenUnpauseTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyTokenChange(ctx, e, "tokenUnpause", tokenId, "unpaused",
+			func(token *mirror.Token) bool { return token.PauseStatus == "UNPAUSED" })
+	}
+}
+
+func tokenUpdateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	name := fs.String("name", "", "new token name")
+	symbol := fs.String("symbol", "", "new token symbol")
+	memo := fs.String("memo", "", "new token memo")
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+		if *name == "" && *symbol == "" && *memo == "" {
+			return requireFlag("name, -symbol, or -memo", "")
+		}
+
+		// Only the fields that are set are updated
+		fmt.Println("🟣 Updating the token with the admin key")
+		tokenUpdateTx := hedera.NewTokenUpdateTransaction().SetTokenID(tokenId)
+		if *name != "" {
+			tokenUpdateTx.SetTokenName(*name)
+		}
+		if *symbol != "" {
+			tokenUpdateTx.SetTokenSymbol(*symbol)
+		}
+		if *memo != "" {
+			tokenUpdateTx.SetTokenMemo(*memo)
+		}
+		tokenUpdateTx, err = freezeWith(e, freeze, tokenUpdateTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenUpdateTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenUpdateTx)
+		}
+		if err := submitSigned(e, "tokenUpdate", tokenUpdateTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyTokenChange(ctx, e, "tokenUpdate", tokenId, "updated",
+			func(token *mirror.Token) bool {
+				return (*name == "" || token.Name == *name) &&
+					(*symbol == "" || token.Symbol == *symbol) &&
+					(*memo == "" || token.Memo == *memo)
+			})
+	}
+}
+
+func tokenDeleteCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	tf := registerTokenFlags(fs)
+	freeze := registerFreezeFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		tokenId, err := tf.tokenID(e)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("🟣 Deleting the token with the admin key")
+		tokenDeleteTx := hedera.NewTokenDeleteTransaction().
+			SetTokenID(tokenId)
+		tokenDeleteTx, err = freezeWith(e, freeze, tokenDeleteTx)
+		if err != nil {
+			return fmt.Errorf("freezing TokenDeleteTransaction: %w", err)
+		}
+		if freeze.enabled() {
+			return freeze.export(e, tokenDeleteTx)
+		}
+		if err := submitSigned(e, "tokenDelete", tokenDeleteTx, tf.keys); err != nil {
+			return err
+		}
+		return verifyTokenChange(ctx, e, "tokenDelete", tokenId, "deleted",
+			func(token *mirror.Token) bool { return token.Deleted })
+	}
+}
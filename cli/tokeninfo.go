@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/fees"
+	"lib/mirror"
+)
+
+// tokenField is a single field of a token, as shown by the mirror node and by the consensus network
+type tokenField struct {
+	Name    string `json:"name"`
+	Mirror  string `json:"mirror"`
+	Network string `json:"network,omitempty"`
+	Match   bool   `json:"match"`
+}
+
+func tokenInfoCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	token := fs.String("token", "", "token ID, which may also be given as the only argument (required)")
+	mirrorOnly := fs.Bool("mirror-only", false, "only show the mirror node data, without the paid TokenInfoQuery to the consensus network")
+	return func(ctx context.Context, e *env) error {
+		if *token == "" && fs.NArg() == 1 {
+			*token = fs.Arg(0)
+		}
+		if err := requireFlag("token", *token); err != nil {
+			return err
+		}
+		tokenId, err := hedera.TokenIDFromString(*token)
+		if err != nil {
+			return flagError("token", err)
+		}
+		e.report.AddEntity("token", tokenId)
+
+		fmt.Println("🟣 Get token data from the Hedera Mirror Node")
+		tokenMirrorNodeApiUrl := e.mirror.URL(fmt.Sprintf("tokens/%s", tokenId.String()), nil)
+		fmt.Printf("The token Hedera Mirror Node API URL: %s\n", tokenMirrorNodeApiUrl)
+		tokenResp, err := e.mirror.GetToken(ctx, tokenId.String())
+		e.report.AddMirrorVerification("token", tokenMirrorNodeApiUrl, tokenResp, err)
+		if err != nil {
+			return fmt.Errorf("fetching token: %w", err)
+		}
+		fields := mirrorTokenFields(tokenResp)
+
+		if !*mirrorOnly {
+			// The query is answered by a consensus node, which always has the latest state,
+			// while the mirror node may lag behind by a few seconds
+			fmt.Println("🟣 Get token data from the consensus network with TokenInfoQuery")
+			tokenInfo, err := hedera.NewTokenInfoQuery().
+				SetTokenID(tokenId).
+				Execute(e.client)
+			if err != nil {
+				return fmt.Errorf("executing TokenInfoQuery: %w", err)
+			}
+			compareTokenFields(fields, networkTokenFields(tokenInfo, tokenResp))
+		}
+
+		printTokenFields(fields, !*mirrorOnly)
+		e.report.Set("tokenInfo", fields)
+		if *mirrorOnly {
+			return nil
+		}
+		mismatches := 0
+		for _, field := range fields {
+			if !field.Match {
+				mismatches++
+			}
+		}
+		if mismatches > 0 {
+			fmt.Printf("%d fields differ, the mirror node may not have caught up with the latest transactions yet\n", mismatches)
+		}
+		return nil
+	}
+}
+
+// mirrorTokenFields renders each field of the mirror node token
+func mirrorTokenFields(token *mirror.Token) []tokenField {
+	maxSupply := "none"
+	if token.SupplyType == "FINITE" {
+		maxSupply = supplyString(token, int64(token.MaxSupply))
+	}
+	expiry := ""
+	if token.ExpiryTimestamp != 0 {
+		expiry = time.Unix(0, token.ExpiryTimestamp).UTC().Format(time.RFC3339)
+	}
+	return []tokenField{
+		{Name: "Name", Mirror: token.Name},
+		{Name: "Symbol", Mirror: token.Symbol},
+		{Name: "Type", Mirror: token.Type},
+		{Name: "Decimals", Mirror: strconv.FormatInt(int64(token.Decimals), 10)},
+		{Name: "Total supply", Mirror: supplyString(token, int64(token.TotalSupply))},
+		{Name: "Supply type", Mirror: token.SupplyType},
+		{Name: "Max supply", Mirror: maxSupply},
+		{Name: "Treasury", Mirror: token.TreasuryAccountID},
+		{Name: "Memo", Mirror: token.Memo},
+		{Name: "Freeze default", Mirror: strconv.FormatBool(token.FreezeDefault)},
+		{Name: "Pause status", Mirror: token.PauseStatus},
+		{Name: "Deleted", Mirror: strconv.FormatBool(token.Deleted)},
+		{Name: "Admin key", Mirror: mirrorKeyString(token.AdminKey)},
+		{Name: "Supply key", Mirror: mirrorKeyString(token.SupplyKey)},
+		{Name: "Freeze key", Mirror: mirrorKeyString(token.FreezeKey)},
+		{Name: "KYC key", Mirror: mirrorKeyString(token.KycKey)},
+		{Name: "Wipe key", Mirror: mirrorKeyString(token.WipeKey)},
+		{Name: "Pause key", Mirror: mirrorKeyString(token.PauseKey)},
+		{Name: "Fee schedule key", Mirror: mirrorKeyString(token.FeeScheduleKey)},
+		{Name: "Auto renew account", Mirror: token.AutoRenewAccount},
+		{Name: "Auto renew period", Mirror: periodString(token.AutoRenewPeriod)},
+		{Name: "Expiry", Mirror: expiry},
+		{Name: "Created", Mirror: timestampString(token.CreatedTimestamp)},
+		{Name: "Modified", Mirror: timestampString(token.ModifiedTimestamp)},
+		{Name: "Custom fees", Mirror: strings.Join(fees.DescribeMirror(token.CustomFees), "; ")},
+	}
+}
+
+// networkTokenFields renders the fields of a TokenInfoQuery response that the mirror node also has,
+// keyed by field name, in the same form as mirrorTokenFields.
+// The mirror node token is only used for its decimals.
+func networkTokenFields(info hedera.TokenInfo, token *mirror.Token) map[string]string {
+	maxSupply := "none"
+	if info.SupplyType == hedera.TokenSupplyTypeFinite {
+		maxSupply = supplyString(token, info.MaxSupply)
+	}
+	freezeDefault := info.DefaultFreezeStatus != nil && *info.DefaultFreezeStatus
+	// PauseStatus is nil for tokens without a pause key
+	pauseStatus := "NOT_APPLICABLE"
+	if info.PauseStatus != nil && *info.PauseStatus {
+		pauseStatus = "PAUSED"
+	} else if info.PauseStatus != nil {
+		pauseStatus = "UNPAUSED"
+	}
+	autoRenewAccount := ""
+	if info.AutoRenewAccountID != (hedera.AccountID{}) {
+		autoRenewAccount = info.AutoRenewAccountID.String()
+	}
+	autoRenewPeriod := ""
+	if info.AutoRenewPeriod != nil {
+		autoRenewPeriod = periodString(int64(info.AutoRenewPeriod.Seconds()))
+	}
+	expiry := ""
+	if info.ExpirationTime != nil {
+		expiry = info.ExpirationTime.UTC().Format(time.RFC3339)
+	}
+	return map[string]string{
+		"Name":               info.Name,
+		"Symbol":             info.Symbol,
+		"Type":               strings.TrimPrefix(info.TokenType.String(), "TOKEN_TYPE_"),
+		"Decimals":           strconv.FormatUint(uint64(info.Decimals), 10),
+		"Total supply":       supplyString(token, int64(info.TotalSupply)),
+		"Supply type":        strings.TrimPrefix(info.SupplyType.String(), "TOKEN_SUPPLY_TYPE_"),
+		"Max supply":         maxSupply,
+		"Treasury":           info.Treasury.String(),
+		"Memo":               info.TokenMemo,
+		"Freeze default":     strconv.FormatBool(freezeDefault),
+		"Pause status":       pauseStatus,
+		"Deleted":            strconv.FormatBool(info.Deleted),
+		"Admin key":          networkKeyString(info.AdminKey),
+		"Supply key":         networkKeyString(info.SupplyKey),
+		"Freeze key":         networkKeyString(info.FreezeKey),
+		"KYC key":            networkKeyString(info.KycKey),
+		"Wipe key":           networkKeyString(info.WipeKey),
+		"Pause key":          networkKeyString(info.PauseKey),
+		"Fee schedule key":   networkKeyString(info.FeeScheduleKey),
+		"Auto renew account": autoRenewAccount,
+		"Auto renew period":  autoRenewPeriod,
+		"Expiry":             expiry,
+		"Custom fees":        strings.Join(fees.Describe(info.CustomFees, info.TokenID), "; "),
+	}
+}
+
+// compareTokenFields sets the network value of each field that the query returns,
+// and whether it matches the mirror node.
+// Fields that only the mirror node has, such as the timestamps, always match.
+func compareTokenFields(fields []tokenField, network map[string]string) {
+	for i := range fields {
+		value, ok := network[fields[i].Name]
+		if !ok {
+			fields[i].Match = true
+			continue
+		}
+		fields[i].Network = value
+		if fields[i].Name == "Custom fees" {
+			fields[i].Match = fees.Equal(strings.Split(fields[i].Mirror, "; "), strings.Split(value, "; "))
+		} else {
+			fields[i].Match = fields[i].Mirror == value
+		}
+	}
+}
+
+func printTokenFields(fields []tokenField, compared bool) {
+	if !compared {
+		for _, field := range fields {
+			fmt.Printf("%-20s %s\n", field.Name+":", field.Mirror)
+		}
+		return
+	}
+	fmt.Printf("%-20s %-45s %s\n", "Field", "Mirror node", "Consensus network")
+	fmt.Println(strings.Repeat("-", 100))
+	for _, field := range fields {
+		marker := " "
+		if !field.Match {
+			marker = "≠"
+		}
+		fmt.Printf("%-20s %-45s %s %s\n", field.Name, field.Mirror, marker, field.Network)
+	}
+}
+
+// supplyString shows an amount both in the smallest denomination and in whole units of the token
+func supplyString(token *mirror.Token, amount int64) string {
+	if token.Decimals == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+	return fmt.Sprintf("%d (%s %s)", amount, token.FormatAmount(amount), token.Symbol)
+}
+
+// mirrorKeyString returns the hex of a single public key, or the kind of a complex key
+func mirrorKeyString(key *mirror.Key) string {
+	switch {
+	case key == nil:
+		return "none"
+	case key.Type == "ProtobufEncoded":
+		return "key list or threshold key"
+	}
+	return strings.ToLower(key.Key)
+}
+
+// networkKeyString returns the hex of a single public key, in the same form as mirrorKeyString
+func networkKeyString(key hedera.Key) string {
+	switch k := key.(type) {
+	case nil:
+		return "none"
+	case hedera.PublicKey:
+		return strings.ToLower(k.StringRaw())
+	case *hedera.PublicKey:
+		return strings.ToLower(k.StringRaw())
+	}
+	return "key list or threshold key"
+}
+
+func periodString(seconds int64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func timestampString(timestamp string) string {
+	t, err := mirror.ParseTimestamp(timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return t.Format(time.RFC3339Nano)
+}
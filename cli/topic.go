@@ -0,0 +1,760 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/chunks"
+	"lib/envelope"
+	"lib/keys"
+	"lib/mirror"
+	"lib/operator"
+	"lib/protected"
+)
+
+// builtinSchemas are the JSON Schemas that envelopes can name without -schemas, one <schema ID>.json file each
+//
+//go:embed schemas/*.json
+var builtinSchemas embed.FS
+
+// keyFlagUsage describes the -admin-key and -submit-key flags
+const keyFlagUsage = "public key, operator for the operator key, a comma separated key list, or a threshold key such as 2-of:<key>,<key>,<key>"
+
+// topicKeyFlags are the flags that set the keys and the auto renewal of a topic
+type topicKeyFlags struct {
+	adminKey         *string
+	submitKey        *string
+	autoRenewAccount *string
+	autoRenewPeriod  *time.Duration
+	keys             operator.KeyList
+}
+
+func registerTopicKeyFlags(fs *flag.FlagSet, update bool) *topicKeyFlags {
+	clearUsage := ""
+	if update {
+		clearUsage = ", or none to remove it"
+	}
+	f := &topicKeyFlags{
+		adminKey:         fs.String("admin-key", "", "admin key, which can update and delete the topic: "+keyFlagUsage+clearUsage),
+		submitKey:        fs.String("submit-key", "", "submit key, which needs to sign every message: "+keyFlagUsage+clearUsage),
+		autoRenewAccount: fs.String("auto-renew-account", "", "account that pays to renew the topic when it expires"+clearUsage),
+		autoRenewPeriod:  fs.Duration("auto-renew-period", 0, "period the topic is renewed for, between 2160h (90 days) and 8000h"),
+	}
+	fs.Var(&f.keys, "key", "private key that needs to sign, e.g. of an admin key or auto renew account that is not the operator's, may be repeated")
+	return f
+}
+
+// parseKey parses a key flag, where `none` returns nil
+func parseKey(e *env, name string, value string) (*keys.Key, error) {
+	if value == "none" {
+		return nil, nil
+	}
+	key, err := keys.Parse(value, e.operator.PrivateKey.PublicKey())
+	if err != nil {
+		return nil, flagError(name, err)
+	}
+	return &key, nil
+}
+
+// isSet reports whether a flag was passed, to tell an empty value apart from a missing one
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func topicCreateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	memo := fs.String("memo", "", "topic memo")
+	kf := registerTopicKeyFlags(fs, false)
+	freeze := registerFreezeFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		fmt.Println("🟣 Creating new HCS topic")
+		topicCreateTxId, err := freeze.transactionID(e)
+		if err != nil {
+			return err
+		}
+		topicCreateTx := hedera.NewTopicCreateTransaction().
+			SetTransactionID(topicCreateTxId).
+			SetTransactionValidDuration(*freeze.validDuration).
+			SetTopicMemo(*memo)
+		// Without an admin key, the topic can never be updated or deleted,
+		// and without a submit key, anyone can submit messages to it
+		if *kf.adminKey != "" {
+			adminKey, err := parseKey(e, "admin-key", *kf.adminKey)
+			if err != nil {
+				return err
+			}
+			topicCreateTx.SetAdminKey(adminKey.SDK())
+		}
+		if *kf.submitKey != "" {
+			submitKey, err := parseKey(e, "submit-key", *kf.submitKey)
+			if err != nil {
+				return err
+			}
+			topicCreateTx.SetSubmitKey(submitKey.SDK())
+		}
+		if *kf.autoRenewAccount != "" {
+			autoRenewAccountId, err := accountFlag(e, "auto-renew-account", *kf.autoRenewAccount)
+			if err != nil {
+				return err
+			}
+			topicCreateTx.SetAutoRenewAccountID(autoRenewAccountId)
+		}
+		if *kf.autoRenewPeriod != 0 {
+			topicCreateTx.SetAutoRenewPeriod(*kf.autoRenewPeriod)
+		}
+		// Freeze the transaction to prepare for signing
+		topicCreateTx, err = topicCreateTx.FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TopicCreateTransaction: %w", err)
+		}
+		fmt.Printf("The topic create transaction ID: %s\n", topicCreateTxId.String())
+		if freeze.enabled() {
+			return freeze.export(e, topicCreateTx)
+		}
+
+		// Sign with the operator key, and the `-key` keys, which the admin key needs to sign
+		for _, key := range kf.keys {
+			topicCreateTx.Sign(key)
+		}
+		topicCreateTxSubmitted, err := topicCreateTx.Sign(e.operator.PrivateKey).Execute(e.client)
+		if err != nil {
+			return fmt.Errorf("executing TopicCreateTransaction: %w", err)
+		}
+		topicCreateTxReceipt, err := topicCreateTxSubmitted.GetReceipt(e.client)
+		if err != nil {
+			return fmt.Errorf("getting receipt for TopicCreateTransaction: %w", err)
+		}
+		e.report.AddTransaction("topicCreate", topicCreateTxId, topicCreateTxReceipt.Status)
+
+		topicId := *topicCreateTxReceipt.TopicID
+		e.report.AddEntity("topic", topicId)
+		fmt.Printf("Topic ID: %s\n", topicId.String())
+		fmt.Printf("Topic Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("topic", topicId))
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+func topicSubmitCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	message := fs.String("message", "", "message to submit (required, unless -file is set)")
+	file := fs.String("file", "", "file to submit as the message, e.g. a JSON document, which is split into chunks of 1024 bytes")
+	maxChunks := fs.Uint64("max-chunks", 0, "maximum number of chunks to split the message into (default: as many as the message needs)")
+	memo := fs.String("memo", "", "transaction memo")
+	schemaID := fs.String("schema", "", "ID of the JSON Schema to validate the message against, and to wrap it in an envelope of, e.g. sensor-reading.v1")
+	schemasDir := fs.String("schemas", "", "directory of more JSON Schemas, one <schema ID>.json file each, besides the built-in ones")
+	encodingName := fs.String("encoding", "json", "encoding of the envelope and its payload, with -schema: json, cbor, or protobuf")
+	sign := fs.Bool("sign", false, "sign the message with the operator key, as the operator account, so that readers can verify who wrote it")
+	var recipients listFlag
+	fs.Var(&recipients, "encrypt-to", "public key to encrypt the message to, so that only its holder can read it, may be repeated, implies -sign")
+	var signerKeys operator.KeyList
+	fs.Var(&signerKeys, "key", "private key of the submit key of the topic, when it is not the operator key, may be repeated")
+	freeze := registerFreezeFlags(fs)
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		if *message != "" && *file != "" {
+			return flagError("file", fmt.Errorf("only one of -message and -file can be set"))
+		}
+		payload := []byte(*message)
+		if *file != "" {
+			var err error
+			payload, err = os.ReadFile(*file)
+			if err != nil {
+				return flagError("file", err)
+			}
+		}
+		if len(payload) == 0 {
+			return requireFlag("message or -file", "")
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		if *schemaID != "" {
+			payload, err = sealPayload(payload, *schemaID, *schemasDir, *encodingName, e.operator.AccountID)
+			if err != nil {
+				return err
+			}
+		}
+		if *sign || len(recipients) > 0 {
+			payload, err = protectPayload(payload, topicId, e.operator, recipients)
+			if err != nil {
+				return err
+			}
+		}
+		chunkCount := chunks.Count(len(payload))
+		if *maxChunks == 0 {
+			*maxChunks = uint64(max(chunkCount, chunks.DefaultMaxChunks))
+		}
+		if uint64(chunkCount) > *maxChunks {
+			return flagError("max-chunks", fmt.Errorf("the message of %d bytes needs %d chunks, more than %d", len(payload), chunkCount, *maxChunks))
+		}
+		// A transaction file holds a single transaction, and each chunk is a transaction of its own
+		if freeze.enabled() && chunkCount > 1 {
+			return flagError("freeze-to", fmt.Errorf("the message of %d bytes needs %d chunks, only messages of up to %d bytes can be frozen", len(payload), chunkCount, chunks.Size))
+		}
+		// A frozen transaction is signed later, so its signers are not known yet
+		if !freeze.enabled() {
+			signers := keys.PublicKeys(append([]hedera.PrivateKey{e.operator.PrivateKey}, signerKeys...)...)
+			if err := checkSubmitKey(ctx, e, topicId, signers); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("🟣 Publish message to HCS topic")
+		topicMsgSubmitTxId, err := freeze.transactionID(e)
+		if err != nil {
+			return err
+		}
+		// Messages larger than 1024 bytes are split into chunks, each submitted as its own transaction.
+		// The first chunk has the transaction ID, and the others have IDs with later valid starts.
+		topicMsgSubmitTx, err := hedera.NewTopicMessageSubmitTransaction().
+			SetTransactionID(topicMsgSubmitTxId).
+			SetTransactionValidDuration(*freeze.validDuration).
+			SetTransactionMemo(*memo).
+			SetTopicID(topicId).
+			SetMessage(payload).
+			SetMaxChunks(*maxChunks).
+			// Freeze the transaction to prepare for signing
+			FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TopicMessageSubmitTransaction: %w", err)
+		}
+		fmt.Printf("The topic message submit transaction ID: %s\n", topicMsgSubmitTxId.String())
+		if freeze.enabled() {
+			return freeze.export(e, topicMsgSubmitTx)
+		}
+		if chunkCount > 1 {
+			fmt.Printf("The message of %d bytes is split into %d chunks\n", len(payload), chunkCount)
+		}
+
+		// Sign every chunk with the operator key, and the `-key` keys of the submit key, submit, and wait for the receipts
+		for _, key := range signerKeys {
+			topicMsgSubmitTx.Sign(key)
+		}
+		topicMsgSubmitTxResponses, err := topicMsgSubmitTx.Sign(e.operator.PrivateKey).ExecuteAll(e.client)
+		if err != nil {
+			return fmt.Errorf("executing TopicMessageSubmitTransaction: %w", err)
+		}
+		e.report.AddEntity("topic", topicId)
+		topicMsgSeqNums := []uint64{}
+		for i, topicMsgSubmitTxSubmitted := range topicMsgSubmitTxResponses {
+			topicMsgSubmitTxReceipt, err := topicMsgSubmitTxSubmitted.GetReceipt(e.client)
+			if err != nil {
+				return fmt.Errorf("getting receipt for TopicMessageSubmitTransaction chunk %d: %w", i+1, err)
+			}
+			e.report.AddTransaction("topicMessageSubmit", topicMsgSubmitTxSubmitted.TransactionID, topicMsgSubmitTxReceipt.Status)
+			topicMsgSeqNums = append(topicMsgSeqNums, topicMsgSubmitTxReceipt.TopicSequenceNumber)
+		}
+
+		if len(topicMsgSeqNums) == 1 {
+			e.report.Set("topicMessageSequenceNumber", topicMsgSeqNums[0])
+			fmt.Printf("Topic Message Sequence Number: %v\n", topicMsgSeqNums[0])
+		} else {
+			e.report.Set("topicMessageSequenceNumbers", topicMsgSeqNums)
+			fmt.Printf("Topic Message Sequence Numbers of the chunks: %v\n", topicMsgSeqNums)
+		}
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+// sealPayload validates a JSON document against its schema, and wraps it in an envelope from the operator
+func sealPayload(payload []byte, schemaID string, schemasDir string, encodingName string, sender hedera.AccountID) ([]byte, error) {
+	encoding, err := envelope.ParseEncoding(encodingName)
+	if err != nil {
+		return nil, flagError("encoding", err)
+	}
+	registry, err := newRegistry(schemasDir)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(payload) {
+		return nil, flagError("schema", fmt.Errorf("the message needs to be a JSON document to validate it against a schema"))
+	}
+	sealed, err := registry.Seal(encoding, schemaID, sender.String(), json.RawMessage(payload))
+	if errors.Is(err, envelope.ErrUnknownSchema) || errors.Is(err, envelope.ErrInvalidPayload) {
+		return nil, flagError("schema", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("The message is valid against schema %s, and wrapped in a %s envelope of %d bytes\n", schemaID, encoding, len(sealed))
+	return sealed, nil
+}
+
+// newRegistry returns a registry of the built-in schemas, and of the schemas in dir, when it is set
+func newRegistry(dir string) (*envelope.Registry, error) {
+	registry := envelope.NewRegistry()
+	schemas, err := fs.Sub(builtinSchemas, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.RegisterFS(schemas); err != nil {
+		return nil, fmt.Errorf("built-in schemas: %w", err)
+	}
+	if dir != "" {
+		if err := registry.RegisterDir(dir); err != nil {
+			return nil, flagError("schemas", err)
+		}
+	}
+	return registry, nil
+}
+
+// protectPayload signs a message with the operator key, as the operator account, and encrypts it to the recipients, if any
+func protectPayload(payload []byte, topicId hedera.TopicID, op *operator.Config, recipients []string) ([]byte, error) {
+	opts := protected.Options{Sender: op.AccountID.String()}
+	for _, recipient := range recipients {
+		recipientKey, err := hedera.PublicKeyFromString(recipient)
+		if err != nil {
+			return nil, flagError("encrypt-to", err)
+		}
+		opts.Recipients = append(opts.Recipients, recipientKey)
+	}
+	sealed, err := protected.Seal(topicId.String(), op.PrivateKey, payload, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Recipients) > 0 {
+		fmt.Printf("The message is signed by %s, and encrypted to %d recipients, in %d bytes\n", op.AccountID, len(opts.Recipients), len(sealed))
+	} else {
+		fmt.Printf("The message is signed by %s, in %d bytes\n", op.AccountID, len(sealed))
+	}
+	return sealed, nil
+}
+
+// checkSubmitKey rejects a message before it reaches the network,
+// when the topic is deleted, or has a submit key that the signers do not satisfy
+func checkSubmitKey(ctx context.Context, e *env, topicId hedera.TopicID, signers []hedera.PublicKey) error {
+	// A topic that was just created may not be on the mirror node yet
+	topicResp, err := e.mirror.WaitForTopicChange(ctx, mirror.DefaultBackoff, topicId.String(),
+		func(*mirror.Topic) bool { return true })
+	if err != nil {
+		return fmt.Errorf("fetching topic: %w", err)
+	}
+	if topicResp.Deleted {
+		return flagError("topic", fmt.Errorf("topic %s is deleted", topicId.String()))
+	}
+	submitKey, err := keys.FromMirror(topicResp.SubmitKey)
+	if err != nil {
+		return fmt.Errorf("decoding the submit key of topic %s: %w", topicId.String(), err)
+	}
+	if submitKey == nil {
+		return nil
+	}
+	if err := submitKey.Require("the submit key of topic "+topicId.String(), signers); err != nil {
+		return flagError("key", err)
+	}
+	return nil
+}
+
+func topicUpdateCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	memo := fs.String("memo", "", "new topic memo, an empty memo removes it")
+	kf := registerTopicKeyFlags(fs, true)
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+
+		// Only the fields that are set are updated
+		topicUpdateTx := hedera.NewTopicUpdateTransaction().SetTopicID(topicId)
+		updated := false
+		if isSet(fs, "memo") {
+			if *memo == "" {
+				topicUpdateTx.ClearTopicMemo()
+			} else {
+				topicUpdateTx.SetTopicMemo(*memo)
+			}
+			updated = true
+		}
+		var adminKey, submitKey *keys.Key
+		if *kf.adminKey != "" {
+			adminKey, err = parseKey(e, "admin-key", *kf.adminKey)
+			if err != nil {
+				return err
+			}
+			// Removing the admin key makes the topic immutable
+			if adminKey == nil {
+				topicUpdateTx.ClearAdminKey()
+			} else {
+				topicUpdateTx.SetAdminKey(adminKey.SDK())
+			}
+			updated = true
+		}
+		if *kf.submitKey != "" {
+			submitKey, err = parseKey(e, "submit-key", *kf.submitKey)
+			if err != nil {
+				return err
+			}
+			if submitKey == nil {
+				topicUpdateTx.ClearSubmitKey()
+			} else {
+				topicUpdateTx.SetSubmitKey(submitKey.SDK())
+			}
+			updated = true
+		}
+		autoRenewAccount := ""
+		if *kf.autoRenewAccount == "none" {
+			topicUpdateTx.ClearAutoRenewAccountID()
+			updated = true
+		} else if *kf.autoRenewAccount != "" {
+			autoRenewAccountId, err := accountFlag(e, "auto-renew-account", *kf.autoRenewAccount)
+			if err != nil {
+				return err
+			}
+			topicUpdateTx.SetAutoRenewAccountID(autoRenewAccountId)
+			autoRenewAccount = autoRenewAccountId.String()
+			updated = true
+		}
+		if *kf.autoRenewPeriod != 0 {
+			topicUpdateTx.SetAutoRenewPeriod(*kf.autoRenewPeriod)
+			updated = true
+		}
+		if !updated {
+			return requireFlag("memo, -admin-key, -submit-key, -auto-renew-account, or -auto-renew-period", "")
+		}
+		fmt.Println("🟣 Updating the topic with the admin key")
+		topicUpdateTx, err = topicUpdateTx.FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TopicUpdateTransaction: %w", err)
+		}
+		// The current admin key signs, and so does a new admin key
+		if err := submitSigned(e, "topicUpdate", topicUpdateTx, kf.keys); err != nil {
+			return err
+		}
+		e.report.AddEntity("topic", topicId)
+
+		return verifyTopicChange(ctx, e, "topicUpdate", topicId, "updated", func(topic *mirror.Topic) bool {
+			return (!isSet(fs, "memo") || topic.Memo == *memo) &&
+				(*kf.adminKey == "" || sameKey(topic.AdminKey, adminKey)) &&
+				(*kf.submitKey == "" || sameKey(topic.SubmitKey, submitKey)) &&
+				(*kf.autoRenewAccount == "" || topic.AutoRenewAccount == autoRenewAccount) &&
+				(*kf.autoRenewPeriod == 0 || topic.AutoRenewPeriod == int64(kf.autoRenewPeriod.Seconds()))
+		})
+	}
+}
+
+func topicDeleteCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	var signerKeys operator.KeyList
+	fs.Var(&signerKeys, "key", "private key of the admin key of the topic, when it is not the operator key, may be repeated")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+
+		// The messages of a deleted topic stay on the mirror node, but no new messages can be submitted
+		fmt.Println("🟣 Deleting the topic with the admin key")
+		topicDeleteTx, err := hedera.NewTopicDeleteTransaction().
+			SetTopicID(topicId).
+			FreezeWith(e.client)
+		if err != nil {
+			return fmt.Errorf("freezing TopicDeleteTransaction: %w", err)
+		}
+		if err := submitSigned(e, "topicDelete", topicDeleteTx, signerKeys); err != nil {
+			return err
+		}
+		e.report.AddEntity("topic", topicId)
+		return verifyTopicChange(ctx, e, "topicDelete", topicId, "deleted",
+			func(topic *mirror.Topic) bool { return topic.Deleted })
+	}
+}
+
+// sameKey reports whether the mirror node shows the expected key, where nil is no key
+func sameKey(mirrorKey *mirror.Key, expected *keys.Key) bool {
+	key, err := keys.FromMirror(mirrorKey)
+	if err != nil || (key == nil) != (expected == nil) {
+		return false
+	}
+	return key == nil || key.String() == expected.String()
+}
+
+// verifyTopicChange polls the topic on the mirror node until done reports that it is what, e.g. deleted
+func verifyTopicChange(ctx context.Context, e *env, name string, topicId hedera.TopicID, what string, done func(*mirror.Topic) bool) error {
+	topicMirrorNodeApiUrl := e.mirror.URL(fmt.Sprintf("topics/%s", topicId.String()), nil)
+	topicResp, err := e.mirror.WaitForTopicChange(ctx, mirror.DefaultBackoff, topicId.String(), done)
+	e.report.AddMirrorVerification(name, topicMirrorNodeApiUrl, topicResp, err)
+	if err != nil {
+		return fmt.Errorf("verifying topic %s: %w", what, err)
+	}
+	fmt.Printf("The mirror node shows the topic %s\n", what)
+	return nil
+}
+
+func topicReadCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	from := fs.Uint64("from", 1, "first sequence number to read")
+	limit := fs.Int("limit", 0, "maximum number of messages to read, counting a chunked message once (default: all)")
+	wait := fs.Uint64("wait-for", 0, "wait until the message with this sequence number is on the mirror node")
+	showChunks := fs.Bool("chunks", false, "show each chunk of a chunked message, instead of reassembling them")
+	envelopes := fs.Bool("envelopes", false, "open the envelope of every message, and validate its payload against the JSON Schema it names")
+	schemasDir := fs.String("schemas", "", "directory of more JSON Schemas, one <schema ID>.json file each, besides the built-in ones, implies -envelopes")
+	deadLetter := fs.String("dead-letter", "", "file to append the messages that cannot be opened to, as lines of JSON: protected messages with an invalid signature or an unverified sender, and with -envelopes, messages that are not valid envelopes (default: only report them)")
+	var decryptKeys operator.KeyList
+	fs.Var(&decryptKeys, "decrypt-key", "private key to decrypt protected messages with, besides the operator key, may be repeated")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		// With -envelopes, every message is expected to be an envelope of one of the schemas,
+		// and any other message is set aside as a dead letter
+		var registry *envelope.Registry
+		if *envelopes || *schemasDir != "" {
+			if *showChunks {
+				return flagError("chunks", fmt.Errorf("only one of -chunks and -envelopes can be set"))
+			}
+			registry, err = newRegistry(*schemasDir)
+			if err != nil {
+				return err
+			}
+		}
+		deadLetterWriter, closeDeadLetter, err := openAppend("dead-letter", *deadLetter)
+		if err != nil {
+			return err
+		}
+		defer closeDeadLetter()
+		deadLetters := envelope.NewDeadLetters(deadLetterWriter)
+		// Protected messages are verified, and decrypted with the operator key or the `-decrypt-key` keys
+		protectedMessages := newProtectedReader(topicId, append(decryptKeys, e.operator.PrivateKey))
+		e.report.AddEntity("topic", topicId)
+
+		if *wait > 0 {
+			// Poll until the message is visible, instead of reading a partial list
+			_, err := e.mirror.WaitForTopicMessage(ctx, mirror.DefaultBackoff, topicId.String(), *wait)
+			if err != nil {
+				return fmt.Errorf("finding topic message on the mirror node: %w", err)
+			}
+		}
+
+		fmt.Println("🟣 Get topic messages from the Hedera Mirror Node")
+		opts := mirror.ListOptions{
+			Limit:          100,
+			Order:          mirror.OrderAsc,
+			SequenceNumber: []mirror.Condition{mirror.Gte(*from)},
+			Extra:          url.Values{"encoding": {"base64"}},
+		}
+		if *limit > 0 && *showChunks {
+			opts.Limit = min(*limit, opts.Limit)
+		}
+		topicMirrorNodeApiUrl :=
+			e.mirror.URL(fmt.Sprintf("topics/%s/messages", topicId.String()), opts.Query())
+		fmt.Printf("The topic Hedera Mirror Node API URL: %s\n", topicMirrorNodeApiUrl)
+
+		type topicMessage struct {
+			SequenceNumber     int64  `json:"sequenceNumber"`
+			ConsensusTimestamp string `json:"consensusTimestamp"`
+			Message            string `json:"message"`
+			// Chunks are the sequence numbers of the chunks of a chunked message
+			Chunks []int64 `json:"chunks,omitempty"`
+			// SchemaID and Sender are from the envelope of the message, with -envelopes,
+			// in which case Message is its payload, as JSON
+			SchemaID string `json:"schemaId,omitempty"`
+			Sender   string `json:"sender,omitempty"`
+			// SignedBy is the signer of a protected message, in which case Message is its payload,
+			// or empty when it could not be decrypted
+			SignedBy *messageSigner `json:"signedBy,omitempty"`
+		}
+		topicMessages := []topicMessage{}
+		// Chunks are collected until every chunk of their message has been read,
+		// so the message is shown at the sequence number of its last chunk
+		assembler := chunks.NewAssembler()
+		err = e.mirror.EachTopicMessage(ctx, topicId.String(), opts, func(entry mirror.TopicMessage) error {
+			var decodedMsg []byte
+			var chunkSeqNums []int64
+			var schemaID, sender string
+			var signedBy *messageSigner
+			var err error
+			if *showChunks {
+				decodedMsg, err = entry.Contents()
+				if err != nil {
+					return fmt.Errorf("decoding topic message #%d: %w", entry.SequenceNumber, err)
+				}
+				if entry.ChunkInfo != nil && entry.ChunkInfo.Total > 1 {
+					fmt.Printf("#%v (chunk %d of %d of %s): %s\n", entry.SequenceNumber,
+						entry.ChunkInfo.Number, entry.ChunkInfo.Total, entry.ChunkInfo.InitialTransactionID, decodedMsg)
+				} else {
+					fmt.Printf("#%v: %s\n", entry.SequenceNumber, decodedMsg)
+				}
+			} else {
+				message, err := assembler.Add(entry)
+				if err != nil {
+					return err
+				}
+				if message == nil {
+					return nil
+				}
+				decodedMsg, err = message.Contents()
+				if err != nil {
+					return err
+				}
+				if message.Total > 1 {
+					chunkSeqNums = message.SequenceNumbers()
+				}
+				// Dead letters keep the message as it is on the topic, so a decrypted payload is not written out
+				contents := decodedMsg
+				signedBy, decodedMsg, err = protectedMessages.open(ctx, e, contents)
+				if err != nil {
+					fmt.Printf("⚠️ #%v: set aside as a dead letter: %v\n", entry.SequenceNumber, err)
+					return deadLetters.Add(entry.SequenceNumber, entry.ConsensusTimestamp, contents, err)
+				}
+				if signedBy != nil {
+					fmt.Printf("#%v: %s\n", entry.SequenceNumber, signedBy)
+				}
+				if signedBy != nil && decodedMsg == nil {
+					fmt.Printf("#%v: encrypted to other keys than the operator key and the -decrypt-key keys\n", entry.SequenceNumber)
+				} else if registry != nil {
+					opened, err := registry.Open(decodedMsg)
+					if err != nil {
+						fmt.Printf("⚠️ #%v: set aside as a dead letter: %v\n", entry.SequenceNumber, err)
+						return deadLetters.Add(entry.SequenceNumber, entry.ConsensusTimestamp, contents, err)
+					}
+					decodedMsg, err = json.Marshal(opened.Value)
+					if err != nil {
+						return fmt.Errorf("encoding payload of topic message #%d: %w", entry.SequenceNumber, err)
+					}
+					schemaID, sender = opened.SchemaID, opened.Sender
+					fmt.Printf("#%v (%s envelope of %s from %s): %s\n", entry.SequenceNumber, opened.Encoding, schemaID, sender, decodedMsg)
+				} else if message.Total > 1 {
+					fmt.Printf("#%v (%d chunks %v): %s\n", entry.SequenceNumber, message.Total, chunkSeqNums, decodedMsg)
+				} else {
+					fmt.Printf("#%v: %s\n", entry.SequenceNumber, decodedMsg)
+				}
+			}
+			topicMessages = append(topicMessages, topicMessage{
+				SequenceNumber:     entry.SequenceNumber,
+				ConsensusTimestamp: entry.ConsensusTimestamp,
+				Message:            string(decodedMsg),
+				Chunks:             chunkSeqNums,
+				SchemaID:           schemaID,
+				Sender:             sender,
+				SignedBy:           signedBy,
+			})
+			if *limit > 0 && len(topicMessages) >= *limit {
+				return mirror.ErrStopIteration
+			}
+			return nil
+		})
+		e.report.AddMirrorVerification("topicMessages", topicMirrorNodeApiUrl, topicMessages, err)
+		if err != nil {
+			return fmt.Errorf("fetching topic messages: %w", err)
+		}
+
+		// A chunk set is incomplete when some of its chunks are before -from, after -limit,
+		// not on the mirror node yet, or failed to submit
+		if pending := assembler.Pending(); len(pending) > 0 {
+			type incompleteMessage struct {
+				InitialTransactionID string  `json:"initialTransactionId"`
+				Total                int     `json:"total"`
+				Missing              []int   `json:"missing"`
+				Chunks               []int64 `json:"chunks"`
+			}
+			incompleteMessages := make([]incompleteMessage, len(pending))
+			for i, message := range pending {
+				incompleteMessages[i] = incompleteMessage{
+					InitialTransactionID: message.InitialTransactionID,
+					Total:                message.Total,
+					Missing:              message.Missing(),
+					Chunks:               message.SequenceNumbers(),
+				}
+				fmt.Printf("⚠️ Incomplete message %s: read chunks %v, missing chunks %v of %d\n",
+					message.InitialTransactionID, message.SequenceNumbers(), message.Missing(), message.Total)
+			}
+			e.report.Set("incompleteMessages", incompleteMessages)
+		}
+		if registry != nil || len(deadLetters.Letters()) > 0 {
+			e.report.Set("deadLetters", deadLetters.Letters())
+		}
+		return nil
+	}
+}
+
+// messageSigner is who signed a protected message, whose key satisfies the key of the sender account,
+// and whether its payload was decrypted
+type messageSigner struct {
+	Sender    string `json:"sender"`
+	PublicKey string `json:"publicKey"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+	Decrypted bool   `json:"decrypted,omitempty"`
+}
+
+func (s *messageSigner) String() string {
+	signed := fmt.Sprintf("signed by %s, verified with key %s", s.Sender, s.PublicKey)
+	switch {
+	case s.Decrypted:
+		return signed + ", decrypted"
+	case s.Encrypted:
+		return signed + ", encrypted"
+	}
+	return signed
+}
+
+// protectedReader opens the protected messages of a topic, and verifies each of their senders once
+type protectedReader struct {
+	topicId string
+	keys    []hedera.PrivateKey
+	// verified holds the result of verifying each sender and public key
+	verified map[string]error
+}
+
+func newProtectedReader(topicId hedera.TopicID, keys []hedera.PrivateKey) *protectedReader {
+	return &protectedReader{topicId: topicId.String(), keys: keys, verified: map[string]error{}}
+}
+
+// open returns the signer and the payload of a protected message, or no signer and the message itself for any other message.
+// An encrypted message that none of the keys can decrypt has a nil payload.
+// A protected message with an invalid signature, or whose sender is not verified, returns an error,
+// so that it is set aside like any other message that cannot be opened.
+func (r *protectedReader) open(ctx context.Context, e *env, message []byte) (*messageSigner, []byte, error) {
+	opened, err := protected.Open(r.topicId, message, r.keys...)
+	if errors.Is(err, protected.ErrNotProtected) {
+		return nil, message, nil
+	}
+	if err != nil && !errors.Is(err, protected.ErrNotRecipient) {
+		return nil, nil, err
+	}
+	// Anyone can sign a message that claims to be from any account, so the key is checked against the account
+	signer := &messageSigner{
+		Sender:    opened.Sender,
+		PublicKey: opened.PublicKey.StringRaw(),
+		Encrypted: opened.Encrypted(),
+		Decrypted: opened.Encrypted() && err == nil,
+	}
+	sender := signer.Sender + "/" + signer.PublicKey
+	verifyErr, ok := r.verified[sender]
+	if !ok {
+		verifyErr = protected.VerifySender(ctx, e.mirror, opened)
+		r.verified[sender] = verifyErr
+	}
+	if verifyErr != nil {
+		return nil, nil, fmt.Errorf("the sender is not verified: %w", verifyErr)
+	}
+	return signer, opened.Payload, nil
+}
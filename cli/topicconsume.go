@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/chunks"
+	"lib/consumer"
+	"lib/subscribe"
+)
+
+func topicConsumeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	checkpoint := fs.String("checkpoint", "", "file to keep the checkpoint in (default: checkpoints/<topic>.json)")
+	follow := fs.Bool("follow", false, "after catching up, keep consuming new messages from the gRPC API until interrupted")
+	out := fs.String("out", "", "file to append each message to, as a line of JSON (default: only print them)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		if *checkpoint == "" {
+			*checkpoint = filepath.Join("checkpoints", topicId.String()+".json")
+		}
+		e.report.AddEntity("topic", topicId)
+
+		writer, closeOut, err := openAppend("out", *out)
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		handle := func(ctx context.Context, message *chunks.Message) error {
+			last := message.Last()
+			contents, err := message.Contents()
+			if err != nil {
+				return err
+			}
+			runningHash, err := base64.StdEncoding.DecodeString(last.RunningHash)
+			if err != nil {
+				return fmt.Errorf("decoding running hash of topic message #%d: %w", last.SequenceNumber, err)
+			}
+			fmt.Printf("#%d %s: %s\n", last.SequenceNumber, last.ConsensusTimestamp, contents)
+			line := subscribedMessage{
+				SequenceNumber:     uint64(last.SequenceNumber),
+				ConsensusTimestamp: last.ConsensusTimestamp,
+				Message:            string(contents),
+				RunningHash:        hex.EncodeToString(runningHash),
+			}
+			if message.Total > 1 {
+				line.Chunks = message.Total
+			}
+			return writeMessage(writer, line)
+		}
+		opts := consumer.Options{
+			OnGap: func(from uint64, to uint64) {
+				fmt.Printf("⚠️ Messages #%d to #%d are missing, fetching them from the REST API\n", from, to)
+			},
+		}
+		c, err := consumer.New(e.mirror, topicId.String(), consumer.FileStore{Path: *checkpoint}, handle, opts)
+		if err != nil {
+			return flagError("checkpoint", err)
+		}
+		if saved := c.Checkpoint(); saved.SequenceNumber > 0 {
+			fmt.Printf("Continuing after message #%d, from checkpoint %s\n", saved.SequenceNumber, *checkpoint)
+		}
+		// The stats and the checkpoint are reported even when consuming stops with an error,
+		// since every message before the checkpoint has been handled
+		defer func() {
+			stats := c.Stats()
+			e.report.Set("messagesDelivered", stats.Delivered)
+			e.report.Set("duplicatesDropped", stats.Duplicates)
+			e.report.Set("gapsFilled", stats.Gaps)
+			e.report.Set("messagesRefetched", stats.Refetched)
+			e.report.Set("checkpoint", c.Checkpoint())
+			fmt.Printf("Delivered %d messages, dropped %d duplicates, filled %d gaps, checkpoint at #%d\n",
+				stats.Delivered, stats.Duplicates, stats.Gaps, c.Checkpoint().SequenceNumber)
+		}()
+
+		fmt.Printf("🟣 Get new topic messages of topic %s from the Hedera Mirror Node\n", topicId.String())
+		if err := c.Poll(ctx); err != nil {
+			return fmt.Errorf("consuming topic messages: %w", err)
+		}
+		if !*follow {
+			return nil
+		}
+
+		// Consume until interrupted, e.g. with Ctrl+C, or stopped
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("🟣 Subscribe to topic %s on the mirror node gRPC API, press Ctrl+C to stop\n", topicId.String())
+		err = c.Run(ctx, e.client, subscribe.Options{
+			OnReconnect: func(attempt int, resumeFrom time.Time, err error) {
+				fmt.Fprintf(os.Stderr, "Disconnected (%v), reconnecting from %s, attempt %d\n", err, resumeFrom.UTC().Format(time.RFC3339Nano), attempt)
+			},
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("consuming topic messages: %w", err)
+		}
+		return nil
+	}
+}
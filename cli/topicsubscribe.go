@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+	"lib/subscribe"
+)
+
+// subscribedMessage is a line of the `-out` file
+type subscribedMessage struct {
+	SequenceNumber     uint64 `json:"sequenceNumber"`
+	ConsensusTimestamp string `json:"consensusTimestamp"`
+	Message            string `json:"message"`
+	RunningHash        string `json:"runningHash"`
+	// Chunks is the number of chunks of a chunked message
+	Chunks int `json:"chunks,omitempty"`
+}
+
+func topicSubscribeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	topic := fs.String("topic", "", "topic ID (required)")
+	startTime := fs.String("start-time", "", "consensus time to start at, as RFC 3339 or seconds.nanoseconds (default: the first message)")
+	fromSequence := fs.Uint64("from", 0, "sequence number to start at, instead of -start-time")
+	endTime := fs.String("end-time", "", "consensus time to end at, as RFC 3339 or seconds.nanoseconds (default: never)")
+	limit := fs.Uint64("limit", 0, "number of messages after which to end (default: never)")
+	out := fs.String("out", "", "file to append each message to, as a line of JSON (default: only print them)")
+	maxReconnects := fs.Int("max-reconnects", subscribe.DefaultMaxReconnects, "number of reconnects in a row, without a message in between, before giving up")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("topic", *topic); err != nil {
+			return err
+		}
+		topicId, err := hedera.TopicIDFromString(*topic)
+		if err != nil {
+			return flagError("topic", err)
+		}
+		if *startTime != "" && *fromSequence > 0 {
+			return flagError("from", fmt.Errorf("only one of -start-time and -from can be set"))
+		}
+		opts := subscribe.Options{
+			Limit:         *limit,
+			MaxReconnects: *maxReconnects,
+			OnReconnect: func(attempt int, resumeFrom time.Time, err error) {
+				fmt.Fprintf(os.Stderr, "Disconnected (%v), reconnecting from %s, attempt %d\n", err, resumeFrom.UTC().Format(time.RFC3339Nano), attempt)
+			},
+		}
+		if *startTime != "" {
+			opts.StartTime, err = parseTime(*startTime)
+			if err != nil {
+				return flagError("start-time", err)
+			}
+		}
+		if *endTime != "" {
+			opts.EndTime, err = parseTime(*endTime)
+			if err != nil {
+				return flagError("end-time", err)
+			}
+		}
+		e.report.AddEntity("topic", topicId)
+
+		if *fromSequence > 0 {
+			// The gRPC API starts at a consensus time, so the sequence number is looked up on the REST API
+			fmt.Printf("🟣 Get topic message #%d from the Hedera Mirror Node\n", *fromSequence)
+			topicMsg, err := e.mirror.WaitForTopicMessage(ctx, mirror.DefaultBackoff, topicId.String(), *fromSequence)
+			if err != nil {
+				return fmt.Errorf("finding topic message on the mirror node: %w", err)
+			}
+			opts.StartTime, err = mirror.ParseTimestamp(topicMsg.ConsensusTimestamp)
+			if err != nil {
+				return fmt.Errorf("parsing consensus timestamp of topic message #%d: %w", *fromSequence, err)
+			}
+			opts.AfterSequenceNumber = *fromSequence - 1
+		}
+
+		writer, closeOut, err := openAppend("out", *out)
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+
+		// Subscribe until interrupted, e.g. with Ctrl+C, or stopped, and report the last message seen
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("🟣 Subscribe to topic %s on the mirror node gRPC API, press Ctrl+C to stop\n", topicId.String())
+		position, err := subscribe.Run(ctx, e.client, topicId, opts, func(topicMsg hedera.TopicMessage) error {
+			fmt.Printf("#%d %s: %s\n", topicMsg.SequenceNumber, topicMsg.ConsensusTimestamp.UTC().Format(time.RFC3339Nano), topicMsg.Contents)
+			return writeMessage(writer, subscribedMessage{
+				SequenceNumber:     topicMsg.SequenceNumber,
+				ConsensusTimestamp: fmt.Sprintf("%d.%09d", topicMsg.ConsensusTimestamp.Unix(), topicMsg.ConsensusTimestamp.Nanosecond()),
+				Message:            string(topicMsg.Contents),
+				RunningHash:        hex.EncodeToString(topicMsg.RunningHash),
+				Chunks:             len(topicMsg.Chunks),
+			})
+		})
+		e.report.Set("messagesReceived", position.Delivered)
+		if position.SequenceNumber > 0 {
+			e.report.Set("lastSequenceNumber", position.SequenceNumber)
+			fmt.Printf("Received %d messages, the last one is #%d\n", position.Delivered, position.SequenceNumber)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("subscribing to topic: %w", err)
+		}
+		return nil
+	}
+}
+
+// openAppend opens the file of a flag, e.g. `-out`, for appending, or returns a nil writer when path is empty
+func openAppend(name string, path string) (io.Writer, func(), error) {
+	if path == "" {
+		return nil, func() {}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, flagError(name, err)
+	}
+	return file, func() { file.Close() }, nil
+}
+
+// writeMessage appends a message to the `-out` file as a line of JSON, if there is one
+func writeMessage(writer io.Writer, message subscribedMessage) error {
+	if writer == nil {
+		return nil
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encoding topic message #%d: %w", message.SequenceNumber, err)
+	}
+	if _, err := writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing topic message #%d: %w", message.SequenceNumber, err)
+	}
+	return nil
+}
+
+// parseTime accepts RFC 3339 times, and mirror node timestamps in seconds.nanoseconds
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	t, err := mirror.ParseTimestamp(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor seconds.nanoseconds", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/failure"
+	"lib/offline"
+	"lib/operator"
+)
+
+// freezeFlags are the flags of the commands that can export their transaction to a file,
+// to be signed elsewhere and submitted later with `tx submit`, instead of submitting it
+type freezeFlags struct {
+	out           *string
+	payer         *string
+	validStart    *string
+	validDuration *time.Duration
+}
+
+// maxValidDuration is the longest valid duration that the network accepts
+const maxValidDuration = 180 * time.Second
+
+func registerFreezeFlags(fs *flag.FlagSet) *freezeFlags {
+	return &freezeFlags{
+		out:           fs.String("freeze-to", "", "freeze the transaction and write it to this file, instead of signing and submitting it"),
+		payer:         fs.String("payer", "", "fee payer account ID of the frozen transaction (default: the operator account)"),
+		validStart:    fs.String("valid-start", "", "valid start of the frozen transaction, in RFC 3339, e.g. 2024-04-05T19:00:00Z (default: now)"),
+		validDuration: fs.Duration("valid-duration", 120*time.Second, "valid duration of the transaction, at most 3m0s"),
+	}
+}
+
+// enabled reports whether the transaction is exported instead of submitted
+func (f *freezeFlags) enabled() bool {
+	return *f.out != ""
+}
+
+// transactionID returns a new transaction ID for the fee payer, valid from `-valid-start`.
+// The payer signs the transaction file like any other signer, so its key does not need to be on this machine,
+// although the operator config is loaded as for any other command, for the network and the default keys.
+func (f *freezeFlags) transactionID(e *env) (hedera.TransactionID, error) {
+	if *f.validDuration <= 0 || *f.validDuration > maxValidDuration {
+		return hedera.TransactionID{}, flagError("valid-duration", fmt.Errorf("must be between 1s and %v, got %v", maxValidDuration, *f.validDuration))
+	}
+	payerId := e.operator.AccountID
+	if *f.payer != "" && !f.enabled() {
+		return hedera.TransactionID{}, flagError("payer", fmt.Errorf("only used together with -freeze-to"))
+	}
+	if *f.payer != "" {
+		var err error
+		payerId, err = hedera.AccountIDFromString(*f.payer)
+		if err != nil {
+			return hedera.TransactionID{}, flagError("payer", err)
+		}
+	}
+	if *f.validStart == "" {
+		return hedera.TransactionIDGenerate(payerId), nil
+	}
+	// A later valid start leaves the signers more time than the valid duration,
+	// as the network only accepts the transaction from its valid start
+	if !f.enabled() {
+		return hedera.TransactionID{}, flagError("valid-start", fmt.Errorf("only used together with -freeze-to"))
+	}
+	validStart, err := time.Parse(time.RFC3339Nano, *f.validStart)
+	if err != nil {
+		return hedera.TransactionID{}, flagError("valid-start", err)
+	}
+	return hedera.NewTransactionIDWithValidStart(payerId, validStart), nil
+}
+
+// unfrozenTransaction is a transaction that is being built, e.g. *hedera.TokenMintTransaction
+type unfrozenTransaction[T any] interface {
+	SetTransactionID(transactionID hedera.TransactionID) T
+	SetTransactionValidDuration(duration time.Duration) T
+	FreezeWith(client *hedera.Client) (T, error)
+}
+
+// freezeWith freezes tx with the transaction ID and valid duration of the freeze flags
+func freezeWith[T unfrozenTransaction[T]](e *env, f *freezeFlags, tx T) (T, error) {
+	txId, err := f.transactionID(e)
+	if err != nil {
+		return tx, err
+	}
+	return tx.SetTransactionID(txId).SetTransactionValidDuration(*f.validDuration).FreezeWith(e.client)
+}
+
+// export writes the frozen transaction to the `-freeze-to` file
+func (f *freezeFlags) export(e *env, tx offline.Transaction) error {
+	if err := offline.Write(*f.out, tx); err != nil {
+		return fmt.Errorf("writing transaction file: %w", err)
+	}
+	e.report.Set("transactionFile", *f.out)
+	fmt.Printf("The frozen transaction has been written to: %s\n", *f.out)
+	fmt.Println("Sign it with `hfw tx sign`, then submit it with `hfw tx submit`, from its valid start until its valid duration runs out")
+	return nil
+}
+
+func txShowCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	in := fs.String("in", "", "transaction file (required)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("in", *in); err != nil {
+			return err
+		}
+		tx, err := offline.Read(*in)
+		if err != nil {
+			return flagError("in", err)
+		}
+		return showTransaction(e, tx)
+	}
+}
+
+func txSignCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	in := fs.String("in", "", "transaction file (required)")
+	out := fs.String("out", "", "signed transaction file (default: overwrite the -in file)")
+	var keys operator.KeyList
+	fs.Var(&keys, "key", "private key to sign with, may be repeated")
+	var keyFiles listFlag
+	fs.Var(&keyFiles, "key-file", "file holding a private key to sign with, may be repeated")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("in", *in); err != nil {
+			return err
+		}
+		// Keys in files stay out of the shell history
+		for _, keyFile := range keyFiles {
+			keyBytes, err := os.ReadFile(keyFile)
+			if err != nil {
+				return flagError("key-file", err)
+			}
+			if err := keys.Set(string(keyBytes)); err != nil {
+				return flagError("key-file", fmt.Errorf("%s: %w", keyFile, err))
+			}
+		}
+		if len(keys) == 0 {
+			return requireFlag("key or -key-file", "")
+		}
+		tx, err := offline.Read(*in)
+		if err != nil {
+			return flagError("in", err)
+		}
+
+		// Review what is being signed, as the signature authorizes exactly this transaction
+		if err := showTransaction(e, tx); err != nil {
+			return err
+		}
+		fmt.Println("🟣 Signing the transaction")
+		tx, err = offline.Sign(tx, keys...)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Printf("Signed with: %s\n", key.PublicKey().String())
+		}
+		outPath := *out
+		if outPath == "" {
+			outPath = *in
+		}
+		if err := offline.Write(outPath, tx); err != nil {
+			return fmt.Errorf("writing transaction file: %w", err)
+		}
+		e.report.Set("transactionFile", outPath)
+		fmt.Printf("The signed transaction has been written to: %s\n", outPath)
+		return nil
+	}
+}
+
+func txMergeCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	out := fs.String("out", "", "merged transaction file (required)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("out", *out); err != nil {
+			return err
+		}
+		if fs.NArg() < 2 {
+			return failure.Wrap(failure.KindConfig, fmt.Errorf("expected two or more signed transaction files after the flags"))
+		}
+		fmt.Printf("🟣 Merging the signatures of %s\n", strings.Join(fs.Args(), ", "))
+		if err := offline.MergeFiles(*out, fs.Args()...); err != nil {
+			return failure.Wrap(failure.KindConfig, fmt.Errorf("merging transaction files: %w", err))
+		}
+		tx, err := offline.Read(*out)
+		if err != nil {
+			return err
+		}
+		e.report.Set("transactionFile", *out)
+		fmt.Printf("The merged transaction has been written to: %s\n", *out)
+		return showTransaction(e, tx)
+	}
+}
+
+func txSubmitCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) error {
+	in := fs.String("in", "", "signed transaction file (required)")
+	return func(ctx context.Context, e *env) error {
+		if err := requireFlag("in", *in); err != nil {
+			return err
+		}
+		tx, err := offline.Read(*in)
+		if err != nil {
+			return flagError("in", err)
+		}
+		summary, err := offline.Describe(tx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🟣 Submitting the signed %s\n", summary.Type)
+		fmt.Printf("The transaction ID: %s\n", summary.TransactionID.String())
+		txSubmitted, err := offline.Submit(e.client, tx)
+		if err != nil {
+			return fmt.Errorf("executing %s: %w", summary.Type, err)
+		}
+		txReceipt, err := txSubmitted.GetReceipt(e.client)
+		if err != nil {
+			return fmt.Errorf("getting receipt for %s: %w", summary.Type, err)
+		}
+		e.report.AddTransaction(summary.Type, summary.TransactionID, txReceipt.Status)
+		fmt.Printf("The transaction status is: %s\n", txReceipt.Status.String())
+
+		// Show the entity created, as the scripts do
+		switch {
+		case txReceipt.TopicID != nil:
+			e.report.AddEntity("topic", *txReceipt.TopicID)
+			fmt.Printf("Topic ID: %s\n", txReceipt.TopicID.String())
+		case txReceipt.TokenID != nil:
+			e.report.AddEntity("token", *txReceipt.TokenID)
+			fmt.Printf("Token ID: %s\n", txReceipt.TokenID.String())
+		case txReceipt.TopicSequenceNumber > 0:
+			e.report.Set("topicMessageSequenceNumber", txReceipt.TopicSequenceNumber)
+			fmt.Printf("Topic Message Sequence Number: %v\n", txReceipt.TopicSequenceNumber)
+		}
+		fmt.Printf("Transaction Hashscan URL: %s\n", e.operator.Network.ExplorerEntityURL("transaction", summary.TransactionID))
+		return e.report.FillFromMirror(ctx, e.mirror)
+	}
+}
+
+// showTransaction prints what a transaction file does, and who has signed it so far
+func showTransaction(e *env, tx offline.Transaction) error {
+	summary, err := offline.Describe(tx)
+	if err != nil {
+		return err
+	}
+	e.report.Set("transaction", summary)
+	fmt.Printf("Type: %s\n", summary.Type)
+	fmt.Printf("Transaction ID: %s\n", summary.TransactionID.String())
+	fmt.Printf("Memo: %s\n", summary.Memo)
+	fmt.Printf("Max transaction fee: %s\n", summary.MaxFee.String())
+	fmt.Printf("Nodes: %d\n", len(summary.Nodes))
+	for _, line := range summary.Details {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("Signed by %d key(s):\n", len(summary.SignedBy))
+	for _, publicKey := range summary.SignedBy {
+		fmt.Printf("  %s\n", publicKey)
+	}
+	return nil
+}
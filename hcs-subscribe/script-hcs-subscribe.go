@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/chunks"
+	"lib/consumer"
+	"lib/failure"
+	"lib/fakemirror"
+	"lib/mirror"
+	"lib/output"
+	"lib/subscribe"
+)
+
+// The topics only exist on the fake mirror node, so any topic IDs will do
+var (
+	topicId         = hedera.TopicID{Topic: 1001}
+	consumerTopicId = hedera.TopicID{Topic: 1002}
+)
+
+// receiveTimeout is how long to wait for the subscription to deliver a message
+const receiveTimeout = 10 * time.Second
+
+func main() {
+	report := output.RegisterFlags(flag.CommandLine, "hcs-subscribe")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
+	fmt.Println("🏁 Hello Future World - HCS Subscribe - start")
+
+	// This script runs offline, against a fake mirror node in the same process,
+	// so it needs neither an operator account nor a network
+	err := run(report)
+	report.Finish(err)
+	if err != nil {
+		failure.Exit(err)
+	}
+
+	fmt.Println("🎉 Hello Future World - HCS Subscribe - complete")
+}
+
+func run(report *output.Report) error {
+	fmt.Println("🟣 Starting the fake mirror node")
+	server, err := fakemirror.Start()
+	if err != nil {
+		return fmt.Errorf("starting fake mirror node: %w", err)
+	}
+	defer server.Close()
+	fmt.Printf("The fake mirror node gRPC address: %s\n", server.GRPCAddress())
+	fmt.Printf("The fake mirror node REST API URL: %s\n", server.RESTURL())
+
+	// The client only talks to the mirror node, so its consensus node is never used
+	client := hedera.ClientForNetwork(map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}})
+	defer client.Close()
+	client.SetMirrorNetwork([]string{server.GRPCAddress()})
+	mirrorClient := mirror.NewClient(server.RESTURL())
+
+	// A message larger than 1024 bytes is published in chunks, which the SDK reassembles
+	largeMessage := []byte(strings.Repeat("Hello HCS in chunks! ", 100))
+	messages := [][]byte{[]byte("Hello HCS #1"), []byte("Hello HCS #2"), largeMessage, []byte("Hello HCS #3")}
+	fmt.Printf("🟣 Publishing %d messages to topic %s\n", len(messages), topicId.String())
+	for _, message := range messages {
+		server.Publish(topicId, message)
+	}
+
+	if err := subscribeWithDisconnect(client, server, messages, report); err != nil {
+		return err
+	}
+	return consumeWithCheckpoint(client, server, mirrorClient, report)
+}
+
+// subscribeWithDisconnect subscribes from the first message, cuts the stream off once every message has arrived,
+// publishes more messages, and shows where the subscription resumed from
+func subscribeWithDisconnect(client *hedera.Client, server *fakemirror.Server, messages [][]byte, report *output.Report) error {
+	fmt.Println("🟣 Subscribing to the topic from its first message")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan hedera.TopicMessage)
+	reconnected := make(chan time.Time, 1)
+	opts := subscribe.Options{
+		ReconnectDelay: 100 * time.Millisecond,
+		OnReconnect: func(attempt int, resumeFrom time.Time, err error) {
+			fmt.Printf("Disconnected (%v), reconnecting from %s\n", err, resumeFrom.UTC().Format(time.RFC3339Nano))
+			select {
+			case reconnected <- resumeFrom:
+			default:
+			}
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := subscribe.Run(ctx, client, topicId, opts, func(message hedera.TopicMessage) error {
+			received <- message
+			return nil
+		})
+		done <- err
+	}()
+	if err := receive(received, len(messages)); err != nil {
+		return err
+	}
+
+	fmt.Println("🟣 Disconnecting the subscription, and publishing while it is disconnected")
+	server.Disconnect()
+	var resumeFrom time.Time
+	select {
+	case resumeFrom = <-reconnected:
+	case <-time.After(receiveTimeout):
+		return fmt.Errorf("the subscription did not reconnect")
+	}
+	moreMessages := [][]byte{[]byte("Hello HCS #4"), []byte("Hello HCS #5")}
+	for _, message := range moreMessages {
+		server.Publish(topicId, message)
+	}
+	if err := receive(received, len(moreMessages)); err != nil {
+		return err
+	}
+	for _, subscription := range server.Subscriptions() {
+		fmt.Printf("The fake mirror node received a subscription from %s\n", subscription.StartTime.UTC().Format(time.RFC3339Nano))
+	}
+	report.Set("resumedFrom", resumeFrom)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("ending the subscription: %w", err)
+	}
+	return nil
+}
+
+// consumeWithCheckpoint consumes a topic whose gRPC stream loses a message, which the consumer fetches from the REST API,
+// and then restarts the consumer from its checkpoint, to deliver only the messages published since
+func consumeWithCheckpoint(client *hedera.Client, server *fakemirror.Server, mirrorClient *mirror.Client, report *output.Report) error {
+	dir, err := os.MkdirTemp("", "hcs-subscribe")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	store := consumer.FileStore{Path: filepath.Join(dir, consumerTopicId.String()+".json")}
+
+	// Message #2 never arrives on the stream, and the large message is chunks #3 to #5
+	messages := [][]byte{[]byte("Hello consumer #1"), []byte("Hello consumer #2"), []byte(strings.Repeat("Hello consumer in chunks! ", 100)), []byte("Hello consumer #3")}
+	fmt.Printf("🟣 Publishing %d messages to topic %s, and losing message #2 on the gRPC stream\n", len(messages), consumerTopicId.String())
+	for _, message := range messages {
+		server.Publish(consumerTopicId, message)
+	}
+	server.HideFromStream(consumerTopicId, 2)
+
+	fmt.Println("🟣 Consuming the topic from the gRPC API")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var delivered []uint64
+	handle := func(ctx context.Context, message *chunks.Message) error {
+		sequenceNumber := uint64(message.Last().SequenceNumber)
+		fmt.Printf("#%d: %d chunks\n", sequenceNumber, message.Total)
+		delivered = append(delivered, sequenceNumber)
+		if len(delivered) == len(messages) {
+			cancel()
+		}
+		return nil
+	}
+	opts := consumer.Options{
+		OnGap: func(from uint64, to uint64) {
+			fmt.Printf("Messages #%d to #%d are missing, fetching them from the REST API\n", from, to)
+		},
+	}
+	first, err := consumer.New(mirrorClient, consumerTopicId.String(), store, handle, opts)
+	if err != nil {
+		return err
+	}
+	ctx, stop := context.WithTimeout(ctx, receiveTimeout)
+	defer stop()
+	if err := first.Run(ctx, client, subscribe.Options{}); !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("consuming topic: %w", err)
+	}
+	stats := first.Stats()
+	fmt.Printf("Delivered %d messages, and fetched %d from the REST API to fill %d gaps\n", stats.Delivered, stats.Refetched, stats.Gaps)
+
+	moreMessages := [][]byte{[]byte("Hello consumer #4"), []byte("Hello consumer #5")}
+	fmt.Printf("🟣 Publishing %d more messages, and restarting the consumer from its checkpoint\n", len(moreMessages))
+	for _, message := range moreMessages {
+		server.Publish(consumerTopicId, message)
+	}
+	delivered = nil
+	second, err := consumer.New(mirrorClient, consumerTopicId.String(), store, handle, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Continuing after message #%d\n", second.Checkpoint().SequenceNumber)
+	if err := second.Poll(context.Background()); err != nil {
+		return fmt.Errorf("consuming topic: %w", err)
+	}
+	checkpoint := second.Checkpoint()
+	fmt.Printf("The restarted consumer delivered messages %v, and saved its checkpoint at #%d\n", delivered, checkpoint.SequenceNumber)
+	report.Set("checkpoint", checkpoint)
+	return nil
+}
+
+// receive waits for count messages, and prints them
+func receive(received <-chan hedera.TopicMessage, count int) error {
+	for i := 0; i < count; i++ {
+		select {
+		case message := <-received:
+			fmt.Printf("#%d %s: %d bytes\n", message.SequenceNumber, message.ConsensusTimestamp.UTC().Format(time.RFC3339Nano), len(message.Contents))
+		case <-time.After(receiveTimeout):
+			return fmt.Errorf("received %d of %d messages", i, count)
+		}
+	}
+	return nil
+}
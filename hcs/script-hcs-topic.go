@@ -1,150 +1,215 @@
 package main
 
 import (
-	"encoding/json"
-	"encoding/base64"
+	"context"
+	"flag"
 	"fmt"
-	"log"
-	"os"
-	"strings"
-	"time"
+	"net/url"
 
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"github.com/imroc/req/v3"
-	"github.com/joho/godotenv"
-)
 
-type TopicMessagesMNAPIResponse struct {
-	Messages []struct {
-		SequenceNumber int64 `json:"sequence_number"`
-		Message string `json:"message"`
-	} `json:"messages"`
-}
+	"lib/failure"
+	"lib/mirror"
+	"lib/operator"
+	"lib/output"
+)
 
 func main() {
+	operatorOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "hcs-topic")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
 	fmt.Println("🏁 Hello Future World - HCS Topic - start")
 
-	// Load environment variables from .env file
-	err := godotenv.Load("../.env")
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*operatorOpts, report)
+	report.Finish(err)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		failure.Exit(err)
 	}
 
-	// Initialize the operator account
-	operatorIdStr := os.Getenv("OPERATOR_ACCOUNT_ID")
-	operatorKeyStr := os.Getenv("OPERATOR_ACCOUNT_PRIVATE_KEY")
-	if operatorIdStr == "" || operatorKeyStr == "" {
-		log.Fatal("Must set OPERATOR_ACCOUNT_ID, OPERATOR_ACCOUNT_PRIVATE_KEY")
-	}
+	fmt.Println("🎉 Hello Future World - HCS Topic - complete")
+}
 
-	operatorId, _ := hedera.AccountIDFromString(operatorIdStr)
-	// Necessary because Go SDK v2.37.0 does not handle the `0x` prefix automatically
-	// Ref: https://github.com/hashgraph/hedera-sdk-go/issues/1057
-	operatorKeyStr = strings.TrimPrefix(operatorKeyStr, "0x")
-	operatorKey, _ := hedera.PrivateKeyFromStringECDSA(operatorKeyStr)
+func run(operatorOpts operator.Options, report *output.Report) error {
+	// Load the operator account from flags, environment variables, or the .env file
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+	operatorId := operatorConfig.AccountID
+	operatorKey := operatorConfig.PrivateKey
 	fmt.Printf("Using account: %s\n", operatorId)
-	fmt.Printf("Using operatorKey: %s\n", operatorKeyStr)
+	fmt.Printf("Using operatorKey: %s\n", operatorKey.StringRaw())
 
 	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
-	client := hedera.ClientForTestnet()
-	client.SetOperator(operatorId, operatorKey)
+	// The client also sets the default maximum transaction fee and query payment
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
+
+	topicId, err := createTopic(client, operatorKey, report)
+	if err != nil {
+		return err
+	}
 
-	// Set the default maximum transaction fee (in HBAR)
-	client.SetDefaultMaxTransactionFee(hedera.HbarFrom(100, hedera.HbarUnits.Hbar))
-	// Set the default maximum payment for queries (in HBAR)
-	client.SetDefaultMaxQueryPayment(hedera.HbarFrom(50, hedera.HbarUnits.Hbar))
+	topicMsgSeqNum, err := submitMessage(client, operatorKey, topicId, report)
+	if err != nil {
+		return err
+	}
 
-	// Create a Hedera Consensus Service (HCS) topic
+	// Verify transaction using Hashscan
+	// This is a manual step, the code below only outputs the URL to visit
+
+	// View your topic on HashScan
+	fmt.Println("🟣 View the topic on HashScan")
+	topicHashscanUrl :=
+		operatorConfig.Network.ExplorerEntityURL("topic", topicId)
+	fmt.Printf("Topic Hashscan URL: %s\n", topicHashscanUrl)
+
+	mirrorClient := mirror.NewClient(operatorConfig.Network.MirrorNodeURL)
+	err = verifyTopicMessages(mirrorClient, topicId, topicMsgSeqNum, report)
+	if err != nil {
+		return err
+	}
+	return report.FillFromMirror(context.Background(), mirrorClient)
+}
+
+// createTopic creates a Hedera Consensus Service (HCS) topic
+func createTopic(client *hedera.Client, operatorKey hedera.PrivateKey, report *output.Report) (hedera.TopicID, error) {
 	fmt.Println("🟣 Creating new HCS topic")
-	topicCreateTx, _ := hedera.NewTopicCreateTransaction().
-	  SetTopicMemo("Hello Future World topic - xyz").
-	  // Freeze the transaction to prepare for signing
-	  FreezeWith(client);
+	topicCreateTx, err := hedera.NewTopicCreateTransaction().
+		SetTopicMemo("Hello Future World topic - xyz").
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return hedera.TopicID{}, fmt.Errorf("freezing TopicCreateTransaction: %w", err)
+	}
 
 	// Get the transaction ID of the transaction.
 	// The SDK automatically generates and assigns a transaction ID when the transaction is created
-	topicCreateTxId := topicCreateTx.GetTransactionID();
+	topicCreateTxId := topicCreateTx.GetTransactionID()
 	fmt.Printf("The topic create transaction ID: %s\n", topicCreateTxId.String())
 
 	// Sign the transaction with the private key of the treasury account (operator key)
 	topicCreateTxSigned := topicCreateTx.Sign(operatorKey)
 
 	// Submit the transaction to the Hedera Testnet
-	topicCreateTxSubmitted, _ := topicCreateTxSigned.Execute(client)
+	topicCreateTxSubmitted, err := topicCreateTxSigned.Execute(client)
+	if err != nil {
+		return hedera.TopicID{}, fmt.Errorf("executing TopicCreateTransaction: %w", err)
+	}
 
 	// Get the transaction receipt
-	topicCreateTxReceipt, _ := topicCreateTxSubmitted.GetReceipt(client)
+	topicCreateTxReceipt, err := topicCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.TopicID{}, fmt.Errorf("getting receipt for TopicCreateTransaction: %w", err)
+	}
 
-	// Get the token ID
-	topicId := topicCreateTxReceipt.TopicID
+	report.AddTransaction("topicCreate", topicCreateTxId, topicCreateTxReceipt.Status)
+
+	// Get the topic ID
+	topicId := *topicCreateTxReceipt.TopicID
 	fmt.Printf("Topic ID: %s\n", topicId.String())
+	report.AddEntity("topic", topicId)
+	return topicId, nil
+}
 
-	// Publish a message to the Hedera Consensus Service (HCS) topic
+// submitMessage publishes a message to the Hedera Consensus Service (HCS) topic,
+// and returns its sequence number
+func submitMessage(client *hedera.Client, operatorKey hedera.PrivateKey, topicId hedera.TopicID, report *output.Report) (uint64, error) {
 	fmt.Println("🟣 Publish message to HCS topic")
-	topicMsgSubmitTx, _ := hedera.NewTopicMessageSubmitTransaction().
-	  //Set the transaction memo with the hello future world ID
-	  SetTransactionMemo("Hello Future World topic message - xyz").
-	  SetTopicID(*topicId).
-	  // Set the topic message contents
-	  SetMessage([]byte("Hello HCS!")).
-	  // Freeze the transaction to prepare for signing
-	  FreezeWith(client);
+	topicMsgSubmitTx, err := hedera.NewTopicMessageSubmitTransaction().
+		//Set the transaction memo with the hello future world ID
+		SetTransactionMemo("Hello Future World topic message - xyz").
+		SetTopicID(topicId).
+		// Set the topic message contents
+		SetMessage([]byte("Hello HCS!")).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return 0, fmt.Errorf("freezing TopicMessageSubmitTransaction: %w", err)
+	}
 
 	// Get the transaction ID of the transaction.
 	// The SDK automatically generates and assigns a transaction ID when the transaction is created
-	topicMsgSubmitTxId := topicMsgSubmitTx.GetTransactionID();
+	topicMsgSubmitTxId := topicMsgSubmitTx.GetTransactionID()
 	fmt.Printf("The topic message submit transaction ID: %s\n", topicMsgSubmitTxId.String())
 
 	// Sign the transaction with the private key of the treasury account (operator key)
 	topicMsgSubmitTxSigned := topicMsgSubmitTx.Sign(operatorKey)
 
 	// Submit the transaction to the Hedera Testnet
-	topicMsgSubmitTxSubmitted, _ := topicMsgSubmitTxSigned.Execute(client)
+	topicMsgSubmitTxSubmitted, err := topicMsgSubmitTxSigned.Execute(client)
+	if err != nil {
+		return 0, fmt.Errorf("executing TopicMessageSubmitTransaction: %w", err)
+	}
 
 	// Get the transaction receipt
-	topicMsgSubmitTxReceipt, _ := topicMsgSubmitTxSubmitted.GetReceipt(client)
+	topicMsgSubmitTxReceipt, err := topicMsgSubmitTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return 0, fmt.Errorf("getting receipt for TopicMessageSubmitTransaction: %w", err)
+	}
+
+	report.AddTransaction("topicMessageSubmit", topicMsgSubmitTxId, topicMsgSubmitTxReceipt.Status)
 
 	// Get the topic message sequence number
 	topicMsgSeqNum := topicMsgSubmitTxReceipt.TopicSequenceNumber
 	fmt.Printf("Topic Message Sequence Number: %v\n", topicMsgSeqNum)
+	report.Set("topicMessageSequenceNumber", topicMsgSeqNum)
+	return topicMsgSeqNum, nil
+}
 
-	client.Close()
-
-	// Verify transaction using Hashscan
-	// This is a manual step, the code below only outputs the URL to visit
-
-	// View your topic on HashScan
-	fmt.Println("🟣 View the topic on HashScan")
-	topicHashscanUrl :=
-		fmt.Sprintf("https://hashscan.io/testnet/topic/%s", topicId.String())
-	fmt.Printf("Topic Hashscan URL: %s\n", topicHashscanUrl)
-
-	// Wait for 6s for record files (blocks) to propagate to mirror nodes
-	time.Sleep(6 * time.Second)
+// verifyTopicMessages reads the topic messages back from the mirror node
+func verifyTopicMessages(mirrorClient *mirror.Client, topicId hedera.TopicID, topicMsgSeqNum uint64, report *output.Report) error {
+	// Wait for record files (blocks) to propagate to mirror nodes,
+	// by polling until the message that was just published is visible
+	topicMessageMirrorNodeApiUrl :=
+		mirrorClient.URL(fmt.Sprintf("topics/%s/messages/%d", topicId.String(), topicMsgSeqNum), nil)
+	topicMessage, err := mirrorClient.WaitForTopicMessage(context.Background(), mirror.DefaultBackoff, topicId.String(), topicMsgSeqNum)
+	report.AddMirrorVerification("topicMessage", topicMessageMirrorNodeApiUrl, topicMessage, err)
+	if err != nil {
+		return fmt.Errorf("finding topic message on the mirror node: %w", err)
+	}
 
 	// Verify topic using Mirror Node API
 	fmt.Println("🟣 Get topic data from the Hedera Mirror Node")
+	// Read every message from the start of the topic, 5 per page,
+	// following the `links.next` cursor until the last page
+	topicMirrorNodeApiOpts := mirror.ListOptions{
+		Limit:          5,
+		Order:          mirror.OrderAsc,
+		SequenceNumber: []mirror.Condition{mirror.Gte(1)},
+		Extra:          url.Values{"encoding": {"base64"}},
+	}
 	topicMirrorNodeApiUrl :=
-		fmt.Sprintf("https://testnet.mirrornode.hedera.com/api/v1/topics/%s/messages?encoding=base64&limit=5&order=asc&sequencenumber=1", topicId.String())
+		mirrorClient.URL(fmt.Sprintf("topics/%s/messages", topicId.String()), topicMirrorNodeApiOpts.Query())
 	fmt.Printf("The token Hedera Mirror Node API URL: %s\n", topicMirrorNodeApiUrl)
 
-	httpResp, err := req.R().Get(topicMirrorNodeApiUrl)
-	if err != nil {
-		log.Fatalf("Failed to fetch token URL: %v", err)
-	}
-	var topicResp TopicMessagesMNAPIResponse
-	err = json.Unmarshal(httpResp.Bytes(), &topicResp)
-	if err != nil {
-		log.Fatalf("Failed to parse JSON of response fetched from topic message URL: %v", err)
-	}
-
-	topicMessages := topicResp.Messages
 	fmt.Println("Messages retrieved from this topic:")
-	for _, entry := range topicMessages {
+	var topicMessages []string
+	err = mirrorClient.EachTopicMessage(context.Background(), topicId.String(), topicMirrorNodeApiOpts, func(entry mirror.TopicMessage) error {
 		seqNum := entry.SequenceNumber
-		decodedMsg, _ := base64.StdEncoding.DecodeString(entry.Message)
+		decodedMsg, err := entry.Contents()
+		if err != nil {
+			return fmt.Errorf("decoding topic message #%d: %w", seqNum, err)
+		}
 		fmt.Printf("#%v: %s\n", seqNum, decodedMsg)
+		topicMessages = append(topicMessages, string(decodedMsg))
+		return nil
+	})
+	report.AddMirrorVerification("topicMessages", topicMirrorNodeApiUrl, topicMessages, err)
+	if err != nil {
+		return fmt.Errorf("fetching topic messages: %w", err)
 	}
-
-	fmt.Println("🎉 Hello Future World - HCS Topic - complete")
+	return nil
 }
@@ -20,7 +20,6 @@ require (
 	github.com/go-task/slim-sprig/v3 v3.0.0 // indirect
 	github.com/google/pprof v0.0.0-20240903155634-a8630aee4ab9 // indirect
 	github.com/hashgraph/hedera-protobufs-go v0.2.1-0.20240507130336-6f8f530d2c86 // indirect
-	github.com/hashgraph/hedera-sdk-go/v2 v2.37.0 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/holiman/uint256 v1.2.4 // indirect
@@ -58,3 +57,10 @@ require (
 	google.golang.org/protobuf v1.34.1 // indirect
 	rsc.io/tmplfunc v0.0.3 // indirect
 )
+
+require (
+	github.com/hashgraph/hedera-sdk-go/v2 v2.37.0
+	lib v0.0.0-00010101000000-000000000000
+)
+
+replace lib => ../lib
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.17;
+
+contract MyContract {
+    string public greeting;
+    mapping(address => string) public names;
+
+    event Introduced(address indexed who, string name);
+
+    constructor(string memory _greeting) {
+        greeting = _greeting;
+    }
+
+    function introduce(string memory name) public {
+        names[msg.sender] = name;
+        emit Introduced(msg.sender, name);
+    }
+
+    function greet() public view returns (string memory) {
+        return string.concat(greeting, names[msg.sender]);
+    }
+}
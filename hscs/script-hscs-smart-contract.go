@@ -1,53 +1,359 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"github.com/joho/godotenv"
+
+	"lib/contract"
+	"lib/failure"
+	"lib/mirror"
+	"lib/operator"
+	"lib/output"
+	"lib/txid"
+)
+
+// bytecodeFileChunkSize is the amount of bytecode uploaded by the FileCreateTransaction,
+// the remainder is uploaded by the FileAppendTransaction, which splits it into further chunks.
+// This is the same split that the SDK's ContractCreateFlow uses.
+const bytecodeFileChunkSize = 2048
+
+var (
+	bytecodePath = flag.String("bin", "my_contract_sol_MyContract.bin", "path of the compiled contract bytecode, in hex")
+	abiPath      = flag.String("abi", "my_contract_sol_MyContract.abi", "path of the compiled contract ABI")
 )
 
 func main() {
+	operatorOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "hscs-smart-contract")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
 	fmt.Println("🏁 Hello Future World - HSCS Smart Contract - start")
 
-	// Load environment variables from .env file
-	err := godotenv.Load("../.env")
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*operatorOpts, report)
+	report.Finish(err)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		failure.Exit(err)
 	}
 
-	// Initialize the operator account
-	operatorIdStr := os.Getenv("OPERATOR_ACCOUNT_ID")
-	operatorKeyStr := os.Getenv("OPERATOR_ACCOUNT_PRIVATE_KEY")
-	if operatorIdStr == "" || operatorKeyStr == "" {
-		log.Fatal("Must set OPERATOR_ACCOUNT_ID, OPERATOR_ACCOUNT_PRIVATE_KEY")
-	}
+	fmt.Println("🎉 Hello Future World - HSCS Smart Contract - complete")
+}
 
-	operatorId, _ := hedera.AccountIDFromString(operatorIdStr)
-	// Necessary because Go SDK v2.37.0 does not handle the `0x` prefix automatically
-	// Ref: https://github.com/hashgraph/hedera-sdk-go/issues/1057
-	operatorKeyStr = strings.TrimPrefix(operatorKeyStr, "0x")
-	operatorKey, _ := hedera.PrivateKeyFromStringECDSA(operatorKeyStr)
+func run(operatorOpts operator.Options, report *output.Report) error {
+	// Load the operator account from flags, environment variables, or the .env file
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+	operatorId := operatorConfig.AccountID
+	operatorKey := operatorConfig.PrivateKey
 	fmt.Printf("Using account: %s\n", operatorId)
-	fmt.Printf("Using operatorKey: %s\n", operatorKeyStr)
+	fmt.Printf("Using operatorKey: %s\n", operatorKey.StringRaw())
+
+	// Read the EVM bytecode of the compiled smart contract, `my_contract.sol`
+	// Compile it first using `npm run compile-contract`, which runs `solcjs`
+	fmt.Println("🟣 Reading compiled smart contract bytecode")
+	bytecodeFile, err := os.ReadFile(*bytecodePath)
+	if err != nil {
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("reading contract bytecode, compile my_contract.sol first with `npm run compile-contract`: %w", err))
+	}
+	// The file service stores the bytecode as hex text, which is what solc outputs
+	bytecode := []byte(strings.TrimSpace(string(bytecodeFile)))
+	fmt.Printf("Compiled smart contract EVM bytecode size: %d bytes\n", len(bytecode))
+
+	// Read the ABI of the compiled smart contract, which describes its functions and events
+	// It is used to encode the function parameters and decode the results
+	contractABI, err := contract.Load(*abiPath)
+	if err != nil {
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("reading contract ABI, compile my_contract.sol first with `npm run compile-contract`: %w", err))
+	}
 
 	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
-	client := hedera.ClientForTestnet()
-	client.SetOperator(operatorId, operatorKey)
+	// The client also sets the default maximum transaction fee and query payment
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
+
+	bytecodeFileId, err := uploadBytecode(client, operatorKey, bytecode, report)
+	if err != nil {
+		return err
+	}
+
+	contractId, err := deployContract(client, operatorKey, contractABI, bytecodeFileId, report)
+	if err != nil {
+		return err
+	}
+
+	contractExecuteTxId, err := callIntroduce(client, operatorKey, contractABI, contractId, report)
+	if err != nil {
+		return err
+	}
+
+	err = queryGreet(client, contractABI, contractId, report)
+	if err != nil {
+		return err
+	}
 
-	// Set the default maximum transaction fee (in HBAR)
-	client.SetDefaultMaxTransactionFee(hedera.HbarFrom(100, hedera.HbarUnits.Hbar))
-	// Set the default maximum payment for queries (in HBAR)
-	client.SetDefaultMaxQueryPayment(hedera.HbarFrom(50, hedera.HbarUnits.Hbar))
+	// View the contract in HashScan
+	fmt.Println("🟣 View the smart contract in HashScan")
+	contractHashscanUrl :=
+		operatorConfig.Network.ExplorerEntityURL("contract", contractId)
+	fmt.Printf("Copy and paste this URL in your browser: %s\n", contractHashscanUrl)
 
-	// TODO ... SDK methods
+	mirrorClient := mirror.NewClient(operatorConfig.Network.MirrorNodeURL)
+	err = verifyContract(mirrorClient, contractABI, contractId, contractExecuteTxId, report)
+	if err != nil {
+		return err
+	}
+	return report.FillFromMirror(context.Background(), mirrorClient)
+}
 
-	client.Close()
+// uploadBytecode stores the contract bytecode in a file, using the file service,
+// and returns the file ID
+func uploadBytecode(client *hedera.Client, operatorKey hedera.PrivateKey, bytecode []byte, report *output.Report) (hedera.FileID, error) {
+	fmt.Println("🟣 Uploading smart contract bytecode to a file")
+	fileCreateContents := bytecode[:min(len(bytecode), bytecodeFileChunkSize)]
+	fileCreateTx, err := hedera.NewFileCreateTransaction().
+		// The operator key is needed to append the rest of the bytecode to the file
+		SetKeys(operatorKey.PublicKey()).
+		// Set the first chunk of the bytecode
+		SetContents(fileCreateContents).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return hedera.FileID{}, fmt.Errorf("freezing FileCreateTransaction: %w", err)
+	}
 
-	// TODO ... Hashscan/ Mirror node APIs
+	// Sign the transaction with the operator key, which is both the fee payer and the file key
+	fileCreateTxSigned := fileCreateTx.Sign(operatorKey)
+
+	// Submit the transaction to the Hedera Testnet
+	fileCreateTxSubmitted, err := fileCreateTxSigned.Execute(client)
+	if err != nil {
+		return hedera.FileID{}, fmt.Errorf("executing FileCreateTransaction: %w", err)
+	}
+
+	// Get the transaction receipt, which contains the file ID
+	fileCreateTxReceipt, err := fileCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.FileID{}, fmt.Errorf("getting receipt for FileCreateTransaction: %w", err)
+	}
+	report.AddTransaction("fileCreate", fileCreateTx.GetTransactionID(), fileCreateTxReceipt.Status)
+	bytecodeFileId := *fileCreateTxReceipt.FileID
+	fmt.Printf("The bytecode file ID: %s\n", bytecodeFileId.String())
+	report.AddEntity("file", bytecodeFileId)
+
+	// Append the rest of the bytecode, if any
+	// The SDK splits the contents into multiple transactions, one per chunk
+	if len(bytecode) > bytecodeFileChunkSize {
+		fileAppendTx, err := hedera.NewFileAppendTransaction().
+			SetFileID(bytecodeFileId).
+			// Set the remaining bytecode
+			SetContents(bytecode[bytecodeFileChunkSize:]).
+			// Allow more chunks than the default, for large contracts
+			SetMaxChunks(40).
+			// Freeze the transaction to prepare for signing
+			FreezeWith(client)
+		if err != nil {
+			return hedera.FileID{}, fmt.Errorf("freezing FileAppendTransaction: %w", err)
+		}
+
+		// Sign every chunk with the file key (operator key)
+		fileAppendTxSigned := fileAppendTx.Sign(operatorKey)
+
+		// Submit each chunk, waiting for the receipt of each one before submitting the next
+		fileAppendTxResponses, err := fileAppendTxSigned.ExecuteAll(client)
+		if err != nil {
+			return hedera.FileID{}, fmt.Errorf("executing FileAppendTransaction: %w", err)
+		}
+		// ExecuteAll waits for the receipt of every chunk, but does not check its status
+		for _, fileAppendTxResponse := range fileAppendTxResponses {
+			fileAppendTxReceipt, err := fileAppendTxResponse.GetReceipt(client)
+			if err != nil {
+				return hedera.FileID{}, fmt.Errorf("getting receipt for FileAppendTransaction: %w", err)
+			}
+			report.AddTransaction("fileAppend", fileAppendTxResponse.TransactionID, fileAppendTxReceipt.Status)
+		}
+	}
+	return bytecodeFileId, nil
+}
 
-	fmt.Println("🎉 Hello Future World - Transfer Hbar - complete")
+// deployContract creates the contract from the bytecode file, and returns the contract ID
+func deployContract(client *hedera.Client, operatorKey hedera.PrivateKey, contractABI *contract.ABI, bytecodeFileId hedera.FileID, report *output.Report) (hedera.ContractID, error) {
+	fmt.Println("🟣 Deploying smart contract")
+	// The parameters passed into the constructor, `constructor(string memory _greeting)`
+	contractCreateParams, err := contractABI.EncodeConstructor("Hello future! - ")
+	if err != nil {
+		return hedera.ContractID{}, err
+	}
+	contractCreateTx, err := hedera.NewContractCreateTransaction().
+		// The file that contains the bytecode
+		SetBytecodeFileID(bytecodeFileId).
+		// The maximum amount of gas that the constructor may use
+		SetGas(500_000).
+		// The ABI encoded constructor parameters
+		SetConstructorParametersRaw(contractCreateParams).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return hedera.ContractID{}, fmt.Errorf("freezing ContractCreateTransaction: %w", err)
+	}
+
+	// Sign the transaction with the operator key
+	contractCreateTxSigned := contractCreateTx.Sign(operatorKey)
+
+	// Submit the transaction to the Hedera Testnet
+	contractCreateTxSubmitted, err := contractCreateTxSigned.Execute(client)
+	if err != nil {
+		return hedera.ContractID{}, fmt.Errorf("executing ContractCreateTransaction: %w", err)
+	}
+
+	// Get the transaction receipt, which contains the contract ID
+	contractCreateTxReceipt, err := contractCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.ContractID{}, fmt.Errorf("getting receipt for ContractCreateTransaction: %w", err)
+	}
+	report.AddTransaction("contractCreate", contractCreateTx.GetTransactionID(), contractCreateTxReceipt.Status)
+	contractId := *contractCreateTxReceipt.ContractID
+	fmt.Printf("The deployed contract ID: %s\n", contractId.String())
+	fmt.Printf("The deployed contract EVM address: 0x%s\n", contractId.ToSolidityAddress())
+	report.AddEntity("contract", contractId)
+	return contractId, nil
+}
+
+// callIntroduce calls the state changing `introduce` function,
+// and returns the transaction ID
+func callIntroduce(client *hedera.Client, operatorKey hedera.PrivateKey, contractABI *contract.ABI, contractId hedera.ContractID, report *output.Report) (hedera.TransactionID, error) {
+	fmt.Println("🟣 Calling the introduce function of the smart contract")
+	// The function to call, `introduce(string memory name)`, and its parameters
+	contractExecuteParams, err := contractABI.EncodeCall("introduce", "bguiz")
+	if err != nil {
+		return hedera.TransactionID{}, err
+	}
+	contractExecuteTx, err := hedera.NewContractExecuteTransaction().
+		// The contract to call
+		SetContractID(contractId).
+		// The maximum amount of gas that the function call may use
+		SetGas(100_000).
+		// The ABI encoded function selector and parameters
+		SetFunctionParameters(contractExecuteParams).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return hedera.TransactionID{}, fmt.Errorf("freezing ContractExecuteTransaction: %w", err)
+	}
+
+	// Get the transaction ID, to look up the result on the mirror node later
+	contractExecuteTxId := contractExecuteTx.GetTransactionID()
+	fmt.Printf("The contract execute transaction ID: %s\n", contractExecuteTxId.String())
+
+	// Sign the transaction with the operator key
+	contractExecuteTxSigned := contractExecuteTx.Sign(operatorKey)
+
+	// Submit the transaction to the Hedera Testnet
+	contractExecuteTxSubmitted, err := contractExecuteTxSigned.Execute(client)
+	if err != nil {
+		return hedera.TransactionID{}, fmt.Errorf("executing ContractExecuteTransaction: %w", err)
+	}
+
+	// Get the transaction receipt
+	contractExecuteTxReceipt, err := contractExecuteTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.TransactionID{}, fmt.Errorf("getting receipt for ContractExecuteTransaction: %w", err)
+	}
+	fmt.Printf("The contract execute transaction status is: %s\n", contractExecuteTxReceipt.Status.String())
+	report.AddTransaction("contractExecute", contractExecuteTxId, contractExecuteTxReceipt.Status)
+	return contractExecuteTxId, nil
+}
+
+// queryGreet calls the read only `greet` function
+func queryGreet(client *hedera.Client, contractABI *contract.ABI, contractId hedera.ContractID, report *output.Report) error {
+	fmt.Println("🟣 Querying the greet function of the smart contract")
+	// A contract call query does not change state, so it is not a transaction
+	// It is paid for by the operator account, but does not need to be signed separately
+	contractCallParams, err := contractABI.EncodeCall("greet")
+	if err != nil {
+		return err
+	}
+	contractCallResult, err := hedera.NewContractCallQuery().
+		// The contract to query
+		SetContractID(contractId).
+		// The maximum amount of gas that the function call may use
+		SetGas(100_000).
+		// The ABI encoded function selector, `greet()` takes no parameters
+		SetFunctionParameters(contractCallParams).
+		Execute(client)
+	if err != nil {
+		return fmt.Errorf("executing ContractCallQuery: %w", err)
+	}
+	var greeting string
+	err = contractABI.DecodeResultInto(&greeting, "greet", contractCallResult.ContractCallResult)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("The greet function returned: %s\n", greeting)
+	report.Set("greeting", greeting)
+	return nil
+}
+
+// verifyContract reads the contract, and the result of the `introduce` call,
+// back from the mirror node
+func verifyContract(mirrorClient *mirror.Client, contractABI *contract.ABI, contractId hedera.ContractID, contractExecuteTxId hedera.TransactionID, report *output.Report) error {
+	fmt.Println("🟣 Get smart contract data from the Hedera Mirror Node")
+	contractMirrorNodeApiUrl :=
+		mirrorClient.URL(fmt.Sprintf("contracts/%s", contractId.String()), nil)
+	fmt.Printf("The contract Hedera Mirror Node API URL: %s\n", contractMirrorNodeApiUrl)
+
+	// Wait for record files (blocks) to propagate to mirror nodes,
+	// by polling until the contract is visible
+	contractResp, err := mirrorClient.WaitForContract(context.Background(), mirror.DefaultBackoff, contractId.String())
+	report.AddMirrorVerification("contract", contractMirrorNodeApiUrl, contractResp, err)
+	if err != nil {
+		return fmt.Errorf("fetching contract: %w", err)
+	}
+	fmt.Printf("The EVM address of this contract: %s\n", contractResp.EvmAddress)
+	fmt.Printf("The bytecode file of this contract: %s\n", contractResp.FileID)
+
+	// The contract result is looked up by transaction ID, in the mirror node format (0.0.x-x-x)
+	contractExecuteTxIdMirrorNodeFormat, _, err := txid.ToMirror(contractExecuteTxId)
+	if err != nil {
+		return fmt.Errorf("converting transaction ID: %w", err)
+	}
+	contractResultMirrorNodeApiUrl :=
+		mirrorClient.URL(fmt.Sprintf("contracts/results/%s", contractExecuteTxIdMirrorNodeFormat), nil)
+	fmt.Printf("The contract execute result Hedera Mirror Node API URL: %s\n", contractResultMirrorNodeApiUrl)
+
+	contractResultResp, err := mirrorClient.WaitForContractResult(context.Background(), mirror.DefaultBackoff, contractExecuteTxIdMirrorNodeFormat, nil)
+	report.AddMirrorVerification("contractResult", contractResultMirrorNodeApiUrl, contractResultResp, err)
+	if err != nil {
+		return fmt.Errorf("fetching contract result: %w", err)
+	}
+	fmt.Printf("The result of the introduce function call: %s\n", contractResultResp.Result)
+	fmt.Printf("The gas used by the introduce function call: %d\n", contractResultResp.GasUsed)
+	// Decode the events emitted by the function call, using the ABI
+	var events []*contract.Event
+	for _, contractLog := range contractResultResp.Logs {
+		event, err := contractABI.DecodeMirrorLog(contractLog)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("The introduce function call emitted event %s: %v\n", event.Name, event.Args)
+		events = append(events, event)
+	}
+	report.Set("events", events)
+	return nil
 }
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/failure"
+	"lib/fees"
+	"lib/mirror"
+	"lib/nft"
+	"lib/operator"
+	"lib/output"
+)
+
+// The collection can never have more than nftMaxSupply serials
+const nftMaxSupply = 10
+
+// The royalty fee is 5% of the value exchanged for a serial,
+// or a fallback fee of 1 HBAR, paid by the receiver, when no value is exchanged
+const (
+	royaltyNumerator   = 5
+	royaltyDenominator = 100
+)
+
+var (
+	nftCount        = flag.Int("count", 5, "number of serials to mint")
+	metadataDir     = flag.String("metadata-dir", "nft-metadata", "directory to write the HIP-412 JSON documents to, to upload to IPFS")
+	metadataBaseURI = flag.String("metadata-base-uri", "ipfs://bafybeihellofutureworldnftmetadataplaceholder", "URI of the uploaded metadata directory, the default is a placeholder")
+	transferSerials = flag.Int("transfer", 2, "number of serials to transfer, starting with serial #1")
+)
+
+func main() {
+	operatorOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "hts-nft")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
+	fmt.Println("🏁 Hello Future World - HTS Non-Fungible Token - start")
+
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*operatorOpts, report)
+	report.Finish(err)
+	if err != nil {
+		failure.Exit(err)
+	}
+
+	fmt.Println("🎉 Hello Future World - HTS Non-Fungible Token - complete")
+}
+
+func run(operatorOpts operator.Options, report *output.Report) error {
+	// Load the operator account from flags, environment variables, or the .env file
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+	operatorId := operatorConfig.AccountID
+	operatorKey := operatorConfig.PrivateKey
+	fmt.Printf("Using account: %s\n", operatorId)
+	fmt.Printf("Using operatorKey: %s\n", operatorKey.StringRaw())
+
+	if *nftCount < 1 || *nftCount > nftMaxSupply {
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("-count must be between 1 and %d, got %d", nftMaxSupply, *nftCount))
+	}
+	if *transferSerials < 0 || *transferSerials > *nftCount {
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("-transfer must be between 0 and -count, got %d", *transferSerials))
+	}
+	metadata, err := buildMetadata(*nftCount)
+	if err != nil {
+		return err
+	}
+
+	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
+	// The client also sets the default maximum transaction fee and query payment
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
+
+	tokenId, err := createCollection(client, operatorId, operatorKey, report)
+	if err != nil {
+		return err
+	}
+	fmt.Println("🟣 View the collection on HashScan")
+	fmt.Printf("Token Hashscan URL: %s\n", operatorConfig.Network.ExplorerEntityURL("token", tokenId))
+
+	serials, err := mintSerials(client, operatorKey, tokenId, metadata, report)
+	if err != nil {
+		return err
+	}
+
+	// The transferred serials need an account other than the treasury to receive them
+	accountId, accountKey, err := createAccount(client, tokenId, report)
+	if err != nil {
+		return err
+	}
+	transferred := serials[:*transferSerials]
+	if err := transferNfts(client, operatorId, operatorKey, tokenId, transferred, accountId, accountKey, report); err != nil {
+		return err
+	}
+
+	mirrorClient := mirror.NewClient(operatorConfig.Network.MirrorNodeURL)
+	if err := verifyNfts(mirrorClient, tokenId, transferred, accountId, len(serials), report); err != nil {
+		return err
+	}
+	return report.FillFromMirror(context.Background(), mirrorClient)
+}
+
+// buildMetadata writes a HIP-412 JSON document for each serial to the metadata directory,
+// and returns the metadata to mint, which are the URIs of those documents once uploaded
+func buildMetadata(count int) ([][]byte, error) {
+	fmt.Println("🟣 Writing the HIP-412 metadata documents")
+	documents := make([]nft.Document, count)
+	for i := range documents {
+		documents[i] = nft.Document{
+			Name:        fmt.Sprintf("Hello Future World NFT #%d", i+1),
+			Creator:     "Hello Future World",
+			Description: "An NFT minted by the Hello Future World HTS NFT script",
+			Image:       fmt.Sprintf("%s/%d.png", strings.TrimSuffix(*metadataBaseURI, "/"), i+1),
+			Type:        "image/png",
+			Format:      nft.Format,
+			Attributes: []nft.Attribute{
+				{TraitType: "edition", Value: i + 1},
+			},
+		}
+	}
+	paths, err := nft.WriteDocuments(*metadataDir, documents)
+	if err != nil {
+		return nil, fmt.Errorf("writing metadata documents: %w", err)
+	}
+	fmt.Printf("The metadata documents are in: %s\n", *metadataDir)
+	fmt.Println("Upload the directory, with the images, to IPFS, then set -metadata-base-uri to its URI")
+
+	uris := nft.MetadataURIs(*metadataBaseURI, len(paths))
+	metadata, err := nft.Metadata(uris)
+	if err != nil {
+		return nil, failure.Wrap(failure.KindConfig, fmt.Errorf("-metadata-base-uri: %w", err))
+	}
+	for i, uri := range uris {
+		fmt.Printf("  %s -> %s\n", paths[i], uri)
+	}
+	return metadata, nil
+}
+
+// createCollection creates a non-fungible HTS token, with a finite supply, a royalty fee,
+// and the operator account as its treasury and supply key
+func createCollection(client *hedera.Client, operatorId hedera.AccountID, operatorKey hedera.PrivateKey, report *output.Report) (hedera.TokenID, error) {
+	fmt.Println("🟣 Creating new HTS NFT collection")
+	tokenCreateTx, err := hedera.NewTokenCreateTransaction().
+		// HTS `TokenType.NonFungibleUnique` behaves similarly to ERC721
+		SetTokenType(hedera.TokenTypeNonFungibleUnique).
+		SetTokenName("htsNft collection").
+		SetTokenSymbol("HTSNFT").
+		// NFTs have no decimals, and start with no serials, which are minted later
+		SetDecimals(0).
+		SetInitialSupply(0).
+		// A finite supply caps the number of serials that can ever be minted
+		SetSupplyType(hedera.TokenSupplyTypeFinite).
+		SetMaxSupply(nftMaxSupply).
+		// The treasury account receives the minted serials
+		SetTreasuryAccountID(operatorId).
+		// The supply key can mint and burn serials
+		SetSupplyKey(operatorKey.PublicKey()).
+		// The royalty fee is collected by the treasury on every later sale of a serial
+		SetCustomFees([]hedera.Fee{
+			hedera.NewCustomRoyaltyFee().
+				SetNumerator(royaltyNumerator).
+				SetDenominator(royaltyDenominator).
+				SetFallbackFee(hedera.NewCustomFixedFee().SetHbarAmount(hedera.NewHbar(1))).
+				SetFeeCollectorAccountID(operatorId),
+		}).
+		// Freeze the transaction to prepare for signing
+		FreezeWith(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("freezing TokenCreateTransaction: %w", err)
+	}
+	tokenCreateTxId := tokenCreateTx.GetTransactionID()
+	fmt.Printf("The token create transaction ID: %s\n", tokenCreateTxId.String())
+
+	// Sign the transaction with the private key of the treasury account (operator key)
+	tokenCreateTxSubmitted, err := tokenCreateTx.Sign(operatorKey).Execute(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("executing TokenCreateTransaction: %w", err)
+	}
+	tokenCreateTxReceipt, err := tokenCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("getting receipt for TokenCreateTransaction: %w", err)
+	}
+	report.AddTransaction("tokenCreate", tokenCreateTxId, tokenCreateTxReceipt.Status)
+
+	tokenId := *tokenCreateTxReceipt.TokenID
+	fmt.Printf("Token ID: %s\n", tokenId.String())
+	report.AddEntity("token", tokenId)
+	return tokenId, nil
+}
+
+// mintSerials mints one serial per metadata, in batches of up to nft.MaxMintBatch,
+// and returns the serial numbers
+func mintSerials(client *hedera.Client, operatorKey hedera.PrivateKey, tokenId hedera.TokenID, metadata [][]byte, report *output.Report) ([]int64, error) {
+	serials := []int64{}
+	for i, batch := range nft.Batches(metadata, nft.MaxMintBatch) {
+		fmt.Printf("🟣 Minting %d serials\n", len(batch))
+		tokenMintTx, err := hedera.NewTokenMintTransaction().
+			SetTokenID(tokenId).
+			// Each metadata mints one serial
+			SetMetadatas(batch).
+			FreezeWith(client)
+		if err != nil {
+			return nil, fmt.Errorf("freezing TokenMintTransaction: %w", err)
+		}
+		tokenMintTxId := tokenMintTx.GetTransactionID()
+		fmt.Printf("The token mint transaction ID: %s\n", tokenMintTxId.String())
+
+		// Sign with the supply key (operator key)
+		tokenMintTxSubmitted, err := tokenMintTx.Sign(operatorKey).Execute(client)
+		if err != nil {
+			return nil, fmt.Errorf("executing TokenMintTransaction: %w", err)
+		}
+		tokenMintTxReceipt, err := tokenMintTxSubmitted.GetReceipt(client)
+		if err != nil {
+			return nil, fmt.Errorf("getting receipt for TokenMintTransaction: %w", err)
+		}
+		report.AddTransaction(fmt.Sprintf("tokenMint%d", i+1), tokenMintTxId, tokenMintTxReceipt.Status)
+
+		// The receipt holds the serial numbers, in the order of the metadata
+		fmt.Printf("The minted serials: %v\n", tokenMintTxReceipt.SerialNumbers)
+		serials = append(serials, tokenMintTxReceipt.SerialNumbers...)
+	}
+	report.Set("serials", serials)
+	return serials, nil
+}
+
+// createAccount creates an account with a new key, and associates it with the collection
+func createAccount(client *hedera.Client, tokenId hedera.TokenID, report *output.Report) (hedera.AccountID, hedera.PrivateKey, error) {
+	fmt.Println("🟣 Creating new account to receive the NFTs")
+	accountKey, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("generating account key: %w", err)
+	}
+	accountCreateTx, err := hedera.NewAccountCreateTransaction().
+		SetKey(accountKey.PublicKey()).
+		// The receiver of a serial pays the fallback fee of the royalty fee when it is charged
+		SetInitialBalance(hedera.NewHbar(2)).
+		FreezeWith(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("freezing AccountCreateTransaction: %w", err)
+	}
+	accountCreateTxSubmitted, err := accountCreateTx.Execute(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("executing AccountCreateTransaction: %w", err)
+	}
+	accountCreateTxReceipt, err := accountCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("getting receipt for AccountCreateTransaction: %w", err)
+	}
+	report.AddTransaction("accountCreate", accountCreateTx.GetTransactionID(), accountCreateTxReceipt.Status)
+	accountId := *accountCreateTxReceipt.AccountID
+	fmt.Printf("Account ID: %s\n", accountId.String())
+	report.AddEntity("account", accountId)
+
+	// An account has to be associated with a token before it can hold it,
+	// which the account signs for
+	fmt.Println("🟣 Associating the account with the collection")
+	tokenAssociateTx, err := hedera.NewTokenAssociateTransaction().
+		SetAccountID(accountId).
+		SetTokenIDs(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("freezing TokenAssociateTransaction: %w", err)
+	}
+	tokenAssociateTxSubmitted, err := tokenAssociateTx.Sign(accountKey).Execute(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("executing TokenAssociateTransaction: %w", err)
+	}
+	tokenAssociateTxReceipt, err := tokenAssociateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("getting receipt for TokenAssociateTransaction: %w", err)
+	}
+	report.AddTransaction("tokenAssociate", tokenAssociateTx.GetTransactionID(), tokenAssociateTxReceipt.Status)
+	return accountId, accountKey, nil
+}
+
+// transferNfts transfers specific serials from the treasury account to the account
+func transferNfts(client *hedera.Client, operatorId hedera.AccountID, operatorKey hedera.PrivateKey, tokenId hedera.TokenID, serials []int64, accountId hedera.AccountID, accountKey hedera.PrivateKey, report *output.Report) error {
+	if len(serials) == 0 {
+		return nil
+	}
+	fmt.Printf("🟣 Transferring serials %v to %s\n", serials, accountId.String())
+	nftTransferTx := hedera.NewTransferTransaction()
+	for _, serial := range serials {
+		// Each serial is transferred as a whole, from its owner
+		nftTransferTx.AddNftTransfer(hedera.NftID{TokenID: tokenId, SerialNumber: serial}, operatorId, accountId)
+	}
+	nftTransferTx, err := nftTransferTx.FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TransferTransaction: %w", err)
+	}
+	nftTransferTxId := nftTransferTx.GetTransactionID()
+	fmt.Printf("The NFT transfer transaction ID: %s\n", nftTransferTxId.String())
+
+	// Sign with the key of the sender, the treasury account (operator key),
+	// and with the key of the receiver, which would pay the fallback fee if it is charged
+	nftTransferTxSubmitted, err := nftTransferTx.Sign(operatorKey).Sign(accountKey).Execute(client)
+	if err != nil {
+		return fmt.Errorf("executing TransferTransaction: %w", err)
+	}
+	nftTransferTxReceipt, err := nftTransferTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return fmt.Errorf("getting receipt for TransferTransaction: %w", err)
+	}
+	report.AddTransaction("nftTransfer", nftTransferTxId, nftTransferTxReceipt.Status)
+	fmt.Printf("The NFT transfer transaction status is: %s\n", nftTransferTxReceipt.Status.String())
+	return nil
+}
+
+// verifyNfts waits until the mirror node shows the new owner of the transferred serials,
+// then lists every serial of the collection, with its owner and metadata
+func verifyNfts(mirrorClient *mirror.Client, tokenId hedera.TokenID, transferred []int64, accountId hedera.AccountID, count int, report *output.Report) error {
+	fmt.Println("🟣 Get the collection's NFTs from the Hedera Mirror Node")
+	opts := mirror.ListOptions{Limit: 100, Order: mirror.OrderAsc}
+	nftsMirrorNodeApiUrl := mirrorClient.URL(fmt.Sprintf("tokens/%s/nfts", tokenId.String()), opts.Query())
+	fmt.Printf("The NFTs Hedera Mirror Node API URL: %s\n", nftsMirrorNodeApiUrl)
+
+	// Wait for record files (blocks) to propagate to mirror nodes,
+	// by polling until the last serial is minted, and the transferred serials have their new owner
+	lastSerial := int64(count)
+	err := mirror.Poll(context.Background(), mirror.DefaultBackoff, fmt.Sprintf("token %s serial #%d", tokenId.String(), lastSerial), func(ctx context.Context) (bool, error) {
+		_, err := mirrorClient.GetNFT(ctx, tokenId.String(), lastSerial)
+		return err == nil, err
+	})
+	if err != nil {
+		report.AddMirrorVerification("nfts", nftsMirrorNodeApiUrl, nil, err)
+		return fmt.Errorf("fetching NFTs: %w", err)
+	}
+	for _, serial := range transferred {
+		if _, err := mirrorClient.WaitForNFTOwner(context.Background(), mirror.DefaultBackoff, tokenId.String(), serial, accountId.String()); err != nil {
+			report.AddMirrorVerification("nfts", nftsMirrorNodeApiUrl, nil, err)
+			return fmt.Errorf("verifying NFT transfer: %w", err)
+		}
+	}
+
+	type nftEntry struct {
+		SerialNumber int64  `json:"serialNumber"`
+		Owner        string `json:"owner"`
+		Metadata     string `json:"metadata"`
+	}
+	nfts := []nftEntry{}
+	fmt.Printf("%-8s %-15s %s\n", "Serial", "Owner", "Metadata")
+	fmt.Println(strings.Repeat("-", 60))
+	err = mirrorClient.EachTokenNFT(context.Background(), tokenId.String(), opts, func(entry mirror.NFT) error {
+		metadata, err := entry.Contents()
+		if err != nil {
+			return fmt.Errorf("decoding metadata of serial #%d: %w", entry.SerialNumber, err)
+		}
+		fmt.Printf("%-8d %-15s %s\n", entry.SerialNumber, entry.AccountID, metadata)
+		nfts = append(nfts, nftEntry{SerialNumber: entry.SerialNumber, Owner: entry.AccountID, Metadata: string(metadata)})
+		return nil
+	})
+	report.AddMirrorVerification("nfts", nftsMirrorNodeApiUrl, nfts, err)
+	if err != nil {
+		return fmt.Errorf("fetching NFTs: %w", err)
+	}
+
+	fmt.Println("🟣 Get the collection's custom fees from the Hedera Mirror Node")
+	tokenMirrorNodeApiUrl := mirrorClient.URL(fmt.Sprintf("tokens/%s", tokenId.String()), nil)
+	tokenResp, err := mirrorClient.GetToken(context.Background(), tokenId.String())
+	report.AddMirrorVerification("token", tokenMirrorNodeApiUrl, tokenResp, err)
+	if err != nil {
+		return fmt.Errorf("fetching token: %w", err)
+	}
+	fmt.Printf("The name of this collection: %s\n", tokenResp.Name)
+	fmt.Printf("The total supply of this collection: %d of %d\n", tokenResp.TotalSupply, tokenResp.MaxSupply)
+	fmt.Println("The custom fees of this collection:")
+	for _, line := range fees.DescribeMirror(tokenResp.CustomFees) {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
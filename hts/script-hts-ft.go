@@ -1,58 +1,451 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"log"
-	"os"
-	"strings"
-	"time"
+	"net/url"
+	"strconv"
 
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"github.com/imroc/req/v3"
-	"github.com/joho/godotenv"
+
+	"lib/failure"
+	"lib/fees"
+	"lib/mirror"
+	"lib/operator"
+	"lib/output"
 )
 
-type TokenMNAPIResponse struct {
-	Name        string `json:"name"`
-	TotalSupply string `json:"total_supply"`
-}
+// Amounts are in the smallest denomination, with 2 decimals, so 1_000_000 is 10,000.00 tokens
+const (
+	tokenDecimals       = 2
+	tokenInitialSupply  = 1_000_000
+	tokenTransferAmount = 100_000
+	tokenMintAmount     = 50_000
+	tokenBurnAmount     = 20_000
+	tokenWipeAmount     = 10_000
+	tokenReturnAmount   = 5_000
+	// The fractional fee is 1% of each transfer, between 0.01 and 50.00 tokens
+	tokenFeeNumerator   = 1
+	tokenFeeDenominator = 100
+	tokenFeeMin         = 1
+	tokenFeeMax         = 5_000
+)
 
 func main() {
+	operatorOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "hts-ft")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
 	fmt.Println("🏁 Hello Future World - HTS Fungible Token - start")
 
-	// Load environment variables from .env file
-	err := godotenv.Load("../.env")
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*operatorOpts, report)
+	report.Finish(err)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		failure.Exit(err)
 	}
 
-	// Initialize the operator account
-	operatorIdStr := os.Getenv("OPERATOR_ACCOUNT_ID")
-	operatorKeyStr := os.Getenv("OPERATOR_ACCOUNT_PRIVATE_KEY")
-	if operatorIdStr == "" || operatorKeyStr == "" {
-		log.Fatal("Must set OPERATOR_ACCOUNT_ID, OPERATOR_ACCOUNT_PRIVATE_KEY")
-	}
+	fmt.Println("🎉 Hello Future World - HTS Fungible Token - complete")
+}
 
-	operatorId, _ := hedera.AccountIDFromString(operatorIdStr)
-	// Necessary because Go SDK v2.37.0 does not handle the `0x` prefix automatically
-	// Ref: https://github.com/hashgraph/hedera-sdk-go/issues/1057
-	operatorKeyStr = strings.TrimPrefix(operatorKeyStr, "0x")
-	operatorKey, _ := hedera.PrivateKeyFromStringECDSA(operatorKeyStr)
+func run(operatorOpts operator.Options, report *output.Report) error {
+	// Load the operator account from flags, environment variables, or the .env file
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+	operatorId := operatorConfig.AccountID
+	operatorKey := operatorConfig.PrivateKey
 	fmt.Printf("Using account: %s\n", operatorId)
-	fmt.Printf("Using operatorKey: %s\n", operatorKeyStr)
+	fmt.Printf("Using operatorKey: %s\n", operatorKey.StringRaw())
 
 	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
-	client := hedera.ClientForTestnet()
-	client.SetOperator(operatorId, operatorKey)
+	// The client also sets the default maximum transaction fee and query payment
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
+
+	tokenId, err := createToken(client, operatorId, operatorKey, report)
+	if err != nil {
+		return err
+	}
+
+	// Verify transactions using Hashscan
+	// This is a manual step, the code below only outputs the URL to visit
+
+	// View your token on HashScan
+	fmt.Println("🟣 View the token on HashScan")
+	tokenHashscanUrl :=
+		operatorConfig.Network.ExplorerEntityURL("token", tokenId)
+	fmt.Printf("Token Hashscan URL: %s\n", tokenHashscanUrl)
+
+	mirrorClient := mirror.NewClient(operatorConfig.Network.MirrorNodeURL)
+	err = verifyToken(mirrorClient, tokenId, report)
+	if err != nil {
+		return err
+	}
+
+	// The rest of the lifecycle needs an account other than the treasury to hold the token
+	accountId, accountKey, err := createAccount(client, report)
+	if err != nil {
+		return err
+	}
+	if err := runLifecycle(client, mirrorClient, tokenId, accountId, accountKey, operatorId, operatorKey, report); err != nil {
+		return err
+	}
+	return report.FillFromMirror(context.Background(), mirrorClient)
+}
+
+// runLifecycle runs each follow-on operation of the token in turn,
+// and verifies its effect on the mirror node before moving on to the next one.
+// The operator key is the admin, supply, freeze, KYC, wipe, pause, and fee schedule key of the token.
+func runLifecycle(client *hedera.Client, mirrorClient *mirror.Client, tokenId hedera.TokenID, accountId hedera.AccountID, accountKey hedera.PrivateKey, operatorId hedera.AccountID, operatorKey hedera.PrivateKey, report *output.Report) error {
+	accountBalance := int64(0)
+	totalSupply := uint64(tokenInitialSupply)
+
+	// An account has to be associated with a token before it can hold it,
+	// which the account signs for
+	fmt.Println("🟣 Associating the account with the token")
+	tokenAssociateTx, err := hedera.NewTokenAssociateTransaction().
+		SetAccountID(accountId).
+		SetTokenIDs(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenAssociateTransaction: %w", err)
+	}
+	err = submit(client, "tokenAssociate", "TokenAssociateTransaction", tokenAssociateTx.GetTransactionID(), tokenAssociateTx.Sign(accountKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyRelationship(mirrorClient, accountId, tokenId, "tokenAssociate", "associated",
+		func(rel *mirror.TokenRelationship) bool { return rel != nil }, report)
+	if err != nil {
+		return err
+	}
+
+	// The token has a KYC key, so the account can only receive the token once KYC is granted
+	fmt.Println("🟣 Granting KYC to the account")
+	tokenGrantKycTx, err := hedera.NewTokenGrantKycTransaction().
+		SetAccountID(accountId).
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenGrantKycTransaction: %w", err)
+	}
+	// Sign with the KYC key (operator key)
+	err = submit(client, "tokenGrantKyc", "TokenGrantKycTransaction", tokenGrantKycTx.GetTransactionID(), tokenGrantKycTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyRelationship(mirrorClient, accountId, tokenId, "tokenGrantKyc", "KYC granted",
+		func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.KycStatus == "GRANTED" }, report)
+	if err != nil {
+		return err
+	}
+
+	// With decimals, the network rejects the transfer if the token has a different number of decimals,
+	// which guards against sending 100 times more or less than intended
+	fmt.Println("🟣 Transferring tokens from the treasury to the account")
+	tokenTransferTx, err := hedera.NewTransferTransaction().
+		AddTokenTransferWithDecimals(tokenId, operatorId, -tokenTransferAmount, tokenDecimals).
+		AddTokenTransferWithDecimals(tokenId, accountId, tokenTransferAmount, tokenDecimals).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TransferTransaction: %w", err)
+	}
+	// Sign with the treasury key (operator key)
+	err = submit(client, "tokenTransfer", "TransferTransaction", tokenTransferTx.GetTransactionID(), tokenTransferTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	accountBalance += tokenTransferAmount
+	err = verifyBalance(mirrorClient, accountId, tokenId, "tokenTransfer", accountBalance, report)
+	if err != nil {
+		return err
+	}
+
+	// Minting adds to the treasury balance, and to the total supply
+	fmt.Println("🟣 Minting tokens with the supply key")
+	tokenMintTx, err := hedera.NewTokenMintTransaction().
+		SetTokenID(tokenId).
+		SetAmount(tokenMintAmount).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenMintTransaction: %w", err)
+	}
+	// Sign with the supply key (operator key)
+	err = submit(client, "tokenMint", "TokenMintTransaction", tokenMintTx.GetTransactionID(), tokenMintTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	totalSupply += tokenMintAmount
+	err = verifyTotalSupply(mirrorClient, tokenId, "tokenMint", totalSupply, report)
+	if err != nil {
+		return err
+	}
+
+	// Burning removes tokens from the treasury balance, and from the total supply
+	fmt.Println("🟣 Burning tokens with the supply key")
+	tokenBurnTx, err := hedera.NewTokenBurnTransaction().
+		SetTokenID(tokenId).
+		SetAmount(tokenBurnAmount).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenBurnTransaction: %w", err)
+	}
+	// Sign with the supply key (operator key)
+	err = submit(client, "tokenBurn", "TokenBurnTransaction", tokenBurnTx.GetTransactionID(), tokenBurnTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	totalSupply -= tokenBurnAmount
+	err = verifyTotalSupply(mirrorClient, tokenId, "tokenBurn", totalSupply, report)
+	if err != nil {
+		return err
+	}
+
+	// A frozen account can neither send nor receive the token
+	fmt.Println("🟣 Freezing the account for the token")
+	tokenFreezeTx, err := hedera.NewTokenFreezeTransaction().
+		SetAccountID(accountId).
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenFreezeTransaction: %w", err)
+	}
+	// Sign with the freeze key (operator key)
+	err = submit(client, "tokenFreeze", "TokenFreezeTransaction", tokenFreezeTx.GetTransactionID(), tokenFreezeTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyRelationship(mirrorClient, accountId, tokenId, "tokenFreeze", "frozen",
+		func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.FreezeStatus == "FROZEN" }, report)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🟣 Unfreezing the account for the token")
+	tokenUnfreezeTx, err := hedera.NewTokenUnfreezeTransaction().
+		SetAccountID(accountId).
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenUnfreezeTransaction: %w", err)
+	}
+	// Sign with the freeze key (operator key)
+	err = submit(client, "tokenUnfreeze", "TokenUnfreezeTransaction", tokenUnfreezeTx.GetTransactionID(), tokenUnfreezeTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyRelationship(mirrorClient, accountId, tokenId, "tokenUnfreeze", "unfrozen",
+		func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.FreezeStatus == "UNFROZEN" }, report)
+	if err != nil {
+		return err
+	}
+
+	// Wiping removes tokens from an account other than the treasury, and from the total supply,
+	// without the signature of the account
+	fmt.Println("🟣 Wiping tokens from the account with the wipe key")
+	tokenWipeTx, err := hedera.NewTokenWipeTransaction().
+		SetAccountID(accountId).
+		SetTokenID(tokenId).
+		SetAmount(tokenWipeAmount).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenWipeTransaction: %w", err)
+	}
+	// Sign with the wipe key (operator key)
+	err = submit(client, "tokenWipe", "TokenWipeTransaction", tokenWipeTx.GetTransactionID(), tokenWipeTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	accountBalance -= tokenWipeAmount
+	totalSupply -= tokenWipeAmount
+	err = verifyBalance(mirrorClient, accountId, tokenId, "tokenWipe", accountBalance, report)
+	if err != nil {
+		return err
+	}
+	err = verifyTotalSupply(mirrorClient, tokenId, "tokenWipeSupply", totalSupply, report)
+	if err != nil {
+		return err
+	}
+
+	// Without decimals, the amount is taken as is, in the smallest denomination
+	fmt.Println("🟣 Transferring tokens from the account back to the treasury")
+	tokenReturnTx, err := hedera.NewTransferTransaction().
+		AddTokenTransfer(tokenId, accountId, -tokenReturnAmount).
+		AddTokenTransfer(tokenId, operatorId, tokenReturnAmount).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TransferTransaction: %w", err)
+	}
+	// Sign with the key of the debited account
+	err = submit(client, "tokenReturn", "TransferTransaction", tokenReturnTx.GetTransactionID(), tokenReturnTx.Sign(accountKey), report)
+	if err != nil {
+		return err
+	}
+	accountBalance -= tokenReturnAmount
+	err = verifyBalance(mirrorClient, accountId, tokenId, "tokenReturn", accountBalance, report)
+	if err != nil {
+		return err
+	}
+
+	// The fee schedule is replaced as a whole, so it lists every fee the token keeps.
+	// From now on, a transfer from the account would also pay 1 HBAR to the treasury.
+	fmt.Println("🟣 Updating the custom fees of the token")
+	newFees := updatedFees(operatorId, tokenId)
+	tokenFeeScheduleUpdateTx, err := hedera.NewTokenFeeScheduleUpdateTransaction().
+		SetTokenID(tokenId).
+		SetCustomFees(newFees).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenFeeScheduleUpdateTransaction: %w", err)
+	}
+	// Sign with the fee schedule key (operator key)
+	err = submit(client, "tokenFeeScheduleUpdate", "TokenFeeScheduleUpdateTransaction", tokenFeeScheduleUpdateTx.GetTransactionID(), tokenFeeScheduleUpdateTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	newFeeLines := fees.Describe(newFees, tokenId)
+	err = verifyTokenChange(mirrorClient, tokenId, "tokenFeeScheduleUpdate", "with the new custom fees",
+		func(token *mirror.Token) bool { return fees.Equal(fees.DescribeMirror(token.CustomFees), newFeeLines) }, report)
+	if err != nil {
+		return err
+	}
+	for _, line := range newFeeLines {
+		fmt.Printf("  %s\n", line)
+	}
+
+	fmt.Println("🟣 Revoking KYC from the account")
+	tokenRevokeKycTx, err := hedera.NewTokenRevokeKycTransaction().
+		SetAccountID(accountId).
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenRevokeKycTransaction: %w", err)
+	}
+	// Sign with the KYC key (operator key)
+	err = submit(client, "tokenRevokeKyc", "TokenRevokeKycTransaction", tokenRevokeKycTx.GetTransactionID(), tokenRevokeKycTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyRelationship(mirrorClient, accountId, tokenId, "tokenRevokeKyc", "KYC revoked",
+		func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.KycStatus == "REVOKED" }, report)
+	if err != nil {
+		return err
+	}
+
+	// A paused token cannot be part of any transaction, other than unpausing it
+	fmt.Println("🟣 Pausing the token")
+	tokenPauseTx, err := hedera.NewTokenPauseTransaction().
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenPauseTransaction: %w", err)
+	}
+	// Sign with the pause key (operator key)
+	err = submit(client, "tokenPause", "TokenPauseTransaction", tokenPauseTx.GetTransactionID(), tokenPauseTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyTokenChange(mirrorClient, tokenId, "tokenPause", "paused",
+		func(token *mirror.Token) bool { return token.PauseStatus == "PAUSED" }, report)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🟣 Unpausing the token")
+	tokenUnpauseTx, err := hedera.NewTokenUnpauseTransaction().
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenUnpauseTransaction: %w", err)
+	}
+	// Sign with the pause key (operator key)
+	err = submit(client, "tokenUnpause", "TokenUnpauseTransaction", tokenUnpauseTx.GetTransactionID(), tokenUnpauseTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyTokenChange(mirrorClient, tokenId, "tokenUnpause", "unpaused",
+		func(token *mirror.Token) bool { return token.PauseStatus == "UNPAUSED" }, report)
+	if err != nil {
+		return err
+	}
+
+	// Only the fields that are set are updated
+	fmt.Println("🟣 Updating the token name and memo")
+	updatedTokenName := "htsFt coin v2"
+	tokenUpdateTx, err := hedera.NewTokenUpdateTransaction().
+		SetTokenID(tokenId).
+		SetTokenName(updatedTokenName).
+		SetTokenMemo("Hello Future World token - updated").
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenUpdateTransaction: %w", err)
+	}
+	// Sign with the admin key (operator key)
+	err = submit(client, "tokenUpdate", "TokenUpdateTransaction", tokenUpdateTx.GetTransactionID(), tokenUpdateTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyTokenChange(mirrorClient, tokenId, "tokenUpdate", "renamed to "+updatedTokenName,
+		func(token *mirror.Token) bool { return token.Name == updatedTokenName }, report)
+	if err != nil {
+		return err
+	}
 
-	// Set the default maximum transaction fee (in HBAR)
-	client.SetDefaultMaxTransactionFee(hedera.HbarFrom(100, hedera.HbarUnits.Hbar))
-	// Set the default maximum payment for queries (in HBAR)
-	client.SetDefaultMaxQueryPayment(hedera.HbarFrom(50, hedera.HbarUnits.Hbar))
+	fmt.Println("🟣 Deleting the token")
+	tokenDeleteTx, err := hedera.NewTokenDeleteTransaction().
+		SetTokenID(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenDeleteTransaction: %w", err)
+	}
+	// Sign with the admin key (operator key)
+	err = submit(client, "tokenDelete", "TokenDeleteTransaction", tokenDeleteTx.GetTransactionID(), tokenDeleteTx.Sign(operatorKey), report)
+	if err != nil {
+		return err
+	}
+	err = verifyTokenChange(mirrorClient, tokenId, "tokenDelete", "deleted",
+		func(token *mirror.Token) bool { return token.Deleted }, report)
+	if err != nil {
+		return err
+	}
+
+	// An account can only dissociate from a token once its balance is zero,
+	// unless the token has been deleted, as it has here
+	fmt.Println("🟣 Dissociating the account from the token")
+	tokenDissociateTx, err := hedera.NewTokenDissociateTransaction().
+		SetAccountID(accountId).
+		SetTokenIDs(tokenId).
+		FreezeWith(client)
+	if err != nil {
+		return fmt.Errorf("freezing TokenDissociateTransaction: %w", err)
+	}
+	err = submit(client, "tokenDissociate", "TokenDissociateTransaction", tokenDissociateTx.GetTransactionID(), tokenDissociateTx.Sign(accountKey), report)
+	if err != nil {
+		return err
+	}
+	return verifyRelationship(mirrorClient, accountId, tokenId, "tokenDissociate", "dissociated",
+		func(rel *mirror.TokenRelationship) bool { return rel == nil }, report)
+}
 
+// createToken creates a fungible HTS token, with the operator account as its treasury,
+// and the operator key as every one of its keys
+func createToken(client *hedera.Client, operatorId hedera.AccountID, operatorKey hedera.PrivateKey, report *output.Report) (hedera.TokenID, error) {
 	fmt.Println("🟣 Creating new HTS token")
-	tokenCreateTx, _ := hedera.NewTokenCreateTransaction().
+	operatorPublicKey := operatorKey.PublicKey()
+	tokenCreateTx, err := hedera.NewTokenCreateTransaction().
 		//Set the transaction memo
 		SetTransactionMemo("Hello Future World token - xyz").
 		// HTS `TokenType.FungibleCommon` behaves similarly to ERC20
@@ -62,15 +455,34 @@ func main() {
 		// Set the token symbol
 		SetTokenSymbol("HTSFT").
 		// Set the token decimals to 2
-		SetDecimals(2).
+		SetDecimals(tokenDecimals).
 		// Set the initial supply of the token to 1,000,000
-		SetInitialSupply(1_000_000).
+		SetInitialSupply(tokenInitialSupply).
 		// Configure token access permissions: treasury account, admin, freezing
 		SetTreasuryAccountID(operatorId).
 		// Set the freeze default value to false
 		SetFreezeDefault(false).
+		// The admin key can update and delete the token
+		SetAdminKey(operatorPublicKey).
+		// The supply key can mint and burn tokens
+		SetSupplyKey(operatorPublicKey).
+		// The freeze key can freeze and unfreeze accounts
+		SetFreezeKey(operatorPublicKey).
+		// The KYC key can grant and revoke KYC
+		SetKycKey(operatorPublicKey).
+		// The wipe key can wipe tokens from accounts
+		SetWipeKey(operatorPublicKey).
+		// The pause key can pause and unpause the token
+		SetPauseKey(operatorPublicKey).
+		// The fee schedule key can replace the custom fees
+		SetFeeScheduleKey(operatorPublicKey).
+		// Custom fees are charged on each transfer of the token, on top of the transaction fee
+		SetCustomFees(createFees(operatorId)).
 		//Freeze the transaction and prepare for signing
 		FreezeWith(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("freezing TokenCreateTransaction: %w", err)
+	}
 
 	// Get the transaction ID of the transaction. The SDK automatically generates and assigns a transaction ID when the transaction is created
 	tokenCreateTxId := tokenCreateTx.GetTransactionID()
@@ -80,48 +492,186 @@ func main() {
 	tokenCreateTxSigned := tokenCreateTx.Sign(operatorKey)
 
 	// Submit the transaction to the Hedera Testnet
-	tokenCreateTxSubmitted, _ := tokenCreateTxSigned.Execute(client)
+	tokenCreateTxSubmitted, err := tokenCreateTxSigned.Execute(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("executing TokenCreateTransaction: %w", err)
+	}
 
 	// Get the transaction receipt
-	tokenCreateTxReceipt, _ := tokenCreateTxSubmitted.GetReceipt(client)
+	tokenCreateTxReceipt, err := tokenCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.TokenID{}, fmt.Errorf("getting receipt for TokenCreateTransaction: %w", err)
+	}
+
+	report.AddTransaction("tokenCreate", tokenCreateTxId, tokenCreateTxReceipt.Status)
 
 	// Get the token ID
-	tokenId := tokenCreateTxReceipt.TokenID
+	tokenId := *tokenCreateTxReceipt.TokenID
 	fmt.Printf("Token ID: %s\n", tokenId.String())
+	report.AddEntity("token", tokenId)
+	return tokenId, nil
+}
 
-	client.Close()
+// createFees returns the custom fees of the token when it is created:
+// a fractional fee, deducted from the amount received, and collected by the treasury.
+// Transfers from the treasury, such as the first transfer to the account, are exempt from custom fees.
+func createFees(operatorId hedera.AccountID) []hedera.Fee {
+	return []hedera.Fee{
+		hedera.NewCustomFractionalFee().
+			SetNumerator(tokenFeeNumerator).
+			SetDenominator(tokenFeeDenominator).
+			SetMin(tokenFeeMin).
+			SetMax(tokenFeeMax).
+			SetFeeCollectorAccountID(operatorId),
+	}
+}
 
-	// Verify transactions using Hashscan
-	// This is a manual step, the code below only outputs the URL to visit
+// updatedFees returns the fee schedule that replaces the custom fees of the token:
+// the fractional fee, now paid by the sender on top of the amount,
+// a fixed fee in HBAR, and a fixed fee in the token itself
+func updatedFees(operatorId hedera.AccountID, tokenId hedera.TokenID) []hedera.Fee {
+	return []hedera.Fee{
+		hedera.NewCustomFractionalFee().
+			SetNumerator(tokenFeeNumerator).
+			SetDenominator(tokenFeeDenominator).
+			SetMin(tokenFeeMin).
+			SetMax(tokenFeeMax).
+			SetAssessmentMethod(hedera.FeeAssessmentMethodExclusive).
+			SetFeeCollectorAccountID(operatorId),
+		hedera.NewCustomFixedFee().
+			SetHbarAmount(hedera.NewHbar(1)).
+			SetFeeCollectorAccountID(operatorId),
+		// The fee collector has to be associated with the token that the fee is paid in,
+		// which the treasury always is.
+		// Only TokenCreateTransaction accepts SetDenominatingTokenToSameToken, so the token is named by its ID.
+		hedera.NewCustomFixedFee().
+			SetAmount(10).
+			SetDenominatingTokenID(tokenId).
+			SetFeeCollectorAccountID(operatorId),
+	}
+}
 
-	// View your token on HashScan
-	fmt.Println("🟣 View the token on HashScan")
-	tokenHashscanUrl :=
-		fmt.Sprintf("https://hashscan.io/testnet/token/%s", tokenId.String())
-	fmt.Printf("Token Hashscan URL: %s\n", tokenHashscanUrl)
+// createAccount creates an account with a new key, to associate with the token
+func createAccount(client *hedera.Client, report *output.Report) (hedera.AccountID, hedera.PrivateKey, error) {
+	fmt.Println("🟣 Creating new account to hold the token")
+	accountKey, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("generating account key: %w", err)
+	}
+	accountCreateTx, err := hedera.NewAccountCreateTransaction().
+		SetKey(accountKey.PublicKey()).
+		// The operator account pays the fees of the token transactions,
+		// so the account does not need any HBAR
+		SetInitialBalance(hedera.ZeroHbar).
+		FreezeWith(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("freezing AccountCreateTransaction: %w", err)
+	}
+	accountCreateTxSubmitted, err := accountCreateTx.Execute(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("executing AccountCreateTransaction: %w", err)
+	}
+	accountCreateTxReceipt, err := accountCreateTxSubmitted.GetReceipt(client)
+	if err != nil {
+		return hedera.AccountID{}, hedera.PrivateKey{}, fmt.Errorf("getting receipt for AccountCreateTransaction: %w", err)
+	}
+	report.AddTransaction("accountCreate", accountCreateTx.GetTransactionID(), accountCreateTxReceipt.Status)
+	accountId := *accountCreateTxReceipt.AccountID
+	fmt.Printf("Account ID: %s\n", accountId.String())
+	report.AddEntity("account", accountId)
+	return accountId, accountKey, nil
+}
 
-	// Wait for 6s for record files (blocks) to propagate to mirror nodes
-	time.Sleep(6 * time.Second)
+// executable is any frozen and signed SDK transaction
+type executable interface {
+	Execute(client *hedera.Client) (hedera.TransactionResponse, error)
+}
 
+// submit executes a signed transaction, and waits for its receipt
+func submit(client *hedera.Client, name string, txType string, txId hedera.TransactionID, tx executable, report *output.Report) error {
+	fmt.Printf("The %s transaction ID: %s\n", name, txId.String())
+	txSubmitted, err := tx.Execute(client)
+	if err != nil {
+		return fmt.Errorf("executing %s: %w", txType, err)
+	}
+	txReceipt, err := txSubmitted.GetReceipt(client)
+	if err != nil {
+		return fmt.Errorf("getting receipt for %s: %w", txType, err)
+	}
+	report.AddTransaction(name, txId, txReceipt.Status)
+	fmt.Printf("The %s transaction status is: %s\n", name, txReceipt.Status.String())
+	return nil
+}
+
+// verifyToken reads the token back from the mirror node
+func verifyToken(mirrorClient *mirror.Client, tokenId hedera.TokenID, report *output.Report) error {
 	// Verify token using Mirror Node API
 	fmt.Println("🟣 Get token data from the Hedera Mirror Node")
 	tokenMirrorNodeApiUrl :=
-		fmt.Sprintf("https://testnet.mirrornode.hedera.com/api/v1/tokens/%s", tokenId.String())
+		mirrorClient.URL(fmt.Sprintf("tokens/%s", tokenId.String()), nil)
 	fmt.Printf("The token Hedera Mirror Node API URL: %s\n", tokenMirrorNodeApiUrl)
 
-	httpResp, err := req.R().Get(tokenMirrorNodeApiUrl)
-	if err != nil {
-		log.Fatalf("Failed to fetch token URL: %v", err)
-	}
-	var tokenResp TokenMNAPIResponse
-	err = json.Unmarshal(httpResp.Bytes(), &tokenResp)
+	// Wait for record files (blocks) to propagate to mirror nodes,
+	// by polling until the token is visible
+	tokenResp, err := mirrorClient.WaitForToken(context.Background(), mirror.DefaultBackoff, tokenId.String())
+	report.AddMirrorVerification("token", tokenMirrorNodeApiUrl, tokenResp, err)
 	if err != nil {
-		log.Fatalf("Failed to parse JSON of response fetched from token URL: %v", err)
+		return fmt.Errorf("fetching token: %w", err)
 	}
 	tokenName := tokenResp.Name
 	fmt.Printf("The name of this token: %s\n", tokenName)
+	// The total supply is in the smallest denomination, so 1000000 with 2 decimals is 10000.00 tokens
 	tokenTotalSupply := tokenResp.TotalSupply
-	fmt.Printf("The total supply of this token: %s\n", tokenTotalSupply)
+	fmt.Printf("The total supply of this token: %d (%s %s)\n", tokenTotalSupply, tokenResp.FormatAmount(int64(tokenTotalSupply)), tokenResp.Symbol)
+	printFees(tokenResp)
+	return nil
+}
 
-	fmt.Println("🎉 Hello Future World - HTS Fungible Token - complete")
+// printFees prints the custom fees of a token returned by the mirror node
+func printFees(tokenResp *mirror.Token) {
+	fmt.Println("The custom fees of this token:")
+	for _, line := range fees.DescribeMirror(tokenResp.CustomFees) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// verifyTokenChange polls the token on the mirror node until done reports that it is what, e.g. paused
+func verifyTokenChange(mirrorClient *mirror.Client, tokenId hedera.TokenID, name string, what string, done func(*mirror.Token) bool, report *output.Report) error {
+	tokenMirrorNodeApiUrl :=
+		mirrorClient.URL(fmt.Sprintf("tokens/%s", tokenId.String()), nil)
+	tokenResp, err := mirrorClient.WaitForTokenChange(context.Background(), mirror.DefaultBackoff, tokenId.String(), done)
+	report.AddMirrorVerification(name, tokenMirrorNodeApiUrl, tokenResp, err)
+	if err != nil {
+		return fmt.Errorf("verifying token %s: %w", what, err)
+	}
+	fmt.Printf("The mirror node shows the token %s\n", what)
+	return nil
+}
+
+// verifyTotalSupply polls the token on the mirror node until it has the expected total supply
+func verifyTotalSupply(mirrorClient *mirror.Client, tokenId hedera.TokenID, name string, totalSupply uint64, report *output.Report) error {
+	expected := strconv.FormatUint(totalSupply, 10)
+	return verifyTokenChange(mirrorClient, tokenId, name, "with a total supply of "+expected,
+		func(token *mirror.Token) bool { return uint64(token.TotalSupply) == totalSupply }, report)
+}
+
+// verifyRelationship polls the account's relationship with the token on the mirror node
+// until done reports that it is what, e.g. frozen
+func verifyRelationship(mirrorClient *mirror.Client, accountId hedera.AccountID, tokenId hedera.TokenID, name string, what string, done func(*mirror.TokenRelationship) bool, report *output.Report) error {
+	relationshipMirrorNodeApiUrl :=
+		mirrorClient.URL(fmt.Sprintf("accounts/%s/tokens", accountId.String()), url.Values{"token.id": {tokenId.String()}})
+	relationshipResp, err := mirrorClient.WaitForTokenRelationship(context.Background(), mirror.DefaultBackoff, accountId.String(), tokenId.String(), done)
+	report.AddMirrorVerification(name, relationshipMirrorNodeApiUrl, relationshipResp, err)
+	if err != nil {
+		return fmt.Errorf("verifying account %s: %w", what, err)
+	}
+	fmt.Printf("The mirror node shows the account %s\n", what)
+	return nil
+}
+
+// verifyBalance polls the account's relationship with the token on the mirror node
+// until it has the expected balance
+func verifyBalance(mirrorClient *mirror.Client, accountId hedera.AccountID, tokenId hedera.TokenID, name string, balance int64, report *output.Report) error {
+	return verifyRelationship(mirrorClient, accountId, tokenId, name, fmt.Sprintf("with a balance of %d", balance),
+		func(rel *mirror.TokenRelationship) bool { return rel != nil && rel.Balance == balance }, report)
 }
@@ -0,0 +1,130 @@
+// Package auditlog keeps a tamper-evident audit log on an HCS topic.
+//
+// Each entry carries the hash of the entry before it, so that the entries form a chain,
+// and no entry can be changed, dropped, or inserted without breaking the links after it:
+//
+//	{"auditlog":"hfw/1","index":1,"previous":"<SHA-384 of the entry before, in hex>","record":{...}}
+//
+// The topic orders the entries by consensus, and chains every message with its running hash.
+// An Index rebuilds the chain from the messages on the mirror node, checks their running hashes,
+// and flags the entries that fork the chain, or link to entries that are not on the topic.
+// It also builds a Merkle tree over the entries on the chain,
+// and proves that a record is in the log, at its consensus timestamp and running hash, with Prove.
+package auditlog
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Version marks an audit log entry, and is the value of its `auditlog` field
+const Version = "hfw/1"
+
+var (
+	// ErrNotEntry is returned for messages that are not audit log entries
+	ErrNotEntry = errors.New("not an audit log entry")
+	// ErrMalformed is returned for entries that cannot be decoded
+	ErrMalformed = errors.New("malformed audit log entry")
+)
+
+// Hash is a SHA-384 hash, which is hex encoded in JSON
+type Hash []byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h)
+}
+
+// MarshalText encodes the hash in hex
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText decodes a hash in hex
+func (h *Hash) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*h = decoded
+	return nil
+}
+
+// Entry is an entry of the audit log
+type Entry struct {
+	// Index is the position of the entry in the log, starting at 0
+	Index uint64 `json:"index"`
+	// Previous is the hash of the entry before it, and empty for the first entry
+	Previous Hash `json:"previous,omitempty"`
+	// Record is the JSON document that the entry logs
+	Record json.RawMessage `json:"record"`
+}
+
+type wireEntry struct {
+	AuditLog string `json:"auditlog"`
+	Entry
+}
+
+// Head is the last entry of a log, which the next entry links to
+type Head struct {
+	Index uint64 `json:"index"`
+	Hash  Hash   `json:"hash"`
+}
+
+// HashOf returns the hash of an entry, the SHA-384 of its message as submitted to the topic
+func HashOf(message []byte) Hash {
+	hash := sha512.Sum384(message)
+	return hash[:]
+}
+
+// Append returns the message of an entry that logs a record after head, or the first entry of a log when head is nil,
+// and the head that the entry after it links to.
+// The record is any value that encoding/json can encode, e.g. a struct, or a json.RawMessage.
+func Append(head *Head, record any) ([]byte, Head, error) {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, Head{}, fmt.Errorf("encoding record: %w", err)
+	}
+	entry := Entry{Record: encoded}
+	if head != nil {
+		entry.Index = head.Index + 1
+		entry.Previous = head.Hash
+	}
+	message, err := json.Marshal(wireEntry{AuditLog: Version, Entry: entry})
+	if err != nil {
+		return nil, Head{}, err
+	}
+	return message, Head{Index: entry.Index, Hash: HashOf(message)}, nil
+}
+
+// Parse decodes the message of an entry.
+// It returns ErrNotEntry for other messages, and ErrMalformed for entries that cannot be decoded.
+func Parse(message []byte) (Entry, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(message), []byte("{")) {
+		return Entry{}, ErrNotEntry
+	}
+	var marker struct {
+		AuditLog string `json:"auditlog"`
+	}
+	if err := json.Unmarshal(message, &marker); err != nil || marker.AuditLog != Version {
+		return Entry{}, ErrNotEntry
+	}
+	var wire wireEntry
+	if err := json.Unmarshal(message, &wire); err != nil {
+		return Entry{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	entry := wire.Entry
+	if len(entry.Record) == 0 {
+		return Entry{}, fmt.Errorf("%w: no record", ErrMalformed)
+	}
+	if (entry.Index == 0) != (len(entry.Previous) == 0) {
+		return Entry{}, fmt.Errorf("%w: only the first entry, at index 0, does not link to an entry before it", ErrMalformed)
+	}
+	if len(entry.Previous) > 0 && len(entry.Previous) != sha512.Size384 {
+		return Entry{}, fmt.Errorf("%w: the hash of the entry before it is %d bytes, not %d", ErrMalformed, len(entry.Previous), sha512.Size384)
+	}
+	return entry, nil
+}
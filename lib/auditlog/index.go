@@ -0,0 +1,262 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"lib/chunks"
+	"lib/mirror"
+	"lib/runninghash"
+)
+
+// ErrNotOnChain is returned when proving a record that is not on the chain
+var ErrNotOnChain = errors.New("not an entry on the chain of the audit log")
+
+// ProblemKind is what is wrong with a message that does not extend the chain
+type ProblemKind string
+
+const (
+	// ProblemRunningHash is a message whose running hash is not the one computed from the message,
+	// so the mirror node serves a message other than the one the network put on the topic
+	ProblemRunningHash ProblemKind = "running-hash"
+	// ProblemMalformed is an entry that cannot be decoded
+	ProblemMalformed ProblemKind = "malformed"
+	// ProblemFork is an entry that links to an entry that another entry already follows
+	ProblemFork ProblemKind = "fork"
+	// ProblemMissingLink is an entry that links to an entry that is not on the topic
+	ProblemMissingLink ProblemKind = "missing-link"
+	// ProblemIndex is an entry whose index is not one more than the index of the entry it links to
+	ProblemIndex ProblemKind = "index"
+	// ProblemDetached is an entry that links to an entry that is not on the chain, because of an earlier problem
+	ProblemDetached ProblemKind = "detached"
+)
+
+// Problem is a message that does not extend the chain
+type Problem struct {
+	Kind           ProblemKind `json:"kind"`
+	SequenceNumber uint64      `json:"sequenceNumber"`
+	Detail         string      `json:"detail"`
+}
+
+// Record is an entry, where the topic put it
+type Record struct {
+	Entry
+	Hash               Hash   `json:"hash"`
+	SequenceNumber     uint64 `json:"sequenceNumber"`
+	ConsensusTimestamp string `json:"consensusTimestamp"`
+	// RunningHash is the running hash of the topic at the entry, at its last chunk if it was split into chunks
+	RunningHash Hash `json:"runningHash"`
+	// Message is the entry as submitted to the topic
+	Message []byte `json:"-"`
+}
+
+// Index is the audit log of a topic, rebuilt from its messages, in consensus order
+type Index struct {
+	TopicID string
+	// Chain holds the entries that extend the log from its first entry, in order,
+	// so that the entry at index i of the log is Chain[i]
+	Chain    []*Record
+	Problems []Problem
+	// Skipped counts the messages that are not entries, which the topic may have too
+	Skipped int
+	// RunningHashesVerified counts the messages whose running hash was checked,
+	// which is every message unless the topic has messages from before running hash version 3
+	RunningHashesVerified int
+
+	// records holds every entry by its hash, on the chain or not
+	records            map[string]*Record
+	onChain            map[string]bool
+	lastSequenceNumber int64
+	runningHash        []byte
+	// tampered holds the sequence numbers of the chunks whose running hash is wrong
+	tampered  map[int64]bool
+	assembler *chunks.Assembler
+}
+
+// NewIndex returns an empty index of the audit log of a topic, to Add its messages to
+func NewIndex(topicID string) *Index {
+	return &Index{
+		TopicID:     topicID,
+		records:     map[string]*Record{},
+		onChain:     map[string]bool{},
+		runningHash: runninghash.Initial(),
+		tampered:    map[int64]bool{},
+		assembler:   chunks.NewAssembler(),
+	}
+}
+
+// Build reads every message of a topic from the mirror node, from the first, and rebuilds its audit log
+func Build(ctx context.Context, mirrorClient *mirror.Client, topicID string) (*Index, error) {
+	index := NewIndex(topicID)
+	opts := mirror.ListOptions{
+		Limit:          100,
+		Order:          mirror.OrderAsc,
+		SequenceNumber: []mirror.Condition{mirror.Gte(1)},
+		Extra:          url.Values{"encoding": {"base64"}},
+	}
+	err := mirrorClient.EachTopicMessage(ctx, topicID, opts, func(message mirror.TopicMessage) error {
+		return index.Add(message)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading topic messages: %w", err)
+	}
+	return index, nil
+}
+
+// Add adds the next message of the topic, or chunk of a message.
+// Messages are added in order, from the first message of the topic, so that every running hash can be checked.
+func (ix *Index) Add(message mirror.TopicMessage) error {
+	if message.SequenceNumber != ix.lastSequenceNumber+1 {
+		return fmt.Errorf("expected topic message #%d, got #%d", ix.lastSequenceNumber+1, message.SequenceNumber)
+	}
+	ix.lastSequenceNumber = message.SequenceNumber
+	runningHash, err := base64.StdEncoding.DecodeString(message.RunningHash)
+	if err != nil {
+		return fmt.Errorf("decoding running hash of topic message #%d: %w", message.SequenceNumber, err)
+	}
+	err = runninghash.Verify(ix.runningHash, message)
+	switch {
+	case err == nil:
+		ix.RunningHashesVerified++
+	case errors.Is(err, runninghash.ErrMismatch):
+		ix.tampered[message.SequenceNumber] = true
+		ix.problem(ProblemRunningHash, message.SequenceNumber, "the message is not the one the network put on the topic")
+	case !errors.Is(err, runninghash.ErrUnsupportedVersion):
+		return err
+	}
+	// The next running hash is checked against this one, even when it is wrong,
+	// so that a single altered message is only flagged once
+	ix.runningHash = runningHash
+
+	assembled, err := ix.assembler.Add(message)
+	if err != nil || assembled == nil {
+		return err
+	}
+	for _, sequenceNumber := range assembled.SequenceNumbers() {
+		if ix.tampered[sequenceNumber] {
+			return nil
+		}
+	}
+	contents, err := assembled.Contents()
+	if err != nil {
+		return err
+	}
+	entry, err := Parse(contents)
+	if errors.Is(err, ErrNotEntry) {
+		ix.Skipped++
+		return nil
+	}
+	if err != nil {
+		ix.problem(ProblemMalformed, message.SequenceNumber, err.Error())
+		return nil
+	}
+	ix.link(&Record{
+		Entry:              entry,
+		Hash:               HashOf(contents),
+		SequenceNumber:     uint64(message.SequenceNumber),
+		ConsensusTimestamp: message.ConsensusTimestamp,
+		RunningHash:        runningHash,
+		Message:            contents,
+	})
+	return nil
+}
+
+// link adds a record to the chain, when it follows the last entry on the chain, or flags it
+func (ix *Index) link(record *Record) {
+	hash := record.Hash.String()
+	// A repeated entry has the hash of the first one, which is the one that counts
+	if _, ok := ix.records[hash]; !ok {
+		ix.records[hash] = record
+	}
+	sequenceNumber := int64(record.SequenceNumber)
+
+	if record.Index == 0 {
+		if len(ix.Chain) > 0 {
+			ix.problem(ProblemFork, sequenceNumber, fmt.Sprintf("a second first entry, after the one at #%d", ix.Chain[0].SequenceNumber))
+			return
+		}
+		ix.extend(record)
+		return
+	}
+	previous, ok := ix.records[record.Previous.String()]
+	switch {
+	case !ok:
+		ix.problem(ProblemMissingLink, sequenceNumber, fmt.Sprintf("entry %d links to %s, which is not an entry on the topic", record.Index, record.Previous))
+	case !ix.onChain[record.Previous.String()]:
+		ix.problem(ProblemDetached, sequenceNumber, fmt.Sprintf("entry %d links to the entry at #%d, which is not on the chain", record.Index, previous.SequenceNumber))
+	case previous != ix.Chain[len(ix.Chain)-1]:
+		next := ix.Chain[previous.Index+1]
+		ix.problem(ProblemFork, sequenceNumber, fmt.Sprintf("entry %d links to entry %d at #%d, which entry %d at #%d already follows",
+			record.Index, previous.Index, previous.SequenceNumber, next.Index, next.SequenceNumber))
+	case record.Index != previous.Index+1:
+		ix.problem(ProblemIndex, sequenceNumber, fmt.Sprintf("entry %d links to entry %d at #%d", record.Index, previous.Index, previous.SequenceNumber))
+	default:
+		ix.extend(record)
+	}
+}
+
+func (ix *Index) extend(record *Record) {
+	ix.Chain = append(ix.Chain, record)
+	ix.onChain[record.Hash.String()] = true
+}
+
+func (ix *Index) problem(kind ProblemKind, sequenceNumber int64, detail string) {
+	ix.Problems = append(ix.Problems, Problem{Kind: kind, SequenceNumber: uint64(sequenceNumber), Detail: detail})
+}
+
+// Incomplete returns an error when the topic ends with a chunked message that is missing some of its chunks
+func (ix *Index) Incomplete() error {
+	return ix.assembler.Incomplete()
+}
+
+// Head returns the last entry on the chain, which the next entry links to, and nil for an empty log
+func (ix *Index) Head() *Head {
+	if len(ix.Chain) == 0 {
+		return nil
+	}
+	last := ix.Chain[len(ix.Chain)-1]
+	return &Head{Index: last.Index, Hash: last.Hash}
+}
+
+// Root returns the root of the Merkle tree over the entries on the chain
+func (ix *Index) Root() Hash {
+	return treeHash(ix.leaves())
+}
+
+// Prove returns a proof that the entry at a sequence number is on the chain,
+// under the root of the tree over every entry on the chain
+func (ix *Index) Prove(sequenceNumber uint64) (*Proof, error) {
+	for i, record := range ix.Chain {
+		if record.SequenceNumber != sequenceNumber {
+			continue
+		}
+		leaves := ix.leaves()
+		proof := &Proof{
+			TopicID:            ix.TopicID,
+			SequenceNumber:     record.SequenceNumber,
+			ConsensusTimestamp: record.ConsensusTimestamp,
+			RunningHash:        record.RunningHash,
+			Message:            record.Message,
+			LeafIndex:          uint64(i),
+			TreeSize:           uint64(len(leaves)),
+			Path:               []Hash{},
+			Root:               treeHash(leaves),
+		}
+		for _, sibling := range inclusionPath(i, leaves) {
+			proof.Path = append(proof.Path, sibling)
+		}
+		return proof, nil
+	}
+	return nil, fmt.Errorf("%w: topic message #%d", ErrNotOnChain, sequenceNumber)
+}
+
+func (ix *Index) leaves() [][]byte {
+	leaves := make([][]byte, len(ix.Chain))
+	for i, record := range ix.Chain {
+		leaves[i] = leafHash(ix.TopicID, record.SequenceNumber, record.ConsensusTimestamp, record.RunningHash, record.Hash)
+	}
+	return leaves
+}
@@ -0,0 +1,182 @@
+package auditlog
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"lib/fakemirror"
+)
+
+func TestIndexProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		// publish publishes the messages of the topic, from heads, the heads of a chain of 3 entries at #1 to #3
+		publish      func(t *testing.T, server *fakemirror.Server, heads []Head)
+		wantProblems []ProblemKind
+		wantChain    int
+		wantSkipped  int
+	}{
+		{
+			name:      "chain",
+			publish:   func(t *testing.T, server *fakemirror.Server, heads []Head) {},
+			wantChain: 3,
+		},
+		{
+			name: "fork",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				appendEntry(t, server, &heads[0], "fork")
+			},
+			wantProblems: []ProblemKind{ProblemFork},
+			wantChain:    3,
+		},
+		{
+			name: "second first entry",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				appendEntry(t, server, nil, "create again")
+			},
+			wantProblems: []ProblemKind{ProblemFork},
+			wantChain:    3,
+		},
+		{
+			name: "missing link",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				appendEntry(t, server, &Head{Index: 3, Hash: HashOf([]byte("not an entry"))}, "missing")
+			},
+			wantProblems: []ProblemKind{ProblemMissingLink},
+			wantChain:    3,
+		},
+		{
+			name: "index",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				appendEntry(t, server, &Head{Index: 5, Hash: heads[2].Hash}, "skip ahead")
+			},
+			wantProblems: []ProblemKind{ProblemIndex},
+			wantChain:    3,
+		},
+		{
+			name: "detached",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				fork := appendEntry(t, server, &heads[0], "fork")
+				appendEntry(t, server, &fork, "after the fork")
+			},
+			wantProblems: []ProblemKind{ProblemFork, ProblemDetached},
+			wantChain:    3,
+		},
+		{
+			// The altered entry is flagged, the entry after it links to an entry that is not on the topic,
+			// and the entry after that to an entry that is not on the chain
+			name: "running hash",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				server.Tamper(topicID, 2, []byte(`{"auditlog":"hfw/1","index":1,"record":{}}`))
+				appendEntry(t, server, &heads[2], "delete")
+			},
+			wantProblems: []ProblemKind{ProblemRunningHash, ProblemMissingLink, ProblemDetached},
+			wantChain:    1,
+		},
+		{
+			name: "malformed",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				server.Publish(topicID, []byte(`{"auditlog":"hfw/1","index":4}`))
+			},
+			wantProblems: []ProblemKind{ProblemMalformed},
+			wantChain:    3,
+		},
+		{
+			name: "not an entry",
+			publish: func(t *testing.T, server *fakemirror.Server, heads []Head) {
+				server.Publish(topicID, []byte("Hello HCS"))
+				server.Publish(topicID, []byte(`{"hello":"HCS"}`))
+			},
+			wantChain:   3,
+			wantSkipped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, mirrorClient := startServer(t)
+			var heads []Head
+			var head *Head
+			for _, action := range []string{"create", "update", "submit"} {
+				next := appendEntry(t, server, head, action)
+				heads = append(heads, next)
+				head = &next
+			}
+			tt.publish(t, server, heads)
+
+			index := build(t, mirrorClient)
+			var kinds []ProblemKind
+			for _, problem := range index.Problems {
+				kinds = append(kinds, problem.Kind)
+			}
+			if !slices.Equal(kinds, tt.wantProblems) {
+				t.Errorf("Problems = %v, want %v", index.Problems, tt.wantProblems)
+			}
+			if len(index.Chain) != tt.wantChain {
+				t.Errorf("got %d entries on the chain, want %d", len(index.Chain), tt.wantChain)
+			}
+			if index.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %d, want %d", index.Skipped, tt.wantSkipped)
+			}
+			if head := index.Head(); head == nil || head.Index != uint64(tt.wantChain-1) {
+				t.Errorf("Head = %+v, want entry %d", head, tt.wantChain-1)
+			}
+			if err := index.Incomplete(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestIndexAddOutOfOrder(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	first := appendEntry(t, server, nil, "create")
+	appendEntry(t, server, &first, "update")
+	second, err := mirrorClient.GetTopicMessage(context.Background(), topicID.String(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewIndex(topicID.String()).Add(*second); err == nil {
+		t.Error("Add of #2 before #1 = nil, want an error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	message, head, err := Append(nil, record{Action: "create"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Parse(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Index != 0 || len(entry.Previous) != 0 || string(entry.Record) != `{"action":"create"}` {
+		t.Errorf("Parse = %+v", entry)
+	}
+	next, _, err := Append(&head, record{Action: "update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := Parse(next); err != nil || entry.Index != 1 || entry.Previous.String() != head.Hash.String() {
+		t.Errorf("Parse of the second entry = %+v, %v, want entry 1 after %s", entry, err, head.Hash)
+	}
+
+	for _, tt := range []struct {
+		message string
+		want    error
+	}{
+		{"Hello HCS", ErrNotEntry},
+		{`{"hello":"HCS"}`, ErrNotEntry},
+		{`{"auditlog":"hfw/2","index":0,"record":{}}`, ErrNotEntry},
+		{`{"auditlog":"hfw/1","index":0}`, ErrMalformed},
+		{`{"auditlog":"hfw/1","index":"zero","record":{}}`, ErrMalformed},
+		{`{"auditlog":"hfw/1","index":1,"record":{}}`, ErrMalformed},
+		{`{"auditlog":"hfw/1","index":0,"previous":"` + head.Hash.String() + `","record":{}}`, ErrMalformed},
+		{`{"auditlog":"hfw/1","index":1,"previous":"abcd","record":{}}`, ErrMalformed},
+	} {
+		if _, err := Parse([]byte(tt.message)); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%s) = %v, want %v", tt.message, err, tt.want)
+		}
+	}
+}
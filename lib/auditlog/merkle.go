@@ -0,0 +1,240 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/url"
+	"os"
+
+	"lib/chunks"
+	"lib/mirror"
+)
+
+// The Merkle tree is built the way RFC 9162 builds the trees of Certificate Transparency logs,
+// with SHA-384, and with leaf and node hashes prefixed with 0 and 1, so that a node cannot pass as a leaf
+
+// ErrInvalidProof is returned for proofs that do not lead from the record to the root
+var ErrInvalidProof = errors.New("invalid inclusion proof")
+
+// Proof proves that a record is in the audit log of a topic,
+// at the consensus timestamp and running hash of its entry,
+// by leading from the entry to the root of the Merkle tree over the first TreeSize entries of the log
+type Proof struct {
+	TopicID            string `json:"topicId"`
+	SequenceNumber     uint64 `json:"sequenceNumber"`
+	ConsensusTimestamp string `json:"consensusTimestamp"`
+	RunningHash        Hash   `json:"runningHash"`
+	// Message is the entry as submitted to the topic, which holds the record
+	Message   []byte `json:"message"`
+	LeafIndex uint64 `json:"leafIndex"`
+	TreeSize  uint64 `json:"treeSize"`
+	// Path holds the hashes of the sibling subtrees, from the leaf up
+	Path []Hash `json:"path"`
+	Root Hash   `json:"root"`
+}
+
+// WriteProof writes a proof to a file, as JSON
+func WriteProof(path string, proof *Proof) error {
+	data, err := json.MarshalIndent(proof, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding proof: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadProof reads a proof from a file written by WriteProof
+func ReadProof(path string) (*Proof, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var proof Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return nil, fmt.Errorf("decoding proof: %w", err)
+	}
+	return &proof, nil
+}
+
+// Entry decodes the entry that the proof is for
+func (p *Proof) Entry() (Entry, error) {
+	return Parse(p.Message)
+}
+
+// Verify checks that the path leads from the entry, at its sequence number, consensus timestamp, and running hash,
+// to the root. It does not check that the topic has the entry, which VerifyOnMirror does.
+func (p *Proof) Verify() error {
+	if p.LeafIndex >= p.TreeSize {
+		return fmt.Errorf("%w: leaf %d is outside a tree of %d leaves", ErrInvalidProof, p.LeafIndex, p.TreeSize)
+	}
+	leaf := leafHash(p.TopicID, p.SequenceNumber, p.ConsensusTimestamp, p.RunningHash, HashOf(p.Message))
+	root, ok := rootFromPath(p.LeafIndex, p.TreeSize, leaf, p.Path)
+	if !ok || !bytes.Equal(root, p.Root) {
+		return fmt.Errorf("%w: the path does not lead to root %s", ErrInvalidProof, p.Root)
+	}
+	return nil
+}
+
+// VerifyOnMirror checks that the mirror node has the entry at its sequence number,
+// with its consensus timestamp and running hash.
+// A chunked entry is reassembled from every one of its chunks, found by the chunk_info of its last chunk,
+// whose sequence number, consensus timestamp, and running hash are those of the proof.
+func (p *Proof) VerifyOnMirror(ctx context.Context, mirrorClient *mirror.Client) error {
+	topicMessage, err := mirrorClient.GetTopicMessage(ctx, p.TopicID, p.SequenceNumber)
+	if err != nil {
+		return fmt.Errorf("getting topic message #%d: %w", p.SequenceNumber, err)
+	}
+	runningHash, err := base64.StdEncoding.DecodeString(topicMessage.RunningHash)
+	if err != nil {
+		return fmt.Errorf("decoding running hash of topic message #%d: %w", p.SequenceNumber, err)
+	}
+	switch {
+	case topicMessage.ConsensusTimestamp != p.ConsensusTimestamp:
+		return fmt.Errorf("topic message #%d reached consensus at %s, not %s", p.SequenceNumber, topicMessage.ConsensusTimestamp, p.ConsensusTimestamp)
+	case !bytes.Equal(runningHash, p.RunningHash):
+		return fmt.Errorf("topic message #%d has running hash %s, not %s", p.SequenceNumber, Hash(runningHash), p.RunningHash)
+	}
+	contents, err := p.contentsOnMirror(ctx, mirrorClient, *topicMessage)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(contents, p.Message) {
+		return fmt.Errorf("topic message #%d is not the entry of the proof", p.SequenceNumber)
+	}
+	return nil
+}
+
+// contentsOnMirror returns the message that ends with the topic message of the proof,
+// joined from the chunks before it when it was split into chunks
+func (p *Proof) contentsOnMirror(ctx context.Context, mirrorClient *mirror.Client, last mirror.TopicMessage) ([]byte, error) {
+	info := last.ChunkInfo
+	if info == nil || info.Total <= 1 {
+		return last.Contents()
+	}
+	if info.Number != info.Total {
+		return nil, fmt.Errorf("topic message #%d is chunk %d of %d, not the last chunk of the entry of the proof", p.SequenceNumber, info.Number, info.Total)
+	}
+	assembler := chunks.NewAssembler()
+	message, err := assembler.Add(last)
+	if err != nil {
+		return nil, err
+	}
+	// The other chunks come before the last one, with messages of other writers possibly in between,
+	// and reached consensus after the valid start of the first chunk
+	initialTxID := info.InitialTransactionID.String()
+	opts := mirror.ListOptions{
+		Limit:          100,
+		Order:          mirror.OrderDesc,
+		SequenceNumber: []mirror.Condition{mirror.Lt(p.SequenceNumber)},
+		Timestamp:      []mirror.Condition{mirror.Gte(info.InitialTransactionID.TransactionValidStart)},
+		Extra:          url.Values{"encoding": {"base64"}},
+	}
+	err = mirrorClient.EachTopicMessage(ctx, p.TopicID, opts, func(topicMessage mirror.TopicMessage) error {
+		if topicMessage.ChunkInfo == nil || topicMessage.ChunkInfo.InitialTransactionID.String() != initialTxID {
+			return nil
+		}
+		complete, err := assembler.Add(topicMessage)
+		if err != nil {
+			return err
+		}
+		if complete != nil {
+			message = complete
+			return mirror.ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading the chunks of topic message #%d: %w", p.SequenceNumber, err)
+	}
+	if message == nil {
+		return nil, assembler.Incomplete()
+	}
+	return message.Contents()
+}
+
+// leafHash hashes an entry into a leaf of the tree, together with where the topic put it
+func leafHash(topicID string, sequenceNumber uint64, consensusTimestamp string, runningHash []byte, entryHash []byte) []byte {
+	h := sha512.New384()
+	h.Write([]byte{0})
+	for _, field := range [][]byte{
+		[]byte(topicID),
+		binary.BigEndian.AppendUint64(nil, sequenceNumber),
+		[]byte(consensusTimestamp),
+		runningHash,
+		entryHash,
+	} {
+		// Each field is prefixed with its length, so that no two leaves have the same data
+		_ = binary.Write(h, binary.BigEndian, uint32(len(field)))
+		h.Write(field)
+	}
+	return h.Sum(nil)
+}
+
+func nodeHash(left []byte, right []byte) []byte {
+	h := sha512.New384()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// splitPoint returns the largest power of two smaller than n, where the tree of n leaves is split
+func splitPoint(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// treeHash returns the root of the tree over leaves
+func treeHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		empty := sha512.Sum384(nil)
+		return empty[:]
+	case 1:
+		return leaves[0]
+	}
+	k := splitPoint(len(leaves))
+	return nodeHash(treeHash(leaves[:k]), treeHash(leaves[k:]))
+}
+
+// inclusionPath returns the hashes of the sibling subtrees of the leaf at index, from the leaf up
+func inclusionPath(index int, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := splitPoint(len(leaves))
+	if index < k {
+		return append(inclusionPath(index, leaves[:k]), treeHash(leaves[k:]))
+	}
+	return append(inclusionPath(index-k, leaves[k:]), treeHash(leaves[:k]))
+}
+
+// rootFromPath computes the root that a path leads to from the leaf at index, in a tree of size leaves,
+// as RFC 9162 verifies inclusion proofs, and reports whether the path has the length the tree needs
+func rootFromPath(index uint64, size uint64, leaf []byte, path []Hash) ([]byte, bool) {
+	fn, sn := index, size-1
+	root := leaf
+	for _, sibling := range path {
+		if sn == 0 {
+			return nil, false
+		}
+		if fn&1 == 1 || fn == sn {
+			root = nodeHash(sibling, root)
+			// Skip the levels where the leaf is the last node, without a right sibling
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			root = nodeHash(root, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return root, sn == 0
+}
@@ -0,0 +1,233 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/fakemirror"
+	"lib/mirror"
+)
+
+var topicID = hedera.TopicID{Topic: 1003}
+
+type record struct {
+	Action string `json:"action"`
+}
+
+// startServer starts a fake mirror node, and returns it with a REST API client
+func startServer(t *testing.T) (*fakemirror.Server, *mirror.Client) {
+	t.Helper()
+	server, err := fakemirror.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server, mirror.NewClient(server.RESTURL())
+}
+
+// appendEntry publishes the entry of a record after head, and returns the head after it
+func appendEntry(t *testing.T, server *fakemirror.Server, head *Head, action string) Head {
+	t.Helper()
+	message, next, err := Append(head, record{Action: action})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Publish(topicID, message)
+	return next
+}
+
+func build(t *testing.T, mirrorClient *mirror.Client) *Index {
+	t.Helper()
+	index, err := Build(context.Background(), mirrorClient, topicID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func TestVerifyOnMirror(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	// The second entry is chunks #2 to #4
+	first := appendEntry(t, server, nil, "create")
+	second := appendEntry(t, server, &first, strings.Repeat("update ", 300))
+	appendEntry(t, server, &second, "delete")
+	index := build(t, mirrorClient)
+	if len(index.Chain) != 3 {
+		t.Fatalf("got %d entries on the chain, want 3", len(index.Chain))
+	}
+
+	for _, sequenceNumber := range []uint64{1, 4, 5} {
+		proof, err := index.Prove(sequenceNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proof.VerifyOnMirror(context.Background(), mirrorClient); err != nil {
+			t.Errorf("VerifyOnMirror of #%d = %v", sequenceNumber, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		proof  func(proof *Proof)
+		tamper func()
+	}{
+		{"other consensus timestamp", func(proof *Proof) { proof.ConsensusTimestamp = "1712345678.000000001" }, nil},
+		{"other running hash", func(proof *Proof) { proof.RunningHash = HashOf(nil) }, nil},
+		{"altered message", func(proof *Proof) { proof.Message = append([]byte(" "), proof.Message...) }, nil},
+		// The mirror node serves another first chunk, with the last chunk untouched
+		{"altered first chunk", nil, func() { server.Tamper(topicID, 2, []byte("altered")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := index.Prove(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.proof != nil {
+				tt.proof(proof)
+			}
+			if tt.tamper != nil {
+				tt.tamper()
+			}
+			if err := proof.VerifyOnMirror(context.Background(), mirrorClient); err == nil {
+				t.Error("VerifyOnMirror = nil, want an error")
+			}
+		})
+	}
+}
+
+// testLeaves returns n distinct leaves
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = leafHash(topicID.String(), uint64(i+1), "1712345678.000000001", nil, HashOf([]byte{byte(i)}))
+	}
+	return leaves
+}
+
+func TestInclusionPath(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		leaves := testLeaves(size)
+		root := treeHash(leaves)
+		for index := range leaves {
+			var path []Hash
+			for _, sibling := range inclusionPath(index, leaves) {
+				path = append(path, sibling)
+			}
+			got, ok := rootFromPath(uint64(index), uint64(size), leaves[index], path)
+			if !ok || !bytes.Equal(got, root) {
+				t.Errorf("leaf %d of %d: the path does not lead to the root", index, size)
+			}
+			// Another leaf at the same index does not lead to the root
+			if got, _ := rootFromPath(uint64(index), uint64(size), leaves[(index+1)%size], path); size > 1 && bytes.Equal(got, root) {
+				t.Errorf("leaf %d of %d: the path leads to the root from another leaf", index, size)
+			}
+			if len(path) > 0 {
+				if _, ok := rootFromPath(uint64(index), uint64(size), leaves[index], path[:len(path)-1]); ok {
+					t.Errorf("leaf %d of %d: a path that is too short is accepted", index, size)
+				}
+			}
+			if _, ok := rootFromPath(uint64(index), uint64(size), leaves[index], append(path, root)); ok {
+				t.Errorf("leaf %d of %d: a path that is too long is accepted", index, size)
+			}
+		}
+	}
+}
+
+func TestTreeHash(t *testing.T) {
+	leaves := testLeaves(3)
+	// A tree of 3 leaves splits into the first 2 leaves, and the third
+	want := nodeHash(nodeHash(leaves[0], leaves[1]), leaves[2])
+	if got := treeHash(leaves); !bytes.Equal(got, want) {
+		t.Errorf("treeHash = %x, want %x", got, want)
+	}
+	if got := treeHash(leaves[:1]); !bytes.Equal(got, leaves[0]) {
+		t.Errorf("treeHash of 1 leaf = %x, want the leaf", got)
+	}
+}
+
+func TestProofVerify(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	var head *Head
+	for _, action := range []string{"create", "update", "submit", "delete", "archive"} {
+		next := appendEntry(t, server, head, action)
+		head = &next
+	}
+	index := build(t, mirrorClient)
+	for _, record := range index.Chain {
+		proof, err := index.Prove(record.SequenceNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proof.Verify(); err != nil {
+			t.Errorf("Verify of #%d = %v", record.SequenceNumber, err)
+		}
+		if !bytes.Equal(proof.Root, index.Root()) {
+			t.Errorf("proof of #%d has root %s, want %s", record.SequenceNumber, proof.Root, index.Root())
+		}
+	}
+	if _, err := index.Prove(99); !errors.Is(err, ErrNotOnChain) {
+		t.Errorf("Prove of a message that is not on the topic = %v, want ErrNotOnChain", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(proof *Proof)
+	}{
+		{"message", func(proof *Proof) {
+			proof.Message = bytes.Replace(proof.Message, []byte("update"), []byte("delete"), 1)
+		}},
+		{"sequence number", func(proof *Proof) { proof.SequenceNumber++ }},
+		{"consensus timestamp", func(proof *Proof) { proof.ConsensusTimestamp = "1712345678.000000001" }},
+		{"running hash", func(proof *Proof) { proof.RunningHash = HashOf(nil) }},
+		{"topic", func(proof *Proof) { proof.TopicID = "0.0.9999" }},
+		{"leaf index", func(proof *Proof) { proof.LeafIndex = 0 }},
+		{"leaf index outside the tree", func(proof *Proof) { proof.LeafIndex = proof.TreeSize }},
+		{"tree size", func(proof *Proof) { proof.TreeSize = 4 }},
+		{"path", func(proof *Proof) { proof.Path[0] = HashOf(nil) }},
+		{"path too short", func(proof *Proof) { proof.Path = proof.Path[:len(proof.Path)-1] }},
+		{"path too long", func(proof *Proof) { proof.Path = append(proof.Path, HashOf(nil)) }},
+		{"root", func(proof *Proof) { proof.Root = HashOf(nil) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := index.Prove(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(proof)
+			if err := proof.Verify(); !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("Verify = %v, want ErrInvalidProof", err)
+			}
+		})
+	}
+}
+
+func TestWriteAndReadProof(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	appendEntry(t, server, nil, "create")
+	proof, err := build(t, mirrorClient).Prove(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "proof.json")
+	if err := WriteProof(path, proof); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadProof(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := read.Verify(); err != nil {
+		t.Errorf("Verify of the proof read back = %v", err)
+	}
+	if !bytes.Equal(read.Message, proof.Message) || !bytes.Equal(read.Root, proof.Root) {
+		t.Errorf("ReadProof = %+v, want %+v", read, proof)
+	}
+}
@@ -0,0 +1,215 @@
+// Package chunks reassembles topic messages that were split into chunks when submitted.
+//
+// A single HCS message is at most 1024 bytes, so TopicMessageSubmitTransaction splits larger
+// payloads into chunks, each submitted as its own transaction, with its own sequence number.
+// Every chunk carries a `chunk_info` with the transaction ID of the first chunk,
+// its number, and the total number of chunks, which the mirror node returns with each message.
+//
+// Chunks of different messages may be interleaved on the topic, and a chunk set is incomplete
+// when a chunk failed to submit, or is outside the range of messages that were read.
+package chunks
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"lib/mirror"
+)
+
+const (
+	// Size is the number of bytes in each chunk, which the SDK fixes at 1024
+	Size = 1024
+	// DefaultMaxChunks is the number of chunks the SDK allows unless SetMaxChunks raises it
+	DefaultMaxChunks = 20
+)
+
+// completedWindow is how many sequence numbers away from the chunks of a completed message
+// the Assembler still recognises them, so that reading them again, e.g. where pages overlap,
+// does not start a new message. It spans a page of the mirror node, the most that is read twice.
+const completedWindow = 100
+
+var (
+	// ErrIncomplete is returned for chunked messages that are missing some of their chunks
+	ErrIncomplete = errors.New("incomplete chunked message")
+	// ErrInvalidChunk is returned for chunks whose chunk_info does not fit the other chunks of their message
+	ErrInvalidChunk = errors.New("invalid chunk")
+)
+
+// Count returns the number of chunks that a payload of size bytes is split into
+func Count(size int) int {
+	return max(1, (size+Size-1)/Size)
+}
+
+// Message is a topic message, and the chunks it was reassembled from
+type Message struct {
+	// InitialTransactionID is the transaction ID of the first chunk, in the SDK form,
+	// and is empty for messages that were submitted without chunk_info
+	InitialTransactionID string
+	Total                int
+	// Chunks are ordered by chunk number, with nil for missing chunks
+	Chunks []*mirror.TopicMessage
+}
+
+// Complete reports whether every chunk is present
+func (m *Message) Complete() bool {
+	return len(m.Missing()) == 0
+}
+
+// Missing returns the numbers of the missing chunks, starting at 1
+func (m *Message) Missing() []int {
+	var missing []int
+	for i, chunk := range m.Chunks {
+		if chunk == nil {
+			missing = append(missing, i+1)
+		}
+	}
+	return missing
+}
+
+// Contents returns the decoded payload, joined from every chunk
+func (m *Message) Contents() ([]byte, error) {
+	if missing := m.Missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("%w %s: missing chunks %v of %d", ErrIncomplete, m.InitialTransactionID, missing, m.Total)
+	}
+	var contents []byte
+	for _, chunk := range m.Chunks {
+		data, err := chunk.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("decoding topic message #%d: %w", chunk.SequenceNumber, err)
+		}
+		contents = append(contents, data...)
+	}
+	return contents, nil
+}
+
+// SequenceNumbers returns the sequence numbers of the chunks that are present, in chunk order
+func (m *Message) SequenceNumbers() []int64 {
+	var sequenceNumbers []int64
+	for _, chunk := range m.Chunks {
+		if chunk != nil {
+			sequenceNumbers = append(sequenceNumbers, chunk.SequenceNumber)
+		}
+	}
+	return sequenceNumbers
+}
+
+// Last returns the chunk with the highest sequence number,
+// whose consensus timestamp is when a complete message became readable
+func (m *Message) Last() *mirror.TopicMessage {
+	var last *mirror.TopicMessage
+	for _, chunk := range m.Chunks {
+		if chunk != nil && (last == nil || chunk.SequenceNumber > last.SequenceNumber) {
+			last = chunk
+		}
+	}
+	return last
+}
+
+// Assembler collects chunks, in the order they are read from the mirror node,
+// until each message is complete
+type Assembler struct {
+	pending map[string]*Message
+	// complete holds the messages of more than one chunk that were completed,
+	// so that reading their chunks again does not start a new pending message,
+	// until the messages read are more than completedWindow sequence numbers away from them
+	complete map[string]*Message
+	// order holds the initial transaction IDs of the pending messages, in the order they were first seen
+	order []string
+}
+
+// NewAssembler returns an Assembler without pending messages
+func NewAssembler() *Assembler {
+	return &Assembler{pending: map[string]*Message{}, complete: map[string]*Message{}}
+}
+
+// Add adds a message read from the mirror node, and returns the message it completes,
+// or nil while chunks of the message are still missing.
+// Messages without chunk_info, or with a single chunk, are complete on their own.
+func (a *Assembler) Add(topicMessage mirror.TopicMessage) (*Message, error) {
+	a.forget(topicMessage.SequenceNumber)
+	info := topicMessage.ChunkInfo
+	if info == nil {
+		return &Message{Total: 1, Chunks: []*mirror.TopicMessage{&topicMessage}}, nil
+	}
+	initialTxId := info.InitialTransactionID.String()
+	if info.Total < 1 || info.Number < 1 || info.Number > info.Total {
+		return nil, fmt.Errorf("%w: topic message #%d is chunk %d of %d of %s",
+			ErrInvalidChunk, topicMessage.SequenceNumber, info.Number, info.Total, initialTxId)
+	}
+
+	message, ok := a.pending[initialTxId]
+	if completed, found := a.complete[initialTxId]; found {
+		message = completed
+	} else if !ok {
+		message = &Message{
+			InitialTransactionID: initialTxId,
+			Total:                info.Total,
+			Chunks:               make([]*mirror.TopicMessage, info.Total),
+		}
+	}
+	if info.Total != message.Total {
+		return nil, fmt.Errorf("%w: topic message #%d is chunk %d of %d of %s, whose other chunks have a total of %d",
+			ErrInvalidChunk, topicMessage.SequenceNumber, info.Number, info.Total, initialTxId, message.Total)
+	}
+	if existing := message.Chunks[info.Number-1]; existing != nil {
+		// The same message read twice, e.g. when pages overlap, is not a conflict
+		if existing.SequenceNumber == topicMessage.SequenceNumber {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: topic messages #%d and #%d are both chunk %d of %s",
+			ErrInvalidChunk, existing.SequenceNumber, topicMessage.SequenceNumber, info.Number, initialTxId)
+	}
+	message.Chunks[info.Number-1] = &topicMessage
+
+	if message.Complete() {
+		if message.Total > 1 {
+			a.complete[initialTxId] = message
+		}
+		if ok {
+			delete(a.pending, initialTxId)
+			a.order = slices.DeleteFunc(a.order, func(id string) bool { return id == initialTxId })
+		}
+		return message, nil
+	}
+	if !ok {
+		a.pending[initialTxId] = message
+		a.order = append(a.order, initialTxId)
+	}
+	return nil, nil
+}
+
+// forget drops the completed messages whose chunks are more than completedWindow sequence numbers
+// away from sequenceNumber, in either direction, as topics may be read in either order,
+// so that a long running reader does not hold on to every message it has completed
+func (a *Assembler) forget(sequenceNumber int64) {
+	for initialTxId, message := range a.complete {
+		sequenceNumbers := message.SequenceNumbers()
+		if sequenceNumber > slices.Max(sequenceNumbers)+completedWindow || sequenceNumber < slices.Min(sequenceNumbers)-completedWindow {
+			delete(a.complete, initialTxId)
+		}
+	}
+}
+
+// Pending returns the messages that are still missing chunks, in the order they were first seen
+func (a *Assembler) Pending() []*Message {
+	pending := make([]*Message, len(a.order))
+	for i, initialTxId := range a.order {
+		pending[i] = a.pending[initialTxId]
+	}
+	return pending
+}
+
+// Incomplete returns an ErrIncomplete error that lists the missing chunks of every pending message,
+// or nil when every message is complete
+func (a *Assembler) Incomplete() error {
+	var incomplete []string
+	for _, message := range a.Pending() {
+		incomplete = append(incomplete, fmt.Sprintf("%s is missing chunks %v of %d", message.InitialTransactionID, message.Missing(), message.Total))
+	}
+	if len(incomplete) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrIncomplete, strings.Join(incomplete, "; "))
+}
@@ -0,0 +1,97 @@
+package chunks
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"lib/mirror"
+)
+
+// chunk returns chunk number of total of the message first submitted at validStart, at sequenceNumber
+func chunk(sequenceNumber int64, validStart int, number int, total int) mirror.TopicMessage {
+	return mirror.TopicMessage{
+		SequenceNumber: sequenceNumber,
+		Message:        base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("[%d/%d]", number, total))),
+		ChunkInfo: &mirror.ChunkInfo{
+			InitialTransactionID: mirror.TransactionIDInfo{AccountID: "0.0.2", TransactionValidStart: fmt.Sprintf("1712345678.%09d", validStart)},
+			Number:               number,
+			Total:                total,
+		},
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	a := NewAssembler()
+	// Two messages, interleaved, and a message without chunk_info in between
+	steps := []struct {
+		message mirror.TopicMessage
+		want    string
+	}{
+		{chunk(1, 1, 1, 2), ""},
+		{chunk(2, 2, 1, 3), ""},
+		{mirror.TopicMessage{SequenceNumber: 3, Message: base64.StdEncoding.EncodeToString([]byte("plain"))}, "plain"},
+		{chunk(4, 1, 2, 2), "[1/2][2/2]"},
+		{chunk(5, 2, 2, 3), ""},
+		{chunk(6, 2, 3, 3), "[1/3][2/3][3/3]"},
+		// Read again where pages overlap
+		{chunk(6, 2, 3, 3), ""},
+		{chunk(4, 1, 2, 2), ""},
+	}
+	for i, step := range steps {
+		message, err := a.Add(step.message)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		got := ""
+		if message != nil {
+			contents, err := message.Contents()
+			if err != nil {
+				t.Fatalf("step %d: %v", i, err)
+			}
+			got = string(contents)
+		}
+		if got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+	}
+	if err := a.Incomplete(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := a.Add(chunk(7, 3, 3, 2)); !errors.Is(err, ErrInvalidChunk) {
+		t.Errorf("chunk 3 of 2: %v, want ErrInvalidChunk", err)
+	}
+	if _, err := a.Add(chunk(8, 4, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Add(chunk(9, 4, 1, 2)); !errors.Is(err, ErrInvalidChunk) {
+		t.Errorf("chunk 1 at two sequence numbers: %v, want ErrInvalidChunk", err)
+	}
+	if err := a.Incomplete(); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Incomplete = %v, want ErrIncomplete", err)
+	}
+}
+
+func TestAssemblerForgetsCompletedMessages(t *testing.T) {
+	a := NewAssembler()
+	const messages = 1000
+	for i := 0; i < messages; i++ {
+		for number := 1; number <= 2; number++ {
+			message, err := a.Add(chunk(int64(2*i+number), i, number, 2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (message != nil) != (number == 2) {
+				t.Fatalf("message %d chunk %d: completed %v", i, number, message != nil)
+			}
+		}
+	}
+	if len(a.complete) > completedWindow {
+		t.Errorf("the assembler holds %d completed messages, after reading %d", len(a.complete), messages)
+	}
+	if len(a.Pending()) != 0 {
+		t.Errorf("pending %d messages", len(a.Pending()))
+	}
+}
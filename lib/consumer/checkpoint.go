@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is how far a consumer has got through a topic, saved after every message it delivers
+type Checkpoint struct {
+	TopicID string `json:"topicId"`
+	// SequenceNumber is the last sequence number up to which every message has been delivered
+	SequenceNumber uint64 `json:"sequenceNumber"`
+	// ConsensusTimestamp is the consensus timestamp of that message, in seconds.nanoseconds
+	ConsensusTimestamp string `json:"consensusTimestamp,omitempty"`
+	// Delivered holds the sequence numbers of messages after SequenceNumber that have been delivered already,
+	// because the chunks of an earlier message were still missing.
+	// A chunked message has the sequence number of its last chunk.
+	Delivered []uint64 `json:"delivered,omitempty"`
+}
+
+// Store loads and saves the checkpoint of a consumer
+type Store interface {
+	// Load returns the saved checkpoint, or the zero Checkpoint when none has been saved yet
+	Load() (Checkpoint, error)
+	Save(checkpoint Checkpoint) error
+}
+
+// FileStore keeps the checkpoint in a JSON file.
+// The file is replaced by renaming a new file over it,
+// so that a crash leaves either the old or the new checkpoint, and never part of one.
+type FileStore struct {
+	Path string
+}
+
+// Load returns the checkpoint in the file, or the zero Checkpoint when the file does not exist
+func (s FileStore) Load() (Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Checkpoint{}, nil
+	}
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return Checkpoint{}, fmt.Errorf("parsing checkpoint %s: %w", s.Path, err)
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file next to the checkpoint file, and renames it
+func (s FileStore) Save(checkpoint Checkpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("creating checkpoint directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	// Flush to disk before the rename, so that the renamed file is never empty after a power loss
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("replacing checkpoint: %w", err)
+	}
+	return nil
+}
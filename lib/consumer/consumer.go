@@ -0,0 +1,289 @@
+// Package consumer delivers the messages of a topic to a handler in sequence number order,
+// each one once, and saves a checkpoint after each, so that a restarted consumer continues where it left off.
+//
+// Messages may arrive from the REST API, from a gRPC subscription, or both, in any order, and more than once.
+// A message at or before the next expected sequence number is a duplicate, and is dropped.
+// A message after a missing sequence number is held back, while the missing range is fetched from the REST API.
+// Chunked messages are reassembled with lib/chunks, and delivered when their last chunk arrives.
+//
+// The checkpoint is saved after the handler returns, so a crash in between delivers that message again
+// when the consumer restarts. Handlers that must never see a message twice can record the sequence number
+// of the last chunk of each message together with its effects, and skip the ones they have seen.
+package consumer
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/chunks"
+	"lib/mirror"
+	"lib/subscribe"
+)
+
+var (
+	// ErrTopicMismatch is returned when the saved checkpoint is for another topic
+	ErrTopicMismatch = errors.New("checkpoint is for another topic")
+	// ErrMissing is returned when the REST API does not have the messages of a gap
+	ErrMissing = errors.New("topic messages missing on the mirror node")
+)
+
+// Handler handles a complete message, which has a single chunk unless it was split when submitted.
+// An error stops the consumer, without moving the checkpoint past the message.
+type Handler func(ctx context.Context, message *chunks.Message) error
+
+// Stats count what a consumer has seen since it was created
+type Stats struct {
+	Delivered int `json:"delivered"`
+	// Duplicates are messages that were dropped, because they had been delivered already
+	Duplicates int `json:"duplicates"`
+	// Gaps are the ranges of missing sequence numbers that were fetched from the REST API
+	Gaps int `json:"gaps"`
+	// Refetched are the messages fetched from the REST API to fill gaps, or to get the chunks of a message
+	Refetched int `json:"refetched"`
+}
+
+// Options are the optional settings of a consumer
+type Options struct {
+	// OnGap is called with the missing range of sequence numbers, before it is fetched
+	OnGap func(from uint64, to uint64)
+	// Backoff controls how long to wait for the messages of a gap to reach the REST API,
+	// which may lag behind a gRPC subscription
+	Backoff mirror.Backoff
+}
+
+// Consumer delivers the messages of a single topic.
+// It is not safe for concurrent use, and after an error,
+// a new consumer should be created, to continue from the saved checkpoint.
+type Consumer struct {
+	mirror  *mirror.Client
+	topicID string
+	store   Store
+	handle  Handler
+	opts    Options
+
+	checkpoint Checkpoint
+	// next is the sequence number of the next message to pass to the assembler
+	next uint64
+	// ahead holds the messages after next, until the messages before them have arrived
+	ahead map[uint64]mirror.TopicMessage
+	// timestamps holds the consensus timestamps of the messages after the checkpoint,
+	// for when the checkpoint moves forward to one of them
+	timestamps map[uint64]string
+	assembler  *chunks.Assembler
+	stats      Stats
+}
+
+// New loads the checkpoint from the store, and returns a consumer that continues after it
+func New(mirrorClient *mirror.Client, topicID string, store Store, handle Handler, opts Options) (*Consumer, error) {
+	checkpoint, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint.TopicID == "" {
+		checkpoint.TopicID = topicID
+	} else if checkpoint.TopicID != topicID {
+		return nil, fmt.Errorf("%w: %s, not %s", ErrTopicMismatch, checkpoint.TopicID, topicID)
+	}
+	return &Consumer{
+		mirror:     mirrorClient,
+		topicID:    topicID,
+		store:      store,
+		handle:     handle,
+		opts:       opts,
+		checkpoint: checkpoint,
+		next:       checkpoint.SequenceNumber + 1,
+		ahead:      map[uint64]mirror.TopicMessage{},
+		timestamps: map[uint64]string{},
+		assembler:  chunks.NewAssembler(),
+	}, nil
+}
+
+// Checkpoint returns the last saved checkpoint
+func (c *Consumer) Checkpoint() Checkpoint {
+	return c.checkpoint
+}
+
+// Stats returns what the consumer has seen so far
+func (c *Consumer) Stats() Stats {
+	return c.stats
+}
+
+// Poll fetches every message after the checkpoint from the REST API, and delivers them
+func (c *Consumer) Poll(ctx context.Context) error {
+	opts := mirror.ListOptions{
+		Limit:          100,
+		Order:          mirror.OrderAsc,
+		SequenceNumber: []mirror.Condition{mirror.Gte(c.next)},
+		Extra:          url.Values{"encoding": {"base64"}},
+	}
+	return c.mirror.EachTopicMessage(ctx, c.topicID, opts, func(message mirror.TopicMessage) error {
+		return c.Add(ctx, message)
+	})
+}
+
+// Run subscribes to the topic with the gRPC API, from after the checkpoint,
+// and delivers every message until ctx is cancelled or an error occurs.
+// The gRPC API returns chunked messages already reassembled, so their chunks are fetched from the REST API.
+func (c *Consumer) Run(ctx context.Context, client *hedera.Client, opts subscribe.Options) error {
+	topicID, err := hedera.TopicIDFromString(c.topicID)
+	if err != nil {
+		return err
+	}
+	opts.AfterSequenceNumber = c.next - 1
+	if c.checkpoint.ConsensusTimestamp != "" {
+		checkpointTime, err := mirror.ParseTimestamp(c.checkpoint.ConsensusTimestamp)
+		if err != nil {
+			return fmt.Errorf("parsing checkpoint: %w", err)
+		}
+		opts.StartTime = checkpointTime.Add(time.Nanosecond)
+	}
+	_, err = subscribe.Run(ctx, client, topicID, opts, func(message hedera.TopicMessage) error {
+		if len(message.Chunks) > 0 {
+			// Messages missing before the first chunk are a gap, the other messages between the chunks are not
+			first := message.Chunks[0].SequenceNumber
+			for _, chunk := range message.Chunks {
+				first = min(first, chunk.SequenceNumber)
+			}
+			if first > c.next {
+				if err := c.fetch(ctx, first-1, true); err != nil {
+					return err
+				}
+			}
+			return c.fetch(ctx, message.SequenceNumber, false)
+		}
+		return c.Add(ctx, mirror.TopicMessage{
+			ConsensusTimestamp: fmt.Sprintf("%d.%09d", message.ConsensusTimestamp.Unix(), message.ConsensusTimestamp.Nanosecond()),
+			Message:            base64.StdEncoding.EncodeToString(message.Contents),
+			RunningHash:        base64.StdEncoding.EncodeToString(message.RunningHash),
+			SequenceNumber:     int64(message.SequenceNumber),
+			TopicID:            c.topicID,
+		})
+	})
+	return err
+}
+
+// Add passes a message to the consumer, which delivers it, and any held back messages after it,
+// once every message before it has been delivered
+func (c *Consumer) Add(ctx context.Context, message mirror.TopicMessage) error {
+	sequenceNumber := uint64(message.SequenceNumber)
+	if _, held := c.ahead[sequenceNumber]; held || sequenceNumber < c.next {
+		c.stats.Duplicates++
+		return nil
+	}
+	if sequenceNumber > c.next {
+		c.ahead[sequenceNumber] = message
+		return c.fetch(ctx, sequenceNumber-1, true)
+	}
+	if err := c.process(ctx, message); err != nil {
+		return err
+	}
+	return c.drain(ctx)
+}
+
+// drain processes the held back messages that are next in line
+func (c *Consumer) drain(ctx context.Context) error {
+	for {
+		message, ok := c.ahead[c.next]
+		if !ok {
+			return nil
+		}
+		if err := c.process(ctx, message); err != nil {
+			return err
+		}
+	}
+}
+
+// fetch processes the messages from next up to and including to, fetched from the REST API
+func (c *Consumer) fetch(ctx context.Context, to uint64, gap bool) error {
+	if to < c.next {
+		return c.drain(ctx)
+	}
+	from := c.next
+	if gap {
+		c.stats.Gaps++
+		if c.opts.OnGap != nil {
+			c.opts.OnGap(from, to)
+		}
+	}
+	if _, err := c.mirror.WaitForTopicMessage(ctx, c.opts.Backoff, c.topicID, to); err != nil {
+		return fmt.Errorf("waiting for topic message #%d: %w", to, err)
+	}
+	opts := mirror.ListOptions{
+		Limit:          100,
+		Order:          mirror.OrderAsc,
+		SequenceNumber: []mirror.Condition{mirror.Gte(from), mirror.Lte(to)},
+		Extra:          url.Values{"encoding": {"base64"}},
+	}
+	err := c.mirror.EachTopicMessage(ctx, c.topicID, opts, func(message mirror.TopicMessage) error {
+		if uint64(message.SequenceNumber) != c.next {
+			return nil
+		}
+		c.stats.Refetched++
+		return c.process(ctx, message)
+	})
+	if err != nil {
+		return fmt.Errorf("fetching topic messages #%d to #%d: %w", from, to, err)
+	}
+	if c.next <= to {
+		return fmt.Errorf("%w: #%d to #%d", ErrMissing, c.next, to)
+	}
+	return c.drain(ctx)
+}
+
+// process passes the next message to the assembler, delivers the message it completes,
+// and moves the checkpoint forward
+func (c *Consumer) process(ctx context.Context, message mirror.TopicMessage) error {
+	sequenceNumber := uint64(message.SequenceNumber)
+	complete, err := c.assembler.Add(message)
+	if err != nil {
+		return err
+	}
+	c.next = sequenceNumber + 1
+	delete(c.ahead, sequenceNumber)
+	c.timestamps[sequenceNumber] = message.ConsensusTimestamp
+
+	changed := false
+	if complete != nil {
+		last := uint64(complete.Last().SequenceNumber)
+		if slices.Contains(c.checkpoint.Delivered, last) {
+			// Delivered before a restart, after the checkpoint, while an earlier message was incomplete
+			c.stats.Duplicates++
+		} else {
+			if err := c.handle(ctx, complete); err != nil {
+				return fmt.Errorf("handling topic message #%d: %w", last, err)
+			}
+			c.stats.Delivered++
+			c.checkpoint.Delivered = append(c.checkpoint.Delivered, last)
+			changed = true
+		}
+	}
+
+	// The checkpoint stays before the first chunk of any message that is still missing chunks,
+	// so that a restarted consumer reads those chunks again
+	position := sequenceNumber
+	for _, pending := range c.assembler.Pending() {
+		position = min(position, uint64(slices.Min(pending.SequenceNumbers()))-1)
+	}
+	if position > c.checkpoint.SequenceNumber {
+		c.checkpoint.SequenceNumber = position
+		c.checkpoint.ConsensusTimestamp = c.timestamps[position]
+		for seq := range c.timestamps {
+			if seq <= position {
+				delete(c.timestamps, seq)
+			}
+		}
+		c.checkpoint.Delivered = slices.DeleteFunc(c.checkpoint.Delivered, func(seq uint64) bool { return seq <= position })
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return c.store.Save(c.checkpoint)
+}
@@ -0,0 +1,206 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/chunks"
+	"lib/fakemirror"
+	"lib/mirror"
+	"lib/subscribe"
+)
+
+// receiveTimeout is how long to wait for a consumer to deliver the messages of a test
+const receiveTimeout = 10 * time.Second
+
+var topicID = hedera.TopicID{Topic: 1002}
+
+// startServer starts a fake mirror node with 4 messages, where the third one is chunks #3 to #5,
+// and returns it with a REST API client
+func startServer(t *testing.T) (*fakemirror.Server, *mirror.Client) {
+	t.Helper()
+	server, err := fakemirror.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	for _, message := range []string{"Hello consumer #1", "Hello consumer #2", strings.Repeat("Hello consumer in chunks! ", 100), "Hello consumer #3"} {
+		server.Publish(topicID, []byte(message))
+	}
+	return server, mirror.NewClient(server.RESTURL())
+}
+
+// newConsumer returns a consumer that records the sequence numbers of the messages it delivers
+func newConsumer(t *testing.T, mirrorClient *mirror.Client, store Store, opts Options) (*Consumer, *[]uint64) {
+	t.Helper()
+	delivered := &[]uint64{}
+	handle := func(ctx context.Context, message *chunks.Message) error {
+		*delivered = append(*delivered, uint64(message.Last().SequenceNumber))
+		return nil
+	}
+	c, err := New(mirrorClient, topicID.String(), store, handle, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, delivered
+}
+
+func newStore(t *testing.T) FileStore {
+	return FileStore{Path: filepath.Join(t.TempDir(), "checkpoints", topicID.String()+".json")}
+}
+
+func checkDelivered(t *testing.T, got []uint64, want []uint64) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func checkCheckpoint(t *testing.T, store FileStore, want uint64) {
+	t.Helper()
+	checkpoint, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.TopicID != topicID.String() || checkpoint.SequenceNumber != want || checkpoint.ConsensusTimestamp == "" {
+		t.Errorf("saved checkpoint %+v, want #%d of %s", checkpoint, want, topicID)
+	}
+}
+
+func TestRunFillsGaps(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	server.HideFromStream(topicID, 2)
+	client := hedera.ClientForNetwork(map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}})
+	defer client.Close()
+	client.SetMirrorNetwork([]string{server.GRPCAddress()})
+
+	store := newStore(t)
+	var gaps [][2]uint64
+	c, delivered := newConsumer(t, mirrorClient, store, Options{
+		OnGap: func(from uint64, to uint64) { gaps = append(gaps, [2]uint64{from, to}) },
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), receiveTimeout)
+	defer cancel()
+	// The subscription never ends, so it is stopped once the last message is delivered
+	c.handle = func(ctx context.Context, message *chunks.Message) error {
+		*delivered = append(*delivered, uint64(message.Last().SequenceNumber))
+		if message.Last().SequenceNumber == 6 {
+			cancel()
+		}
+		return nil
+	}
+	if err := c.Run(ctx, client, subscribe.Options{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+
+	checkDelivered(t, *delivered, []uint64{1, 2, 5, 6})
+	if want := [][2]uint64{{2, 2}}; !slices.Equal(gaps, want) {
+		t.Errorf("gaps %v, want %v", gaps, want)
+	}
+	if stats := c.Stats(); stats.Gaps != 1 || stats.Delivered != 4 {
+		t.Errorf("Stats = %+v, want 1 gap and 4 delivered", stats)
+	}
+	checkCheckpoint(t, store, 6)
+}
+
+func TestAddOutOfOrder(t *testing.T) {
+	_, mirrorClient := startServer(t)
+	last, err := mirrorClient.GetTopicMessage(context.Background(), topicID.String(), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The messages before #6 are fetched from the REST API, and delivered before it
+	c, delivered := newConsumer(t, mirrorClient, newStore(t), Options{})
+	if err := c.Add(context.Background(), *last); err != nil {
+		t.Fatal(err)
+	}
+	checkDelivered(t, *delivered, []uint64{1, 2, 5, 6})
+	if stats := c.Stats(); stats.Gaps != 1 || stats.Refetched != 5 {
+		t.Errorf("Stats = %+v, want 1 gap and 5 refetched", stats)
+	}
+}
+
+func TestAddDropsDuplicates(t *testing.T) {
+	_, mirrorClient := startServer(t)
+	c, delivered := newConsumer(t, mirrorClient, newStore(t), Options{})
+	if err := c.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkDelivered(t, *delivered, []uint64{1, 2, 5, 6})
+
+	// Every message, and every chunk, arrives again, as from a second source
+	for seq := uint64(1); seq <= 6; seq++ {
+		message, err := mirrorClient.GetTopicMessage(context.Background(), topicID.String(), seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Add(context.Background(), *message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkDelivered(t, *delivered, []uint64{1, 2, 5, 6})
+	if stats := c.Stats(); stats.Duplicates != 6 || stats.Delivered != 4 {
+		t.Errorf("Stats = %+v, want 6 duplicates and 4 delivered", stats)
+	}
+}
+
+func TestRestartFromCheckpoint(t *testing.T) {
+	server, mirrorClient := startServer(t)
+	store := newStore(t)
+	first, delivered := newConsumer(t, mirrorClient, store, Options{})
+	if err := first.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkDelivered(t, *delivered, []uint64{1, 2, 5, 6})
+	checkCheckpoint(t, store, 6)
+
+	server.Publish(topicID, []byte("Hello consumer #4"))
+	server.Publish(topicID, []byte("Hello consumer #5"))
+	second, delivered := newConsumer(t, mirrorClient, store, Options{})
+	if got := second.Checkpoint().SequenceNumber; got != 6 {
+		t.Errorf("restarted from #%d, want #6", got)
+	}
+	if err := second.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkDelivered(t, *delivered, []uint64{7, 8})
+	checkCheckpoint(t, store, 8)
+
+	if _, err := New(mirrorClient, "0.0.9999", store, nil, Options{}); !errors.Is(err, ErrTopicMismatch) {
+		t.Errorf("New for another topic = %v, want ErrTopicMismatch", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := newStore(t)
+	checkpoint, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.TopicID != "" || checkpoint.SequenceNumber != 0 {
+		t.Errorf("Load without a file = %+v, want the zero Checkpoint", checkpoint)
+	}
+	want := Checkpoint{TopicID: topicID.String(), SequenceNumber: 3, ConsensusTimestamp: "1712345678.000000001", Delivered: []uint64{6}}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TopicID != want.TopicID || got.SequenceNumber != want.SequenceNumber ||
+		got.ConsensusTimestamp != want.ConsensusTimestamp || !slices.Equal(got.Delivered, want.Delivered) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
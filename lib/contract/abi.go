@@ -0,0 +1,181 @@
+// Package contract encodes smart contract calls and decodes their results
+// using the contract's ABI, as output by `solcjs --abi`.
+//
+// The SDK's ContractFunctionParameters is positional and untyped,
+// whereas here functions are looked up by name, and arguments and results
+// are native Go values, e.g. `string`, `*big.Int`, `common.Address`.
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+)
+
+// ErrUnknownEvent is returned when decoding a log that matches no event in the ABI
+var ErrUnknownEvent = errors.New("unknown event")
+
+// ABI is the parsed interface of a single contract
+type ABI struct {
+	abi abi.ABI
+}
+
+// Load reads an ABI JSON file, e.g. `my_contract_sol_MyContract.abi`
+func Load(path string) (*ABI, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	contractABI, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return contractABI, nil
+}
+
+// Parse reads ABI JSON
+func Parse(r io.Reader) (*ABI, error) {
+	parsed, err := abi.JSON(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ABI: %w", err)
+	}
+	return &ABI{abi: parsed}, nil
+}
+
+// Raw returns the underlying go-ethereum ABI
+func (a *ABI) Raw() abi.ABI {
+	return a.abi
+}
+
+// EncodeConstructor returns the constructor arguments,
+// for ContractCreateTransaction.SetConstructorParametersRaw
+func (a *ABI) EncodeConstructor(args ...any) ([]byte, error) {
+	params, err := a.abi.Pack("", args...)
+	if err != nil {
+		return nil, fmt.Errorf("encoding constructor arguments: %w", err)
+	}
+	return params, nil
+}
+
+// EncodeCall returns the function selector followed by the arguments,
+// for ContractExecuteTransaction.SetFunctionParameters
+// and ContractCallQuery.SetFunctionParameters
+func (a *ABI) EncodeCall(function string, args ...any) ([]byte, error) {
+	params, err := a.abi.Pack(function, args...)
+	if err != nil {
+		return nil, fmt.Errorf("encoding call to %s: %w", function, err)
+	}
+	return params, nil
+}
+
+// DecodeResult returns the values returned by a function, in order
+func (a *ABI) DecodeResult(function string, data []byte) ([]any, error) {
+	values, err := a.abi.Unpack(function, data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding result of %s: %w", function, err)
+	}
+	return values, nil
+}
+
+// DecodeResultInto copies the values returned by a function into out,
+// which is a pointer to either a single value, or a struct with one field per value
+func (a *ABI) DecodeResultInto(out any, function string, data []byte) error {
+	values, err := a.DecodeResult(function, data)
+	if err != nil {
+		return err
+	}
+	if err := a.abi.Methods[function].Outputs.Copy(out, values); err != nil {
+		return fmt.Errorf("decoding result of %s: %w", function, err)
+	}
+	return nil
+}
+
+// DecodeSDKResult decodes the result of a ContractCallQuery or ContractExecuteTransaction record
+func (a *ABI) DecodeSDKResult(function string, result hedera.ContractFunctionResult) ([]any, error) {
+	return a.DecodeResult(function, result.ContractCallResult)
+}
+
+// DecodeMirrorResult decodes the `call_result` of a mirror node contract result
+func (a *ABI) DecodeMirrorResult(function string, result mirror.ContractResult) ([]any, error) {
+	data, err := DecodeHex(result.CallResult)
+	if err != nil {
+		return nil, fmt.Errorf("decoding result of %s: %w", function, err)
+	}
+	return a.DecodeResult(function, data)
+}
+
+// Event is a decoded contract log
+type Event struct {
+	Name string
+	// Args holds both the indexed and non-indexed arguments, by name
+	Args map[string]any
+}
+
+// DecodeLog decodes a log, identifying the event by its first topic
+func (a *ABI) DecodeLog(topics [][]byte, data []byte) (*Event, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("%w: anonymous events are not supported", ErrUnknownEvent)
+	}
+	event, err := a.abi.EventByID(common.BytesToHash(topics[0]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: topic 0x%x", ErrUnknownEvent, topics[0])
+	}
+
+	args := map[string]any{}
+	if len(data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(args, data); err != nil {
+			return nil, fmt.Errorf("decoding %s data: %w", event.Name, err)
+		}
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	topicHashes := make([]common.Hash, 0, len(topics)-1)
+	for _, topic := range topics[1:] {
+		topicHashes = append(topicHashes, common.BytesToHash(topic))
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, topicHashes); err != nil {
+		return nil, fmt.Errorf("decoding %s topics: %w", event.Name, err)
+	}
+	return &Event{Name: event.Name, Args: args}, nil
+}
+
+// DecodeSDKLog decodes a log from a ContractFunctionResult
+func (a *ABI) DecodeSDKLog(log hedera.ContractLogInfo) (*Event, error) {
+	return a.DecodeLog(log.Topics, log.Data)
+}
+
+// DecodeMirrorLog decodes a log from a mirror node contract result
+func (a *ABI) DecodeMirrorLog(log mirror.ContractLog) (*Event, error) {
+	topics := make([][]byte, 0, len(log.Topics))
+	for _, topicHex := range log.Topics {
+		topic, err := DecodeHex(topicHex)
+		if err != nil {
+			return nil, fmt.Errorf("decoding log topic: %w", err)
+		}
+		topics = append(topics, topic)
+	}
+	data, err := DecodeHex(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding log data: %w", err)
+	}
+	return a.DecodeLog(topics, data)
+}
+
+// DecodeHex decodes hex as returned by the mirror node, with or without the `0x` prefix
+func DecodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
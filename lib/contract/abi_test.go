@@ -0,0 +1,203 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+)
+
+// myContractABI is hscs/my_contract.sol compiled with `solcjs --abi`
+const myContractABI = `[
+  {"inputs":[{"internalType":"string","name":"_greeting","type":"string"}],"stateMutability":"nonpayable","type":"constructor"},
+  {"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"who","type":"address"},{"indexed":false,"internalType":"string","name":"name","type":"string"}],"name":"Introduced","type":"event"},
+  {"inputs":[],"name":"greet","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},
+  {"inputs":[],"name":"greeting","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},
+  {"inputs":[{"internalType":"string","name":"name","type":"string"}],"name":"introduce","outputs":[],"stateMutability":"nonpayable","type":"function"},
+  {"inputs":[{"internalType":"address","name":"","type":"address"}],"name":"names","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+// Selectors and event topics are the first 4 bytes, and all 32 bytes, of the keccak256 hash of the signature
+const (
+	greetSelector     = "cfae3217"
+	introduceSelector = "c63193f6"
+	namesSelector     = "5cf3d346"
+	introducedTopic   = "615024d0b02ddc6e411313333571356b4cd947ec00e02d189c186dcb32eacf27"
+)
+
+// operatorAddress is the long zero address of 0.0.1001, as an ABI word
+const operatorAddress = "00000000000000000000000000000000000000000000000000000000000003e9"
+
+func newABI(t *testing.T) *ABI {
+	t.Helper()
+	contractABI, err := Parse(strings.NewReader(myContractABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contractABI
+}
+
+// encodedString is the ABI encoding of a single string of up to 32 bytes, as returned by greet:
+// the offset of the string, its length, and its bytes padded to a word
+func encodedString(s string) string {
+	word := func(n int) string { return strings.Repeat("0", 62) + hex.EncodeToString([]byte{byte(n)}) }
+	return word(0x20) + word(len(s)) + hex.EncodeToString([]byte(s)) + strings.Repeat("00", 32-len(s))
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := DecodeHex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEncodeCall(t *testing.T) {
+	contractABI := newABI(t)
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     string
+	}{
+		{"without arguments", "greet", nil, greetSelector},
+		{"string", "introduce", []string{"bguiz"}, introduceSelector + encodedString("bguiz")},
+		{"entity ID address", "names", []string{"0.0.1001"}, namesSelector + operatorAddress},
+		{"hex address", "names", []string{"0x00000000000000000000000000000000000003E9"}, namesSelector + operatorAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := contractABI.ParseArgs(tt.function, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			params, err := contractABI.EncodeCall(tt.function, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(params); got != tt.want {
+				t.Errorf("EncodeCall(%s, %q) =\n%s\nwant\n%s", tt.function, tt.args, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := contractABI.EncodeCall("farewell"); err == nil {
+		t.Error("EncodeCall of an unknown function = nil, want an error")
+	}
+	if _, err := contractABI.EncodeCall("introduce", 42); err == nil {
+		t.Error("EncodeCall with an argument of another type = nil, want an error")
+	}
+}
+
+func TestEncodeConstructor(t *testing.T) {
+	contractABI := newABI(t)
+	args, err := contractABI.ParseConstructorArgs([]string{"Hello future! - "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := contractABI.EncodeConstructor(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Constructor arguments have no selector
+	if got, want := hex.EncodeToString(params), encodedString("Hello future! - "); got != want {
+		t.Errorf("EncodeConstructor =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDecodeResult(t *testing.T) {
+	contractABI := newABI(t)
+	result := encodedString("Hello future! - bguiz")
+	tests := []struct {
+		name   string
+		decode func() ([]any, error)
+	}{
+		{"bytes", func() ([]any, error) { return contractABI.DecodeResult("greet", mustDecodeHex(t, result)) }},
+		{"SDK", func() ([]any, error) {
+			return contractABI.DecodeSDKResult("greet", hedera.ContractFunctionResult{ContractCallResult: mustDecodeHex(t, result)})
+		}},
+		{"mirror", func() ([]any, error) {
+			return contractABI.DecodeMirrorResult("greet", mirror.ContractResult{CallResult: "0x" + result})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := tt.decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(values) != 1 || values[0] != "Hello future! - bguiz" {
+				t.Errorf("DecodeResult = %#v, want [Hello future! - bguiz]", values)
+			}
+		})
+	}
+
+	var greeting string
+	if err := contractABI.DecodeResultInto(&greeting, "greet", mustDecodeHex(t, result)); err != nil || greeting != "Hello future! - bguiz" {
+		t.Errorf("DecodeResultInto = %q, %v, want Hello future! - bguiz", greeting, err)
+	}
+	if _, err := contractABI.DecodeResult("greet", mustDecodeHex(t, result[:64])); err == nil {
+		t.Error("DecodeResult of a cut short result = nil, want an error")
+	}
+	if _, err := contractABI.DecodeMirrorResult("greet", mirror.ContractResult{CallResult: "0xzz"}); err == nil {
+		t.Error("DecodeMirrorResult of invalid hex = nil, want an error")
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	contractABI := newABI(t)
+	data := encodedString("bguiz")
+	tests := []struct {
+		name   string
+		decode func() (*Event, error)
+	}{
+		{"bytes", func() (*Event, error) {
+			return contractABI.DecodeLog([][]byte{mustDecodeHex(t, introducedTopic), mustDecodeHex(t, operatorAddress)}, mustDecodeHex(t, data))
+		}},
+		{"SDK", func() (*Event, error) {
+			return contractABI.DecodeSDKLog(hedera.ContractLogInfo{
+				Topics: [][]byte{mustDecodeHex(t, introducedTopic), mustDecodeHex(t, operatorAddress)},
+				Data:   mustDecodeHex(t, data),
+			})
+		}},
+		{"mirror", func() (*Event, error) {
+			return contractABI.DecodeMirrorLog(mirror.ContractLog{Topics: []string{"0x" + introducedTopic, "0x" + operatorAddress}, Data: "0x" + data})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := tt.decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Name != "Introduced" || event.Args["who"] != common.HexToAddress("0x3e9") || event.Args["name"] != "bguiz" {
+				t.Errorf("DecodeLog = %+v, want Introduced by 0.0.1001 as bguiz", event)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name   string
+		topics [][]byte
+		want   error
+	}{
+		{"anonymous", nil, ErrUnknownEvent},
+		{"unknown event", [][]byte{mustDecodeHex(t, operatorAddress)}, ErrUnknownEvent},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if event, err := contractABI.DecodeLog(tt.topics, mustDecodeHex(t, data)); !errors.Is(err, tt.want) {
+				t.Errorf("DecodeLog = %+v, %v, want %v", event, err, tt.want)
+			}
+		})
+	}
+	if _, err := contractABI.DecodeMirrorLog(mirror.ContractLog{Topics: []string{"0x" + introducedTopic}, Data: "0xzz"}); err == nil {
+		t.Error("DecodeMirrorLog of invalid hex = nil, want an error")
+	}
+}
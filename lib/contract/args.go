@@ -0,0 +1,121 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// ErrUnsupportedType is returned when parsing arguments of types that
+// cannot be given as a single string, e.g. arrays and tuples
+var ErrUnsupportedType = errors.New("unsupported argument type")
+
+// ParseConstructorArgs converts strings, e.g. from the command line,
+// into the Go values expected by EncodeConstructor
+func (a *ABI) ParseConstructorArgs(args []string) ([]any, error) {
+	return parseArgs("constructor", a.abi.Constructor.Inputs, args)
+}
+
+// ParseArgs converts strings, e.g. from the command line,
+// into the Go values expected by EncodeCall for the given function
+func (a *ABI) ParseArgs(function string, args []string) ([]any, error) {
+	method, ok := a.abi.Methods[function]
+	if !ok {
+		return nil, fmt.Errorf("function %q not found in ABI", function)
+	}
+	return parseArgs(function, method.Inputs, args)
+}
+
+func parseArgs(name string, inputs abi.Arguments, args []string) ([]any, error) {
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("%s: expected %d arguments, got %d", name, len(inputs), len(args))
+	}
+	values := make([]any, len(args))
+	for i, input := range inputs {
+		value, err := ParseValue(input.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d (%s %s): %w", name, i, input.Type.String(), input.Name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ParseValue converts a string into the Go value for a single ABI type.
+// Addresses are given either in hex, or as a Hedera entity ID (`0.0.x`),
+// integers in decimal or `0x` prefixed hex, and bytes in hex.
+func ParseValue(typ abi.Type, s string) (any, error) {
+	switch typ.T {
+	case abi.StringTy:
+		return s, nil
+	case abi.BoolTy:
+		return strconv.ParseBool(s)
+	case abi.AddressTy:
+		return ParseAddress(s)
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		if typ.T == abi.UintTy && n.Sign() < 0 {
+			return nil, fmt.Errorf("negative value %q for unsigned integer", s)
+		}
+		goType := typ.GetType()
+		if goType == reflect.TypeOf((*big.Int)(nil)) {
+			return n, nil
+		}
+		// Integers of up to 64 bits are packed from the exactly sized Go type
+		value := reflect.New(goType).Elem()
+		if typ.T == abi.UintTy {
+			if !n.IsUint64() || value.OverflowUint(n.Uint64()) {
+				return nil, fmt.Errorf("value %q overflows %s", s, typ.String())
+			}
+			value.SetUint(n.Uint64())
+		} else {
+			if !n.IsInt64() || value.OverflowInt(n.Int64()) {
+				return nil, fmt.Errorf("value %q overflows %s", s, typ.String())
+			}
+			value.SetInt(n.Int64())
+		}
+		return value.Interface(), nil
+	case abi.BytesTy:
+		return DecodeHex(s)
+	case abi.FixedBytesTy:
+		b, err := DecodeHex(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > typ.Size {
+			return nil, fmt.Errorf("%d bytes do not fit in %s", len(b), typ.String())
+		}
+		// Fixed size byte arrays are right padded, as in Solidity
+		value := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(b))
+		return value.Interface(), nil
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedType, typ.String())
+	}
+}
+
+// ParseAddress accepts either an EVM address in hex,
+// or a Hedera entity ID, `0.0.x`, which is converted to its long zero address
+func ParseAddress(s string) (common.Address, error) {
+	if strings.Count(s, ".") == 2 {
+		accountID, err := hedera.AccountIDFromString(s)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return common.HexToAddress(accountID.ToSolidityAddress()), nil
+	}
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return common.HexToAddress(s), nil
+}
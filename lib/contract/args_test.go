@@ -0,0 +1,126 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		typ     string
+		s       string
+		want    any
+		wantErr bool
+	}{
+		{typ: "string", s: "bguiz", want: "bguiz"},
+		{typ: "bool", s: "true", want: true},
+		{typ: "bool", s: "yes", wantErr: true},
+		{typ: "address", s: "0.0.1001", want: common.HexToAddress("0x3e9")},
+		{typ: "address", s: "0x00000000000000000000000000000000000003e9", want: common.HexToAddress("0x3e9")},
+		{typ: "address", s: "0x3e9", wantErr: true},
+		{typ: "address", s: "0.0.x", wantErr: true},
+		// Integers of up to 64 bits are exactly sized, larger ones are big integers
+		{typ: "uint8", s: "255", want: uint8(255)},
+		{typ: "uint8", s: "256", wantErr: true},
+		{typ: "uint8", s: "-1", wantErr: true},
+		{typ: "uint64", s: "0x10", want: uint64(16)},
+		{typ: "int8", s: "-128", want: int8(-128)},
+		{typ: "int8", s: "128", wantErr: true},
+		{typ: "int32", s: "ten", wantErr: true},
+		{typ: "uint256", s: "115792089237316195423570985008687907853269984665640564039457584007913129639935", want: bigInt("115792089237316195423570985008687907853269984665640564039457584007913129639935")},
+		{typ: "int256", s: "-1", want: big.NewInt(-1)},
+		{typ: "uint256", s: "-1", wantErr: true},
+		{typ: "bytes", s: "0xdead", want: []byte{0xde, 0xad}},
+		{typ: "bytes", s: "dead", want: []byte{0xde, 0xad}},
+		{typ: "bytes", s: "0xdea", wantErr: true},
+		// Fixed size byte arrays are right padded
+		{typ: "bytes4", s: "0xdead", want: [4]byte{0xde, 0xad, 0, 0}},
+		{typ: "bytes2", s: "0xdeadbe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.s, func(t *testing.T) {
+			typ, err := abi.NewType(tt.typ, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseValue(typ, tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseValue = %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Compared with their Go type, and big integers in decimal
+			if gotStr, wantStr := fmt.Sprintf("%T %v", got, got), fmt.Sprintf("%T %v", tt.want, tt.want); gotStr != wantStr {
+				t.Errorf("ParseValue = %s, want %s", gotStr, wantStr)
+			}
+		})
+	}
+
+	arrayType, err := abi.NewType("uint256[]", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseValue(arrayType, "1,2"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("ParseValue(uint256[]) = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	contractABI := newABI(t)
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     string
+	}{
+		{"string", "introduce", []string{"bguiz"}, "[bguiz]"},
+		{"address", "names", []string{"0.0.1001"}, "[0x00000000000000000000000000000000000003e9]"},
+		{"none", "greet", nil, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := contractABI.ParseArgs(tt.function, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(values); got != tt.want {
+				t.Errorf("ParseArgs = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name     string
+		function string
+		args     []string
+	}{
+		{"unknown function", "farewell", nil},
+		{"too many arguments", "greet", []string{"bguiz"}},
+		{"too few arguments", "introduce", nil},
+		{"invalid address", "names", []string{"bguiz"}},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if values, err := contractABI.ParseArgs(tt.function, tt.args); err == nil {
+				t.Errorf("ParseArgs = %v, want an error", values)
+			}
+		})
+	}
+	if values, err := contractABI.ParseConstructorArgs(nil); err == nil {
+		t.Errorf("ParseConstructorArgs without the greeting = %v, want an error", values)
+	}
+}
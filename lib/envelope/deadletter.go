@@ -0,0 +1,56 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DeadLetter is a message that could not be opened, kept aside with the reason,
+// so that reading the rest of the topic can go on
+type DeadLetter struct {
+	SequenceNumber     int64  `json:"sequenceNumber"`
+	ConsensusTimestamp string `json:"consensusTimestamp"`
+	// Message is base64 encoded, as on the mirror node, since it may not be text
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+// DeadLetters appends dead letters to a writer, as lines of JSON
+type DeadLetters struct {
+	writer  io.Writer
+	letters []DeadLetter
+}
+
+// NewDeadLetters returns dead letters that are written to writer, or only kept, when writer is nil
+func NewDeadLetters(writer io.Writer) *DeadLetters {
+	return &DeadLetters{writer: writer, letters: []DeadLetter{}}
+}
+
+// Add keeps a message aside, with the error that opening it returned
+func (d *DeadLetters) Add(sequenceNumber int64, consensusTimestamp string, contents []byte, reason error) error {
+	letter := DeadLetter{
+		SequenceNumber:     sequenceNumber,
+		ConsensusTimestamp: consensusTimestamp,
+		Message:            base64.StdEncoding.EncodeToString(contents),
+		Reason:             reason.Error(),
+	}
+	d.letters = append(d.letters, letter)
+	if d.writer == nil {
+		return nil
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("encoding dead letter #%d: %w", sequenceNumber, err)
+	}
+	if _, err := d.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing dead letter #%d: %w", sequenceNumber, err)
+	}
+	return nil
+}
+
+// Letters returns the dead letters added so far
+func (d *DeadLetters) Letters() []DeadLetter {
+	return d.letters
+}
@@ -0,0 +1,169 @@
+// Package envelope wraps HCS message payloads in an envelope that names their schema,
+// content type, creation time, and sender, encoded as JSON, CBOR, or protobuf.
+//
+// Envelopes are optional: any other message is left as it is, and Unmarshal reports ErrNotEnvelope for it.
+// Each encoding marks its envelopes with an `envelope` field set to Version, so readers can tell them apart:
+//
+//	JSON      {"envelope":"hfw/1","schemaId":"...","contentType":"application/json","timestamp":"...","sender":"0.0.2","payload":{...}}
+//	CBOR      the self-described CBOR tag, followed by a map with the same keys, and the payload as a CBOR value
+//	protobuf  an Envelope message, whose first field is the version, and whose payload is a google.protobuf.Value
+//
+// The payload is encoded the same way as its envelope, as named by the content type.
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Version marks an envelope, and is the value of its `envelope` field
+const Version = "hfw/1"
+
+var (
+	// ErrNotEnvelope is returned for messages that are not envelopes, e.g. plain text
+	ErrNotEnvelope = errors.New("not an envelope")
+	// ErrMalformed is returned for envelopes that cannot be decoded
+	ErrMalformed = errors.New("malformed envelope")
+)
+
+// Encoding is how an envelope and its payload are encoded
+type Encoding string
+
+const (
+	JSON     Encoding = "json"
+	CBOR     Encoding = "cbor"
+	Protobuf Encoding = "protobuf"
+)
+
+// ParseEncoding parses the name of an encoding, e.g. from a flag
+func ParseEncoding(name string) (Encoding, error) {
+	switch encoding := Encoding(name); encoding {
+	case JSON, CBOR, Protobuf:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q, expected json, cbor, or protobuf", name)
+}
+
+// ContentType returns the media type of payloads in this encoding
+func (e Encoding) ContentType() string {
+	switch e {
+	case CBOR:
+		return "application/cbor"
+	case Protobuf:
+		return "application/x-protobuf"
+	}
+	return "application/json"
+}
+
+// checkContentType checks that the payload is encoded the same way as its envelope
+func checkContentType(encoding Encoding, contentType string) error {
+	if contentType != encoding.ContentType() {
+		return fmt.Errorf("%w: content type %q in a %s envelope", ErrMalformed, contentType, encoding)
+	}
+	return nil
+}
+
+// Envelope is the header of a message, and its encoded payload
+type Envelope struct {
+	SchemaID string
+	Encoding Encoding
+	// Timestamp is when the sender created the message, which is before its consensus timestamp
+	Timestamp time.Time
+	// Sender is the account that created the message, as claimed by the sender
+	Sender string
+	// Payload is encoded with Encoding
+	Payload []byte
+}
+
+// ContentType returns the media type of the payload
+func (e Envelope) ContentType() string {
+	return e.Encoding.ContentType()
+}
+
+// selfDescribedCBOR is the tag that starts CBOR envelopes, so that they can be told apart from other messages
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// header holds the fields of JSON and CBOR envelopes, with the payload left encoded
+type header[P any] struct {
+	Envelope    string `json:"envelope" cbor:"envelope"`
+	SchemaID    string `json:"schemaId" cbor:"schemaId"`
+	ContentType string `json:"contentType" cbor:"contentType"`
+	Timestamp   string `json:"timestamp" cbor:"timestamp"`
+	Sender      string `json:"sender" cbor:"sender"`
+	Payload     P      `json:"payload" cbor:"payload"`
+}
+
+// Marshal encodes the envelope with its encoding
+func Marshal(e Envelope) ([]byte, error) {
+	if e.SchemaID == "" {
+		return nil, errors.New("envelope without a schema ID")
+	}
+	switch e.Encoding {
+	case JSON:
+		return json.Marshal(header[json.RawMessage]{
+			Envelope:    Version,
+			SchemaID:    e.SchemaID,
+			ContentType: e.ContentType(),
+			Timestamp:   e.Timestamp.UTC().Format(time.RFC3339Nano),
+			Sender:      e.Sender,
+			Payload:     e.Payload,
+		})
+	case CBOR:
+		data, err := cbor.Marshal(header[cbor.RawMessage]{
+			Envelope:    Version,
+			SchemaID:    e.SchemaID,
+			ContentType: e.ContentType(),
+			Timestamp:   e.Timestamp.UTC().Format(time.RFC3339Nano),
+			Sender:      e.Sender,
+			Payload:     e.Payload,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(bytes.Clone(selfDescribedCBOR), data...), nil
+	case Protobuf:
+		return marshalProtobuf(e), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", e.Encoding)
+}
+
+// Unmarshal decodes an envelope in any encoding, or returns ErrNotEnvelope
+func Unmarshal(data []byte) (Envelope, error) {
+	switch {
+	case bytes.HasPrefix(data, selfDescribedCBOR):
+		var h header[cbor.RawMessage]
+		if err := cbor.Unmarshal(data[len(selfDescribedCBOR):], &h); err != nil || h.Envelope != Version {
+			return Envelope{}, ErrNotEnvelope
+		}
+		return h.envelope(CBOR, []byte(h.Payload))
+	case bytes.HasPrefix(data, protobufPrefix):
+		return unmarshalProtobuf(data)
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		var h header[json.RawMessage]
+		if err := json.Unmarshal(data, &h); err != nil || h.Envelope != Version {
+			return Envelope{}, ErrNotEnvelope
+		}
+		return h.envelope(JSON, []byte(h.Payload))
+	}
+	return Envelope{}, ErrNotEnvelope
+}
+
+// envelope checks the header of an envelope in the given encoding, and returns it as an Envelope
+func (h header[P]) envelope(encoding Encoding, payload []byte) (Envelope, error) {
+	if err := checkContentType(encoding, h.ContentType); err != nil {
+		return Envelope{}, err
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, h.Timestamp)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("%w: timestamp %q: %v", ErrMalformed, h.Timestamp, err)
+	}
+	if h.SchemaID == "" {
+		return Envelope{}, fmt.Errorf("%w: missing schema ID", ErrMalformed)
+	}
+	return Envelope{SchemaID: h.SchemaID, Encoding: encoding, Timestamp: timestamp, Sender: h.Sender, Payload: payload}, nil
+}
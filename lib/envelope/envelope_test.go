@@ -0,0 +1,87 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testEnvelope = Envelope{
+	SchemaID:  "sensor-reading.v1",
+	Timestamp: time.Date(2024, 4, 5, 19, 1, 18, 123456789, time.UTC),
+	Sender:    "0.0.1001",
+}
+
+func TestMarshalAndUnmarshal(t *testing.T) {
+	for _, encoding := range []Encoding{JSON, CBOR, Protobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			payload, err := encodePayload(map[string]any{"sensor": "sensor-01", "value": 20.5}, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := testEnvelope
+			want.Encoding = encoding
+			want.Payload = payload
+			data, err := Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Unmarshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.SchemaID != want.SchemaID || got.Encoding != encoding || !got.Timestamp.Equal(want.Timestamp) ||
+				got.Sender != want.Sender || !bytes.Equal(got.Payload, want.Payload) {
+				t.Errorf("Unmarshal = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestMarshalWithoutSchemaID(t *testing.T) {
+	e := testEnvelope
+	e.SchemaID = ""
+	e.Encoding = JSON
+	if _, err := Marshal(e); err == nil {
+		t.Error("Marshal without a schema ID = nil, want an error")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("Hello HCS"), ErrNotEnvelope},
+		{"empty", nil, ErrNotEnvelope},
+		{"JSON without envelope", []byte(`{"sensor":"sensor-01"}`), ErrNotEnvelope},
+		{"JSON of another version", []byte(`{"envelope":"hfw/2","schemaId":"a","contentType":"application/json","timestamp":"2024-04-05T19:01:18Z","payload":{}}`), ErrNotEnvelope},
+		{"CBOR of another version", append(bytes.Clone(selfDescribedCBOR), 0xa1, 0x68, 'e', 'n', 'v', 'e', 'l', 'o', 'p', 'e', 0x65, 'h', 'f', 'w', '/', '2'), ErrNotEnvelope},
+		{"content type", []byte(`{"envelope":"hfw/1","schemaId":"a","contentType":"application/cbor","timestamp":"2024-04-05T19:01:18Z","payload":{}}`), ErrMalformed},
+		{"timestamp", []byte(`{"envelope":"hfw/1","schemaId":"a","contentType":"application/json","timestamp":"yesterday","payload":{}}`), ErrMalformed},
+		{"schema ID", []byte(`{"envelope":"hfw/1","contentType":"application/json","timestamp":"2024-04-05T19:01:18Z","payload":{}}`), ErrMalformed},
+		{"protobuf cut short", append(bytes.Clone(protobufPrefix), 0x12, 0x10, 'a'), ErrMalformed},
+		{"protobuf without schema ID", append(bytes.Clone(protobufPrefix), appendBytes(nil, fieldContentType, []byte("application/x-protobuf"))...), ErrMalformed},
+		{"protobuf content type", appendBytes(bytes.Clone(protobufPrefix), fieldSchemaID, []byte("a")), ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Unmarshal(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Unmarshal = %+v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, name := range []string{"json", "cbor", "protobuf"} {
+		if encoding, err := ParseEncoding(name); err != nil || string(encoding) != name {
+			t.Errorf("ParseEncoding(%q) = %q, %v", name, encoding, err)
+		}
+	}
+	if _, err := ParseEncoding("xml"); err == nil {
+		t.Error("ParseEncoding(\"xml\") = nil, want an error")
+	}
+}
@@ -0,0 +1,102 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Payloads are converted to and from their JSON form, of maps, slices, numbers, strings, booleans, and nil,
+// which is what JSON Schemas validate, whatever the encoding
+
+// cborDecoding decodes CBOR maps with string keys, as in JSON, rather than with keys of any type
+var cborDecoding, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+
+// toJSONValue returns the JSON form of a Go value, e.g. a struct, by encoding it as JSON
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are kept as written, so that large integers are not rounded
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encodePayload encodes the JSON form of a payload
+func encodePayload(value any, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case JSON:
+		return json.Marshal(value)
+	case CBOR:
+		return cbor.Marshal(withoutJSONNumbers(value))
+	case Protobuf:
+		protoValue, err := structpb.NewValue(withoutJSONNumbers(value))
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(protoValue)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// decodePayload returns the JSON form of an encoded payload.
+// Protobuf payloads hold every number as a double, so integers above 2^53 lose precision.
+func decodePayload(payload []byte, encoding Encoding) (any, error) {
+	switch encoding {
+	case JSON:
+		return decodeJSON(payload)
+	case CBOR:
+		var value any
+		if err := cborDecoding.Unmarshal(payload, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case Protobuf:
+		var protoValue structpb.Value
+		if err := proto.Unmarshal(payload, &protoValue); err != nil {
+			return nil, err
+		}
+		return protoValue.AsInterface(), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// withoutJSONNumbers replaces json.Number, which only JSON encodes as a number, with int64 or float64
+func withoutJSONNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		n, _ := value.Float64()
+		return n
+	case map[string]any:
+		converted := make(map[string]any, len(value))
+		for key, item := range value {
+			converted[key] = withoutJSONNumbers(item)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(value))
+		for i, item := range value {
+			converted[i] = withoutJSONNumbers(item)
+		}
+		return converted
+	}
+	return value
+}
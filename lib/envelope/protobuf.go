@@ -0,0 +1,100 @@
+package envelope
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The fields of the protobuf Envelope message:
+//
+//	message Envelope {
+//	  string envelope = 1;
+//	  string schema_id = 2;
+//	  string content_type = 3;
+//	  google.protobuf.Timestamp timestamp = 4;
+//	  string sender = 5;
+//	  bytes payload = 6; // a serialized google.protobuf.Value
+//	}
+const (
+	fieldEnvelope protowire.Number = iota + 1
+	fieldSchemaID
+	fieldContentType
+	fieldTimestamp
+	fieldSender
+	fieldPayload
+)
+
+// protobufPrefix is the version field, which starts every protobuf envelope
+var protobufPrefix = protowire.AppendString(protowire.AppendTag(nil, fieldEnvelope, protowire.BytesType), Version)
+
+func marshalProtobuf(e Envelope) []byte {
+	timestamp, _ := proto.Marshal(timestamppb.New(e.Timestamp))
+	data := append([]byte(nil), protobufPrefix...)
+	data = appendBytes(data, fieldSchemaID, []byte(e.SchemaID))
+	data = appendBytes(data, fieldContentType, []byte(e.ContentType()))
+	data = appendBytes(data, fieldTimestamp, timestamp)
+	data = appendBytes(data, fieldSender, []byte(e.Sender))
+	return appendBytes(data, fieldPayload, e.Payload)
+}
+
+func appendBytes(data []byte, field protowire.Number, value []byte) []byte {
+	data = protowire.AppendTag(data, field, protowire.BytesType)
+	return protowire.AppendBytes(data, value)
+}
+
+// unmarshalProtobuf decodes the fields of an Envelope message, skipping unknown fields
+func unmarshalProtobuf(data []byte) (Envelope, error) {
+	var version, contentType string
+	e := Envelope{Encoding: Protobuf}
+	for len(data) > 0 {
+		field, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %v", ErrMalformed, protowire.ParseError(n))
+		}
+		data = data[n:]
+		if wireType != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(field, wireType, data)
+			if n < 0 {
+				return Envelope{}, fmt.Errorf("%w: %v", ErrMalformed, protowire.ParseError(n))
+			}
+			data = data[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return Envelope{}, fmt.Errorf("%w: %v", ErrMalformed, protowire.ParseError(n))
+		}
+		data = data[n:]
+		switch field {
+		case fieldEnvelope:
+			version = string(value)
+		case fieldSchemaID:
+			e.SchemaID = string(value)
+		case fieldContentType:
+			contentType = string(value)
+		case fieldTimestamp:
+			var timestamp timestamppb.Timestamp
+			if err := proto.Unmarshal(value, &timestamp); err != nil {
+				return Envelope{}, fmt.Errorf("%w: timestamp: %v", ErrMalformed, err)
+			}
+			e.Timestamp = timestamp.AsTime()
+		case fieldSender:
+			e.Sender = string(value)
+		case fieldPayload:
+			e.Payload = value
+		}
+	}
+	if version != Version {
+		return Envelope{}, ErrNotEnvelope
+	}
+	if err := checkContentType(Protobuf, contentType); err != nil {
+		return Envelope{}, err
+	}
+	if e.SchemaID == "" {
+		return Envelope{}, fmt.Errorf("%w: missing schema ID", ErrMalformed)
+	}
+	return e, nil
+}
@@ -0,0 +1,199 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var (
+	// ErrUnknownSchema is returned for payloads whose schema ID is not registered
+	ErrUnknownSchema = errors.New("unknown schema")
+	// ErrInvalidPayload is returned for payloads that do not conform to their schema
+	ErrInvalidPayload = errors.New("payload does not conform to its schema")
+)
+
+// Registry holds the JSON Schemas that payloads are validated against, by schema ID,
+// and how to decode the payloads of each schema
+type Registry struct {
+	schemas map[string]registeredSchema
+}
+
+type registeredSchema struct {
+	schema *jsonschema.Schema
+	// decode turns the JSON form of a valid payload into the registered type
+	decode func(value any) (any, error)
+}
+
+// Message is a decoded envelope
+type Message struct {
+	Envelope
+	// Value is the payload, as a T for schemas registered with Register[T],
+	// and in its JSON form for schemas registered with RegisterSchema
+	Value any
+}
+
+// NewRegistry returns a registry without any schemas
+func NewRegistry() *Registry {
+	return &Registry{schemas: map[string]registeredSchema{}}
+}
+
+// RegisterSchema registers a JSON Schema, whose payloads are decoded into their JSON form
+func (r *Registry) RegisterSchema(id string, schema []byte) error {
+	return r.register(id, schema, func(value any) (any, error) { return value, nil })
+}
+
+// Register registers a JSON Schema, whose payloads are decoded into a T, with encoding/json
+func Register[T any](r *Registry, id string, schema []byte) error {
+	return r.register(id, schema, func(value any) (any, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var typed T
+		if err := json.Unmarshal(data, &typed); err != nil {
+			return nil, err
+		}
+		return typed, nil
+	})
+}
+
+// RegisterDir registers every `.json` file in dir as a JSON Schema, with RegisterSchema,
+// under the file name without `.json`, e.g. `sensor-reading.v1` for `sensor-reading.v1.json`
+func (r *Registry) RegisterDir(dir string) error {
+	if err := r.RegisterFS(os.DirFS(dir)); err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	return nil
+}
+
+// RegisterFS registers every `.json` file at the root of fsys, e.g. schemas embedded with go:embed, as RegisterDir does
+func (r *Registry) RegisterFS(fsys fs.FS) error {
+	paths, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no schemas")
+	}
+	for _, path := range paths {
+		schema, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("reading schema: %w", err)
+		}
+		if err := r.RegisterSchema(strings.TrimSuffix(path, ".json"), schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) register(id string, schema []byte, decode func(value any) (any, error)) error {
+	if id == "" {
+		return errors.New("schema without an ID")
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return fmt.Errorf("parsing schema %s: %w", id, err)
+	}
+	// Each schema is compiled on its own, at a URL of its own, so that schemas do not need an `$id`
+	location := "urn:hfw:schema:" + id
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(location, doc); err != nil {
+		return fmt.Errorf("adding schema %s: %w", id, err)
+	}
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return fmt.Errorf("compiling schema %s: %w", id, err)
+	}
+	r.schemas[id] = registeredSchema{schema: compiled, decode: decode}
+	return nil
+}
+
+// SchemaIDs returns the IDs of the registered schemas, in order
+func (r *Registry) SchemaIDs() []string {
+	ids := make([]string, 0, len(r.schemas))
+	for id := range r.schemas {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Seal validates a payload against its schema, and encodes it in an envelope from the sender, created now.
+// The payload is any value that encoding/json can encode, e.g. a struct, or a json.RawMessage.
+func (r *Registry) Seal(encoding Encoding, schemaID string, sender string, payload any) ([]byte, error) {
+	value, err := toJSONValue(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding payload: %w", err)
+	}
+	if _, err := r.validate(schemaID, value); err != nil {
+		return nil, err
+	}
+	encoded, err := encodePayload(value, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("encoding payload as %s: %w", encoding, err)
+	}
+	return Marshal(Envelope{
+		SchemaID:  schemaID,
+		Encoding:  encoding,
+		Timestamp: time.Now(),
+		Sender:    sender,
+		Payload:   encoded,
+	})
+}
+
+// Open decodes an envelope, validates its payload against its schema, and decodes the payload.
+// It returns ErrNotEnvelope for messages that are not envelopes, ErrMalformed for envelopes that cannot be decoded,
+// ErrUnknownSchema for schemas that are not registered, and ErrInvalidPayload for payloads that do not conform.
+func (r *Registry) Open(data []byte) (*Message, error) {
+	e, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodePayload(e.Payload, e.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s payload: %v", ErrMalformed, e.Encoding, err)
+	}
+	schema, err := r.validate(e.SchemaID, value)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := schema.decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding payload of schema %s: %w", e.SchemaID, err)
+	}
+	return &Message{Envelope: e, Value: decoded}, nil
+}
+
+func (r *Registry) validate(schemaID string, value any) (registeredSchema, error) {
+	schema, ok := r.schemas[schemaID]
+	if !ok {
+		return registeredSchema{}, fmt.Errorf("%w %q", ErrUnknownSchema, schemaID)
+	}
+	if err := schema.schema.Validate(value); err != nil {
+		return registeredSchema{}, fmt.Errorf("%w %s: %s", ErrInvalidPayload, schemaID, violations(err))
+	}
+	return schema, nil
+}
+
+// violations returns the lines of a validation error that say what is wrong, and where, on a single line.
+// The first line only names the schema.
+func violations(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) > 1 {
+		lines = lines[1:]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimSpace(line), "- ")
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,236 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const sensorReadingSchema = `{
+  "type": "object",
+  "properties": {
+    "sensor": {"type": "string", "pattern": "^sensor-[0-9]{2}$"},
+    "value": {"type": "number"},
+    "unit": {"enum": ["celsius", "fahrenheit"]}
+  },
+  "required": ["sensor", "value", "unit"],
+  "additionalProperties": false
+}`
+
+type sensorReading struct {
+	Sensor string  `json:"sensor"`
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+}
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry := NewRegistry()
+	if err := Register[sensorReading](registry, "sensor-reading.v1", []byte(sensorReadingSchema)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register[int64](registry, "count.v1", []byte(`{"type":"integer"}`)); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestSealAndOpen(t *testing.T) {
+	registry := newRegistry(t)
+	reading := sensorReading{Sensor: "sensor-01", Value: 20.5, Unit: "celsius"}
+	for _, encoding := range []Encoding{JSON, CBOR, Protobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			data, err := registry.Seal(encoding, "sensor-reading.v1", "0.0.1001", reading)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := registry.Open(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opened.SchemaID != "sensor-reading.v1" || opened.Encoding != encoding || opened.Sender != "0.0.1001" || opened.Timestamp.IsZero() {
+				t.Errorf("Open = %+v", opened.Envelope)
+			}
+			if got, ok := opened.Value.(sensorReading); !ok || got != reading {
+				t.Errorf("Value = %#v, want %#v", opened.Value, reading)
+			}
+		})
+	}
+}
+
+func TestSealAndOpenLargeIntegers(t *testing.T) {
+	registry := newRegistry(t)
+	// 2^53 + 1 is the first integer that a double cannot hold
+	const count = int64(1<<53 + 1)
+	tests := []struct {
+		encoding Encoding
+		want     int64
+	}{
+		{JSON, count},
+		{CBOR, count},
+		// Protobuf payloads hold every number as a double
+		{Protobuf, count - 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.encoding), func(t *testing.T) {
+			data, err := registry.Seal(tt.encoding, "count.v1", "0.0.1001", json.RawMessage("9007199254740993"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := registry.Open(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opened.Value != tt.want {
+				t.Errorf("Value = %v, want %v", opened.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealRejects(t *testing.T) {
+	registry := newRegistry(t)
+	tests := []struct {
+		name     string
+		schemaID string
+		payload  any
+		want     error
+	}{
+		{"missing unit", "sensor-reading.v1", map[string]any{"sensor": "sensor-01", "value": 20.5}, ErrInvalidPayload},
+		{"value of another type", "sensor-reading.v1", map[string]any{"sensor": "sensor-01", "value": "hot", "unit": "celsius"}, ErrInvalidPayload},
+		{"unknown schema", "sensor-reading.v2", sensorReading{Sensor: "sensor-01", Value: 20.5, Unit: "celsius"}, ErrUnknownSchema},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := registry.Seal(JSON, tt.schemaID, "0.0.1001", tt.payload); !errors.Is(err, tt.want) {
+				t.Errorf("Seal = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	registry := newRegistry(t)
+	// Other clients may publish envelopes without validating them first
+	marshal := func(encoding Encoding, schemaID string, payload string) []byte {
+		e := testEnvelope
+		e.Encoding = encoding
+		e.SchemaID = schemaID
+		e.Payload = []byte(payload)
+		data, err := Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not an envelope", []byte("Hello HCS"), ErrNotEnvelope},
+		{"invalid payload", marshal(JSON, "sensor-reading.v1", `{"sensor":"sensor-99","value":"hot"}`), ErrInvalidPayload},
+		{"unknown schema", marshal(JSON, "sensor-reading.v2", `{"sensor":"sensor-01","value":20.5,"unit":"celsius"}`), ErrUnknownSchema},
+		// CBOR payloads are maps with string keys, as in JSON
+		{"CBOR payload with other keys", marshal(CBOR, "sensor-reading.v1", "\xa1\x01\x02"), ErrMalformed},
+		{"malformed protobuf payload", marshal(Protobuf, "sensor-reading.v1", "\xff"), ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if opened, err := registry.Open(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Open = %+v, %v, want %v", opened, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sensor-reading.v1.json"), []byte(sensorReadingSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("Not a schema"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry()
+	if err := registry.RegisterDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if ids := registry.SchemaIDs(); !slices.Equal(ids, []string{"sensor-reading.v1"}) {
+		t.Errorf("SchemaIDs = %v, want [sensor-reading.v1]", ids)
+	}
+	// Schemas registered with RegisterDir are decoded into their JSON form
+	data, err := registry.Seal(JSON, "sensor-reading.v1", "0.0.1001", json.RawMessage(`{"sensor":"sensor-01","value":20.5,"unit":"celsius"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := registry.Open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := opened.Value.(map[string]any); !ok || value["sensor"] != "sensor-01" || value["value"] != json.Number("20.5") {
+		t.Errorf("Value = %#v, want the JSON form of the reading", opened.Value)
+	}
+
+	if err := NewRegistry().RegisterDir(t.TempDir()); err == nil {
+		t.Error("RegisterDir of an empty directory = nil, want an error")
+	}
+}
+
+func TestRegisterFS(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.RegisterFS(fstest.MapFS{
+		"sensor-reading.v1.json": {Data: []byte(sensorReadingSchema)},
+		"count.v1.json":          {Data: []byte(`{"type":"integer"}`)},
+		"nested/other.v1.json":   {Data: []byte(`{"type":"string"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := registry.SchemaIDs(); !slices.Equal(ids, []string{"count.v1", "sensor-reading.v1"}) {
+		t.Errorf("SchemaIDs = %v, want [count.v1 sensor-reading.v1]", ids)
+	}
+	err = NewRegistry().RegisterFS(fstest.MapFS{"broken.v1.json": {Data: []byte(`{"type":`)}})
+	if err == nil || !strings.Contains(err.Error(), "broken.v1") {
+		t.Errorf("RegisterFS of a schema that is not JSON = %v, want an error naming it", err)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	var written bytes.Buffer
+	deadLetters := NewDeadLetters(&written)
+	if err := deadLetters.Add(3, "1712345678.000000001", []byte{0xff, 0x00}, ErrNotEnvelope); err != nil {
+		t.Fatal(err)
+	}
+	if err := deadLetters.Add(4, "1712345678.000000002", []byte("Hello HCS"), ErrMalformed); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(written.String(), "\n"), "\n")
+	if len(lines) != 2 || len(deadLetters.Letters()) != 2 {
+		t.Fatalf("wrote %d lines, and kept %d dead letters, want 2", len(lines), len(deadLetters.Letters()))
+	}
+	var letter DeadLetter
+	if err := json.Unmarshal([]byte(lines[0]), &letter); err != nil {
+		t.Fatal(err)
+	}
+	if letter != deadLetters.Letters()[0] {
+		t.Errorf("wrote %+v, kept %+v", letter, deadLetters.Letters()[0])
+	}
+	contents, err := base64.StdEncoding.DecodeString(letter.Message)
+	if err != nil || !bytes.Equal(contents, []byte{0xff, 0x00}) || letter.SequenceNumber != 3 || letter.Reason != ErrNotEnvelope.Error() {
+		t.Errorf("dead letter %+v, want #3 with its contents and reason", letter)
+	}
+
+	// Without a writer, dead letters are only kept
+	kept := NewDeadLetters(nil)
+	if err := kept.Add(3, "1712345678.000000001", []byte("Hello HCS"), ErrNotEnvelope); err != nil || len(kept.Letters()) != 1 {
+		t.Errorf("Add without a writer = %v, kept %d dead letters", err, len(kept.Letters()))
+	}
+}
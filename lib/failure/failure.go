@@ -0,0 +1,148 @@
+// Package failure classifies the errors returned by the Hello Future World
+// scripts, and maps each class to a distinct process exit code,
+// so that wrappers such as CI jobs can tell what failed without parsing output.
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+	"lib/network"
+	"lib/operator"
+)
+
+// Kind is a class of failure
+type Kind int
+
+const (
+	// KindUnknown is any failure that is not classified below
+	KindUnknown Kind = iota
+	// KindConfig is invalid or missing configuration, e.g. the operator account
+	KindConfig
+	// KindPrecheck is a transaction or query rejected by the node before reaching consensus
+	KindPrecheck
+	// KindReceipt is a transaction that reached consensus, with a status other than SUCCESS
+	KindReceipt
+	// KindMirrorHTTP is a failed mirror node REST API request
+	KindMirrorHTTP
+	// KindPropagation is a transaction or entity that did not reach the mirror node in time
+	KindPropagation
+)
+
+// Exit codes, one per Kind.
+// Exit code 2 is also what the flag package uses for invalid flags.
+const (
+	ExitUnknown     = 1
+	ExitConfig      = 2
+	ExitPrecheck    = 3
+	ExitReceipt     = 4
+	ExitMirrorHTTP  = 5
+	ExitPropagation = 6
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindConfig:
+		return "config"
+	case KindPrecheck:
+		return "precheck"
+	case KindReceipt:
+		return "receipt status"
+	case KindMirrorHTTP:
+		return "mirror node HTTP"
+	case KindPropagation:
+		return "mirror node propagation"
+	default:
+		return "unknown"
+	}
+}
+
+// ExitCode returns the process exit code for this kind of failure
+func (k Kind) ExitCode() int {
+	switch k {
+	case KindConfig:
+		return ExitConfig
+	case KindPrecheck:
+		return ExitPrecheck
+	case KindReceipt:
+		return ExitReceipt
+	case KindMirrorHTTP:
+		return ExitMirrorHTTP
+	case KindPropagation:
+		return ExitPropagation
+	default:
+		return ExitUnknown
+	}
+}
+
+// Error explicitly classifies an error that Classify would not recognise,
+// e.g. a missing input file is a config failure.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap classifies err as kind, and returns nil if err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Classify returns the kind of failure of err, looking through wrapped errors
+func Classify(err error) Kind {
+	var explicit *Error
+	if errors.As(err, &explicit) {
+		return explicit.Kind
+	}
+	// A PropagationError wraps the HTTPError of the last attempt, so check for it first
+	if errors.Is(err, mirror.ErrNotYetPropagated) {
+		return KindPropagation
+	}
+	var httpErr *mirror.HTTPError
+	if errors.As(err, &httpErr) {
+		return KindMirrorHTTP
+	}
+	var receiptErr hedera.ErrHederaReceiptStatus
+	var recordErr hedera.ErrHederaRecordStatus
+	if errors.As(err, &receiptErr) || errors.As(err, &recordErr) {
+		return KindReceipt
+	}
+	var precheckErr hedera.ErrHederaPreCheckStatus
+	if errors.As(err, &precheckErr) {
+		return KindPrecheck
+	}
+	var configErr *operator.ConfigError
+	if errors.As(err, &configErr) || errors.Is(err, network.ErrUnknownNetwork) {
+		return KindConfig
+	}
+	return KindUnknown
+}
+
+// ExitCode returns the process exit code for err, 0 if it is nil
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return Classify(err).ExitCode()
+}
+
+// Exit prints err with its kind to stderr, and exits with the matching exit code
+func Exit(err error) {
+	kind := Classify(err)
+	fmt.Fprintf(os.Stderr, "❌ %s error: %v\n", kind, err)
+	os.Exit(kind.ExitCode())
+}
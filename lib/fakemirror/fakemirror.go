@@ -0,0 +1,457 @@
+// Package fakemirror is an in-process stand-in for the mirror node,
+// to run topic subscriptions offline, and to cut them off on purpose.
+//
+// It serves the gRPC ConsensusService that hedera.TopicMessageQuery subscribes to,
+// and the REST endpoints of topic messages, `/api/v1/topics/{id}/messages` and `/api/v1/topics/{id}/messages/{sequenceNumber}`,
+// from messages published with Publish rather than submitted to a network.
+//
+// Point a client at it with:
+//
+//	client.SetMirrorNetwork([]string{server.GRPCAddress()})
+//	mirrorClient := mirror.NewClient(server.RESTURL())
+package fakemirror
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mirrorpb "github.com/hashgraph/hedera-protobufs-go/mirror"
+	"github.com/hashgraph/hedera-protobufs-go/services"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"lib/chunks"
+	"lib/mirror"
+	"lib/runninghash"
+)
+
+// Payer is the account that pays for every published message
+var Payer = hedera.AccountID{Account: 2}
+
+// Subscription is a query received by the server, to check where a client subscribed or resumed from
+type Subscription struct {
+	TopicID   hedera.TopicID
+	StartTime time.Time
+}
+
+// message is a published message, or one chunk of it
+type message struct {
+	topicID  hedera.TopicID
+	response *mirrorpb.ConsensusTopicResponse
+}
+
+// Server is a fake mirror node, listening on a random local port for gRPC and another for REST
+type Server struct {
+	mirrorpb.UnimplementedConsensusServiceServer
+
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	httpServer   *http.Server
+	httpListener net.Listener
+
+	mu       sync.Mutex
+	messages []message
+	// sequenceNumbers and runningHashes hold the last sequence number and running hash of each topic
+	sequenceNumbers map[hedera.TopicID]uint64
+	runningHashes   map[hedera.TopicID][]byte
+	lastTimestamp   time.Time
+	subscriptions   []Subscription
+	// hidden holds the messages that the gRPC streams leave out
+	hidden map[hedera.TopicID]map[uint64]bool
+	// published is closed, and replaced, on every publish, to wake up the open streams
+	published chan struct{}
+	// disconnected is closed, and replaced, to end the open streams
+	disconnected chan struct{}
+}
+
+// Start starts a server without any messages
+func Start() (*Server, error) {
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for gRPC: %w", err)
+	}
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		grpcListener.Close()
+		return nil, fmt.Errorf("listening for REST: %w", err)
+	}
+	s := &Server{
+		grpcServer:      grpc.NewServer(),
+		grpcListener:    grpcListener,
+		httpListener:    httpListener,
+		sequenceNumbers: map[hedera.TopicID]uint64{},
+		runningHashes:   map[hedera.TopicID][]byte{},
+		hidden:          map[hedera.TopicID]map[uint64]bool{},
+		published:       make(chan struct{}),
+		disconnected:    make(chan struct{}),
+	}
+	mirrorpb.RegisterConsensusServiceServer(s.grpcServer, s)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/topics/{id}/messages", s.listTopicMessages)
+	mux.HandleFunc("GET /api/v1/topics/{id}/messages/{sequenceNumber}", s.getTopicMessage)
+	s.httpServer = &http.Server{Handler: mux}
+
+	go s.grpcServer.Serve(grpcListener)
+	go s.httpServer.Serve(httpListener)
+	return s, nil
+}
+
+// GRPCAddress returns the address to set as the mirror network of a client.
+// The SDK uses plaintext for any port other than 443 and 50212.
+func (s *Server) GRPCAddress() string {
+	return s.grpcListener.Addr().String()
+}
+
+// RESTURL returns the base URL of the REST API, without `/api/v1`
+func (s *Server) RESTURL() string {
+	return "http://" + s.httpListener.Addr().String()
+}
+
+// Close stops the server, and ends every open stream
+func (s *Server) Close() {
+	s.grpcServer.Stop()
+	s.httpServer.Close()
+}
+
+// Publish adds a message to the topic, as if it had reached consensus now, and returns its sequence number.
+// Messages larger than a chunk are split into chunks, like TopicMessageSubmitTransaction does,
+// and the sequence number of the last chunk is returned.
+func (s *Server) Publish(topicID hedera.TopicID, contents []byte) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := chunks.Count(len(contents))
+	validStart := time.Now()
+	initialTxID := &services.TransactionID{
+		TransactionValidStart: timestampToProtobuf(validStart),
+		AccountID:             &services.AccountID{Account: &services.AccountID_AccountNum{AccountNum: int64(Payer.Account)}},
+	}
+	var sequenceNumber uint64
+	for number := 1; number <= total; number++ {
+		chunk := contents[(number-1)*chunks.Size : min(number*chunks.Size, len(contents))]
+		// Consensus timestamps are unique, even when messages are published within the same nanosecond
+		consensusTimestamp := time.Now()
+		if !consensusTimestamp.After(s.lastTimestamp) {
+			consensusTimestamp = s.lastTimestamp.Add(time.Nanosecond)
+		}
+		s.lastTimestamp = consensusTimestamp
+		sequenceNumber = s.sequenceNumbers[topicID] + 1
+		s.sequenceNumbers[topicID] = sequenceNumber
+		previous := s.runningHashes[topicID]
+		if previous == nil {
+			previous = runninghash.Initial()
+		}
+		runningHash := runninghash.Next(previous, Payer, topicID, consensusTimestamp, sequenceNumber, chunk)
+		s.runningHashes[topicID] = runningHash
+
+		s.messages = append(s.messages, message{
+			topicID: topicID,
+			response: &mirrorpb.ConsensusTopicResponse{
+				ConsensusTimestamp: timestampToProtobuf(consensusTimestamp),
+				Message:            chunk,
+				RunningHash:        runningHash,
+				SequenceNumber:     sequenceNumber,
+				RunningHashVersion: runninghash.Version,
+				ChunkInfo: &services.ConsensusMessageChunkInfo{
+					InitialTransactionID: initialTxID,
+					Total:                int32(total),
+					Number:               int32(number),
+				},
+			},
+		})
+	}
+	close(s.published)
+	s.published = make(chan struct{})
+	return sequenceNumber
+}
+
+// Disconnect ends every open stream with an UNAVAILABLE error, as when the mirror node restarts
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.disconnected)
+	s.disconnected = make(chan struct{})
+}
+
+// HideFromStream leaves messages out of the gRPC streams, as if they were lost on the way,
+// while the REST API still serves them
+func (s *Server) HideFromStream(topicID hedera.TopicID, sequenceNumbers ...uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hidden[topicID] == nil {
+		s.hidden[topicID] = map[uint64]bool{}
+	}
+	for _, sequenceNumber := range sequenceNumbers {
+		s.hidden[topicID][sequenceNumber] = true
+	}
+}
+
+// Tamper replaces the contents of a published message, without updating its running hash,
+// as a mirror node serving altered messages would
+func (s *Server) Tamper(topicID hedera.TopicID, sequenceNumber uint64, contents []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.topicID == topicID && m.response.SequenceNumber == sequenceNumber {
+			m.response.Message = contents
+		}
+	}
+}
+
+// Subscriptions returns every query received so far, in order
+func (s *Server) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Subscription{}, s.subscriptions...)
+}
+
+// SubscribeTopic streams the messages of the topic from the start time,
+// and then every new message, until the end time, the limit, or a disconnect
+func (s *Server) SubscribeTopic(query *mirrorpb.ConsensusTopicQuery, stream mirrorpb.ConsensusService_SubscribeTopicServer) error {
+	if query.GetTopicID() == nil {
+		return status.Error(codes.InvalidArgument, "missing topic ID")
+	}
+	topicID := hedera.TopicID{
+		Shard: uint64(query.GetTopicID().GetShardNum()),
+		Realm: uint64(query.GetTopicID().GetRealmNum()),
+		Topic: uint64(query.GetTopicID().GetTopicNum()),
+	}
+	start := timestampFromProtobuf(query.GetConsensusStartTime())
+	var end time.Time
+	if query.GetConsensusEndTime() != nil {
+		end = timestampFromProtobuf(query.GetConsensusEndTime())
+	}
+
+	s.mu.Lock()
+	s.subscriptions = append(s.subscriptions, Subscription{TopicID: topicID, StartTime: start})
+	s.mu.Unlock()
+
+	next := 0
+	sent := uint64(0)
+	for {
+		// The responses are copied under the lock, as Tamper may change them while they are sent
+		s.mu.Lock()
+		var pending []message
+		for _, m := range s.messages[next:] {
+			if m.topicID == topicID {
+				pending = append(pending, message{topicID: m.topicID, response: proto.Clone(m.response).(*mirrorpb.ConsensusTopicResponse)})
+			}
+		}
+		next = len(s.messages)
+		published := s.published
+		disconnected := s.disconnected
+		hidden := maps.Clone(s.hidden[topicID])
+		s.mu.Unlock()
+
+		for _, m := range pending {
+			consensusTimestamp := timestampFromProtobuf(m.response.ConsensusTimestamp)
+			if consensusTimestamp.Before(start) || hidden[m.response.SequenceNumber] {
+				continue
+			}
+			if !end.IsZero() && !consensusTimestamp.Before(end) {
+				return nil
+			}
+			if err := stream.Send(m.response); err != nil {
+				return err
+			}
+			sent++
+			if query.GetLimit() > 0 && sent >= query.GetLimit() {
+				return nil
+			}
+		}
+		if !end.IsZero() && !time.Now().Before(end) {
+			return nil
+		}
+
+		select {
+		case <-published:
+		case <-disconnected:
+			return status.Error(codes.Unavailable, "disconnected by the fake mirror node")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// getTopicMessage serves a single message in the same form as the mirror node REST API
+func (s *Server) getTopicMessage(w http.ResponseWriter, r *http.Request) {
+	topicID, err := hedera.TopicIDFromString(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter: topic ID")
+		return
+	}
+	sequenceNumber, err := strconv.ParseUint(r.PathValue("sequenceNumber"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter: sequence number")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.topicID == topicID && m.response.SequenceNumber == sequenceNumber {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(toMirror(m))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not found")
+}
+
+// listTopicMessages serves a page of messages in the same form as the mirror node REST API,
+// filtered by `sequencenumber`, in the `order` given, with a link to the next page
+func (s *Server) listTopicMessages(w http.ResponseWriter, r *http.Request) {
+	topicID, err := hedera.TopicIDFromString(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter: topic ID")
+		return
+	}
+	query := r.URL.Query()
+	limit := 25
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid parameter: limit")
+			return
+		}
+		limit = min(limit, 100)
+	}
+	descending := query.Get("order") == string(mirror.OrderDesc)
+	var conditions []func(uint64) bool
+	for _, value := range query["sequencenumber"] {
+		condition, err := parseCondition(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid parameter: sequencenumber")
+			return
+		}
+		conditions = append(conditions, condition)
+	}
+
+	s.mu.Lock()
+	var matching []mirror.TopicMessage
+	for _, m := range s.messages {
+		if m.topicID != topicID || !matchesAll(conditions, m.response.SequenceNumber) {
+			continue
+		}
+		matching = append(matching, toMirror(m))
+	}
+	s.mu.Unlock()
+	if descending {
+		slices.Reverse(matching)
+	}
+
+	response := mirror.TopicMessagesResponse{Messages: matching}
+	if len(matching) > limit {
+		// The next page continues after the last message of this one, with the same filters
+		response.Messages = matching[:limit]
+		last := response.Messages[limit-1].SequenceNumber
+		next := url.Values{}
+		for key, values := range query {
+			next[key] = values
+		}
+		op := mirror.OpGt
+		if descending {
+			op = mirror.OpLt
+		}
+		next["sequencenumber"] = append(slices.Clone(query["sequencenumber"]), mirror.Condition{Op: op, Value: strconv.FormatInt(last, 10)}.String())
+		response.Links.Next = r.URL.Path + "?" + next.Encode()
+	}
+	if response.Messages == nil {
+		response.Messages = []mirror.TopicMessage{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// parseCondition parses a sequence number filter, e.g. `gte:5`, or `5` for equality
+func parseCondition(value string) (func(uint64) bool, error) {
+	op, operand, found := strings.Cut(value, ":")
+	if !found {
+		op, operand = string(mirror.OpEq), value
+	}
+	n, err := strconv.ParseUint(operand, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	switch mirror.Operator(op) {
+	case mirror.OpEq:
+		return func(seq uint64) bool { return seq == n }, nil
+	case mirror.OpNe:
+		return func(seq uint64) bool { return seq != n }, nil
+	case mirror.OpLt:
+		return func(seq uint64) bool { return seq < n }, nil
+	case mirror.OpLte:
+		return func(seq uint64) bool { return seq <= n }, nil
+	case mirror.OpGt:
+		return func(seq uint64) bool { return seq > n }, nil
+	case mirror.OpGte:
+		return func(seq uint64) bool { return seq >= n }, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+func matchesAll(conditions []func(uint64) bool, sequenceNumber uint64) bool {
+	for _, condition := range conditions {
+		if !condition(sequenceNumber) {
+			return false
+		}
+	}
+	return true
+}
+
+// toMirror converts a message to the REST API model
+func toMirror(m message) mirror.TopicMessage {
+	response := m.response
+	info := response.ChunkInfo
+	initialTxID := info.InitialTransactionID
+	validStart := initialTxID.TransactionValidStart
+	return mirror.TopicMessage{
+		ChunkInfo: &mirror.ChunkInfo{
+			InitialTransactionID: mirror.TransactionIDInfo{
+				AccountID:             fmt.Sprintf("0.0.%d", initialTxID.GetAccountID().GetAccountNum()),
+				TransactionValidStart: fmt.Sprintf("%d.%09d", validStart.Seconds, validStart.Nanos),
+			},
+			Number: int(info.Number),
+			Total:  int(info.Total),
+		},
+		ConsensusTimestamp: fmt.Sprintf("%d.%09d", response.ConsensusTimestamp.Seconds, response.ConsensusTimestamp.Nanos),
+		Message:            base64.StdEncoding.EncodeToString(response.Message),
+		PayerAccountID:     Payer.String(),
+		RunningHash:        base64.StdEncoding.EncodeToString(response.RunningHash),
+		RunningHashVersion: int(response.RunningHashVersion),
+		SequenceNumber:     int64(response.SequenceNumber),
+		TopicID:            m.topicID.String(),
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"_status": map[string]any{"messages": []map[string]string{{"message": message}}},
+	})
+}
+
+func timestampToProtobuf(t time.Time) *services.Timestamp {
+	return &services.Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
+
+func timestampFromProtobuf(timestamp *services.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return time.Unix(timestamp.GetSeconds(), int64(timestamp.GetNanos()))
+}
@@ -0,0 +1,371 @@
+// Package fees builds the custom fees of HTS tokens from strings,
+// and describes custom fees, both as built with the SDK and as returned by the mirror node.
+//
+// A fee is its kind and amount, followed by comma separated options:
+//
+//	fixed:2ℏ                          2 HBAR, in any unit accepted by the transferlist package
+//	fixed:10@0.0.1234                 10 units of token 0.0.1234, in its smallest denomination
+//	fixed:10@self                     10 units of the token the fee is set on
+//	fractional:1/100,min=1,max=500    1% of each transfer, between 1 and 500 units
+//	royalty:5/100,fallback=1ℏ         5% of the value exchanged for an NFT, or 1 HBAR when there is none
+//
+// Every kind accepts `collector=0.0.x`, which defaults to the given collector account,
+// and `exempt`, which exempts every fee collector of the token from this fee.
+// Fractional fees also accept `net`, which charges the fee to the sender on top of the amount,
+// instead of deducting it from the amount received.
+// Ref: https://docs.hedera.com/hedera/sdks-and-apis/sdks/token-service/custom-token-fees
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+	"lib/transferlist"
+)
+
+// MaxCustomFees is the maximum number of custom fees of a single token,
+// set by the `tokens.maxCustomFeesAllowed` network property
+const MaxCustomFees = 10
+
+// Self is the denominating token of fixed fees paid in the token they are set on
+const Self = "self"
+
+var (
+	// ErrInvalidFee is returned for fees that cannot be parsed
+	ErrInvalidFee = errors.New("invalid custom fee")
+	// ErrNotAllowed is returned for fees that the token type does not allow,
+	// fractional fees on NFTs, and royalty fees on fungible tokens
+	ErrNotAllowed = errors.New("custom fee not allowed")
+)
+
+// Parse parses a single fee, with collector as its collector account unless the fee sets its own.
+// tokenId is the token the fee is set on, which `@self` resolves to,
+// or nil for a token that is being created, whose ID is not known yet.
+func Parse(spec string, collector hedera.AccountID, tokenId *hedera.TokenID) (hedera.Fee, error) {
+	kind, rest, found := strings.Cut(strings.TrimSpace(spec), ":")
+	if !found {
+		return nil, fmt.Errorf("%w %q: expected fixed:, fractional:, or royalty:", ErrInvalidFee, spec)
+	}
+	fields := strings.Split(rest, ",")
+	value := strings.TrimSpace(fields[0])
+
+	options := map[string]string{}
+	exempt := false
+	for _, field := range fields[1:] {
+		name, optionValue, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch name {
+		case "exempt":
+			exempt = true
+		case "collector", "min", "max", "fallback", "net":
+			options[name] = strings.TrimSpace(optionValue)
+		default:
+			return nil, fmt.Errorf("%w %q: unknown option %q", ErrInvalidFee, spec, name)
+		}
+	}
+	if collectorStr, ok := options["collector"]; ok {
+		var err error
+		collector, err = hedera.AccountIDFromString(collectorStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: collector: %w", ErrInvalidFee, spec, err)
+		}
+	}
+	allowed := func(names ...string) error {
+		for name := range options {
+			if name != "collector" && !slices.Contains(names, name) {
+				return fmt.Errorf("%w %q: option %q does not apply to %s fees", ErrInvalidFee, spec, name, kind)
+			}
+		}
+		return nil
+	}
+
+	switch kind {
+	case "fixed":
+		if err := allowed(); err != nil {
+			return nil, err
+		}
+		fee, err := parseFixed(value, tokenId)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidFee, spec, err)
+		}
+		fee.SetFeeCollectorAccountID(collector).SetAllCollectorsAreExempt(exempt)
+		return fee, nil
+
+	case "fractional":
+		if err := allowed("min", "max", "net"); err != nil {
+			return nil, err
+		}
+		numerator, denominator, err := parseFraction(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidFee, spec, err)
+		}
+		fee := hedera.NewCustomFractionalFee().
+			SetNumerator(numerator).
+			SetDenominator(denominator).
+			SetFeeCollectorAccountID(collector).
+			SetAllCollectorsAreExempt(exempt)
+		for _, name := range []string{"min", "max"} {
+			if boundStr, ok := options[name]; ok {
+				bound, err := strconv.ParseInt(boundStr, 10, 64)
+				if err != nil || bound < 0 {
+					return nil, fmt.Errorf("%w %q: %s must be a whole number of units, got %q", ErrInvalidFee, spec, name, boundStr)
+				}
+				if name == "min" {
+					fee.SetMin(bound)
+				} else {
+					fee.SetMax(bound)
+				}
+			}
+		}
+		if fee.MaximumAmount != 0 && fee.MinimumAmount > fee.MaximumAmount {
+			return nil, fmt.Errorf("%w %q: min is greater than max", ErrInvalidFee, spec)
+		}
+		if _, ok := options["net"]; ok {
+			fee.SetAssessmentMethod(hedera.FeeAssessmentMethodExclusive)
+		}
+		return fee, nil
+
+	case "royalty":
+		if err := allowed("fallback"); err != nil {
+			return nil, err
+		}
+		numerator, denominator, err := parseFraction(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidFee, spec, err)
+		}
+		fee := hedera.NewCustomRoyaltyFee().
+			SetNumerator(numerator).
+			SetDenominator(denominator).
+			SetFeeCollectorAccountID(collector).
+			SetAllCollectorsAreExempt(exempt)
+		if fallbackStr, ok := options["fallback"]; ok {
+			fallback, err := parseFixed(fallbackStr, tokenId)
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: fallback: %w", ErrInvalidFee, spec, err)
+			}
+			fee.SetFallbackFee(fallback)
+		}
+		return fee, nil
+	}
+	return nil, fmt.Errorf("%w %q: unknown kind %q, expected fixed, fractional, or royalty", ErrInvalidFee, spec, kind)
+}
+
+// ParseList parses each fee, as Parse does, and checks that the list fits on a token of the given type
+func ParseList(specs []string, collector hedera.AccountID, tokenType hedera.TokenType, tokenId *hedera.TokenID) ([]hedera.Fee, error) {
+	if len(specs) > MaxCustomFees {
+		return nil, fmt.Errorf("%w: %d fees, the maximum is %d", ErrNotAllowed, len(specs), MaxCustomFees)
+	}
+	customFees := make([]hedera.Fee, len(specs))
+	for i, spec := range specs {
+		fee, err := Parse(spec, collector, tokenId)
+		if err != nil {
+			return nil, err
+		}
+		switch fee.(type) {
+		case *hedera.CustomFractionalFee:
+			if tokenType != hedera.TokenTypeFungibleCommon {
+				return nil, fmt.Errorf("%w %q: fractional fees only apply to fungible tokens", ErrNotAllowed, spec)
+			}
+		case *hedera.CustomRoyaltyFee:
+			if tokenType != hedera.TokenTypeNonFungibleUnique {
+				return nil, fmt.Errorf("%w %q: royalty fees only apply to NFTs", ErrNotAllowed, spec)
+			}
+		}
+		customFees[i] = fee
+	}
+	return customFees, nil
+}
+
+// parseFixed parses `<amount>` in HBAR, or `<units>@<token ID or self>`
+func parseFixed(s string, self *hedera.TokenID) (*hedera.CustomFixedFee, error) {
+	fee := hedera.NewCustomFixedFee()
+	unitsStr, token, found := strings.Cut(s, "@")
+	if !found {
+		amount, err := transferlist.ParseAmount(s)
+		if err != nil {
+			return nil, err
+		}
+		if amount.AsTinybar() <= 0 {
+			return nil, fmt.Errorf("amount must be positive, got %q", s)
+		}
+		return fee.SetHbarAmount(amount), nil
+	}
+	units, err := strconv.ParseInt(strings.TrimSpace(unitsStr), 10, 64)
+	if err != nil || units <= 0 {
+		return nil, fmt.Errorf("amount must be a positive whole number of units, got %q", unitsStr)
+	}
+	fee.SetAmount(units)
+	if strings.TrimSpace(token) == Self {
+		// Only TokenCreateTransaction accepts the 0.0.0 token ID that stands for the token being created,
+		// any other transaction rejects it with INVALID_TOKEN_ID_IN_CUSTOM_FEES
+		if self != nil {
+			return fee.SetDenominatingTokenID(*self), nil
+		}
+		return fee.SetDenominatingTokenToSameToken(), nil
+	}
+	tokenId, err := hedera.TokenIDFromString(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("denominating token: %w", err)
+	}
+	return fee.SetDenominatingTokenID(tokenId), nil
+}
+
+// parseFraction parses `<numerator>/<denominator>`
+func parseFraction(s string) (int64, int64, error) {
+	numeratorStr, denominatorStr, found := strings.Cut(s, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("expected a fraction, e.g. 1/100, got %q", s)
+	}
+	numerator, err := strconv.ParseInt(strings.TrimSpace(numeratorStr), 10, 64)
+	if err != nil || numerator <= 0 {
+		return 0, 0, fmt.Errorf("numerator must be a positive whole number, got %q", numeratorStr)
+	}
+	denominator, err := strconv.ParseInt(strings.TrimSpace(denominatorStr), 10, 64)
+	if err != nil || denominator <= 0 {
+		return 0, 0, fmt.Errorf("denominator must be a positive whole number, got %q", denominatorStr)
+	}
+	if numerator > denominator {
+		return 0, 0, fmt.Errorf("%q is more than 100%%", s)
+	}
+	return numerator, denominator, nil
+}
+
+// Describe returns a line for each fee, in the same form as DescribeMirror,
+// with fees paid in the token itself shown as paid in tokenId
+func Describe(customFees []hedera.Fee, tokenId hedera.TokenID) []string {
+	lines := []string{}
+	for _, fee := range customFees {
+		switch f := fee.(type) {
+		case *hedera.CustomFixedFee:
+			lines = append(lines, describeFixed(*f, tokenId))
+		case hedera.CustomFixedFee:
+			lines = append(lines, describeFixed(f, tokenId))
+		case *hedera.CustomFractionalFee:
+			lines = append(lines, describeFractional(*f))
+		case hedera.CustomFractionalFee:
+			lines = append(lines, describeFractional(f))
+		case *hedera.CustomRoyaltyFee:
+			lines = append(lines, describeRoyalty(*f, tokenId))
+		case hedera.CustomRoyaltyFee:
+			lines = append(lines, describeRoyalty(f, tokenId))
+		}
+	}
+	return lines
+}
+
+func describeFixed(fee hedera.CustomFixedFee, tokenId hedera.TokenID) string {
+	return "fixed " + fixedAmount(fee.Amount, denomination(fee.DenominationTokenID, tokenId)) +
+		collected(collector(fee.CustomFee), fee.AllCollectorsAreExempt)
+}
+
+func describeFractional(fee hedera.CustomFractionalFee) string {
+	return "fractional " + fractionalAmount(fee.Numerator, fee.Denominator, fee.MinimumAmount, fee.MaximumAmount, bool(fee.AssessmentMethod)) +
+		collected(collector(fee.CustomFee), fee.AllCollectorsAreExempt)
+}
+
+func describeRoyalty(fee hedera.CustomRoyaltyFee, tokenId hedera.TokenID) string {
+	fallback := ""
+	if fee.FallbackFee != nil {
+		fallback = fixedAmount(fee.FallbackFee.Amount, denomination(fee.FallbackFee.DenominationTokenID, tokenId))
+	}
+	return "royalty " + royaltyAmount(fee.Numerator, fee.Denominator, fallback) +
+		collected(collector(fee.CustomFee), fee.AllCollectorsAreExempt)
+}
+
+// denomination is the token ID of a fixed fee, empty for HBAR,
+// where the SDK marks fees paid in the token itself with token ID 0.0.0
+func denomination(denominatingTokenId *hedera.TokenID, tokenId hedera.TokenID) string {
+	switch {
+	case denominatingTokenId == nil:
+		return ""
+	case *denominatingTokenId == hedera.TokenID{}:
+		return tokenId.String()
+	}
+	return denominatingTokenId.String()
+}
+
+func collector(fee hedera.CustomFee) string {
+	if fee.FeeCollectorAccountID == nil {
+		return ""
+	}
+	return fee.FeeCollectorAccountID.String()
+}
+
+// DescribeMirror returns a line for each fee of a token returned by the mirror node
+func DescribeMirror(customFees *mirror.CustomFees) []string {
+	lines := []string{}
+	if customFees == nil {
+		return lines
+	}
+	for _, fee := range customFees.FixedFees {
+		lines = append(lines, "fixed "+fixedAmount(fee.Amount, fee.DenominatingTokenID)+
+			collected(fee.CollectorAccountID, fee.AllCollectorsAreExempt))
+	}
+	for _, fee := range customFees.FractionalFees {
+		maximum := int64(0)
+		if fee.Maximum != nil {
+			maximum = *fee.Maximum
+		}
+		lines = append(lines, "fractional "+fractionalAmount(fee.Amount.Numerator, fee.Amount.Denominator, fee.Minimum, maximum, fee.NetOfTransfers)+
+			collected(fee.CollectorAccountID, fee.AllCollectorsAreExempt))
+	}
+	for _, fee := range customFees.RoyaltyFees {
+		fallback := ""
+		if fee.FallbackFee != nil {
+			fallback = fixedAmount(fee.FallbackFee.Amount, fee.FallbackFee.DenominatingTokenID)
+		}
+		lines = append(lines, "royalty "+royaltyAmount(fee.Amount.Numerator, fee.Amount.Denominator, fallback)+
+			collected(fee.CollectorAccountID, fee.AllCollectorsAreExempt))
+	}
+	return lines
+}
+
+// Equal reports whether two lists of descriptions hold the same fees, in any order
+func Equal(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func fixedAmount(amount int64, denominatingTokenId string) string {
+	if denominatingTokenId == "" {
+		return hedera.HbarFromTinybar(amount).String()
+	}
+	return fmt.Sprintf("%d of %s", amount, denominatingTokenId)
+}
+
+func fractionalAmount(numerator int64, denominator int64, minimum int64, maximum int64, net bool) string {
+	s := fmt.Sprintf("%d/%d", numerator, denominator)
+	if minimum != 0 {
+		s += fmt.Sprintf(", min %d", minimum)
+	}
+	if maximum != 0 {
+		s += fmt.Sprintf(", max %d", maximum)
+	}
+	if net {
+		s += ", net of transfers"
+	}
+	return s
+}
+
+func royaltyAmount(numerator int64, denominator int64, fallback string) string {
+	s := fmt.Sprintf("%d/%d", numerator, denominator)
+	if fallback != "" {
+		s += ", fallback " + fallback
+	}
+	return s
+}
+
+func collected(collectorAccountId string, exempt bool) string {
+	s := " to " + collectorAccountId
+	if exempt {
+		s += ", collectors exempt"
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+package fees
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+)
+
+var (
+	testCollector = hedera.AccountID{Account: 1001}
+	testToken     = hedera.TokenID{Token: 5005}
+)
+
+func TestParseListAndDescribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		tokenType hedera.TokenType
+		want      string
+	}{
+		{"fixed hbar", "fixed:2ℏ", hedera.TokenTypeFungibleCommon, "fixed 2 ℏ to 0.0.1001"},
+		{"fixed hbar in tinybars", "fixed:50 tinybar", hedera.TokenTypeNonFungibleUnique, "fixed 50 tℏ to 0.0.1001"},
+		{"fixed token", "fixed:10@0.0.1234, collector=0.0.2002, exempt", hedera.TokenTypeFungibleCommon, "fixed 10 of 0.0.1234 to 0.0.2002, collectors exempt"},
+		{"fixed self", "fixed:10@self", hedera.TokenTypeFungibleCommon, "fixed 10 of 0.0.5005 to 0.0.1001"},
+		{"fractional", "fractional:1/100,min=1,max=500", hedera.TokenTypeFungibleCommon, "fractional 1/100, min 1, max 500 to 0.0.1001"},
+		{"fractional without bounds", "fractional:1/2", hedera.TokenTypeFungibleCommon, "fractional 1/2 to 0.0.1001"},
+		{"fractional net of transfers", "fractional:1/100,net,exempt", hedera.TokenTypeFungibleCommon, "fractional 1/100, net of transfers to 0.0.1001, collectors exempt"},
+		{"royalty", "royalty:5/100", hedera.TokenTypeNonFungibleUnique, "royalty 5/100 to 0.0.1001"},
+		{"royalty with fallback", "royalty:5/100,fallback=1ℏ", hedera.TokenTypeNonFungibleUnique, "royalty 5/100, fallback 1 ℏ to 0.0.1001"},
+		{"royalty with token fallback", "royalty:5/100,fallback=3@0.0.1234,collector=0.0.2002", hedera.TokenTypeNonFungibleUnique, "royalty 5/100, fallback 3 of 0.0.1234 to 0.0.2002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customFees, err := ParseList([]string{tt.spec}, testCollector, tt.tokenType, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Describe(customFees, testToken); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("Describe = %q, want [%q]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSelf(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokenId *hedera.TokenID
+		want    hedera.TokenID
+	}{
+		// 0.0.0 stands for the token being created
+		{"token being created", nil, hedera.TokenID{}},
+		{"existing token", &testToken, testToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := Parse("fixed:10@self", testCollector, tt.tokenId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixed := fee.(*hedera.CustomFixedFee)
+			if fixed.DenominationTokenID == nil || *fixed.DenominationTokenID != tt.want {
+				t.Errorf("DenominationTokenID = %v, want %v", fixed.DenominationTokenID, tt.want)
+			}
+
+			fee, err = Parse("royalty:1/10,fallback=2@self", testCollector, tt.tokenId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fallback := fee.(*hedera.CustomRoyaltyFee).FallbackFee
+			if fallback == nil || fallback.DenominationTokenID == nil || *fallback.DenominationTokenID != tt.want {
+				t.Errorf("fallback = %+v, want paid in %v", fallback, tt.want)
+			}
+			if got := Describe([]hedera.Fee{fee}, testToken); !slices.Equal(got, []string{"royalty 1/10, fallback 2 of 0.0.5005 to 0.0.1001"}) {
+				t.Errorf("Describe = %q", got)
+			}
+		})
+	}
+}
+
+func TestParseListErrors(t *testing.T) {
+	tooMany := make([]string, MaxCustomFees+1)
+	for i := range tooMany {
+		tooMany[i] = "fixed:1ℏ"
+	}
+	tests := []struct {
+		name      string
+		specs     []string
+		tokenType hedera.TokenType
+		want      error
+	}{
+		{"no kind", []string{"2ℏ"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"unknown kind", []string{"flat:2ℏ"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"unknown option", []string{"fixed:2ℏ,discount=1"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"option of another kind", []string{"fixed:2ℏ,min=1"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"fallback on a fractional fee", []string{"fractional:1/100,fallback=1ℏ"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"invalid collector", []string{"fixed:2ℏ,collector=alice"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"zero hbar", []string{"fixed:0ℏ"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"negative units", []string{"fixed:-1@0.0.1234"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"fractional units", []string{"fixed:1.5@0.0.1234"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"invalid denominating token", []string{"fixed:1@other"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"not a fraction", []string{"fractional:0.01"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"zero denominator", []string{"fractional:1/0"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"more than 100%", []string{"royalty:3/2"}, hedera.TokenTypeNonFungibleUnique, ErrInvalidFee},
+		{"negative min", []string{"fractional:1/100,min=-1"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"min over max", []string{"fractional:1/100,min=10,max=5"}, hedera.TokenTypeFungibleCommon, ErrInvalidFee},
+		{"invalid fallback", []string{"royalty:1/100,fallback=free"}, hedera.TokenTypeNonFungibleUnique, ErrInvalidFee},
+		{"fractional on an NFT", []string{"fixed:1ℏ", "fractional:1/100"}, hedera.TokenTypeNonFungibleUnique, ErrNotAllowed},
+		{"royalty on a fungible token", []string{"royalty:1/100"}, hedera.TokenTypeFungibleCommon, ErrNotAllowed},
+		{"too many fees", tooMany, hedera.TokenTypeFungibleCommon, ErrNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseList(tt.specs, testCollector, tt.tokenType, nil); !errors.Is(err, tt.want) {
+				t.Errorf("ParseList = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeMirror(t *testing.T) {
+	maximum := int64(500)
+	customFees := &mirror.CustomFees{
+		FixedFees: []mirror.FixedFee{
+			{Amount: 200000000, CollectorAccountID: "0.0.1001"},
+			{Amount: 10, CollectorAccountID: "0.0.2002", DenominatingTokenID: "0.0.5005", AllCollectorsAreExempt: true},
+		},
+		FractionalFees: []mirror.FractionalFee{
+			{Amount: mirror.Fraction{Numerator: 1, Denominator: 100}, CollectorAccountID: "0.0.1001", Minimum: 1, Maximum: &maximum, NetOfTransfers: true},
+		},
+		RoyaltyFees: []mirror.RoyaltyFee{
+			{Amount: mirror.Fraction{Numerator: 5, Denominator: 100}, CollectorAccountID: "0.0.1001", FallbackFee: &mirror.FallbackFee{Amount: 100000000}},
+		},
+	}
+	want := []string{
+		"fixed 2 ℏ to 0.0.1001",
+		"fixed 10 of 0.0.5005 to 0.0.2002, collectors exempt",
+		"fractional 1/100, min 1, max 500, net of transfers to 0.0.1001",
+		"royalty 5/100, fallback 1 ℏ to 0.0.1001",
+	}
+	if got := DescribeMirror(customFees); !slices.Equal(got, want) {
+		t.Errorf("DescribeMirror =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := DescribeMirror(nil); len(got) != 0 {
+		t.Errorf("DescribeMirror(nil) = %q, want none", got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	// The fees of a token being created, and the same fees as returned by the mirror node once it exists
+	customFees, err := ParseList([]string{"fractional:1/100,min=1", "fixed:10@self,exempt", "fixed:1ℏ,collector=0.0.2002"},
+		testCollector, hedera.TokenTypeFungibleCommon, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirrorFees := &mirror.CustomFees{
+		FixedFees: []mirror.FixedFee{
+			{Amount: 100000000, CollectorAccountID: "0.0.2002"},
+			{Amount: 10, CollectorAccountID: "0.0.1001", DenominatingTokenID: "0.0.5005", AllCollectorsAreExempt: true},
+		},
+		FractionalFees: []mirror.FractionalFee{
+			{Amount: mirror.Fraction{Numerator: 1, Denominator: 100}, CollectorAccountID: "0.0.1001", Minimum: 1},
+		},
+	}
+	described := Describe(customFees, testToken)
+
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want bool
+	}{
+		{"SDK and mirror", described, DescribeMirror(mirrorFees), true},
+		{"mirror and SDK", DescribeMirror(mirrorFees), described, true},
+		{"none", nil, []string{}, true},
+		{"missing fee", described, described[1:], false},
+		{"duplicate fee", described, append(slices.Clone(described[1:]), described[1]), false},
+		{"other collector", []string{"fixed 1 ℏ to 0.0.1001"}, []string{"fixed 1 ℏ to 0.0.2002"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+
+	// Equal does not reorder the lists it is given
+	if !slices.Equal(described, Describe(customFees, testToken)) {
+		t.Errorf("Equal reordered its arguments to %q", described)
+	}
+}
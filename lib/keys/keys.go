@@ -0,0 +1,273 @@
+// Package keys builds the keys of Hedera entities, such as the admin and submit keys of a topic,
+// as single public keys, key lists, and threshold keys,
+// and checks whether a set of signers satisfies a key, before a transaction reaches the network.
+//
+// The SDK's KeyList does not expose its keys or its threshold, so keys are built as a Key first,
+// and converted to an SDK key with SDK.
+//
+// A key is written as one of:
+//
+//	operator                        the operator public key
+//	302a300506032b6570032100...     a public key, DER encoded or raw hex
+//	<key>,<key>,<key>               a key list, which needs every key to sign
+//	2-of:<key>,<key>,<key>          a threshold key, which needs 2 of the keys to sign
+package keys
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashgraph/hedera-protobufs-go/services"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"google.golang.org/protobuf/proto"
+
+	"lib/mirror"
+)
+
+// Operator is the name of the operator public key in key specs
+const Operator = "operator"
+
+var (
+	// ErrInvalidKey is returned for keys that cannot be parsed or decoded
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrNotSatisfied is returned when the signers of a transaction do not satisfy a key it needs
+	ErrNotSatisfied = errors.New("key not satisfied")
+)
+
+// Key is a single public key, or a list of keys of which Threshold need to sign
+type Key struct {
+	// PublicKey is set for a single key, in which case Keys is empty
+	PublicKey *hedera.PublicKey
+	// Threshold equals len(Keys) for a key list
+	Threshold int
+	Keys      []Key
+}
+
+// Single returns a Key of a single public key
+func Single(publicKey hedera.PublicKey) Key {
+	return Key{PublicKey: &publicKey}
+}
+
+// Threshold returns a Key that needs threshold of the keys to sign
+func Threshold(threshold int, keys ...Key) Key {
+	return Key{Threshold: threshold, Keys: keys}
+}
+
+// Parse parses a key spec, where `operator` stands for operatorKey
+func Parse(spec string, operatorKey hedera.PublicKey) (Key, error) {
+	spec = strings.TrimSpace(spec)
+	threshold := 0
+	if prefix, rest, found := strings.Cut(spec, "-of:"); found {
+		var err error
+		threshold, err = strconv.Atoi(prefix)
+		if err != nil || threshold < 1 {
+			return Key{}, fmt.Errorf("%w %q: threshold must be a positive number, e.g. 2-of:<key>,<key>", ErrInvalidKey, spec)
+		}
+		spec = rest
+	}
+	parts := strings.Split(spec, ",")
+	keys := make([]Key, len(parts))
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == Operator {
+			keys[i] = Single(operatorKey)
+			continue
+		}
+		publicKey, err := hedera.PublicKeyFromString(part)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w %q: %w", ErrInvalidKey, part, err)
+		}
+		keys[i] = Single(publicKey)
+	}
+	if threshold == 0 {
+		if len(keys) == 1 {
+			return keys[0], nil
+		}
+		threshold = len(keys)
+	}
+	key := Threshold(threshold, keys...)
+	if err := key.validate(); err != nil {
+		return Key{}, err
+	}
+	return key, nil
+}
+
+// validate checks that every key list has keys, and a threshold between 1 and its number of keys,
+// so that no key is satisfied without any signature
+func (k Key) validate() error {
+	if k.PublicKey != nil {
+		return nil
+	}
+	if len(k.Keys) == 0 {
+		return fmt.Errorf("%w: a key list needs at least one key", ErrInvalidKey)
+	}
+	if k.Threshold < 1 || k.Threshold > len(k.Keys) {
+		return fmt.Errorf("%w: threshold %d is not between 1 and the %d keys", ErrInvalidKey, k.Threshold, len(k.Keys))
+	}
+	for _, key := range k.Keys {
+		if err := key.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SDK returns the key as an SDK key, to set on a transaction
+func (k Key) SDK() hedera.Key {
+	if k.PublicKey != nil {
+		return *k.PublicKey
+	}
+	var keyList *hedera.KeyList
+	if k.Threshold == len(k.Keys) {
+		keyList = hedera.NewKeyList()
+	} else {
+		keyList = hedera.KeyListWithThreshold(uint(k.Threshold))
+	}
+	for _, key := range k.Keys {
+		keyList.Add(key.SDK())
+	}
+	return keyList
+}
+
+// SatisfiedBy reports whether signatures by the signers are enough for the key.
+// A key list without keys, or without a threshold, such as the zero Key, is never satisfied.
+func (k Key) SatisfiedBy(signers []hedera.PublicKey) bool {
+	if k.PublicKey != nil {
+		for _, signer := range signers {
+			if signer.StringRaw() == k.PublicKey.StringRaw() {
+				return true
+			}
+		}
+		return false
+	}
+	if len(k.Keys) == 0 || k.Threshold < 1 {
+		return false
+	}
+	signed := 0
+	for _, key := range k.Keys {
+		if key.SatisfiedBy(signers) {
+			signed++
+		}
+	}
+	return signed >= k.Threshold
+}
+
+// Require returns an ErrNotSatisfied error unless the signers satisfy the key,
+// which is described by what, e.g. "the submit key of topic 0.0.1234"
+func (k Key) Require(what string, signers []hedera.PublicKey) error {
+	if k.SatisfiedBy(signers) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %s, which the keys signing the transaction do not satisfy", ErrNotSatisfied, what, k.String())
+}
+
+func (k Key) String() string {
+	if k.PublicKey != nil {
+		return k.PublicKey.StringRaw()
+	}
+	keys := make([]string, len(k.Keys))
+	for i, key := range k.Keys {
+		keys[i] = key.String()
+	}
+	if k.Threshold == len(k.Keys) {
+		return "[" + strings.Join(keys, ", ") + "]"
+	}
+	return fmt.Sprintf("%d of [%s]", k.Threshold, strings.Join(keys, ", "))
+}
+
+// PublicKeys returns the public keys of the private keys, e.g. of the signers of a transaction
+func PublicKeys(privateKeys ...hedera.PrivateKey) []hedera.PublicKey {
+	publicKeys := make([]hedera.PublicKey, len(privateKeys))
+	for i, privateKey := range privateKeys {
+		publicKeys[i] = privateKey.PublicKey()
+	}
+	return publicKeys
+}
+
+// FromMirror decodes a key returned by the mirror node, and returns nil for a nil key
+func FromMirror(key *mirror.Key) (*Key, error) {
+	if key == nil {
+		return nil, nil
+	}
+	data, err := hex.DecodeString(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	var result Key
+	switch key.Type {
+	case "ED25519":
+		publicKey, err := hedera.PublicKeyFromBytesEd25519(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		result = Single(publicKey)
+	case "ECDSA_SECP256K1":
+		publicKey, err := hedera.PublicKeyFromBytesECDSA(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		result = Single(publicKey)
+	case "ProtobufEncoded":
+		var pbKey services.Key
+		if err := proto.Unmarshal(data, &pbKey); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		result, err = fromProtobuf(&pbKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.validate(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown key type %q", ErrInvalidKey, key.Type)
+	}
+	return &result, nil
+}
+
+// fromProtobuf decodes public keys, key lists and threshold keys.
+// Other keys, such as contract IDs, cannot be checked locally.
+func fromProtobuf(pbKey *services.Key) (Key, error) {
+	switch k := pbKey.GetKey().(type) {
+	case *services.Key_Ed25519:
+		publicKey, err := hedera.PublicKeyFromBytesEd25519(k.Ed25519)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		return Single(publicKey), nil
+	case *services.Key_ECDSASecp256K1:
+		publicKey, err := hedera.PublicKeyFromBytesECDSA(k.ECDSASecp256K1)
+		if err != nil {
+			return Key{}, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		return Single(publicKey), nil
+	case *services.Key_KeyList:
+		keys, err := fromProtobufList(k.KeyList.GetKeys())
+		if err != nil {
+			return Key{}, err
+		}
+		return Threshold(len(keys), keys...), nil
+	case *services.Key_ThresholdKey:
+		keys, err := fromProtobufList(k.ThresholdKey.GetKeys().GetKeys())
+		if err != nil {
+			return Key{}, err
+		}
+		return Threshold(int(k.ThresholdKey.GetThreshold()), keys...), nil
+	}
+	return Key{}, fmt.Errorf("%w: unsupported key %T", ErrInvalidKey, pbKey.GetKey())
+}
+
+func fromProtobufList(pbKeys []*services.Key) ([]Key, error) {
+	keys := make([]Key, len(pbKeys))
+	for i, pbKey := range pbKeys {
+		key, err := fromProtobuf(pbKey)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,138 @@
+package keys
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/hashgraph/hedera-protobufs-go/services"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"google.golang.org/protobuf/proto"
+
+	"lib/mirror"
+)
+
+func generatePublicKeys(t *testing.T, n int) []hedera.PublicKey {
+	t.Helper()
+	publicKeys := make([]hedera.PublicKey, n)
+	for i := range publicKeys {
+		privateKey, err := hedera.PrivateKeyGenerateEd25519()
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKeys[i] = privateKey.PublicKey()
+	}
+	return publicKeys
+}
+
+func TestSatisfiedBy(t *testing.T) {
+	pk := generatePublicKeys(t, 3)
+	tests := []struct {
+		name    string
+		key     Key
+		signers []hedera.PublicKey
+		want    bool
+	}{
+		{"single key signed", Single(pk[0]), pk[:1], true},
+		{"single key not signed", Single(pk[0]), pk[1:], false},
+		{"key list signed by all", Threshold(2, Single(pk[0]), Single(pk[1])), pk[:2], true},
+		{"key list signed by one", Threshold(2, Single(pk[0]), Single(pk[1])), pk[:1], false},
+		{"1 of 2", Threshold(1, Single(pk[0]), Single(pk[1])), pk[1:2], true},
+		{"nested", Threshold(2, Single(pk[0]), Threshold(1, Single(pk[1]), Single(pk[2]))), []hedera.PublicKey{pk[0], pk[2]}, true},
+		{"zero key", Key{}, pk, false},
+		{"no keys", Threshold(1), pk, false},
+		{"zero threshold", Threshold(0, Single(pk[0])), pk, false},
+		{"nested zero key", Threshold(1, Key{}), pk, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.SatisfiedBy(tt.signers); got != tt.want {
+				t.Errorf("SatisfiedBy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	pk := generatePublicKeys(t, 3)
+	operatorKey := pk[0]
+	valid := map[string]string{
+		"operator":                        pk[0].StringRaw(),
+		pk[1].String():                    pk[1].StringRaw(),
+		"operator," + pk[1].String():      "[" + pk[0].StringRaw() + ", " + pk[1].StringRaw() + "]",
+		"1-of:operator," + pk[2].String(): "1 of [" + pk[0].StringRaw() + ", " + pk[2].StringRaw() + "]",
+	}
+	for spec, want := range valid {
+		key, err := Parse(spec, operatorKey)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", spec, err)
+			continue
+		}
+		if key.String() != want {
+			t.Errorf("Parse(%q) = %s, want %s", spec, key.String(), want)
+		}
+	}
+	for _, spec := range []string{"", ",", "operator,", "0-of:operator", "-1-of:operator", "3-of:operator,operator", "x-of:operator", "not a key"} {
+		if key, err := Parse(spec, operatorKey); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Parse(%q) = %v, %v, want ErrInvalidKey", spec, key, err)
+		}
+	}
+}
+
+func TestFromMirror(t *testing.T) {
+	pk := generatePublicKeys(t, 2)
+	encode := func(pbKey *services.Key) *mirror.Key {
+		t.Helper()
+		data, err := proto.Marshal(pbKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &mirror.Key{Type: "ProtobufEncoded", Key: hex.EncodeToString(data)}
+	}
+	ed25519 := func(publicKey hedera.PublicKey) *services.Key {
+		return &services.Key{Key: &services.Key_Ed25519{Ed25519: publicKey.BytesRaw()}}
+	}
+	keyList := func(keys ...*services.Key) *services.KeyList {
+		return &services.KeyList{Keys: keys}
+	}
+
+	key, err := FromMirror(encode(&services.Key{Key: &services.Key_ThresholdKey{ThresholdKey: &services.ThresholdKey{
+		Threshold: 1,
+		Keys:      keyList(ed25519(pk[0]), ed25519(pk[1])),
+	}}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.SatisfiedBy(pk[1:]) || key.SatisfiedBy(nil) {
+		t.Errorf("threshold key %s", key.String())
+	}
+	key, err = FromMirror(&mirror.Key{Type: "ED25519", Key: pk[0].StringRaw()})
+	if err != nil || !key.SatisfiedBy(pk[:1]) {
+		t.Errorf("FromMirror of an ED25519 key = %v, %v", key, err)
+	}
+	if key, err := FromMirror(nil); key != nil || err != nil {
+		t.Errorf("FromMirror(nil) = %v, %v", key, err)
+	}
+
+	for name, mirrorKey := range map[string]*mirror.Key{
+		"empty key list": encode(&services.Key{Key: &services.Key_KeyList{KeyList: keyList()}}),
+		"zero threshold": encode(&services.Key{Key: &services.Key_ThresholdKey{ThresholdKey: &services.ThresholdKey{
+			Threshold: 0,
+			Keys:      keyList(ed25519(pk[0])),
+		}}}),
+		"threshold above the keys": encode(&services.Key{Key: &services.Key_ThresholdKey{ThresholdKey: &services.ThresholdKey{
+			Threshold: 2,
+			Keys:      keyList(ed25519(pk[0])),
+		}}}),
+		"nested empty key list": encode(&services.Key{Key: &services.Key_KeyList{KeyList: keyList(
+			ed25519(pk[0]),
+			&services.Key{Key: &services.Key_KeyList{KeyList: keyList()}},
+		)}}),
+		"not hex":      {Type: "ED25519", Key: "xyz"},
+		"unknown type": {Type: "RSA_3072", Key: "abcd"},
+	} {
+		if key, err := FromMirror(mirrorKey); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("FromMirror of %s = %v, %v, want ErrInvalidKey", name, key, err)
+		}
+	}
+}
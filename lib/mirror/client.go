@@ -0,0 +1,161 @@
+// Package mirror is a client for the Hedera mirror node REST API.
+// Ref: https://docs.hedera.com/hedera/sdks-and-apis/rest-api
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// ErrNotFound matches an HTTPError for a 404 response,
+// e.g. when a transaction has not reached the mirror node yet.
+var ErrNotFound = errors.New("not found")
+
+// HTTPError is returned when the mirror node responds with a non-2xx status
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	// Messages are taken from the `_status` object of the response body, if any
+	Messages []string
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("mirror node GET %s: HTTP %d", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("mirror node GET %s: HTTP %d: %s", e.URL, e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == 404
+}
+
+// errorResponse is the body returned by the mirror node for failed requests
+type errorResponse struct {
+	Status struct {
+		Messages []struct {
+			Message string `json:"message"`
+		} `json:"messages"`
+	} `json:"_status"`
+}
+
+// Client queries a single mirror node
+type Client struct {
+	// baseURL is the scheme and host of the mirror node, without `/api/v1`,
+	// as the `links.next` URLs in responses are relative to it.
+	baseURL string
+	http    *req.Client
+}
+
+// NewClient returns a client for the mirror node at baseURL,
+// e.g. `https://testnet.mirrornode.hedera.com`
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    req.C(),
+	}
+}
+
+// BaseURL returns the mirror node base URL, without `/api/v1`
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// URL returns the full URL for a path relative to `/api/v1`
+func (c *Client) URL(path string, query url.Values) string {
+	u := c.baseURL + "/api/v1/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// GetTopicMessages returns one page of messages from `/topics/{id}/messages`
+func (c *Client) GetTopicMessages(ctx context.Context, topicID string, query url.Values) (*TopicMessagesResponse, error) {
+	var result TopicMessagesResponse
+	err := c.get(ctx, c.URL("topics/"+url.PathEscape(topicID)+"/messages", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTopic returns the topic from `/topics/{id}`
+func (c *Client) GetTopic(ctx context.Context, topicID string) (*Topic, error) {
+	var result Topic
+	err := c.get(ctx, c.URL("topics/"+url.PathEscape(topicID), nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetToken returns the token from `/tokens/{id}`
+func (c *Client) GetToken(ctx context.Context, tokenID string) (*Token, error) {
+	var result Token
+	err := c.get(ctx, c.URL("tokens/"+url.PathEscape(tokenID), nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTransaction returns the transactions from `/transactions/{id}`,
+// where the ID is in the mirror node format `0.0.x-s-n`.
+// A single transaction ID may match a parent transaction and its children.
+func (c *Client) GetTransaction(ctx context.Context, transactionID string, query url.Values) (*TransactionsResponse, error) {
+	var result TransactionsResponse
+	err := c.get(ctx, c.URL("transactions/"+url.PathEscape(transactionID), query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAccount returns the account from `/accounts/{idOrAliasOrEvmAddress}`
+func (c *Client) GetAccount(ctx context.Context, accountID string) (*Account, error) {
+	var result Account
+	err := c.get(ctx, c.URL("accounts/"+url.PathEscape(accountID), nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAccountTokens returns one page of token relationships from `/accounts/{id}/tokens`
+func (c *Client) GetAccountTokens(ctx context.Context, accountID string, query url.Values) (*TokenRelationshipsResponse, error) {
+	var result TokenRelationshipsResponse
+	err := c.get(ctx, c.URL("accounts/"+url.PathEscape(accountID)+"/tokens", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// get fetches fullURL and parses the JSON response body into result
+func (c *Client) get(ctx context.Context, fullURL string, result any) error {
+	httpResp, err := c.http.R().SetContext(ctx).Get(fullURL)
+	if err != nil {
+		return fmt.Errorf("mirror node GET %s: %w", fullURL, err)
+	}
+	if !httpResp.IsSuccessState() {
+		httpErr := &HTTPError{URL: fullURL, StatusCode: httpResp.GetStatusCode()}
+		var errResp errorResponse
+		if json.Unmarshal(httpResp.Bytes(), &errResp) == nil {
+			for _, msg := range errResp.Status.Messages {
+				httpErr.Messages = append(httpErr.Messages, msg.Message)
+			}
+		}
+		return httpErr
+	}
+	if err := json.Unmarshal(httpResp.Bytes(), result); err != nil {
+		return fmt.Errorf("mirror node GET %s: failed to parse JSON: %w", fullURL, err)
+	}
+	return nil
+}
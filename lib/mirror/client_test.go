@@ -0,0 +1,399 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves canned JSON bodies by request URI, and 404 for every other URI
+func newTestServer(t *testing.T, bodies map[string]string) (*Client, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		body, ok := bodies[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"_status":{"messages":[{"message":"Not found"}]}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL + "/"), &requested
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		uri  string
+		body string
+		get  func(c *Client) (string, error)
+		want string
+	}{
+		{
+			name: "GetTopicMessages",
+			uri:  "/api/v1/topics/0.0.1234/messages?limit=2",
+			body: `{"messages":[{"sequence_number":1,"message":"SGVsbG8="}],"links":{"next":null}}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetTopicMessages(ctx, "0.0.1234", url.Values{"limit": {"2"}})
+				if err != nil {
+					return "", err
+				}
+				contents, err := page.Messages[0].Contents()
+				return fmt.Sprintf("#%d %s", page.Messages[0].SequenceNumber, contents), err
+			},
+			want: "#1 Hello",
+		},
+		{
+			name: "GetTopicMessage",
+			uri:  "/api/v1/topics/0.0.1234/messages/7",
+			body: `{"sequence_number":7,"running_hash_version":3,"chunk_info":{"number":1,"total":2,"initial_transaction_id":{"account_id":"0.0.2","transaction_valid_start":"1712345678.000000001","nonce":0,"scheduled":false}}}`,
+			get: func(c *Client) (string, error) {
+				message, err := c.GetTopicMessage(ctx, "0.0.1234", 7)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("#%d %d/%d of %s", message.SequenceNumber, message.ChunkInfo.Number, message.ChunkInfo.Total, message.ChunkInfo.InitialTransactionID), nil
+			},
+			want: "#7 1/2 of 0.0.2@1712345678.000000001",
+		},
+		{
+			name: "GetTopic",
+			uri:  "/api/v1/topics/0.0.1234",
+			body: `{"topic_id":"0.0.1234","memo":"My topic","submit_key":{"_type":"ED25519","key":"abcd"}}`,
+			get: func(c *Client) (string, error) {
+				topic, err := c.GetTopic(ctx, "0.0.1234")
+				if err != nil {
+					return "", err
+				}
+				return topic.Memo + " " + topic.SubmitKey.Type, nil
+			},
+			want: "My topic ED25519",
+		},
+		{
+			name: "GetToken",
+			uri:  "/api/v1/tokens/0.0.5678",
+			body: `{"token_id":"0.0.5678","symbol":"MT","decimals":"2","total_supply":"1000050"}`,
+			get: func(c *Client) (string, error) {
+				token, err := c.GetToken(ctx, "0.0.5678")
+				if err != nil {
+					return "", err
+				}
+				return token.FormatAmount(int64(token.TotalSupply)) + " " + token.Symbol, nil
+			},
+			want: "10000.50 MT",
+		},
+		{
+			name: "GetTransaction",
+			uri:  "/api/v1/transactions/0.0.2-1712345678-000000001?nonce=1",
+			body: `{"transactions":[{"transaction_id":"0.0.2-1712345678-000000001","result":"SUCCESS","nonce":1}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetTransaction(ctx, "0.0.2-1712345678-000000001", url.Values{"nonce": {"1"}})
+				if err != nil {
+					return "", err
+				}
+				return page.Transactions[0].Result, nil
+			},
+			want: "SUCCESS",
+		},
+		{
+			name: "GetTransactions",
+			uri:  "/api/v1/transactions?account.id=0.0.2",
+			body: `{"transactions":[{"transaction_id":"0.0.2-1712345678-000000001","memo_base64":"bWVtbw=="}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetTransactions(ctx, url.Values{"account.id": {"0.0.2"}})
+				if err != nil {
+					return "", err
+				}
+				return page.Transactions[0].Memo()
+			},
+			want: "memo",
+		},
+		{
+			name: "GetAccount",
+			uri:  "/api/v1/accounts/0.0.2",
+			body: `{"account":"0.0.2","balance":{"balance":100,"tokens":[]},"key":{"_type":"ECDSA_SECP256K1","key":"02ab"}}`,
+			get: func(c *Client) (string, error) {
+				account, err := c.GetAccount(ctx, "0.0.2")
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s %d %s", account.Account, account.Balance.Balance, account.Key.Type), nil
+			},
+			want: "0.0.2 100 ECDSA_SECP256K1",
+		},
+		{
+			name: "GetAccountTokens",
+			uri:  "/api/v1/accounts/0.0.2/tokens",
+			body: `{"tokens":[{"token_id":"0.0.5678","balance":5,"kyc_status":"GRANTED"}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetAccountTokens(ctx, "0.0.2", nil)
+				if err != nil {
+					return "", err
+				}
+				return page.Tokens[0].TokenID + " " + page.Tokens[0].KycStatus, nil
+			},
+			want: "0.0.5678 GRANTED",
+		},
+		{
+			name: "GetTokenBalances",
+			uri:  "/api/v1/tokens/0.0.5678/balances",
+			body: `{"balances":[{"account":"0.0.2","balance":5}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetTokenBalances(ctx, "0.0.5678", nil)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s %d", page.Balances[0].Account, page.Balances[0].Balance), nil
+			},
+			want: "0.0.2 5",
+		},
+		{
+			name: "GetTokenNFTs",
+			uri:  "/api/v1/tokens/0.0.5678/nfts",
+			body: `{"nfts":[{"serial_number":3,"account_id":"0.0.2"}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetTokenNFTs(ctx, "0.0.5678", nil)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d %s", page.NFTs[0].SerialNumber, page.NFTs[0].AccountID), nil
+			},
+			want: "3 0.0.2",
+		},
+		{
+			name: "GetNFT",
+			uri:  "/api/v1/tokens/0.0.5678/nfts/3",
+			body: `{"serial_number":3,"metadata":"aXBmczovL2NpZA=="}`,
+			get: func(c *Client) (string, error) {
+				nft, err := c.GetNFT(ctx, "0.0.5678", 3)
+				if err != nil {
+					return "", err
+				}
+				metadata, err := nft.Contents()
+				return string(metadata), err
+			},
+			want: "ipfs://cid",
+		},
+		{
+			name: "GetContract",
+			uri:  "/api/v1/contracts/0.0.9",
+			body: `{"contract_id":"0.0.9","evm_address":"0x0000000000000000000000000000000000000009"}`,
+			get: func(c *Client) (string, error) {
+				contract, err := c.GetContract(ctx, "0.0.9")
+				if err != nil {
+					return "", err
+				}
+				return contract.EvmAddress, nil
+			},
+			want: "0x0000000000000000000000000000000000000009",
+		},
+		{
+			name: "GetContractResults",
+			uri:  "/api/v1/contracts/results?limit=1",
+			body: `{"results":[{"contract_id":"0.0.9","gas_used":21000}]}`,
+			get: func(c *Client) (string, error) {
+				page, err := c.GetContractResults(ctx, url.Values{"limit": {"1"}})
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s %d", page.Results[0].ContractID, page.Results[0].GasUsed), nil
+			},
+			want: "0.0.9 21000",
+		},
+		{
+			name: "GetContractResult",
+			uri:  "/api/v1/contracts/results/0.0.2-1712345678-000000001",
+			body: `{"contract_id":"0.0.9","status":"0x1","logs":[{"index":0,"topics":["0xab"]}]}`,
+			get: func(c *Client) (string, error) {
+				result, err := c.GetContractResult(ctx, "0.0.2-1712345678-000000001", nil)
+				if err != nil {
+					return "", err
+				}
+				return result.Status + " " + result.Logs[0].Topics[0], nil
+			},
+			want: "0x1 0xab",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, requested := newTestServer(t, map[string]string{tt.uri: tt.body})
+			got, err := tt.get(c)
+			if err != nil {
+				t.Fatalf("requested %v: %v", *requested, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantMessages []string
+		wantNotFound bool
+	}{
+		{
+			name:         "not found",
+			status:       http.StatusNotFound,
+			body:         `{"_status":{"messages":[{"message":"Not found"}]}}`,
+			wantMessages: []string{"Not found"},
+			wantNotFound: true,
+		},
+		{
+			name:         "bad request with several messages",
+			status:       http.StatusBadRequest,
+			body:         `{"_status":{"messages":[{"message":"Invalid parameter: limit"},{"message":"Invalid parameter: order"}]}}`,
+			wantMessages: []string{"Invalid parameter: limit", "Invalid parameter: order"},
+		},
+		{
+			name:         "server error",
+			status:       http.StatusServiceUnavailable,
+			body:         `{"_status":{"messages":[{"message":"Service Unavailable"}]}}`,
+			wantMessages: []string{"Service Unavailable"},
+		},
+		{
+			name:   "server error without a JSON body",
+			status: http.StatusBadGateway,
+			body:   `<html>Bad Gateway</html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL).GetTopic(context.Background(), "0.0.1234")
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("expected an HTTPError, got %v", err)
+			}
+			if httpErr.StatusCode != tt.status {
+				t.Errorf("status code %d, want %d", httpErr.StatusCode, tt.status)
+			}
+			if httpErr.URL != server.URL+"/api/v1/topics/0.0.1234" {
+				t.Errorf("URL %s", httpErr.URL)
+			}
+			if !slices.Equal(httpErr.Messages, tt.wantMessages) {
+				t.Errorf("messages %q, want %q", httpErr.Messages, tt.wantMessages)
+			}
+			for _, message := range tt.wantMessages {
+				if !strings.Contains(err.Error(), message) {
+					t.Errorf("error %q does not contain %q", err, message)
+				}
+			}
+			if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+				t.Errorf("errors.Is(err, ErrNotFound) = %v, want %v", !tt.wantNotFound, tt.wantNotFound)
+			}
+			if isRetryable(err) != (tt.wantNotFound || tt.status >= 500) {
+				t.Errorf("isRetryable(err) = %v", isRetryable(err))
+			}
+		})
+	}
+}
+
+func TestEachTopicMessage(t *testing.T) {
+	// The mirror node returns `links.next` relative to its host
+	c, requested := newTestServer(t, map[string]string{
+		"/api/v1/topics/0.0.1234/messages?limit=2&order=asc&sequencenumber=gte%3A1": `{
+			"messages":[{"sequence_number":1},{"sequence_number":2}],
+			"links":{"next":"/api/v1/topics/0.0.1234/messages?limit=2&order=asc&sequencenumber=gt:2"}}`,
+		"/api/v1/topics/0.0.1234/messages?limit=2&order=asc&sequencenumber=gt:2": `{
+			"messages":[{"sequence_number":3},{"sequence_number":4}],
+			"links":{"next":"/api/v1/topics/0.0.1234/messages?limit=2&order=asc&sequencenumber=gt:4"}}`,
+		"/api/v1/topics/0.0.1234/messages?limit=2&order=asc&sequencenumber=gt:4": `{
+			"messages":[{"sequence_number":5}],
+			"links":{"next":null}}`,
+	})
+	opts := ListOptions{Limit: 2, Order: OrderAsc, SequenceNumber: []Condition{Gte(1)}}
+
+	t.Run("every page", func(t *testing.T) {
+		*requested = nil
+		var got []int64
+		err := c.EachTopicMessage(context.Background(), "0.0.1234", opts, func(message TopicMessage) error {
+			got = append(got, message.SequenceNumber)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if len(*requested) != 3 {
+			t.Errorf("requested %d pages, want 3: %v", len(*requested), *requested)
+		}
+	})
+
+	t.Run("stop iteration", func(t *testing.T) {
+		*requested = nil
+		var got []int64
+		err := c.EachTopicMessage(context.Background(), "0.0.1234", opts, func(message TopicMessage) error {
+			got = append(got, message.SequenceNumber)
+			if message.SequenceNumber == 3 {
+				return ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ErrStopIteration should end the iteration without an error, got %v", err)
+		}
+		if want := []int64{1, 2, 3}; !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if len(*requested) != 2 {
+			t.Errorf("requested %d pages, want 2: %v", len(*requested), *requested)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		err := c.EachTopicMessage(context.Background(), "0.0.1234", opts, func(message TopicMessage) error {
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("got %v, want %v", err, errStop)
+		}
+	})
+
+	t.Run("missing page", func(t *testing.T) {
+		err := c.EachTopicMessage(context.Background(), "0.0.4321", opts, func(message TopicMessage) error {
+			return nil
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestResolve(t *testing.T) {
+	c := NewClient("https://testnet.mirrornode.hedera.com/")
+	tests := map[string]string{
+		"": "",
+		"/api/v1/transactions?timestamp=lt:1712345678.000000001":                                             "https://testnet.mirrornode.hedera.com/api/v1/transactions?timestamp=lt:1712345678.000000001",
+		"api/v1/transactions?timestamp=lt:1712345678.000000001":                                              "https://testnet.mirrornode.hedera.com/api/v1/transactions?timestamp=lt:1712345678.000000001",
+		"https://mainnet-public.mirrornode.hedera.com/api/v1/transactions?timestamp=lt:1712345678.000000001": "https://mainnet-public.mirrornode.hedera.com/api/v1/transactions?timestamp=lt:1712345678.000000001",
+	}
+	for next, want := range tests {
+		if got := c.resolve(next); got != want {
+			t.Errorf("resolve(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
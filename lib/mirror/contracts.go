@@ -0,0 +1,137 @@
+package mirror
+
+import (
+	"context"
+	"net/url"
+)
+
+// Contract is returned by `/contracts/{id}`
+type Contract struct {
+	ContractID       string `json:"contract_id"`
+	EvmAddress       string `json:"evm_address"`
+	FileID           string `json:"file_id"`
+	AdminKey         *Key   `json:"admin_key"`
+	Memo             string `json:"memo"`
+	Deleted          bool   `json:"deleted"`
+	CreatedTimestamp string `json:"created_timestamp"`
+	// Bytecode is the init code from the bytecode file, in hex
+	Bytecode string `json:"bytecode"`
+	// RuntimeBytecode is the code stored on chain after the constructor ran, in hex
+	RuntimeBytecode string `json:"runtime_bytecode"`
+}
+
+// ContractResultsResponse is returned by `/contracts/results` and `/contracts/{id}/results`
+type ContractResultsResponse struct {
+	Results []ContractResult `json:"results"`
+	Links   Links            `json:"links"`
+}
+
+// ContractResult is the outcome of a contract create or call transaction.
+// Logs are only included by `/contracts/results/{transactionIdOrHash}`.
+type ContractResult struct {
+	ContractID         string        `json:"contract_id"`
+	Address            string        `json:"address"`
+	From               string        `json:"from"`
+	To                 string        `json:"to"`
+	FunctionParameters string        `json:"function_parameters"`
+	CallResult         string        `json:"call_result"`
+	ErrorMessage       string        `json:"error_message"`
+	Result             string        `json:"result"`
+	Status             string        `json:"status"`
+	GasLimit           int64         `json:"gas_limit"`
+	GasUsed            int64         `json:"gas_used"`
+	Amount             int64         `json:"amount"`
+	Hash               string        `json:"hash"`
+	Timestamp          string        `json:"timestamp"`
+	CreatedContractIDs []string      `json:"created_contract_ids"`
+	Logs               []ContractLog `json:"logs"`
+}
+
+// ContractLog is an event emitted by a contract, with the data and topics in hex
+type ContractLog struct {
+	Address    string   `json:"address"`
+	ContractID string   `json:"contract_id"`
+	Data       string   `json:"data"`
+	Index      int      `json:"index"`
+	Topics     []string `json:"topics"`
+}
+
+// GetContract returns a contract from `/contracts/{id}`,
+// where the ID is either `0.0.x` or an EVM address
+func (c *Client) GetContract(ctx context.Context, contractIDOrAddress string) (*Contract, error) {
+	var result Contract
+	err := c.get(ctx, c.URL("contracts/"+url.PathEscape(contractIDOrAddress), nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetContractResults returns one page of results from `/contracts/results`
+func (c *Client) GetContractResults(ctx context.Context, query url.Values) (*ContractResultsResponse, error) {
+	var result ContractResultsResponse
+	err := c.get(ctx, c.URL("contracts/results", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetContractResult returns a single result from `/contracts/results/{transactionIdOrHash}`,
+// where the transaction ID is in the mirror node format `0.0.x-s-n`
+func (c *Client) GetContractResult(ctx context.Context, transactionIDOrHash string, query url.Values) (*ContractResult, error) {
+	var result ContractResult
+	err := c.get(ctx, c.URL("contracts/results/"+url.PathEscape(transactionIDOrHash), query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EachContractResult calls fn for every contract result that matches opts,
+// following `links.next` until the last page.
+func (c *Client) EachContractResult(ctx context.Context, opts ListOptions, fn func(ContractResult) error) error {
+	firstURL := c.URL("contracts/results", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *ContractResultsResponse) (string, error) {
+		for _, result := range page.Results {
+			if err := fn(result); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// WaitForContract polls `/contracts/{id}` until the contract is visible
+func (c *Client) WaitForContract(ctx context.Context, backoff Backoff, contractIDOrAddress string) (*Contract, error) {
+	var result *Contract
+	err := Poll(ctx, backoff, "contract "+contractIDOrAddress, func(ctx context.Context) (bool, error) {
+		contract, err := c.GetContract(ctx, contractIDOrAddress)
+		if err != nil {
+			return false, err
+		}
+		result = contract
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForContractResult polls `/contracts/results/{transactionIdOrHash}` until the result is visible
+func (c *Client) WaitForContractResult(ctx context.Context, backoff Backoff, transactionIDOrHash string, query url.Values) (*ContractResult, error) {
+	var result *ContractResult
+	err := Poll(ctx, backoff, "contract result "+transactionIDOrHash, func(ctx context.Context) (bool, error) {
+		contractResult, err := c.GetContractResult(ctx, transactionIDOrHash, query)
+		if err != nil {
+			return false, err
+		}
+		result = contractResult
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,368 @@
+package mirror
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Links holds the pagination cursor of list responses.
+// Next is empty on the last page.
+type Links struct {
+	Next string `json:"next"`
+}
+
+// TopicMessagesResponse is returned by `/topics/{id}/messages`
+type TopicMessagesResponse struct {
+	Messages []TopicMessage `json:"messages"`
+	Links    Links          `json:"links"`
+}
+
+// TopicMessage is a single HCS message
+type TopicMessage struct {
+	ChunkInfo          *ChunkInfo `json:"chunk_info"`
+	ConsensusTimestamp string     `json:"consensus_timestamp"`
+	// Message is base64 encoded, use Contents to decode it
+	Message            string `json:"message"`
+	PayerAccountID     string `json:"payer_account_id"`
+	RunningHash        string `json:"running_hash"`
+	RunningHashVersion int    `json:"running_hash_version"`
+	SequenceNumber     int64  `json:"sequence_number"`
+	TopicID            string `json:"topic_id"`
+}
+
+// Contents returns the decoded message bytes
+func (m *TopicMessage) Contents() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(m.Message)
+}
+
+// ChunkInfo identifies one chunk of a message that was split when submitted
+type ChunkInfo struct {
+	InitialTransactionID TransactionIDInfo `json:"initial_transaction_id"`
+	Number               int               `json:"number"`
+	Total                int               `json:"total"`
+}
+
+// TransactionIDInfo is the object form of a transaction ID
+type TransactionIDInfo struct {
+	AccountID             string `json:"account_id"`
+	Nonce                 int    `json:"nonce"`
+	Scheduled             bool   `json:"scheduled"`
+	TransactionValidStart string `json:"transaction_valid_start"`
+}
+
+// String returns the SDK form of the transaction ID, `0.0.x@s.n[?scheduled][/nonce]`
+func (t TransactionIDInfo) String() string {
+	s := t.AccountID + "@" + t.TransactionValidStart
+	if t.Scheduled {
+		s += "?scheduled"
+	}
+	if t.Nonce != 0 {
+		s += "/" + strconv.Itoa(t.Nonce)
+	}
+	return s
+}
+
+// Topic is returned by `/topics/{id}`
+type Topic struct {
+	TopicID string `json:"topic_id"`
+	Memo    string `json:"memo"`
+	// AdminKey is nil for topics that cannot be updated or deleted,
+	// and SubmitKey is nil for topics that anyone can submit messages to
+	AdminKey  *Key `json:"admin_key"`
+	SubmitKey *Key `json:"submit_key"`
+	// AutoRenewPeriod is in seconds
+	AutoRenewAccount string         `json:"auto_renew_account"`
+	AutoRenewPeriod  int64          `json:"auto_renew_period"`
+	CreatedTimestamp string         `json:"created_timestamp"`
+	Deleted          bool           `json:"deleted"`
+	Timestamp        TimestampRange `json:"timestamp"`
+}
+
+// TimestampRange is the period during which an entity had its current state.
+// To is empty for the current state.
+type TimestampRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Token is returned by `/tokens/{id}`
+type Token struct {
+	TokenID           string    `json:"token_id"`
+	Name              string    `json:"name"`
+	Symbol            string    `json:"symbol"`
+	Type              string    `json:"type"`
+	Decimals          StringInt `json:"decimals"`
+	InitialSupply     StringInt `json:"initial_supply"`
+	TotalSupply       StringInt `json:"total_supply"`
+	TreasuryAccountID string    `json:"treasury_account_id"`
+	Memo              string    `json:"memo"`
+	// SupplyType is FINITE, with a MaxSupply, or INFINITE
+	SupplyType    string    `json:"supply_type"`
+	MaxSupply     StringInt `json:"max_supply"`
+	FreezeDefault bool      `json:"freeze_default"`
+	// PauseStatus is PAUSED, UNPAUSED, or NOT_APPLICABLE for tokens without a pause key
+	PauseStatus string `json:"pause_status"`
+	Deleted     bool   `json:"deleted"`
+	// Each key is nil when the token does not have it
+	AdminKey       *Key `json:"admin_key"`
+	SupplyKey      *Key `json:"supply_key"`
+	FreezeKey      *Key `json:"freeze_key"`
+	KycKey         *Key `json:"kyc_key"`
+	WipeKey        *Key `json:"wipe_key"`
+	PauseKey       *Key `json:"pause_key"`
+	FeeScheduleKey *Key `json:"fee_schedule_key"`
+	MetadataKey    *Key `json:"metadata_key"`
+	// AutoRenewPeriod is in seconds
+	AutoRenewAccount  string `json:"auto_renew_account"`
+	AutoRenewPeriod   int64  `json:"auto_renew_period"`
+	CreatedTimestamp  string `json:"created_timestamp"`
+	ModifiedTimestamp string `json:"modified_timestamp"`
+	// ExpiryTimestamp is in nanoseconds since the epoch, unlike the other timestamps
+	ExpiryTimestamp int64       `json:"expiry_timestamp"`
+	CustomFees      *CustomFees `json:"custom_fees"`
+}
+
+// FormatAmount returns an amount in the smallest denomination of the token
+// in whole units of the token, e.g. 1000050 with 2 decimals is 10000.50
+func (t *Token) FormatAmount(amount int64) string {
+	s := strconv.FormatInt(amount, 10)
+	if t.Decimals <= 0 {
+		return s
+	}
+	sign := ""
+	if amount < 0 {
+		sign, s = "-", s[1:]
+	}
+	decimals := int(t.Decimals)
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	return sign + s[:len(s)-decimals] + "." + s[len(s)-decimals:]
+}
+
+// StringInt is an integer that the mirror node encodes as a JSON string, e.g. "1000000".
+// Plain JSON numbers are accepted too.
+type StringInt int64
+
+// UnmarshalJSON decodes a quoted or plain JSON integer, and null as zero
+func (n *StringInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*n = 0
+		return nil
+	}
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("decoding integer %s: %w", data, err)
+	}
+	*n = StringInt(value)
+	return nil
+}
+
+// ParseTimestamp parses a mirror node timestamp, in seconds and nanoseconds since the epoch,
+// e.g. 1712345678.123456789
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	secondsStr, nanosStr, _ := strings.Cut(timestamp, ".")
+	seconds, err := strconv.ParseInt(secondsStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing timestamp %q: %w", timestamp, err)
+	}
+	nanos := int64(0)
+	if nanosStr != "" {
+		nanos, err = strconv.ParseInt((nanosStr + "000000000")[:9], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing timestamp %q: %w", timestamp, err)
+		}
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// CustomFees is the fee schedule of a token, as at CreatedTimestamp
+type CustomFees struct {
+	CreatedTimestamp string          `json:"created_timestamp"`
+	FixedFees        []FixedFee      `json:"fixed_fees"`
+	FractionalFees   []FractionalFee `json:"fractional_fees"`
+	RoyaltyFees      []RoyaltyFee    `json:"royalty_fees"`
+}
+
+// FixedFee is a fixed amount per transfer,
+// in tinybars when DenominatingTokenID is empty, otherwise in the smallest denomination of that token
+type FixedFee struct {
+	AllCollectorsAreExempt bool   `json:"all_collectors_are_exempt"`
+	Amount                 int64  `json:"amount"`
+	CollectorAccountID     string `json:"collector_account_id"`
+	DenominatingTokenID    string `json:"denominating_token_id"`
+}
+
+// FractionalFee is a fraction of each transfer of a fungible token, between Minimum and Maximum.
+// Maximum is nil when there is no maximum.
+type FractionalFee struct {
+	AllCollectorsAreExempt bool     `json:"all_collectors_are_exempt"`
+	Amount                 Fraction `json:"amount"`
+	CollectorAccountID     string   `json:"collector_account_id"`
+	DenominatingTokenID    string   `json:"denominating_token_id"`
+	Maximum                *int64   `json:"maximum"`
+	Minimum                int64    `json:"minimum"`
+	NetOfTransfers         bool     `json:"net_of_transfers"`
+}
+
+// RoyaltyFee is a fraction of the value exchanged for an NFT,
+// or the fallback fee, charged to the receiver, when no value is exchanged
+type RoyaltyFee struct {
+	AllCollectorsAreExempt bool         `json:"all_collectors_are_exempt"`
+	Amount                 Fraction     `json:"amount"`
+	CollectorAccountID     string       `json:"collector_account_id"`
+	FallbackFee            *FallbackFee `json:"fallback_fee"`
+}
+
+// FallbackFee is the fixed fee of a royalty fee
+type FallbackFee struct {
+	Amount              int64  `json:"amount"`
+	DenominatingTokenID string `json:"denominating_token_id"`
+}
+
+// Fraction is the amount of fractional and royalty fees
+type Fraction struct {
+	Numerator   int64 `json:"numerator"`
+	Denominator int64 `json:"denominator"`
+}
+
+// TransactionsResponse is returned by `/transactions` and `/transactions/{id}`
+type TransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Links        Links         `json:"links"`
+}
+
+// Transaction is a single transaction record
+type Transaction struct {
+	TransactionID       string          `json:"transaction_id"`
+	ConsensusTimestamp  string          `json:"consensus_timestamp"`
+	ValidStartTimestamp string          `json:"valid_start_timestamp"`
+	Name                string          `json:"name"`
+	Result              string          `json:"result"`
+	ChargedTxFee        int64           `json:"charged_tx_fee"`
+	MaxFee              string          `json:"max_fee"`
+	MemoBase64          string          `json:"memo_base64"`
+	EntityID            string          `json:"entity_id"`
+	Node                string          `json:"node"`
+	Nonce               int             `json:"nonce"`
+	Scheduled           bool            `json:"scheduled"`
+	Transfers           []Transfer      `json:"transfers"`
+	TokenTransfers      []TokenTransfer `json:"token_transfers"`
+}
+
+// Memo returns the decoded transaction memo
+func (t *Transaction) Memo() (string, error) {
+	memo, err := base64.StdEncoding.DecodeString(t.MemoBase64)
+	return string(memo), err
+}
+
+// Transfer is an HBAR transfer, with the amount in tinybars
+type Transfer struct {
+	Account    string `json:"account"`
+	Amount     int64  `json:"amount"`
+	IsApproval bool   `json:"is_approval"`
+}
+
+// TokenTransfer is a fungible token transfer, with the amount in the smallest denomination
+type TokenTransfer struct {
+	TokenID    string `json:"token_id"`
+	Account    string `json:"account"`
+	Amount     int64  `json:"amount"`
+	IsApproval bool   `json:"is_approval"`
+}
+
+// Account is returned by `/accounts/{id}`
+type Account struct {
+	Account          string         `json:"account"`
+	Alias            string         `json:"alias"`
+	EvmAddress       string         `json:"evm_address"`
+	Balance          AccountBalance `json:"balance"`
+	Key              *Key           `json:"key"`
+	Memo             string         `json:"memo"`
+	Deleted          bool           `json:"deleted"`
+	CreatedTimestamp string         `json:"created_timestamp"`
+	ExpiryTimestamp  string         `json:"expiry_timestamp"`
+	// Transactions holds the most recent transactions of the account
+	Transactions []Transaction `json:"transactions"`
+	Links        Links         `json:"links"`
+}
+
+// AccountBalance is the balance of an account as at Timestamp
+type AccountBalance struct {
+	Balance   int64          `json:"balance"`
+	Timestamp string         `json:"timestamp"`
+	Tokens    []TokenBalance `json:"tokens"`
+}
+
+// TokenBalance is the balance of a single token held by an account
+type TokenBalance struct {
+	TokenID string `json:"token_id"`
+	Balance int64  `json:"balance"`
+}
+
+// Key is an ED25519 or ECDSA_SECP256K1 public key in hex,
+// or a ProtobufEncoded key list or threshold key in hex
+type Key struct {
+	Type string `json:"_type"`
+	Key  string `json:"key"`
+}
+
+// TokenRelationshipsResponse is returned by `/accounts/{id}/tokens`
+type TokenRelationshipsResponse struct {
+	Tokens []TokenRelationship `json:"tokens"`
+	Links  Links               `json:"links"`
+}
+
+// TokenRelationship is an account's association with a token
+type TokenRelationship struct {
+	TokenID              string `json:"token_id"`
+	Balance              int64  `json:"balance"`
+	Decimals             int64  `json:"decimals"`
+	AutomaticAssociation bool   `json:"automatic_association"`
+	FreezeStatus         string `json:"freeze_status"`
+	KycStatus            string `json:"kyc_status"`
+	CreatedTimestamp     string `json:"created_timestamp"`
+}
+
+// TokenBalancesResponse is returned by `/tokens/{id}/balances`
+type TokenBalancesResponse struct {
+	Timestamp string                `json:"timestamp"`
+	Balances  []AccountTokenBalance `json:"balances"`
+	Links     Links                 `json:"links"`
+}
+
+// AccountTokenBalance is the balance of a token held by a single account
+type AccountTokenBalance struct {
+	Account  string `json:"account"`
+	Balance  int64  `json:"balance"`
+	Decimals int64  `json:"decimals"`
+}
+
+// NFTsResponse is returned by `/tokens/{id}/nfts` and `/accounts/{id}/nfts`
+type NFTsResponse struct {
+	NFTs  []NFT `json:"nfts"`
+	Links Links `json:"links"`
+}
+
+// NFT is a single serial of a non-fungible token
+type NFT struct {
+	TokenID      string `json:"token_id"`
+	SerialNumber int64  `json:"serial_number"`
+	// AccountID is the owner of the serial
+	AccountID string `json:"account_id"`
+	// Metadata is base64 encoded, use Contents to decode it
+	Metadata          string `json:"metadata"`
+	Deleted           bool   `json:"deleted"`
+	Spender           string `json:"spender"`
+	CreatedTimestamp  string `json:"created_timestamp"`
+	ModifiedTimestamp string `json:"modified_timestamp"`
+}
+
+// Contents returns the decoded metadata bytes, usually a URI
+func (n *NFT) Contents() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(n.Metadata)
+}
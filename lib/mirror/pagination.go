@@ -0,0 +1,230 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrStopIteration can be returned by the callback of any Each method
+// to stop walking the remaining pages early, without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// Order of the results of a list endpoint
+type Order string
+
+const (
+	OrderAsc  Order = "asc"
+	OrderDesc Order = "desc"
+)
+
+// Operator compares a query parameter against a value
+type Operator string
+
+const (
+	OpEq  Operator = "eq"
+	OpNe  Operator = "ne"
+	OpLt  Operator = "lt"
+	OpLte Operator = "lte"
+	OpGt  Operator = "gt"
+	OpGte Operator = "gte"
+)
+
+// Condition is a single filter, e.g. `sequencenumber=gte:5`
+type Condition struct {
+	Op    Operator
+	Value string
+}
+
+func (c Condition) String() string {
+	if c.Op == "" {
+		return c.Value
+	}
+	return string(c.Op) + ":" + c.Value
+}
+
+func Eq(value any) Condition  { return Condition{OpEq, fmt.Sprint(value)} }
+func Ne(value any) Condition  { return Condition{OpNe, fmt.Sprint(value)} }
+func Lt(value any) Condition  { return Condition{OpLt, fmt.Sprint(value)} }
+func Lte(value any) Condition { return Condition{OpLte, fmt.Sprint(value)} }
+func Gt(value any) Condition  { return Condition{OpGt, fmt.Sprint(value)} }
+func Gte(value any) Condition { return Condition{OpGte, fmt.Sprint(value)} }
+
+// ListOptions filters and orders the results of a list endpoint.
+// The zero value returns everything, in the default order of the endpoint.
+// Filters that an endpoint does not support are rejected by the mirror node.
+type ListOptions struct {
+	// Limit is the page size, the mirror node caps this at 100
+	Limit int
+	Order Order
+	// SequenceNumber filters topic messages
+	SequenceNumber []Condition
+	// Timestamp filters by consensus timestamp, as `seconds.nanoseconds`
+	Timestamp []Condition
+	// AccountID filters transactions and token balances
+	AccountID []Condition
+	// TransactionType filters transactions, e.g. `CRYPTOTRANSFER`
+	TransactionType string
+	// Extra holds any other query parameters
+	Extra url.Values
+}
+
+// Query returns the query parameters for these options
+func (o ListOptions) Query() url.Values {
+	query := url.Values{}
+	for key, values := range o.Extra {
+		query[key] = append([]string(nil), values...)
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Order != "" {
+		query.Set("order", string(o.Order))
+	}
+	addConditions(query, "sequencenumber", o.SequenceNumber)
+	addConditions(query, "timestamp", o.Timestamp)
+	addConditions(query, "account.id", o.AccountID)
+	if o.TransactionType != "" {
+		query.Set("transactiontype", o.TransactionType)
+	}
+	return query
+}
+
+func addConditions(query url.Values, key string, conditions []Condition) {
+	for _, condition := range conditions {
+		query.Add(key, condition.String())
+	}
+}
+
+// GetTransactions returns one page of transactions from `/transactions`
+func (c *Client) GetTransactions(ctx context.Context, query url.Values) (*TransactionsResponse, error) {
+	var result TransactionsResponse
+	err := c.get(ctx, c.URL("transactions", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTokenBalances returns one page of balances from `/tokens/{id}/balances`
+func (c *Client) GetTokenBalances(ctx context.Context, tokenID string, query url.Values) (*TokenBalancesResponse, error) {
+	var result TokenBalancesResponse
+	err := c.get(ctx, c.URL("tokens/"+url.PathEscape(tokenID)+"/balances", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTokenNFTs returns one page of serials from `/tokens/{id}/nfts`
+func (c *Client) GetTokenNFTs(ctx context.Context, tokenID string, query url.Values) (*NFTsResponse, error) {
+	var result NFTsResponse
+	err := c.get(ctx, c.URL("tokens/"+url.PathEscape(tokenID)+"/nfts", query), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EachTopicMessage calls fn for every message of a topic that matches opts,
+// following `links.next` until the last page.
+func (c *Client) EachTopicMessage(ctx context.Context, topicID string, opts ListOptions, fn func(TopicMessage) error) error {
+	firstURL := c.URL("topics/"+url.PathEscape(topicID)+"/messages", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *TopicMessagesResponse) (string, error) {
+		for _, message := range page.Messages {
+			if err := fn(message); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// EachTransaction calls fn for every transaction that matches opts,
+// following `links.next` until the last page.
+// Use opts.AccountID to walk the history of a single account.
+func (c *Client) EachTransaction(ctx context.Context, opts ListOptions, fn func(Transaction) error) error {
+	firstURL := c.URL("transactions", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *TransactionsResponse) (string, error) {
+		for _, transaction := range page.Transactions {
+			if err := fn(transaction); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// EachTokenBalance calls fn for every account balance of a token that matches opts,
+// following `links.next` until the last page.
+func (c *Client) EachTokenBalance(ctx context.Context, tokenID string, opts ListOptions, fn func(AccountTokenBalance) error) error {
+	firstURL := c.URL("tokens/"+url.PathEscape(tokenID)+"/balances", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *TokenBalancesResponse) (string, error) {
+		for _, balance := range page.Balances {
+			if err := fn(balance); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// EachAccountToken calls fn for every token relationship of an account that matches opts,
+// following `links.next` until the last page.
+func (c *Client) EachAccountToken(ctx context.Context, accountID string, opts ListOptions, fn func(TokenRelationship) error) error {
+	firstURL := c.URL("accounts/"+url.PathEscape(accountID)+"/tokens", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *TokenRelationshipsResponse) (string, error) {
+		for _, token := range page.Tokens {
+			if err := fn(token); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// EachTokenNFT calls fn for every serial of a token that matches opts,
+// following `links.next` until the last page.
+// The mirror node lists serials in descending order by default.
+func (c *Client) EachTokenNFT(ctx context.Context, tokenID string, opts ListOptions, fn func(NFT) error) error {
+	firstURL := c.URL("tokens/"+url.PathEscape(tokenID)+"/nfts", opts.Query())
+	return eachPage(ctx, c, firstURL, func(page *NFTsResponse) (string, error) {
+		for _, nft := range page.NFTs {
+			if err := fn(nft); err != nil {
+				return "", err
+			}
+		}
+		return page.Links.Next, nil
+	})
+}
+
+// eachPage fetches pageURL, passes the parsed page to fn,
+// then repeats with the next page URL that fn returns, until it is empty.
+func eachPage[R any](ctx context.Context, c *Client, pageURL string, fn func(*R) (string, error)) error {
+	for pageURL != "" {
+		var page R
+		if err := c.get(ctx, pageURL, &page); err != nil {
+			return err
+		}
+		next, err := fn(&page)
+		if errors.Is(err, ErrStopIteration) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		pageURL = c.resolve(next)
+	}
+	return nil
+}
+
+// resolve turns a `links.next` value, which is relative to the mirror node host, into a full URL
+func (c *Client) resolve(next string) string {
+	if next == "" || strings.HasPrefix(next, "http://") || strings.HasPrefix(next, "https://") {
+		return next
+	}
+	return c.baseURL + "/" + strings.TrimPrefix(next, "/")
+}
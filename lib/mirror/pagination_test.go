@@ -0,0 +1,160 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestListOptionsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+		want string
+	}{
+		{"none", ListOptions{}, ""},
+		{"time range", ListOptions{Timestamp: []Condition{Gte("1712345678.000000001"), Lt("1712345679")}},
+			"timestamp=gte%3A1712345678.000000001&timestamp=lt%3A1712345679"},
+		{"sequence number range", ListOptions{Limit: 25, Order: OrderAsc, SequenceNumber: []Condition{Gt(1), Lte(10)}},
+			"limit=25&order=asc&sequencenumber=gt%3A1&sequencenumber=lte%3A10"},
+		{"account history", ListOptions{Order: OrderDesc, AccountID: []Condition{Eq("0.0.1001")}, TransactionType: "CRYPTOTRANSFER"},
+			"account.id=eq%3A0.0.1001&order=desc&transactiontype=CRYPTOTRANSFER"},
+		{"several accounts", ListOptions{AccountID: []Condition{Gte("0.0.1001"), Ne("0.0.1002"), {Value: "0.0.1003"}}},
+			"account.id=gte%3A0.0.1001&account.id=ne%3A0.0.1002&account.id=0.0.1003"},
+		{"extra", ListOptions{Limit: 5, Extra: url.Values{"type": {"credit"}, "limit": {"100"}}},
+			"limit=5&type=credit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Query().Encode(); got != tt.want {
+				t.Errorf("Query = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// The query is a copy, that can be changed without changing Extra
+	opts := ListOptions{Extra: url.Values{"type": {"credit"}}}
+	opts.Query().Add("type", "debit")
+	if got := opts.Extra["type"]; !slices.Equal(got, []string{"credit"}) {
+		t.Errorf("Extra = %v after changing the query, want [credit]", got)
+	}
+}
+
+func TestEach(t *testing.T) {
+	ctx := context.Background()
+	// Each endpoint is listed over two pages of two and one results,
+	// the second of which is requested through `links.next`
+	tests := []struct {
+		name   string
+		bodies map[string]string
+		each   func(c *Client, fn func(string) error) error
+		want   []string
+	}{
+		{
+			name: "EachTransaction",
+			bodies: map[string]string{
+				"/api/v1/transactions?account.id=0.0.1001&limit=2": `{
+					"transactions":[{"transaction_id":"0.0.1001-1712345678-000000001"},{"transaction_id":"0.0.1001-1712345678-000000002"}],
+					"links":{"next":"/api/v1/transactions?account.id=0.0.1001&limit=2&timestamp=lt:1712345678.000000002"}}`,
+				"/api/v1/transactions?account.id=0.0.1001&limit=2&timestamp=lt:1712345678.000000002": `{
+					"transactions":[{"transaction_id":"0.0.1001-1712345678-000000003"}],
+					"links":{"next":null}}`,
+			},
+			each: func(c *Client, fn func(string) error) error {
+				opts := ListOptions{Limit: 2, AccountID: []Condition{{Value: "0.0.1001"}}}
+				return c.EachTransaction(ctx, opts, func(transaction Transaction) error { return fn(transaction.TransactionID) })
+			},
+			want: []string{"0.0.1001-1712345678-000000001", "0.0.1001-1712345678-000000002", "0.0.1001-1712345678-000000003"},
+		},
+		{
+			name: "EachTokenBalance",
+			bodies: map[string]string{
+				"/api/v1/tokens/0.0.5005/balances?limit=2": `{
+					"balances":[{"account":"0.0.1001","balance":10},{"account":"0.0.1002","balance":20}],
+					"links":{"next":"/api/v1/tokens/0.0.5005/balances?limit=2&account.id=lt:0.0.1002"}}`,
+				"/api/v1/tokens/0.0.5005/balances?limit=2&account.id=lt:0.0.1002": `{
+					"balances":[{"account":"0.0.1003","balance":30}],
+					"links":{"next":null}}`,
+			},
+			each: func(c *Client, fn func(string) error) error {
+				return c.EachTokenBalance(ctx, "0.0.5005", ListOptions{Limit: 2}, func(balance AccountTokenBalance) error {
+					return fn(fmt.Sprintf("%s %d", balance.Account, balance.Balance))
+				})
+			},
+			want: []string{"0.0.1001 10", "0.0.1002 20", "0.0.1003 30"},
+		},
+		{
+			name: "EachAccountToken",
+			bodies: map[string]string{
+				"/api/v1/accounts/0.0.1001/tokens?limit=2": `{
+					"tokens":[{"token_id":"0.0.5005","balance":10},{"token_id":"0.0.5006","balance":0}],
+					"links":{"next":"/api/v1/accounts/0.0.1001/tokens?limit=2&token.id=gt:0.0.5006"}}`,
+				"/api/v1/accounts/0.0.1001/tokens?limit=2&token.id=gt:0.0.5006": `{
+					"tokens":[{"token_id":"0.0.5007","balance":1}],
+					"links":{"next":null}}`,
+			},
+			each: func(c *Client, fn func(string) error) error {
+				return c.EachAccountToken(ctx, "0.0.1001", ListOptions{Limit: 2}, func(token TokenRelationship) error {
+					return fn(fmt.Sprintf("%s %d", token.TokenID, token.Balance))
+				})
+			},
+			want: []string{"0.0.5005 10", "0.0.5006 0", "0.0.5007 1"},
+		},
+		{
+			name: "EachTokenNFT",
+			bodies: map[string]string{
+				"/api/v1/tokens/0.0.5005/nfts?limit=2": `{
+					"nfts":[{"serial_number":3,"account_id":"0.0.1001"},{"serial_number":2,"account_id":"0.0.1002"}],
+					"links":{"next":"/api/v1/tokens/0.0.5005/nfts?limit=2&serialnumber=lt:2"}}`,
+				"/api/v1/tokens/0.0.5005/nfts?limit=2&serialnumber=lt:2": `{
+					"nfts":[{"serial_number":1,"account_id":"0.0.1001"}],
+					"links":{"next":null}}`,
+			},
+			each: func(c *Client, fn func(string) error) error {
+				return c.EachTokenNFT(ctx, "0.0.5005", ListOptions{Limit: 2}, func(nft NFT) error {
+					return fn(fmt.Sprintf("#%d %s", nft.SerialNumber, nft.AccountID))
+				})
+			},
+			want: []string{"#3 0.0.1001", "#2 0.0.1002", "#1 0.0.1001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, requested := newTestServer(t, tt.bodies)
+
+			var got []string
+			err := tt.each(c, func(item string) error {
+				got = append(got, item)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(*requested) != 2 {
+				t.Errorf("requested %d pages, want 2: %v", len(*requested), *requested)
+			}
+
+			// Stopping on the last result of the first page leaves the second page unrequested
+			*requested = nil
+			got = nil
+			err = tt.each(c, func(item string) error {
+				got = append(got, item)
+				if len(got) == 2 {
+					return ErrStopIteration
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ErrStopIteration should end the iteration without an error, got %v", err)
+			}
+			if !slices.Equal(got, tt.want[:2]) || len(*requested) != 1 {
+				t.Errorf("got %q from %d pages, want %q from 1 page", got, len(*requested), tt.want[:2])
+			}
+		})
+	}
+}
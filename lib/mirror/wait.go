@@ -0,0 +1,295 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ErrNotYetPropagated matches a PropagationError
+var ErrNotYetPropagated = errors.New("not yet propagated to the mirror node")
+
+// PropagationError is returned when an entity or transaction did not become
+// visible on the mirror node before the deadline.
+type PropagationError struct {
+	// What describes the entity being waited for, e.g. `transaction 0.0.2-1-2`
+	What     string
+	Waited   time.Duration
+	Attempts int
+	// Err is the error from the last attempt, usually a 404 HTTPError
+	Err error
+}
+
+func (e *PropagationError) Error() string {
+	return fmt.Sprintf("%s %s after %s (%d attempts): %v", e.What, ErrNotYetPropagated, e.Waited.Round(time.Millisecond), e.Attempts, e.Err)
+}
+
+func (e *PropagationError) Is(target error) bool {
+	return target == ErrNotYetPropagated
+}
+
+func (e *PropagationError) Unwrap() error {
+	return e.Err
+}
+
+// Backoff controls how often, and for how long, the mirror node is polled.
+// Zero fields take their value from DefaultBackoff.
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+	// Max caps the delay between retries
+	Max time.Duration
+	// Multiplier is applied to the delay after each retry
+	Multiplier float64
+	// Timeout is the total time to wait, before giving up
+	Timeout time.Duration
+}
+
+// DefaultBackoff usually sees a new record file within a few seconds,
+// replacing the fixed 6 second sleep that the scripts used to have.
+var DefaultBackoff = Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        4 * time.Second,
+	Multiplier: 1.5,
+	Timeout:    30 * time.Second,
+}
+
+func (b Backoff) withDefaults() Backoff {
+	if b.Initial <= 0 {
+		b.Initial = DefaultBackoff.Initial
+	}
+	if b.Max <= 0 {
+		b.Max = DefaultBackoff.Max
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	if b.Timeout <= 0 {
+		b.Timeout = DefaultBackoff.Timeout
+	}
+	return b
+}
+
+// Poll calls check until it reports done, returns a permanent error,
+// or the backoff timeout elapses.
+// A 404 or 5xx HTTPError from check is treated as "not visible yet",
+// as are network errors, e.g. while a local mirror node is starting.
+// If ctx is cancelled, its error is returned instead of a PropagationError.
+func Poll(ctx context.Context, backoff Backoff, what string, check func(context.Context) (bool, error)) error {
+	backoff = backoff.withDefaults()
+	start := time.Now()
+	deadline, cancel := context.WithTimeout(ctx, backoff.Timeout)
+	defer cancel()
+
+	delay := backoff.Initial
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		done, err := check(deadline)
+		if done && err == nil {
+			return nil
+		}
+		if err != nil && !isRetryable(err) {
+			return err
+		}
+		if err == nil {
+			err = ErrNotFound
+		}
+		// An attempt that timed out, e.g. cut short by the deadline,
+		// says less than the one before it
+		if lastErr == nil || !isTimeout(err) {
+			lastErr = err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-deadline.Done():
+			timer.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &PropagationError{What: what, Waited: time.Since(start), Attempts: attempt, Err: lastErr}
+		case <-timer.C:
+		}
+		delay = min(time.Duration(float64(delay)*backoff.Multiplier), backoff.Max)
+	}
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrNotFound) || isTimeout(err) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	// Failing to connect, or losing the connection, is transient,
+	// but not a malformed URL, which is also a net.Error
+	var opErr *net.OpError
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &opErr)
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// GetTopicMessage returns a single message from `/topics/{id}/messages/{sequenceNumber}`
+func (c *Client) GetTopicMessage(ctx context.Context, topicID string, sequenceNumber uint64) (*TopicMessage, error) {
+	var result TopicMessage
+	path := "topics/" + url.PathEscape(topicID) + "/messages/" + strconv.FormatUint(sequenceNumber, 10)
+	err := c.get(ctx, c.URL(path, nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WaitForTransaction polls `/transactions/{id}` until the transaction is visible,
+// where the ID is in the mirror node format `0.0.x-s-n`.
+func (c *Client) WaitForTransaction(ctx context.Context, backoff Backoff, transactionID string, query url.Values) (*TransactionsResponse, error) {
+	var result *TransactionsResponse
+	err := Poll(ctx, backoff, "transaction "+transactionID, func(ctx context.Context) (bool, error) {
+		resp, err := c.GetTransaction(ctx, transactionID, query)
+		if err != nil {
+			return false, err
+		}
+		result = resp
+		return len(resp.Transactions) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForTopicMessage polls until the message with the given sequence number is visible
+func (c *Client) WaitForTopicMessage(ctx context.Context, backoff Backoff, topicID string, sequenceNumber uint64) (*TopicMessage, error) {
+	var result *TopicMessage
+	what := fmt.Sprintf("topic %s message #%d", topicID, sequenceNumber)
+	err := Poll(ctx, backoff, what, func(ctx context.Context) (bool, error) {
+		message, err := c.GetTopicMessage(ctx, topicID, sequenceNumber)
+		if err != nil {
+			return false, err
+		}
+		result = message
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForTopicChange polls `/topics/{id}` until done reports that the topic
+// reflects a change, e.g. a new memo after an update.
+// Use a done function that always returns true to wait until the topic is visible.
+func (c *Client) WaitForTopicChange(ctx context.Context, backoff Backoff, topicID string, done func(*Topic) bool) (*Topic, error) {
+	var result *Topic
+	err := Poll(ctx, backoff, "topic "+topicID+" change", func(ctx context.Context) (bool, error) {
+		topic, err := c.GetTopic(ctx, topicID)
+		if err != nil {
+			return false, err
+		}
+		result = topic
+		return done(topic), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForToken polls `/tokens/{id}` until the token is visible
+func (c *Client) WaitForToken(ctx context.Context, backoff Backoff, tokenID string) (*Token, error) {
+	var result *Token
+	err := Poll(ctx, backoff, "token "+tokenID, func(ctx context.Context) (bool, error) {
+		token, err := c.GetToken(ctx, tokenID)
+		if err != nil {
+			return false, err
+		}
+		result = token
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForTokenChange polls `/tokens/{id}` until done reports that the token
+// reflects a change, e.g. a new total supply after a mint
+func (c *Client) WaitForTokenChange(ctx context.Context, backoff Backoff, tokenID string, done func(*Token) bool) (*Token, error) {
+	var result *Token
+	err := Poll(ctx, backoff, "token "+tokenID+" change", func(ctx context.Context) (bool, error) {
+		token, err := c.GetToken(ctx, tokenID)
+		if err != nil {
+			return false, err
+		}
+		result = token
+		return done(token), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WaitForTokenRelationship polls `/accounts/{id}/tokens` until done reports that
+// the account's relationship with the token reflects a change, e.g. a new balance.
+// done is called with nil while the account is not associated with the token.
+func (c *Client) WaitForTokenRelationship(ctx context.Context, backoff Backoff, accountID string, tokenID string, done func(*TokenRelationship) bool) (*TokenRelationship, error) {
+	var result *TokenRelationship
+	what := fmt.Sprintf("account %s token %s relationship change", accountID, tokenID)
+	err := Poll(ctx, backoff, what, func(ctx context.Context) (bool, error) {
+		resp, err := c.GetAccountTokens(ctx, accountID, url.Values{"token.id": {tokenID}})
+		if err != nil {
+			return false, err
+		}
+		result = nil
+		for i := range resp.Tokens {
+			if resp.Tokens[i].TokenID == tokenID {
+				result = &resp.Tokens[i]
+			}
+		}
+		return done(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetNFT returns a single serial from `/tokens/{id}/nfts/{serialNumber}`
+func (c *Client) GetNFT(ctx context.Context, tokenID string, serialNumber int64) (*NFT, error) {
+	var result NFT
+	path := "tokens/" + url.PathEscape(tokenID) + "/nfts/" + strconv.FormatInt(serialNumber, 10)
+	err := c.get(ctx, c.URL(path, nil), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WaitForNFTOwner polls `/tokens/{id}/nfts/{serialNumber}` until the serial is owned by accountID,
+// e.g. after a transfer
+func (c *Client) WaitForNFTOwner(ctx context.Context, backoff Backoff, tokenID string, serialNumber int64, accountID string) (*NFT, error) {
+	var result *NFT
+	what := fmt.Sprintf("token %s serial #%d owned by %s", tokenID, serialNumber, accountID)
+	err := Poll(ctx, backoff, what, func(ctx context.Context) (bool, error) {
+		nft, err := c.GetNFT(ctx, tokenID, serialNumber)
+		if err != nil {
+			return false, err
+		}
+		result = nft
+		return nft.AccountID == accountID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testBackoff keeps the tests short, giving up after a few attempts
+var testBackoff = Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2, Timeout: 50 * time.Millisecond}
+
+const (
+	testTransactionID  = "0.0.2-1712345678-000000001"
+	testTransactionURI = "/api/v1/transactions/" + testTransactionID
+)
+
+// newStatusServer responds with each of statuses in turn, and then with the transaction
+func newStatusServer(t *testing.T, statuses ...int) (*Client, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if r.URL.RequestURI() != testTransactionURI {
+			t.Errorf("requested %s, want %s", r.URL.RequestURI(), testTransactionURI)
+		}
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			fmt.Fprint(w, `{"_status":{"messages":[{"message":"Try again"}]}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"transactions":[{"transaction_id":%q}]}`, testTransactionID)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL + "/"), &requests
+}
+
+func TestPollBackoff(t *testing.T) {
+	// The delay grows fourfold from 10ms, capped at 20ms rather than reaching 160ms
+	backoff := Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Multiplier: 4, Timeout: time.Minute}
+	var attempts []time.Time
+	err := Poll(context.Background(), backoff, "test", func(context.Context) (bool, error) {
+		attempts = append(attempts, time.Now())
+		return len(attempts) == 4, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 4 {
+		t.Fatalf("Poll made %d attempts, want 4", len(attempts))
+	}
+	for i, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond} {
+		if got := attempts[i+1].Sub(attempts[i]); got < want {
+			t.Errorf("delay before attempt %d = %s, want at least %s", i+2, got, want)
+		}
+	}
+	if got := attempts[3].Sub(attempts[2]); got >= 160*time.Millisecond {
+		t.Errorf("delay before attempt 4 = %s, want it capped at %s", got, backoff.Max)
+	}
+}
+
+func TestPollDeadline(t *testing.T) {
+	client, requests := newStatusServer(t, repeatStatus(http.StatusNotFound, 1000)...)
+	start := time.Now()
+	_, err := client.WaitForTransaction(context.Background(), testBackoff, testTransactionID, nil)
+
+	var propagationErr *PropagationError
+	if !errors.As(err, &propagationErr) || !errors.Is(err, ErrNotYetPropagated) {
+		t.Fatalf("WaitForTransaction = %v, want a PropagationError", err)
+	}
+	if propagationErr.What != "transaction "+testTransactionID || propagationErr.Attempts < 2 || int(requests.Load()) > propagationErr.Attempts {
+		t.Errorf("PropagationError = %+v, want several attempts at the transaction, made %d", propagationErr, requests.Load())
+	}
+	// The last 404 is kept
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("PropagationError.Err = %v, want a 404 HTTPError", propagationErr.Err)
+	}
+	if elapsed := time.Since(start); elapsed < testBackoff.Timeout || propagationErr.Waited < testBackoff.Timeout {
+		t.Errorf("gave up after %s, waited %s, want at least %s", elapsed, propagationErr.Waited, testBackoff.Timeout)
+	}
+}
+
+func TestPollCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backoff := testBackoff
+	backoff.Timeout = time.Minute
+	attempts := 0
+	err := Poll(ctx, backoff, "test", func(context.Context) (bool, error) {
+		attempts++
+		if attempts == 3 {
+			cancel()
+		}
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrNotYetPropagated) {
+		t.Errorf("Poll = %v, want %v", err, context.Canceled)
+	}
+	if attempts != 3 {
+		t.Errorf("Poll made %d attempts after being cancelled on attempt 3", attempts)
+	}
+}
+
+func TestPollRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   int
+		wantRequests int32
+	}{
+		{"not found yet", []int{404, 404}, 0, 3},
+		{"server errors", []int{500, 502, 503}, 0, 4},
+		{"bad request", []int{400}, 400, 1},
+		{"rate limited", []int{429}, 429, 1},
+		{"server error then bad request", []int{503, 400}, 400, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newStatusServer(t, tt.statuses...)
+			backoff := testBackoff
+			backoff.Timeout = time.Minute
+			resp, err := client.WaitForTransaction(context.Background(), backoff, testTransactionID, nil)
+			if tt.wantStatus == 0 {
+				if err != nil || len(resp.Transactions) != 1 {
+					t.Errorf("WaitForTransaction = %+v, %v, want the transaction", resp, err)
+				}
+			} else {
+				// Permanent errors are returned as they are, without waiting for the deadline
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.wantStatus || errors.Is(err, ErrNotYetPropagated) {
+					t.Errorf("WaitForTransaction = %v, want HTTP %d", err, tt.wantStatus)
+				}
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPollNetworkErrors(t *testing.T) {
+	// A closed server refuses connections, as a local mirror node does while it starts
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	_, err := NewClient(server.URL+"/").WaitForTransaction(context.Background(), testBackoff, testTransactionID, nil)
+	var propagationErr *PropagationError
+	if !errors.As(err, &propagationErr) || propagationErr.Attempts < 2 || !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("WaitForTransaction = %v, want a PropagationError after retrying the refused connection", err)
+	}
+
+	// A malformed URL is not worth retrying
+	attempts := 0
+	err = Poll(context.Background(), testBackoff, "test", func(ctx context.Context) (bool, error) {
+		attempts++
+		_, err := NewClient("mirror.invalid/").GetTransaction(ctx, testTransactionID, nil)
+		return false, err
+	})
+	if err == nil || errors.Is(err, ErrNotYetPropagated) || attempts != 1 {
+		t.Errorf("Poll = %v after %d attempts, want the error of the first attempt", err, attempts)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"404", &HTTPError{StatusCode: 404}, true},
+		{"503", fmt.Errorf("getting topic: %w", &HTTPError{StatusCode: 503}), true},
+		{"400", &HTTPError{StatusCode: 400}, false},
+		{"connection refused", fmt.Errorf("mirror node GET: %w", syscall.ECONNREFUSED), true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+		{"request timeout", context.DeadlineExceeded, true},
+		{"cancelled", context.Canceled, false},
+		{"other error", errors.New("failed to parse JSON"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// repeatStatus returns n copies of status
+func repeatStatus(status int, n int) []int {
+	statuses := make([]int, n)
+	for i := range statuses {
+		statuses[i] = status
+	}
+	return statuses
+}
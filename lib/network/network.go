@@ -0,0 +1,182 @@
+// Package network describes the Hedera networks that the scripts can run against.
+// A single Profile drives the SDK client, the mirror node base URL,
+// and the HashScan explorer links, so that these never disagree.
+package network
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// Environment variables read when selecting and customising a network
+const (
+	// NetworkEnvVar selects the network by name, as in `.rpcrelay.env.sample`
+	NetworkEnvVar = "HEDERA_NETWORK"
+	// MirrorNodeURLEnvVar is the mirror node REST API base URL of the local network.
+	// The public networks ignore it, so that a `.env` written for one network
+	// never makes the scripts read from the mirror node of another.
+	MirrorNodeURLEnvVar = "MIRROR_NODE_URL"
+	// LocalNodesEnvVar lists the consensus nodes of the local network,
+	// as comma separated `host:port=0.0.x` pairs
+	LocalNodesEnvVar = "HEDERA_LOCAL_NODES"
+	// LocalMirrorGRPCEnvVar is the mirror node gRPC address of the local network
+	LocalMirrorGRPCEnvVar = "HEDERA_LOCAL_MIRROR_GRPC"
+	// LocalExplorerURLEnvVar is the block explorer base URL of the local network
+	LocalExplorerURLEnvVar = "HEDERA_LOCAL_EXPLORER_URL"
+)
+
+// Network names
+const (
+	Testnet    = "testnet"
+	Previewnet = "previewnet"
+	Mainnet    = "mainnet"
+	Local      = "local"
+)
+
+// Default is the network used when none has been selected
+const Default = Testnet
+
+// Defaults for the local network, matching the ports used by hedera-local-node
+const (
+	defaultLocalNode       = "127.0.0.1:50211"
+	defaultLocalMirrorURL  = "http://localhost:5551"
+	defaultLocalMirrorGRPC = "127.0.0.1:5600"
+	defaultLocalExplorer   = "http://localhost:8080/devnet"
+)
+
+// ErrUnknownNetwork is returned for network names that have no profile
+var ErrUnknownNetwork = errors.New("unknown network")
+
+// Profile holds everything that differs between networks
+type Profile struct {
+	Name string
+	// MirrorNodeURL is the base URL of the mirror node REST API, without `/api/v1`
+	MirrorNodeURL string
+	// ExplorerURL is the base URL of the block explorer for this network
+	ExplorerURL string
+	// ConsensusNodes and MirrorNodeGRPC are only set for the local network,
+	// the SDK has built in address books for the public networks.
+	ConsensusNodes map[string]hedera.AccountID
+	MirrorNodeGRPC []string
+}
+
+// Load returns the profile for the named network.
+// When name is empty, the network is taken from `HEDERA_NETWORK`,
+// and falls back to the Hedera Testnet.
+func Load(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv(NetworkEnvVar)
+	}
+	if name == "" {
+		name = Default
+	}
+
+	var profile *Profile
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case Testnet:
+		profile = publicProfile(Testnet, "https://testnet.mirrornode.hedera.com")
+	case Previewnet:
+		profile = publicProfile(Previewnet, "https://previewnet.mirrornode.hedera.com")
+	case Mainnet:
+		profile = publicProfile(Mainnet, "https://mainnet-public.mirrornode.hedera.com")
+	case Local, "localhost":
+		var err error
+		profile, err = localProfile()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownNetwork, name)
+	}
+
+	profile.MirrorNodeURL = strings.TrimSuffix(profile.MirrorNodeURL, "/")
+	return profile, nil
+}
+
+func publicProfile(name string, mirrorNodeURL string) *Profile {
+	return &Profile{
+		Name:          name,
+		MirrorNodeURL: mirrorNodeURL,
+		ExplorerURL:   "https://hashscan.io/" + name,
+	}
+}
+
+func localProfile() (*Profile, error) {
+	nodes, err := ParseNodes(envOr(LocalNodesEnvVar, defaultLocalNode+"=0.0.3"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", LocalNodesEnvVar, err)
+	}
+	return &Profile{
+		Name:           Local,
+		MirrorNodeURL:  envOr(MirrorNodeURLEnvVar, defaultLocalMirrorURL),
+		ExplorerURL:    strings.TrimSuffix(envOr(LocalExplorerURLEnvVar, defaultLocalExplorer), "/"),
+		ConsensusNodes: nodes,
+		MirrorNodeGRPC: strings.Split(envOr(LocalMirrorGRPCEnvVar, defaultLocalMirrorGRPC), ","),
+	}, nil
+}
+
+// ParseNodes parses an address map of consensus nodes,
+// in the form `127.0.0.1:50211=0.0.3,127.0.0.1:50212=0.0.4`
+func ParseNodes(s string) (map[string]hedera.AccountID, error) {
+	nodes := make(map[string]hedera.AccountID)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		address, accountIDStr, found := strings.Cut(entry, "=")
+		if !found || address == "" {
+			return nil, fmt.Errorf("invalid node entry %q, expected host:port=0.0.x", entry)
+		}
+		accountID, err := hedera.AccountIDFromString(accountIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node account ID in %q: %w", entry, err)
+		}
+		nodes[address] = accountID
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no consensus nodes specified")
+	}
+	return nodes, nil
+}
+
+// NewClient returns a Hedera client for this network, without an operator
+func (p *Profile) NewClient() (*hedera.Client, error) {
+	switch p.Name {
+	case Testnet:
+		return hedera.ClientForTestnet(), nil
+	case Previewnet:
+		return hedera.ClientForPreviewnet(), nil
+	case Mainnet:
+		return hedera.ClientForMainnet(), nil
+	case Local:
+		client := hedera.ClientForNetwork(p.ConsensusNodes)
+		client.SetMirrorNetwork(p.MirrorNodeGRPC)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownNetwork, p.Name)
+	}
+}
+
+// MirrorNodeAPIURL returns the mirror node REST API URL for the given path,
+// e.g. `MirrorNodeAPIURL("tokens/%s", tokenId)`
+func (p *Profile) MirrorNodeAPIURL(format string, args ...any) string {
+	return p.MirrorNodeURL + "/api/v1/" + strings.TrimPrefix(fmt.Sprintf(format, args...), "/")
+}
+
+// ExplorerEntityURL returns the HashScan URL of an entity,
+// where kind is one of `account`, `topic`, `token`, `contract`, or `transaction`.
+func (p *Profile) ExplorerEntityURL(kind string, id fmt.Stringer) string {
+	return fmt.Sprintf("%s/%s/%s", p.ExplorerURL, kind, id.String())
+}
+
+func envOr(envVar string, fallback string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,145 @@
+package network
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// setEnv sets every variable that Load reads, unset ones to empty, for the duration of the test
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range []string{NetworkEnvVar, MirrorNodeURLEnvVar, LocalNodesEnvVar, LocalMirrorGRPCEnvVar, LocalExplorerURLEnvVar} {
+		t.Setenv(name, env[name])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name         string
+		network      string
+		env          map[string]string
+		wantName     string
+		wantMirror   string
+		wantExplorer string
+	}{
+		{"default", "", nil, Testnet, "https://testnet.mirrornode.hedera.com", "https://hashscan.io/testnet"},
+		{"environment", "", map[string]string{NetworkEnvVar: "previewnet"}, Previewnet, "https://previewnet.mirrornode.hedera.com", "https://hashscan.io/previewnet"},
+		{"name over environment", "mainnet", map[string]string{NetworkEnvVar: "previewnet"}, Mainnet, "https://mainnet-public.mirrornode.hedera.com", "https://hashscan.io/mainnet"},
+		{"case and spaces", " Testnet ", nil, Testnet, "https://testnet.mirrornode.hedera.com", "https://hashscan.io/testnet"},
+		{"local", "local", nil, Local, "http://localhost:5551", "http://localhost:8080/devnet"},
+		{"localhost", "", map[string]string{NetworkEnvVar: "localhost"}, Local, "http://localhost:5551", "http://localhost:8080/devnet"},
+		// The local overrides of a `.env` file written for the local network are ignored on the others
+		{"public network ignores the local overrides", "testnet", map[string]string{
+			MirrorNodeURLEnvVar: "http://mirror.example:5551", LocalExplorerURLEnvVar: "http://explorer.example", LocalNodesEnvVar: "invalid",
+		}, Testnet, "https://testnet.mirrornode.hedera.com", "https://hashscan.io/testnet"},
+		{"local overrides", "local", map[string]string{
+			MirrorNodeURLEnvVar: "http://mirror.example:5551/", LocalExplorerURLEnvVar: "http://explorer.example/localnet/",
+		}, Local, "http://mirror.example:5551", "http://explorer.example/localnet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			profile, err := Load(tt.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Name != tt.wantName || profile.MirrorNodeURL != tt.wantMirror || profile.ExplorerURL != tt.wantExplorer {
+				t.Errorf("Load(%q) = %s %s %s, want %s %s %s", tt.network,
+					profile.Name, profile.MirrorNodeURL, profile.ExplorerURL, tt.wantName, tt.wantMirror, tt.wantExplorer)
+			}
+			// The SDK has the address books of the public networks
+			if isLocal := profile.Name == Local; isLocal != (profile.ConsensusNodes != nil) || isLocal != (profile.MirrorNodeGRPC != nil) {
+				t.Errorf("ConsensusNodes = %v, MirrorNodeGRPC = %v, want them set only for the local network", profile.ConsensusNodes, profile.MirrorNodeGRPC)
+			}
+		})
+	}
+
+	setEnv(t, map[string]string{NetworkEnvVar: "devnet"})
+	if _, err := Load(""); !errors.Is(err, ErrUnknownNetwork) {
+		t.Errorf("Load of HEDERA_NETWORK=devnet = %v, want %v", err, ErrUnknownNetwork)
+	}
+}
+
+func TestLoadLocal(t *testing.T) {
+	setEnv(t, nil)
+	profile, err := Load(Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNodes := map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}}
+	if !maps.Equal(profile.ConsensusNodes, wantNodes) || !slices.Equal(profile.MirrorNodeGRPC, []string{"127.0.0.1:5600"}) {
+		t.Errorf("Load(local) = %v %v, want the hedera-local-node defaults", profile.ConsensusNodes, profile.MirrorNodeGRPC)
+	}
+
+	setEnv(t, map[string]string{
+		LocalNodesEnvVar:      "10.0.0.1:50211=0.0.3, 10.0.0.2:50211=0.0.4",
+		LocalMirrorGRPCEnvVar: "10.0.0.1:5600,10.0.0.2:5600",
+	})
+	profile, err = Load(Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNodes = map[string]hedera.AccountID{"10.0.0.1:50211": {Account: 3}, "10.0.0.2:50211": {Account: 4}}
+	if !maps.Equal(profile.ConsensusNodes, wantNodes) || !slices.Equal(profile.MirrorNodeGRPC, []string{"10.0.0.1:5600", "10.0.0.2:5600"}) {
+		t.Errorf("Load(local) = %v %v, want the nodes from the environment", profile.ConsensusNodes, profile.MirrorNodeGRPC)
+	}
+
+	setEnv(t, map[string]string{LocalNodesEnvVar: "10.0.0.1:50211"})
+	if _, err := Load(Local); err == nil || !strings.Contains(err.Error(), LocalNodesEnvVar) {
+		t.Errorf("Load(local) with invalid nodes = %v, want an error naming %s", err, LocalNodesEnvVar)
+	}
+}
+
+func TestParseNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    map[string]hedera.AccountID
+		wantErr bool
+	}{
+		{"single node", "127.0.0.1:50211=0.0.3", map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}}, false},
+		{"several nodes", "127.0.0.1:50211=0.0.3,127.0.0.1:50212=0.0.4",
+			map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}, "127.0.0.1:50212": {Account: 4}}, false},
+		{"spaces and empty entries", " node1:50211=0.0.3 ,, ", map[string]hedera.AccountID{"node1:50211": {Account: 3}}, false},
+		{"without account ID", "127.0.0.1:50211", nil, true},
+		{"without address", "=0.0.3", nil, true},
+		{"invalid account ID", "127.0.0.1:50211=node3", nil, true},
+		{"empty", " , ", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ParseNodes(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseNodes(%q) = %v, want an error", tt.s, nodes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(nodes, tt.want) {
+				t.Errorf("ParseNodes(%q) = %v, want %v", tt.s, nodes, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	setEnv(t, nil)
+	profile, err := Load(Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := profile.MirrorNodeAPIURL("/tokens/%s", "0.0.1234"), "https://testnet.mirrornode.hedera.com/api/v1/tokens/0.0.1234"; got != want {
+		t.Errorf("MirrorNodeAPIURL = %s, want %s", got, want)
+	}
+	if got, want := profile.ExplorerEntityURL("account", hedera.AccountID{Account: 1001}), "https://hashscan.io/testnet/account/0.0.1001"; got != want {
+		t.Errorf("ExplorerEntityURL = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+// Package nft builds the metadata of non-fungible token serials.
+//
+// The metadata stored on chain for each serial is at most 100 bytes,
+// so it holds a URI that points at a HIP-412 JSON document, e.g. `ipfs://<cid>/1.json`,
+// rather than the document itself.
+// Ref: https://hips.hedera.com/hip/hip-412
+package nft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MaxMetadataBytes is the maximum size of the metadata of a single serial,
+	// set by the `tokens.nfts.maxMetadataBytes` network property
+	MaxMetadataBytes = 100
+	// MaxMintBatch is the maximum number of serials minted by a single transaction,
+	// set by the `tokens.nfts.maxBatchSizeMint` network property
+	MaxMintBatch = 10
+	// Format is the HIP-412 version of the documents built by this package
+	Format = "HIP412@2.0.0"
+)
+
+var (
+	// ErrInvalidMetadata is returned for metadata that does not fit on chain,
+	// or that is not a URI
+	ErrInvalidMetadata = errors.New("invalid NFT metadata")
+	// ErrInvalidDocument is returned for HIP-412 documents without a required field
+	ErrInvalidDocument = errors.New("invalid HIP-412 document")
+)
+
+// Document is the HIP-412 JSON document that the metadata URI of a serial points at
+type Document struct {
+	Name        string `json:"name"`
+	Creator     string `json:"creator,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Image is the URI of the image, which is required
+	Image string `json:"image"`
+	// Type is the MIME type of the image, e.g. image/png
+	Type       string         `json:"type"`
+	Format     string         `json:"format"`
+	Properties map[string]any `json:"properties,omitempty"`
+	Attributes []Attribute    `json:"attributes,omitempty"`
+}
+
+// Attribute is a trait of a serial, as shown by wallets and marketplaces
+type Attribute struct {
+	TraitType string `json:"trait_type"`
+	Value     any    `json:"value"`
+}
+
+// Validate checks the fields that HIP-412 requires
+func (d Document) Validate() error {
+	switch {
+	case d.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidDocument)
+	case d.Image == "":
+		return fmt.Errorf("%w: image is required", ErrInvalidDocument)
+	case d.Type == "":
+		return fmt.Errorf("%w: type is required", ErrInvalidDocument)
+	case d.Format != Format:
+		return fmt.Errorf("%w: format must be %s, got %q", ErrInvalidDocument, Format, d.Format)
+	}
+	return nil
+}
+
+// WriteDocuments writes each document to `<dir>/<n>.json`, numbered from 1,
+// ready to be uploaded to IPFS as a single directory
+func WriteDocuments(dir string, documents []Document) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(documents))
+	for i, document := range documents {
+		if err := document.Validate(); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.json", i+1))
+		if err := os.WriteFile(paths[i], append(data, '\n'), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// MetadataURIs returns the metadata URIs of count documents uploaded as a directory,
+// e.g. `ipfs://<cid>/1.json`, numbered from 1
+func MetadataURIs(baseURI string, count int) []string {
+	uris := make([]string, count)
+	for i := range uris {
+		uris[i] = fmt.Sprintf("%s/%d.json", strings.TrimSuffix(baseURI, "/"), i+1)
+	}
+	return uris
+}
+
+// Metadata validates the metadata URIs, and returns them as the bytes to mint
+func Metadata(uris []string) ([][]byte, error) {
+	metadata := make([][]byte, len(uris))
+	for i, uri := range uris {
+		if len(uri) > MaxMetadataBytes {
+			return nil, fmt.Errorf("%w: %q is %d bytes, the maximum is %d", ErrInvalidMetadata, uri, len(uri), MaxMetadataBytes)
+		}
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" {
+			return nil, fmt.Errorf("%w: %q is not a URI, e.g. ipfs://<cid>/1.json", ErrInvalidMetadata, uri)
+		}
+		metadata[i] = []byte(uri)
+	}
+	return metadata, nil
+}
+
+// Batches splits the metadata into batches of at most size serials, one per mint transaction
+func Batches(metadata [][]byte, size int) [][][]byte {
+	batches := [][][]byte{}
+	for start := 0; start < len(metadata); start += size {
+		batches = append(batches, metadata[start:min(start+size, len(metadata))])
+	}
+	return batches
+}
@@ -0,0 +1,417 @@
+// Package offline splits the FreezeWith → Sign → Execute flow of the scripts
+// across processes, and across machines.
+//
+// A transaction is frozen on a machine with network access, and written to a file
+// with the bytes from `ToBytes`. The file is signed on an air-gapped machine that holds
+// the private keys, without any network access. When several signers each sign their own
+// copy of the file, the copies are merged into one. Finally the signed file is submitted
+// by any machine with network access, and the receipt is fetched as usual.
+//
+// Files hold the serialized transaction list exactly as `ToBytes` returns it,
+// one signed transaction per node, so they can also be read by the other Hedera SDKs.
+package offline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashgraph/hedera-protobufs-go/sdk"
+	"github.com/hashgraph/hedera-protobufs-go/services"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"google.golang.org/protobuf/proto"
+
+	"lib/fees"
+)
+
+var (
+	// ErrMismatch is returned when merging files that are not the same frozen transaction
+	ErrMismatch = errors.New("transactions do not match")
+	// ErrNotFrozen is returned when exporting a transaction that has not been frozen
+	ErrNotFrozen = errors.New("transaction is not frozen")
+)
+
+// Transaction is any SDK transaction, e.g. *hedera.TransferTransaction,
+// or the value returned by hedera.TransactionFromBytes
+type Transaction = any
+
+// ToBytes serializes a frozen transaction, with the signatures that it has so far
+func ToBytes(tx Transaction) ([]byte, error) {
+	if frozen, ok := tx.(interface{ IsFrozen() bool }); ok && !frozen.IsFrozen() {
+		return nil, ErrNotFrozen
+	}
+	return hedera.TransactionToBytes(tx)
+}
+
+// FromBytes deserializes a transaction written by ToBytes
+func FromBytes(data []byte) (Transaction, error) {
+	tx, err := hedera.TransactionFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading transaction bytes: %w", err)
+	}
+	return tx, nil
+}
+
+// Write exports a frozen transaction to a file.
+// The file is only readable by its owner, as a signed transaction can be submitted by anyone.
+func Write(path string, tx Transaction) error {
+	data, err := ToBytes(tx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Read imports a transaction from a file written by Write
+func Read(path string) (Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(data)
+}
+
+// Sign adds a signature with each key, for every node that the transaction can be submitted to.
+// Signing needs no client, and no network access.
+func Sign(tx Transaction, keys ...hedera.PrivateKey) (Transaction, error) {
+	for _, key := range keys {
+		var err error
+		tx, err = hedera.TransactionSign(tx, key)
+		if err != nil {
+			return nil, fmt.Errorf("signing transaction: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// Submit executes a signed transaction.
+// The client operator only signs if it is also the fee payer,
+// so the submitting machine does not need any of the other keys.
+func Submit(client *hedera.Client, tx Transaction) (hedera.TransactionResponse, error) {
+	return hedera.TransactionExecute(tx, client)
+}
+
+// Merge combines copies of the same frozen transaction, each signed by different keys,
+// into a single transaction with all of their signatures.
+// The copies must have the same bodies, in the same order, which is checked byte for byte.
+func Merge(copies ...[]byte) ([]byte, error) {
+	if len(copies) == 0 {
+		return nil, fmt.Errorf("%w: nothing to merge", ErrMismatch)
+	}
+	merged := &sdk.TransactionList{}
+	if err := proto.Unmarshal(copies[0], merged); err != nil {
+		return nil, fmt.Errorf("reading transaction bytes: %w", err)
+	}
+	signedTxs, err := signedTransactions(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, data := range copies[1:] {
+		other := &sdk.TransactionList{}
+		if err := proto.Unmarshal(data, other); err != nil {
+			return nil, fmt.Errorf("reading transaction bytes of copy %d: %w", i+2, err)
+		}
+		otherSignedTxs, err := signedTransactions(other)
+		if err != nil {
+			return nil, err
+		}
+		if len(otherSignedTxs) != len(signedTxs) {
+			return nil, fmt.Errorf("%w: copy %d has %d node transactions, expected %d",
+				ErrMismatch, i+2, len(otherSignedTxs), len(signedTxs))
+		}
+		for j, signedTx := range signedTxs {
+			otherSignedTx := otherSignedTxs[j]
+			if !bytes.Equal(signedTx.BodyBytes, otherSignedTx.BodyBytes) {
+				return nil, fmt.Errorf("%w: copy %d has a different body", ErrMismatch, i+2)
+			}
+			mergeSignatures(signedTx, otherSignedTx)
+		}
+	}
+
+	for i, signedTx := range signedTxs {
+		signedTxBytes, err := proto.Marshal(signedTx)
+		if err != nil {
+			return nil, err
+		}
+		merged.TransactionList[i] = &services.Transaction{SignedTransactionBytes: signedTxBytes}
+	}
+	return proto.Marshal(merged)
+}
+
+// MergeFiles merges the signed copies in paths, and writes the result to out
+func MergeFiles(out string, paths ...string) error {
+	copies := make([][]byte, len(paths))
+	for i, path := range paths {
+		var err error
+		copies[i], err = os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+	}
+	merged, err := Merge(copies...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, merged, 0o600)
+}
+
+func signedTransactions(list *sdk.TransactionList) ([]*services.SignedTransaction, error) {
+	if len(list.TransactionList) == 0 {
+		return nil, fmt.Errorf("%w: no transactions", ErrMismatch)
+	}
+	signedTxs := make([]*services.SignedTransaction, len(list.TransactionList))
+	for i, tx := range list.TransactionList {
+		signedTxs[i] = &services.SignedTransaction{}
+		if err := proto.Unmarshal(tx.SignedTransactionBytes, signedTxs[i]); err != nil {
+			return nil, fmt.Errorf("reading signed transaction bytes: %w", err)
+		}
+	}
+	return signedTxs, nil
+}
+
+// mergeSignatures adds the signatures of other to signedTx, skipping keys that already signed
+func mergeSignatures(signedTx *services.SignedTransaction, other *services.SignedTransaction) {
+	if other.SigMap == nil {
+		return
+	}
+	if signedTx.SigMap == nil {
+		signedTx.SigMap = &services.SignatureMap{}
+	}
+	for _, otherPair := range other.SigMap.SigPair {
+		found := false
+		for _, pair := range signedTx.SigMap.SigPair {
+			if bytes.Equal(pair.PubKeyPrefix, otherPair.PubKeyPrefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			signedTx.SigMap.SigPair = append(signedTx.SigMap.SigPair, otherPair)
+		}
+	}
+}
+
+// Summary describes a transaction, for a signer to review before signing it
+type Summary struct {
+	Type          string
+	TransactionID hedera.TransactionID
+	Memo          string
+	MaxFee        hedera.Hbar
+	Nodes         []hedera.AccountID
+	// SignedBy are the public keys that have signed so far
+	SignedBy []string
+	// Details are what the transaction does, for the transaction types of the scripts
+	Details []string
+}
+
+// Describe returns a summary of a transaction
+func Describe(tx Transaction) (Summary, error) {
+	summary := Summary{Type: strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%T", tx), "*"), "hedera.")}
+	var err error
+	if summary.TransactionID, err = hedera.TransactionGetTransactionID(tx); err != nil {
+		return Summary{}, err
+	}
+	if summary.Memo, err = hedera.TransactionGetTransactionMemo(tx); err != nil {
+		return Summary{}, err
+	}
+	if summary.MaxFee, err = hedera.TransactionGetMaxTransactionFee(tx); err != nil {
+		return Summary{}, err
+	}
+	if summary.Nodes, err = hedera.TransactionGetNodeAccountIDs(tx); err != nil {
+		return Summary{}, err
+	}
+	if summary.SignedBy, err = signedBy(tx); err != nil {
+		return Summary{}, err
+	}
+	summary.Details = details(tx)
+	return summary, nil
+}
+
+// signedBy returns the public keys in the signature maps of a transaction.
+// The signature maps are read from the bytes, as the SDK getter skips ECDSA signatures.
+func signedBy(tx Transaction) ([]string, error) {
+	data, err := hedera.TransactionToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	list := &sdk.TransactionList{}
+	if err := proto.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("reading transaction bytes: %w", err)
+	}
+	signedTxs, err := signedTransactions(list)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	publicKeys := []string{}
+	for _, signedTx := range signedTxs {
+		for _, pair := range signedTx.GetSigMap().GetSigPair() {
+			var publicKey hedera.PublicKey
+			switch pair.Signature.(type) {
+			case *services.SignaturePair_Ed25519:
+				publicKey, err = hedera.PublicKeyFromBytesEd25519(pair.PubKeyPrefix)
+			case *services.SignaturePair_ECDSASecp256K1:
+				publicKey, err = hedera.PublicKeyFromBytesECDSA(pair.PubKeyPrefix)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading signature public key: %w", err)
+			}
+			if !seen[publicKey.String()] {
+				seen[publicKey.String()] = true
+				publicKeys = append(publicKeys, publicKey.String())
+			}
+		}
+	}
+	sort.Strings(publicKeys)
+	return publicKeys, nil
+}
+
+// details lists the fields of the transfer, topic, and token transactions that a signer needs to check
+func details(tx Transaction) []string {
+	// TransactionFromBytes returns values, but the getters have pointer receivers
+	switch t := tx.(type) {
+	case hedera.TransferTransaction:
+		return details(&t)
+	case hedera.TopicCreateTransaction:
+		return details(&t)
+	case hedera.TopicMessageSubmitTransaction:
+		return details(&t)
+	case hedera.TokenCreateTransaction:
+		return details(&t)
+	case hedera.TokenAssociateTransaction:
+		return details(&t)
+	case hedera.TokenDissociateTransaction:
+		return details(&t)
+	case hedera.TokenMintTransaction:
+		return details(&t)
+	case hedera.TokenBurnTransaction:
+		return details(&t)
+	case hedera.TokenWipeTransaction:
+		return details(&t)
+	case hedera.TokenFreezeTransaction:
+		return details(&t)
+	case hedera.TokenUnfreezeTransaction:
+		return details(&t)
+	case hedera.TokenGrantKycTransaction:
+		return details(&t)
+	case hedera.TokenRevokeKycTransaction:
+		return details(&t)
+	case hedera.TokenUpdateTransaction:
+		return details(&t)
+	case hedera.TokenDeleteTransaction:
+		return details(&t)
+	case hedera.TokenFeeScheduleUpdateTransaction:
+		return details(&t)
+
+	case *hedera.TransferTransaction:
+		lines := []string{}
+		for accountId, amount := range t.GetHbarTransfers() {
+			lines = append(lines, fmt.Sprintf("HBAR %s %s", accountId.String(), amount.String()))
+		}
+		for tokenId, tokenTransfers := range t.GetTokenTransfers() {
+			for _, tokenTransfer := range tokenTransfers {
+				lines = append(lines, fmt.Sprintf("Token %s %s %d", tokenId.String(), tokenTransfer.AccountID.String(), tokenTransfer.Amount))
+			}
+		}
+		for tokenId, nftTransfers := range t.GetNftTransfers() {
+			for _, nftTransfer := range nftTransfers {
+				lines = append(lines, fmt.Sprintf("NFT %s #%d %s to %s", tokenId.String(), nftTransfer.SerialNumber,
+					nftTransfer.SenderAccountID.String(), nftTransfer.ReceiverAccountID.String()))
+			}
+		}
+		sort.Strings(lines)
+		return lines
+	case *hedera.TopicCreateTransaction:
+		return []string{fmt.Sprintf("Topic memo: %s", t.GetTopicMemo())}
+	case *hedera.TopicMessageSubmitTransaction:
+		return []string{
+			fmt.Sprintf("Topic: %s", t.GetTopicID().String()),
+			fmt.Sprintf("Message: %s", t.GetMessage()),
+		}
+	case *hedera.TokenCreateTransaction:
+		return []string{
+			fmt.Sprintf("Token: %s (%s)", t.GetTokenName(), t.GetTokenSymbol()),
+			fmt.Sprintf("Treasury: %s", t.GetTreasuryAccountID().String()),
+			fmt.Sprintf("Initial supply: %d", t.GetInitialSupply()),
+		}
+	case *hedera.TokenAssociateTransaction:
+		return []string{fmt.Sprintf("Account: %s", t.GetAccountID().String()), fmt.Sprintf("Tokens: %s", tokenList(t.GetTokenIDs()))}
+	case *hedera.TokenDissociateTransaction:
+		return []string{fmt.Sprintf("Account: %s", t.GetAccountID().String()), fmt.Sprintf("Tokens: %s", tokenList(t.GetTokenIDs()))}
+	case *hedera.TokenMintTransaction:
+		lines := supplyChange(t.GetTokenID(), t.GetAmount(), nil)
+		for _, metadata := range t.GetMetadatas() {
+			lines = append(lines, fmt.Sprintf("Metadata: %s", metadata))
+		}
+		return lines
+	case *hedera.TokenBurnTransaction:
+		return supplyChange(t.GetTokenID(), t.GetAmount(), t.GetSerialNumbers())
+	case *hedera.TokenWipeTransaction:
+		return append(supplyChange(t.GetTokenID(), t.GetAmount(), t.GetSerialNumbers()), fmt.Sprintf("Account: %s", t.GetAccountID().String()))
+	case *hedera.TokenFreezeTransaction:
+		return tokenAccount(t.GetTokenID(), t.GetAccountID())
+	case *hedera.TokenUnfreezeTransaction:
+		return tokenAccount(t.GetTokenID(), t.GetAccountID())
+	case *hedera.TokenGrantKycTransaction:
+		return tokenAccount(t.GetTokenID(), t.GetAccountID())
+	case *hedera.TokenRevokeKycTransaction:
+		return tokenAccount(t.GetTokenID(), t.GetAccountID())
+	case *hedera.TokenDeleteTransaction:
+		return []string{fmt.Sprintf("Token: %s", t.GetTokenID().String())}
+	case *hedera.TokenUpdateTransaction:
+		// Only the fields that are set are updated
+		lines := []string{fmt.Sprintf("Token: %s", t.GetTokenID().String())}
+		if name := t.GetTokenName(); name != "" {
+			lines = append(lines, fmt.Sprintf("Name: %s", name))
+		}
+		if symbol := t.GetTokenSymbol(); symbol != "" {
+			lines = append(lines, fmt.Sprintf("Symbol: %s", symbol))
+		}
+		if memo := t.GetTokenMemo(); memo != "" {
+			lines = append(lines, fmt.Sprintf("Memo: %s", memo))
+		}
+		return lines
+	case *hedera.TokenFeeScheduleUpdateTransaction:
+		// The new fee schedule replaces the previous one as a whole
+		lines := []string{fmt.Sprintf("Token: %s", t.GetTokenID().String())}
+		customFees := fees.Describe(t.GetCustomFees(), t.GetTokenID())
+		if len(customFees) == 0 {
+			return append(lines, "Custom fees: none")
+		}
+		for _, line := range customFees {
+			lines = append(lines, fmt.Sprintf("Custom fee: %s", line))
+		}
+		return lines
+	}
+	return nil
+}
+
+func tokenAccount(tokenId hedera.TokenID, accountId hedera.AccountID) []string {
+	return []string{fmt.Sprintf("Token: %s", tokenId.String()), fmt.Sprintf("Account: %s", accountId.String())}
+}
+
+// supplyChange lists the amount of a fungible token, or the serials of an NFT
+func supplyChange(tokenId hedera.TokenID, amount uint64, serials []int64) []string {
+	lines := []string{fmt.Sprintf("Token: %s", tokenId.String())}
+	if amount > 0 {
+		lines = append(lines, fmt.Sprintf("Amount: %d", amount))
+	}
+	if len(serials) > 0 {
+		lines = append(lines, fmt.Sprintf("Serials: %v", serials))
+	}
+	return lines
+}
+
+func tokenList(tokenIds []hedera.TokenID) string {
+	ids := make([]string, len(tokenIds))
+	for i, tokenId := range tokenIds {
+		ids[i] = tokenId.String()
+	}
+	return strings.Join(ids, ", ")
+}
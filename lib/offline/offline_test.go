@@ -0,0 +1,408 @@
+package offline
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// newClient returns a client for a network of two nodes, which is never contacted,
+// as freezing, signing, and merging need no network access
+func newClient(t *testing.T) *hedera.Client {
+	t.Helper()
+	client := hedera.ClientForNetwork(map[string]hedera.AccountID{
+		"127.0.0.1:50211": {Account: 3},
+		"127.0.0.1:50212": {Account: 4},
+	})
+	t.Cleanup(func() { client.Close() })
+	payerKey, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetOperator(hedera.AccountID{Account: 1001}, payerKey)
+	return client
+}
+
+func generateKeys(t *testing.T) []hedera.PrivateKey {
+	t.Helper()
+	ed25519Key, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := hedera.PrivateKeyGenerateEcdsa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []hedera.PrivateKey{ed25519Key, ecdsaKey}
+}
+
+func TestSignAndMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		freeze      func(client *hedera.Client) (Transaction, error)
+		wantType    string
+		wantDetails []string
+	}{
+		{
+			name: "transfer",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTransferTransaction().
+					AddHbarTransfer(hedera.AccountID{Account: 1001}, hedera.NewHbar(-2)).
+					AddHbarTransfer(hedera.AccountID{Account: 1002}, hedera.NewHbar(2)).
+					SetTransactionMemo("offline transfer").
+					FreezeWith(client)
+			},
+			wantType:    "TransferTransaction",
+			wantDetails: []string{"HBAR 0.0.1001 -2 ℏ", "HBAR 0.0.1002 2 ℏ"},
+		},
+		{
+			name: "topic message",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTopicMessageSubmitTransaction().
+					SetTopicID(hedera.TopicID{Topic: 1234}).
+					SetMessage([]byte("Hello offline")).
+					FreezeWith(client)
+			},
+			wantType:    "TopicMessageSubmitTransaction",
+			wantDetails: []string{"Topic: 0.0.1234", "Message: Hello offline"},
+		},
+		{
+			name: "topic create",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTopicCreateTransaction().
+					SetTopicMemo("offline topic").
+					FreezeWith(client)
+			},
+			wantType:    "TopicCreateTransaction",
+			wantDetails: []string{"Topic memo: offline topic"},
+		},
+		{
+			name: "token create",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenCreateTransaction().
+					SetTokenName("Offline token").
+					SetTokenSymbol("OT").
+					SetTreasuryAccountID(hedera.AccountID{Account: 1001}).
+					SetInitialSupply(1000).
+					FreezeWith(client)
+			},
+			wantType:    "TokenCreateTransaction",
+			wantDetails: []string{"Token: Offline token (OT)", "Treasury: 0.0.1001", "Initial supply: 1000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient(t)
+			tx, err := tt.freeze(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			unsigned := filepath.Join(dir, "unsigned.tx")
+			if err := Write(unsigned, tx); err != nil {
+				t.Fatal(err)
+			}
+
+			// Each signer signs their own copy of the file
+			keys := generateKeys(t)
+			var copies []string
+			for i, key := range keys {
+				copyTx, err := Read(unsigned)
+				if err != nil {
+					t.Fatal(err)
+				}
+				signed, err := Sign(copyTx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				copies = append(copies, filepath.Join(dir, []string{"a.tx", "b.tx"}[i]))
+				if err := Write(copies[i], signed); err != nil {
+					t.Fatal(err)
+				}
+			}
+			merged := filepath.Join(dir, "merged.tx")
+			if err := MergeFiles(merged, copies...); err != nil {
+				t.Fatal(err)
+			}
+
+			mergedTx, err := Read(merged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := Describe(mergedTx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantSignedBy []string
+			for _, key := range keys {
+				wantSignedBy = append(wantSignedBy, key.PublicKey().String())
+			}
+			sort.Strings(wantSignedBy)
+			if !slices.Equal(summary.SignedBy, wantSignedBy) {
+				t.Errorf("SignedBy = %v, want %v", summary.SignedBy, wantSignedBy)
+			}
+			if summary.Type != tt.wantType {
+				t.Errorf("Type = %s, want %s", summary.Type, tt.wantType)
+			}
+			if !slices.Equal(summary.Details, tt.wantDetails) {
+				t.Errorf("Details = %q, want %q", summary.Details, tt.wantDetails)
+			}
+			nodes, err := hedera.TransactionGetNodeAccountIDs(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(summary.Nodes, nodes) {
+				t.Errorf("Nodes = %v, want %v", summary.Nodes, nodes)
+			}
+			txID, err := hedera.TransactionGetTransactionID(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.TransactionID.String() != txID.String() {
+				t.Errorf("TransactionID = %s, want %s", summary.TransactionID.String(), txID.String())
+			}
+
+			// Merging a copy again does not add its signatures twice
+			again, err := Merge(mustReadBytes(t, merged), mustReadBytes(t, copies[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			againTx, err := FromBytes(again)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signed, _ := signedBy(againTx); !slices.Equal(signed, wantSignedBy) {
+				t.Errorf("SignedBy after merging a copy again = %v, want %v", signed, wantSignedBy)
+			}
+		})
+	}
+}
+
+func TestDescribeTokenTransactions(t *testing.T) {
+	tokenId := hedera.TokenID{Token: 5005}
+	accountId := hedera.AccountID{Account: 1002}
+	tests := []struct {
+		name        string
+		freeze      func(client *hedera.Client) (Transaction, error)
+		wantDetails []string
+	}{
+		{
+			name: "token transfer",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTransferTransaction().
+					AddTokenTransfer(tokenId, hedera.AccountID{Account: 1001}, -10).
+					AddTokenTransfer(tokenId, accountId, 10).
+					FreezeWith(client)
+			},
+			wantDetails: []string{"Token 0.0.5005 0.0.1001 -10", "Token 0.0.5005 0.0.1002 10"},
+		},
+		{
+			name: "NFT transfer",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTransferTransaction().
+					AddNftTransfer(hedera.NftID{TokenID: tokenId, SerialNumber: 3}, hedera.AccountID{Account: 1001}, accountId).
+					FreezeWith(client)
+			},
+			wantDetails: []string{"NFT 0.0.5005 #3 0.0.1001 to 0.0.1002"},
+		},
+		{
+			name: "associate",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenAssociateTransaction().SetAccountID(accountId).SetTokenIDs(tokenId, hedera.TokenID{Token: 6006}).FreezeWith(client)
+			},
+			wantDetails: []string{"Account: 0.0.1002", "Tokens: 0.0.5005, 0.0.6006"},
+		},
+		{
+			name: "dissociate",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenDissociateTransaction().SetAccountID(accountId).SetTokenIDs(tokenId).FreezeWith(client)
+			},
+			wantDetails: []string{"Account: 0.0.1002", "Tokens: 0.0.5005"},
+		},
+		{
+			name: "mint",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenMintTransaction().SetTokenID(tokenId).SetAmount(500).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Amount: 500"},
+		},
+		{
+			name: "mint serials",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenMintTransaction().SetTokenID(tokenId).SetMetadatas([][]byte{[]byte("ipfs://cid/1.json")}).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Metadata: ipfs://cid/1.json"},
+		},
+		{
+			name: "burn",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenBurnTransaction().SetTokenID(tokenId).SetAmount(20).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Amount: 20"},
+		},
+		{
+			name: "wipe serials",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenWipeTransaction().SetTokenID(tokenId).SetAccountID(accountId).SetSerialNumbers([]int64{1, 2}).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Serials: [1 2]", "Account: 0.0.1002"},
+		},
+		{
+			name: "freeze",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenFreezeTransaction().SetTokenID(tokenId).SetAccountID(accountId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Account: 0.0.1002"},
+		},
+		{
+			name: "unfreeze",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenUnfreezeTransaction().SetTokenID(tokenId).SetAccountID(accountId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Account: 0.0.1002"},
+		},
+		{
+			name: "grant KYC",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenGrantKycTransaction().SetTokenID(tokenId).SetAccountID(accountId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Account: 0.0.1002"},
+		},
+		{
+			name: "revoke KYC",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenRevokeKycTransaction().SetTokenID(tokenId).SetAccountID(accountId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Account: 0.0.1002"},
+		},
+		{
+			name: "update",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenUpdateTransaction().SetTokenID(tokenId).SetTokenName("Renamed").SetTokenMemo("new memo").FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Name: Renamed", "Memo: new memo"},
+		},
+		{
+			name: "delete",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenDeleteTransaction().SetTokenID(tokenId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005"},
+		},
+		{
+			name: "fee schedule",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenFeeScheduleUpdateTransaction().
+					SetTokenID(tokenId).
+					SetCustomFees([]hedera.Fee{
+						hedera.NewCustomFixedFee().SetHbarAmount(hedera.NewHbar(2)).SetFeeCollectorAccountID(accountId),
+					}).
+					FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Custom fee: fixed 2 ℏ to 0.0.1002"},
+		},
+		{
+			name: "clear fee schedule",
+			freeze: func(client *hedera.Client) (Transaction, error) {
+				return hedera.NewTokenFeeScheduleUpdateTransaction().SetTokenID(tokenId).FreezeWith(client)
+			},
+			wantDetails: []string{"Token: 0.0.5005", "Custom fees: none"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := tt.freeze(newClient(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Describe the transaction as read back from a file, as `tx show` and `tx sign` do
+			data, err := ToBytes(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, err := FromBytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := Describe(read)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(summary.Details, tt.wantDetails) {
+				t.Errorf("Details = %q, want %q", summary.Details, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestMergeMismatch(t *testing.T) {
+	client := newClient(t)
+	txID := hedera.TransactionIDGenerate(hedera.AccountID{Account: 1001})
+	freeze := func(memo string, nodes ...hedera.AccountID) []byte {
+		t.Helper()
+		tx := hedera.NewTopicMessageSubmitTransaction().
+			SetTopicID(hedera.TopicID{Topic: 1234}).
+			SetMessage([]byte("Hello offline")).
+			SetTransactionID(txID).
+			SetTransactionMemo(memo)
+		if len(nodes) > 0 {
+			tx.SetNodeAccountIDs(nodes)
+		}
+		frozen, err := tx.FreezeWith(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ToBytes(frozen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	nodes := []hedera.AccountID{{Account: 3}, {Account: 4}}
+	original := freeze("memo", nodes...)
+
+	tests := []struct {
+		name  string
+		other []byte
+	}{
+		{"different body", freeze("another memo", nodes...)},
+		{"different node count", freeze("memo", nodes[0])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Merge(original, tt.other); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Merge = %v, want ErrMismatch", err)
+			}
+		})
+	}
+
+	if _, err := Merge(); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Merge of nothing = %v, want ErrMismatch", err)
+	}
+	if _, err := Merge(original, freeze("memo", nodes...)); err != nil {
+		t.Errorf("Merge of the same transaction = %v", err)
+	}
+}
+
+func TestToBytesNotFrozen(t *testing.T) {
+	if _, err := ToBytes(hedera.NewTransferTransaction()); !errors.Is(err, ErrNotFrozen) {
+		t.Errorf("ToBytes = %v, want ErrNotFrozen", err)
+	}
+}
+
+func mustReadBytes(t *testing.T, path string) []byte {
+	t.Helper()
+	tx, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,396 @@
+// Package operator loads the operator account used by the Hello Future World
+// scripts, and builds a Hedera client that pays for transactions with it,
+// on the network selected with `-network` or `HEDERA_NETWORK`.
+//
+// Values are taken from (highest precedence first) command line flags,
+// process environment variables, and finally a `.env` file.
+// Both the `OPERATOR_ACCOUNT_ID`/`OPERATOR_ACCOUNT_PRIVATE_KEY` names
+// and the shorter `ACCOUNT_ID`/`ACCOUNT_PRIVATE_KEY` names are accepted.
+package operator
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/joho/godotenv"
+
+	"lib/network"
+)
+
+// Environment variable names, in the order that they are looked up
+var (
+	AccountIDEnvVars  = []string{"OPERATOR_ACCOUNT_ID", "ACCOUNT_ID"}
+	PrivateKeyEnvVars = []string{"OPERATOR_ACCOUNT_PRIVATE_KEY", "ACCOUNT_PRIVATE_KEY"}
+	KeyTypeEnvVars    = []string{"OPERATOR_ACCOUNT_KEY_TYPE", "ACCOUNT_KEY_TYPE"}
+	EVMAddressEnvVars = []string{"OPERATOR_ACCOUNT_EVM_ADDRESS", "ACCOUNT_EVM_ADDRESS"}
+)
+
+// Environment variable name formats of the other accounts, numbered from 0,
+// as generated alongside the operator account in `.env.sample`
+const (
+	AccountNIDEnvVar         = "ACCOUNT_%d_ID"
+	AccountNPrivateKeyEnvVar = "ACCOUNT_%d_PRIVATE_KEY"
+	AccountNKeyTypeEnvVar    = "ACCOUNT_%d_KEY_TYPE"
+	AccountNEVMAddressEnvVar = "ACCOUNT_%d_EVM_ADDRESS"
+)
+
+// DefaultEnvFiles are the `.env` files that are tried when none are specified.
+// The scripts are run from within their own directory,
+// so the `.env` file in the root of the repo is one level up.
+var DefaultEnvFiles = []string{".env", "../.env"}
+
+// KeyType identifies the signature algorithm of a private key
+type KeyType string
+
+const (
+	// KeyTypeAuto detects the key type from the key itself.
+	// DER encoded keys carry their own type;
+	// raw hex keys could be either, and are rejected with ErrAmbiguousKeyType,
+	// unless they derive the EVM address configured for their account.
+	KeyTypeAuto    KeyType = ""
+	KeyTypeECDSA   KeyType = "ecdsa"
+	KeyTypeED25519 KeyType = "ed25519"
+)
+
+// Prefix of the DER encoding of an ED25519 private key
+const ed25519DerPrefix = "302e020100300506032b6570"
+
+var (
+	// ErrMissing is returned when a required value has not been set anywhere
+	ErrMissing = errors.New("not set")
+	// ErrInvalidAccountID is returned when the account ID cannot be parsed
+	ErrInvalidAccountID = errors.New("invalid account ID")
+	// ErrInvalidPrivateKey is returned when the private key cannot be parsed
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+	// ErrUnknownKeyType is returned for key types other than ecdsa and ed25519
+	ErrUnknownKeyType = errors.New("unknown key type")
+	// ErrAmbiguousKeyType is returned for a raw hex key without a key type,
+	// which is as valid an ED25519 key as it is an ECDSA key
+	ErrAmbiguousKeyType = errors.New("ambiguous key type")
+)
+
+// ConfigError reports which configuration value could not be loaded
+type ConfigError struct {
+	// Name of the flag or environment variable(s) holding the value
+	Name string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("operator config %s: %v", e.Name, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Options controls where the operator config is loaded from.
+// Any non-empty field takes precedence over the environment.
+type Options struct {
+	AccountID  string
+	PrivateKey string
+	KeyType    string
+	// Network is the name of the network, see network.Load
+	Network string
+	// EnvFiles are tried in order, and only the first one that exists is loaded.
+	// When nil, DefaultEnvFiles is used.
+	EnvFiles []string
+}
+
+// RegisterFlags adds the operator flags to fs,
+// and returns the Options that they are parsed into.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	opts := &Options{}
+	fs.StringVar(&opts.AccountID, "operator-id", "", "operator account ID (default $OPERATOR_ACCOUNT_ID)")
+	fs.StringVar(&opts.PrivateKey, "operator-key", "", "operator account private key (default $OPERATOR_ACCOUNT_PRIVATE_KEY)")
+	fs.StringVar(&opts.KeyType, "operator-key-type", "", "operator key type: ecdsa or ed25519 (default: detect)")
+	fs.StringVar(&opts.Network, "network", "", "Hedera network: testnet, previewnet, mainnet, or local (default $"+network.NetworkEnvVar+" or "+network.Default+")")
+	return opts
+}
+
+// Config is a validated operator account
+type Config struct {
+	AccountID  hedera.AccountID
+	PrivateKey hedera.PrivateKey
+	KeyType    KeyType
+	Network    *network.Profile
+	// Accounts are the other accounts configured with ACCOUNT_0_ID, ACCOUNT_1_ID, and so on,
+	// that can be debited in addition to the operator account
+	Accounts []Account
+}
+
+// Account is an account other than the operator account, with its private key
+type Account struct {
+	// Name is the environment variable prefix, e.g. ACCOUNT_0
+	Name       string
+	AccountID  hedera.AccountID
+	PrivateKey hedera.PrivateKey
+	KeyType    KeyType
+}
+
+// Load reads and validates the operator config
+func Load(opts Options) (*Config, error) {
+	if err := loadEnvFile(opts.EnvFiles); err != nil {
+		return nil, err
+	}
+
+	accountIDName, accountIDStr := lookup("operator-id", opts.AccountID, AccountIDEnvVars)
+	if accountIDStr == "" {
+		return nil, &ConfigError{Name: accountIDName, Err: ErrMissing}
+	}
+	privateKeyName, privateKeyStr := lookup("operator-key", opts.PrivateKey, PrivateKeyEnvVars)
+	if privateKeyStr == "" {
+		return nil, &ConfigError{Name: privateKeyName, Err: ErrMissing}
+	}
+	keyTypeName, keyTypeStr := lookup("operator-key-type", opts.KeyType, KeyTypeEnvVars)
+	_, evmAddress := lookupEnv(EVMAddressEnvVars)
+
+	accountID, err := hedera.AccountIDFromString(accountIDStr)
+	if err != nil {
+		return nil, &ConfigError{Name: accountIDName, Err: fmt.Errorf("%w %q: %v", ErrInvalidAccountID, accountIDStr, err)}
+	}
+	keyType, err := ParseKeyType(keyTypeStr)
+	if err != nil {
+		return nil, &ConfigError{Name: keyTypeName, Err: err}
+	}
+	privateKey, keyType, err := parseAccountKey(privateKeyStr, keyType, evmAddress)
+	if err != nil {
+		if errors.Is(err, ErrAmbiguousKeyType) {
+			err = fmt.Errorf("%w, set -operator-key-type or %s", err, strings.Join(KeyTypeEnvVars, "/"))
+		}
+		return nil, &ConfigError{Name: privateKeyName, Err: err}
+	}
+	networkProfile, err := network.Load(opts.Network)
+	if err != nil {
+		networkName, _ := lookup("network", opts.Network, []string{network.NetworkEnvVar})
+		return nil, &ConfigError{Name: networkName, Err: err}
+	}
+
+	accounts, err := loadAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		AccountID:  accountID,
+		PrivateKey: privateKey,
+		KeyType:    keyType,
+		Network:    networkProfile,
+		Accounts:   accounts,
+	}, nil
+}
+
+// loadAccounts reads ACCOUNT_0_ID and ACCOUNT_0_PRIVATE_KEY, then ACCOUNT_1_ID and so on,
+// stopping at the first number without either.
+// Accounts with an ID but without a key, or the other way around, are a config error.
+// ACCOUNT_0_KEY_TYPE and ACCOUNT_0_EVM_ADDRESS are optional, as for the operator account.
+func loadAccounts() ([]Account, error) {
+	accounts := []Account{}
+	for i := 0; ; i++ {
+		accountIDName, privateKeyName := fmt.Sprintf(AccountNIDEnvVar, i), fmt.Sprintf(AccountNPrivateKeyEnvVar, i)
+		accountIDStr, privateKeyStr := os.Getenv(accountIDName), os.Getenv(privateKeyName)
+		keyTypeName := fmt.Sprintf(AccountNKeyTypeEnvVar, i)
+		evmAddress := os.Getenv(fmt.Sprintf(AccountNEVMAddressEnvVar, i))
+		if accountIDStr == "" && privateKeyStr == "" {
+			return accounts, nil
+		}
+		if accountIDStr == "" {
+			return nil, &ConfigError{Name: accountIDName, Err: ErrMissing}
+		}
+		if privateKeyStr == "" {
+			return nil, &ConfigError{Name: privateKeyName, Err: ErrMissing}
+		}
+		accountID, err := hedera.AccountIDFromString(accountIDStr)
+		if err != nil {
+			return nil, &ConfigError{Name: accountIDName, Err: fmt.Errorf("%w %q: %v", ErrInvalidAccountID, accountIDStr, err)}
+		}
+		keyType, err := ParseKeyType(os.Getenv(keyTypeName))
+		if err != nil {
+			return nil, &ConfigError{Name: keyTypeName, Err: err}
+		}
+		privateKey, keyType, err := parseAccountKey(privateKeyStr, keyType, evmAddress)
+		if err != nil {
+			if errors.Is(err, ErrAmbiguousKeyType) {
+				err = fmt.Errorf("%w, set %s", err, keyTypeName)
+			}
+			return nil, &ConfigError{Name: privateKeyName, Err: err}
+		}
+		accounts = append(accounts, Account{
+			Name:       strings.TrimSuffix(accountIDName, "_ID"),
+			AccountID:  accountID,
+			PrivateKey: privateKey,
+			KeyType:    keyType,
+		})
+	}
+}
+
+// ParseKeyType validates a key type name, case insensitively
+func ParseKeyType(s string) (KeyType, error) {
+	switch keyType := KeyType(strings.ToLower(strings.TrimSpace(s))); keyType {
+	case KeyTypeAuto, KeyTypeECDSA, KeyTypeED25519:
+		return keyType, nil
+	default:
+		return KeyTypeAuto, fmt.Errorf("%w %q", ErrUnknownKeyType, s)
+	}
+}
+
+// ParsePrivateKey parses a hex encoded private key, raw or DER encoded,
+// and returns it together with its detected key type.
+// With KeyTypeAuto, only DER encoded keys are accepted.
+func ParsePrivateKey(s string, keyType KeyType) (hedera.PrivateKey, KeyType, error) {
+	s = trimKey(s)
+
+	var key hedera.PrivateKey
+	var err error
+	switch keyType {
+	case KeyTypeECDSA:
+		key, err = hedera.PrivateKeyFromStringECDSA(s)
+	case KeyTypeED25519:
+		key, err = hedera.PrivateKeyFromStringEd25519(s)
+	case KeyTypeAuto:
+		if isRawKey(s) {
+			return hedera.PrivateKey{}, keyType, fmt.Errorf("%w: a raw hex key can be either ecdsa or ed25519", ErrAmbiguousKeyType)
+		}
+		key, err = hedera.PrivateKeyFromStringDer(s)
+	default:
+		return hedera.PrivateKey{}, keyType, fmt.Errorf("%w %q", ErrUnknownKeyType, keyType)
+	}
+	if err != nil {
+		return hedera.PrivateKey{}, keyType, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+
+	if strings.HasPrefix(key.StringDer(), ed25519DerPrefix) {
+		return key, KeyTypeED25519, nil
+	}
+	return key, KeyTypeECDSA, nil
+}
+
+// parseAccountKey parses the private key of a configured account, as ParsePrivateKey.
+// A raw hex key without a key type is accepted as ECDSA when it derives evmAddress,
+// which the `.env` file generated for the tutorials lists next to each key.
+func parseAccountKey(s string, keyType KeyType, evmAddress string) (hedera.PrivateKey, KeyType, error) {
+	if keyType != KeyTypeAuto || evmAddress == "" || !isRawKey(trimKey(s)) {
+		return ParsePrivateKey(s, keyType)
+	}
+	key, keyType, err := ParsePrivateKey(s, KeyTypeECDSA)
+	if err != nil {
+		return hedera.PrivateKey{}, keyType, err
+	}
+	if !strings.EqualFold(key.PublicKey().ToEvmAddress(), strings.TrimPrefix(strings.TrimSpace(evmAddress), "0x")) {
+		return hedera.PrivateKey{}, KeyTypeAuto, fmt.Errorf("%w: a raw hex key that does not derive the EVM address %s as an ecdsa key", ErrAmbiguousKeyType, evmAddress)
+	}
+	return key, keyType, nil
+}
+
+// trimKey removes the `0x` prefix of a hex encoded key.
+// Necessary because Go SDK v2.37.0 does not handle the `0x` prefix automatically
+// Ref: https://github.com/hashgraph/hedera-sdk-go/issues/1057
+func trimKey(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), "0x")
+}
+
+// isRawKey reports whether a trimmed hex key is too short to be DER encoded
+func isRawKey(s string) bool {
+	return len(s) <= 64
+}
+
+// NewClient returns a client for the configured network,
+// with this operator set to pay for transactions and queries.
+// The caller is responsible for closing the client.
+func (c *Config) NewClient() (*hedera.Client, error) {
+	client, err := c.Network.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
+	client.SetOperator(c.AccountID, c.PrivateKey)
+
+	// Set the default maximum transaction fee (in HBAR)
+	if err := client.SetDefaultMaxTransactionFee(hedera.HbarFrom(100, hedera.HbarUnits.Hbar)); err != nil {
+		client.Close()
+		return nil, err
+	}
+	// Set the default maximum payment for queries (in HBAR)
+	if err := client.SetDefaultMaxQueryPayment(hedera.HbarFrom(50, hedera.HbarUnits.Hbar)); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// loadEnvFile loads the first of the given `.env` files that exists.
+// A missing file is not an error, since all values may come from flags
+// or from the process environment instead.
+func loadEnvFile(envFiles []string) error {
+	if envFiles == nil {
+		envFiles = DefaultEnvFiles
+	}
+	for _, envFile := range envFiles {
+		if _, err := os.Stat(envFile); err != nil {
+			continue
+		}
+		// Values already present in the process environment are not overridden
+		if err := godotenv.Load(envFile); err != nil {
+			return &ConfigError{Name: envFile, Err: err}
+		}
+		return nil
+	}
+	return nil
+}
+
+// lookup returns the flag value if set, otherwise the first environment
+// variable that is set, together with the name that the value came from.
+func lookup(flagName string, flagValue string, envVars []string) (string, string) {
+	if flagValue != "" {
+		return "-" + flagName, flagValue
+	}
+	return lookupEnv(envVars)
+}
+
+// lookupEnv returns the first environment variable that is set, together with its name
+func lookupEnv(envVars []string) (string, string) {
+	for _, envVar := range envVars {
+		if value := os.Getenv(envVar); value != "" {
+			return envVar, value
+		}
+	}
+	return strings.Join(envVars, "/"), ""
+}
+
+// KeyList is a repeatable flag of private keys, e.g. for the other accounts that need to sign.
+// DER encoded keys carry their own type, raw hex keys are prefixed with theirs,
+// e.g. `ecdsa:0x...` or `ed25519:...`.
+type KeyList []hedera.PrivateKey
+
+func (l *KeyList) String() string {
+	keys := make([]string, len(*l))
+	for i, key := range *l {
+		keys[i] = key.PublicKey().String()
+	}
+	return strings.Join(keys, ",")
+}
+
+func (l *KeyList) Set(s string) error {
+	keyType := KeyTypeAuto
+	if prefix, rest, ok := strings.Cut(s, ":"); ok {
+		var err error
+		if keyType, err = ParseKeyType(prefix); err != nil {
+			return err
+		}
+		s = rest
+	}
+	key, _, err := ParsePrivateKey(s, keyType)
+	if errors.Is(err, ErrAmbiguousKeyType) {
+		return fmt.Errorf("%w, prefix it with ecdsa: or ed25519:", err)
+	}
+	if err != nil {
+		return err
+	}
+	*l = append(*l, key)
+	return nil
+}
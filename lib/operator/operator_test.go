@@ -0,0 +1,322 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/network"
+)
+
+type testKeys struct {
+	ecdsa, ed25519 hedera.PrivateKey
+}
+
+func generateKeys(t *testing.T) testKeys {
+	t.Helper()
+	ecdsaKey, err := hedera.PrivateKeyGenerateEcdsa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{ecdsa: ecdsaKey, ed25519: ed25519Key}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	k := generateKeys(t)
+	tests := []struct {
+		name     string
+		s        string
+		keyType  KeyType
+		want     hedera.PrivateKey
+		wantType KeyType
+		wantErr  error
+	}{
+		{"DER ecdsa", k.ecdsa.StringDer(), KeyTypeAuto, k.ecdsa, KeyTypeECDSA, nil},
+		{"DER ed25519", k.ed25519.StringDer(), KeyTypeAuto, k.ed25519, KeyTypeED25519, nil},
+		{"0x DER ed25519", "0x" + k.ed25519.StringDer(), KeyTypeAuto, k.ed25519, KeyTypeED25519, nil},
+		{"DER with a key type", k.ed25519.StringDer(), KeyTypeED25519, k.ed25519, KeyTypeED25519, nil},
+		{"raw ecdsa", k.ecdsa.StringRaw(), KeyTypeECDSA, k.ecdsa, KeyTypeECDSA, nil},
+		{"0x raw ecdsa", " 0x" + k.ecdsa.StringRaw() + "\n", KeyTypeECDSA, k.ecdsa, KeyTypeECDSA, nil},
+		{"raw ed25519", k.ed25519.StringRaw(), KeyTypeED25519, k.ed25519, KeyTypeED25519, nil},
+		// Either key type would parse a raw key, to a different public key
+		{"raw ecdsa without a key type", k.ecdsa.StringRaw(), KeyTypeAuto, hedera.PrivateKey{}, KeyTypeAuto, ErrAmbiguousKeyType},
+		{"raw ed25519 without a key type", "0x" + k.ed25519.StringRaw(), KeyTypeAuto, hedera.PrivateKey{}, KeyTypeAuto, ErrAmbiguousKeyType},
+		{"not hex", "not a key", KeyTypeECDSA, hedera.PrivateKey{}, KeyTypeECDSA, ErrInvalidPrivateKey},
+		{"invalid DER", strings.Repeat("ab", 48), KeyTypeAuto, hedera.PrivateKey{}, KeyTypeAuto, ErrInvalidPrivateKey},
+		{"unknown key type", k.ecdsa.StringRaw(), KeyType("rsa"), hedera.PrivateKey{}, KeyType("rsa"), ErrUnknownKeyType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, keyType, err := ParsePrivateKey(tt.s, tt.keyType)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParsePrivateKey = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.PublicKey().String() != tt.want.PublicKey().String() || keyType != tt.wantType {
+				t.Errorf("ParsePrivateKey = %s %s, want %s %s", keyType, key.PublicKey(), tt.wantType, tt.want.PublicKey())
+			}
+		})
+	}
+}
+
+func TestParseAccountKey(t *testing.T) {
+	k := generateKeys(t)
+	other := generateKeys(t)
+	evmAddress := "0x" + k.ecdsa.PublicKey().ToEvmAddress()
+	tests := []struct {
+		name       string
+		s          string
+		keyType    KeyType
+		evmAddress string
+		want       hedera.PrivateKey
+		wantErr    error
+	}{
+		{"raw ecdsa deriving the EVM address", "0x" + k.ecdsa.StringRaw(), KeyTypeAuto, evmAddress, k.ecdsa, nil},
+		{"EVM address in upper case", k.ecdsa.StringRaw(), KeyTypeAuto, strings.ToUpper(evmAddress[2:]), k.ecdsa, nil},
+		{"raw ed25519", k.ed25519.StringRaw(), KeyTypeAuto, "0x" + other.ecdsa.PublicKey().ToEvmAddress(), hedera.PrivateKey{}, ErrAmbiguousKeyType},
+		{"raw ecdsa of another account", other.ecdsa.StringRaw(), KeyTypeAuto, evmAddress, hedera.PrivateKey{}, ErrAmbiguousKeyType},
+		{"without an EVM address", k.ecdsa.StringRaw(), KeyTypeAuto, "", hedera.PrivateKey{}, ErrAmbiguousKeyType},
+		// A key type or a DER encoding is trusted over the EVM address
+		{"raw ed25519 with a key type", k.ed25519.StringRaw(), KeyTypeED25519, evmAddress, k.ed25519, nil},
+		{"DER ed25519", k.ed25519.StringDer(), KeyTypeAuto, evmAddress, k.ed25519, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _, err := parseAccountKey(tt.s, tt.keyType, tt.evmAddress)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("parseAccountKey = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.PublicKey().String() != tt.want.PublicKey().String() {
+				t.Errorf("parseAccountKey = %s, want %s", key.PublicKey(), tt.want.PublicKey())
+			}
+		})
+	}
+}
+
+func TestKeyList(t *testing.T) {
+	k := generateKeys(t)
+	var keys KeyList
+	for _, s := range []string{"ecdsa:0x" + k.ecdsa.StringRaw(), "ED25519:" + k.ed25519.StringRaw(), k.ed25519.StringDer()} {
+		if err := keys.Set(s); err != nil {
+			t.Fatalf("Set(%s) = %v", s, err)
+		}
+	}
+	want := []string{k.ecdsa.PublicKey().String(), k.ed25519.PublicKey().String(), k.ed25519.PublicKey().String()}
+	if got := keys.String(); got != strings.Join(want, ",") {
+		t.Errorf("KeyList = %s, want %s", got, strings.Join(want, ","))
+	}
+
+	if err := keys.Set(k.ecdsa.StringRaw()); !errors.Is(err, ErrAmbiguousKeyType) {
+		t.Errorf("Set of a raw key without a key type = %v, want %v", err, ErrAmbiguousKeyType)
+	}
+	if err := keys.Set("rsa:" + k.ecdsa.StringRaw()); !errors.Is(err, ErrUnknownKeyType) {
+		t.Errorf("Set with an unknown key type = %v, want %v", err, ErrUnknownKeyType)
+	}
+	if len(keys) != 3 {
+		t.Errorf("KeyList has %d keys after failed Set calls, want 3", len(keys))
+	}
+}
+
+// clearEnv unsets every variable that Load reads, for the duration of the test,
+// including those that a `.env` file sets
+func clearEnv(t *testing.T) {
+	t.Helper()
+	names := []string{network.NetworkEnvVar}
+	names = append(names, AccountIDEnvVars...)
+	names = append(names, PrivateKeyEnvVars...)
+	names = append(names, KeyTypeEnvVars...)
+	names = append(names, EVMAddressEnvVars...)
+	for i := 0; i < 3; i++ {
+		for _, format := range []string{AccountNIDEnvVar, AccountNPrivateKeyEnvVar, AccountNKeyTypeEnvVar, AccountNEVMAddressEnvVar} {
+			names = append(names, fmt.Sprintf(format, i))
+		}
+	}
+	for _, name := range names {
+		// Setenv restores the variable after the test, and an empty variable
+		// would keep a `.env` file from setting it
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeEnvFile(t *testing.T, contents string) string {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return envFile
+}
+
+func TestLoad(t *testing.T) {
+	k := generateKeys(t)
+	account0 := generateKeys(t)
+	envKey := "0x" + k.ecdsa.StringRaw()
+	envFileKey := k.ed25519.StringDer()
+	envFile := writeEnvFile(t, fmt.Sprintf("OPERATOR_ACCOUNT_ID=0.0.3\nOPERATOR_ACCOUNT_PRIVATE_KEY=%s\n", envFileKey))
+	otherEnvFile := writeEnvFile(t, "OPERATOR_ACCOUNT_ID=0.0.4\n")
+
+	tests := []struct {
+		name        string
+		opts        Options
+		env         map[string]string
+		wantAccount string
+		wantKey     hedera.PrivateKey
+		wantType    KeyType
+	}{
+		{
+			name:        ".env file",
+			opts:        Options{EnvFiles: []string{envFile}},
+			wantAccount: "0.0.3",
+			wantKey:     k.ed25519,
+			wantType:    KeyTypeED25519,
+		},
+		{
+			name:        "first .env file that exists",
+			opts:        Options{EnvFiles: []string{filepath.Join(t.TempDir(), ".env"), envFile, otherEnvFile}},
+			wantAccount: "0.0.3",
+			wantKey:     k.ed25519,
+			wantType:    KeyTypeED25519,
+		},
+		{
+			name:        "environment over .env file",
+			opts:        Options{EnvFiles: []string{envFile}},
+			env:         map[string]string{"OPERATOR_ACCOUNT_ID": "0.0.2", "OPERATOR_ACCOUNT_PRIVATE_KEY": envKey, "OPERATOR_ACCOUNT_KEY_TYPE": "ECDSA"},
+			wantAccount: "0.0.2",
+			wantKey:     k.ecdsa,
+			wantType:    KeyTypeECDSA,
+		},
+		{
+			name:        "flags over environment",
+			opts:        Options{AccountID: "0.0.1", PrivateKey: k.ed25519.StringRaw(), KeyType: "ed25519", EnvFiles: []string{envFile}},
+			env:         map[string]string{"OPERATOR_ACCOUNT_ID": "0.0.2", "OPERATOR_ACCOUNT_PRIVATE_KEY": envKey, "OPERATOR_ACCOUNT_KEY_TYPE": "ecdsa"},
+			wantAccount: "0.0.1",
+			wantKey:     k.ed25519,
+			wantType:    KeyTypeED25519,
+		},
+		{
+			name:        "shorter names",
+			opts:        Options{EnvFiles: []string{}},
+			env:         map[string]string{"ACCOUNT_ID": "0.0.5", "ACCOUNT_PRIVATE_KEY": envKey, "ACCOUNT_EVM_ADDRESS": k.ecdsa.PublicKey().ToEvmAddress()},
+			wantAccount: "0.0.5",
+			wantKey:     k.ecdsa,
+			wantType:    KeyTypeECDSA,
+		},
+		{
+			name:        "operator names over shorter names",
+			opts:        Options{EnvFiles: []string{}},
+			env:         map[string]string{"ACCOUNT_ID": "0.0.5", "OPERATOR_ACCOUNT_ID": "0.0.6", "ACCOUNT_PRIVATE_KEY": envKey, "OPERATOR_ACCOUNT_PRIVATE_KEY": envFileKey},
+			wantAccount: "0.0.6",
+			wantKey:     k.ed25519,
+			wantType:    KeyTypeED25519,
+		},
+		{
+			name: "generated .env file",
+			opts: Options{EnvFiles: []string{writeEnvFile(t, fmt.Sprintf(
+				"OPERATOR_ACCOUNT_PRIVATE_KEY=%s\nOPERATOR_ACCOUNT_EVM_ADDRESS=0x%s\nOPERATOR_ACCOUNT_ID=0.0.7\n"+
+					"ACCOUNT_0_PRIVATE_KEY=0x%s\nACCOUNT_0_EVM_ADDRESS=0x%s\nACCOUNT_0_ID=0.0.8\n"+
+					"ACCOUNT_1_PRIVATE_KEY=%s\nACCOUNT_1_KEY_TYPE=ed25519\nACCOUNT_1_ID=0.0.9\n",
+				envKey, k.ecdsa.PublicKey().ToEvmAddress(),
+				account0.ecdsa.StringRaw(), account0.ecdsa.PublicKey().ToEvmAddress(),
+				account0.ed25519.StringRaw()))}},
+			wantAccount: "0.0.7",
+			wantKey:     k.ecdsa,
+			wantType:    KeyTypeECDSA,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			config, err := Load(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.AccountID.String(); got != tt.wantAccount {
+				t.Errorf("AccountID = %s, want %s", got, tt.wantAccount)
+			}
+			if config.PrivateKey.PublicKey().String() != tt.wantKey.PublicKey().String() || config.KeyType != tt.wantType {
+				t.Errorf("PrivateKey = %s %s, want %s %s", config.KeyType, config.PrivateKey.PublicKey(), tt.wantType, tt.wantKey.PublicKey())
+			}
+			if config.Network.Name != network.Default {
+				t.Errorf("Network = %s, want %s", config.Network.Name, network.Default)
+			}
+		})
+	}
+
+	t.Run("accounts", func(t *testing.T) {
+		clearEnv(t)
+		config, err := Load(tests[len(tests)-1].opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, account := range config.Accounts {
+			got = append(got, fmt.Sprintf("%s %s %s %s", account.Name, account.AccountID, account.KeyType, account.PrivateKey.PublicKey()))
+		}
+		want := []string{
+			fmt.Sprintf("ACCOUNT_0 0.0.8 ecdsa %s", account0.ecdsa.PublicKey()),
+			fmt.Sprintf("ACCOUNT_1 0.0.9 ed25519 %s", account0.ed25519.PublicKey()),
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Accounts =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	k := generateKeys(t)
+	tests := []struct {
+		name     string
+		opts     Options
+		env      map[string]string
+		wantName string
+		wantErr  error
+	}{
+		{"missing account ID", Options{PrivateKey: k.ed25519.StringDer()}, nil, "OPERATOR_ACCOUNT_ID/ACCOUNT_ID", ErrMissing},
+		{"missing private key", Options{AccountID: "0.0.2"}, nil, "OPERATOR_ACCOUNT_PRIVATE_KEY/ACCOUNT_PRIVATE_KEY", ErrMissing},
+		{"invalid account ID", Options{AccountID: "alice", PrivateKey: k.ed25519.StringDer()}, nil, "-operator-id", ErrInvalidAccountID},
+		{"unknown key type", Options{AccountID: "0.0.2", PrivateKey: k.ed25519.StringDer()}, map[string]string{"ACCOUNT_KEY_TYPE": "rsa"}, "ACCOUNT_KEY_TYPE", ErrUnknownKeyType},
+		{"raw key without a key type", Options{AccountID: "0.0.2"}, map[string]string{"OPERATOR_ACCOUNT_PRIVATE_KEY": k.ed25519.StringRaw()}, "OPERATOR_ACCOUNT_PRIVATE_KEY", ErrAmbiguousKeyType},
+		{"unknown network", Options{AccountID: "0.0.2", PrivateKey: k.ed25519.StringDer(), Network: "devnet"}, nil, "-network", network.ErrUnknownNetwork},
+		{"account without a key", Options{AccountID: "0.0.2", PrivateKey: k.ed25519.StringDer()}, map[string]string{"ACCOUNT_0_ID": "0.0.5"}, "ACCOUNT_0_PRIVATE_KEY", ErrMissing},
+		{"account raw key without a key type", Options{AccountID: "0.0.2", PrivateKey: k.ed25519.StringDer()},
+			map[string]string{"ACCOUNT_0_ID": "0.0.5", "ACCOUNT_0_PRIVATE_KEY": k.ed25519.StringRaw()}, "ACCOUNT_0_PRIVATE_KEY", ErrAmbiguousKeyType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			tt.opts.EnvFiles = []string{}
+			config, err := Load(tt.opts)
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || configErr.Name != tt.wantName || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load = %+v, %v, want %s: %v", config, err, tt.wantName, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package output implements the `-output json` mode of the scripts.
+//
+// In JSON mode, the human readable progress lines that the scripts print
+// are moved to stderr, and exactly one JSON document, the Report,
+// is written to stdout when the script finishes, whether it succeeded or not.
+// This lets pipelines chain scripts without scraping their output.
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/failure"
+	"lib/mirror"
+	"lib/network"
+	"lib/operator"
+	"lib/txid"
+)
+
+// Format of the script output
+type Format string
+
+const (
+	// FormatText is the human readable output, with emoji progress lines
+	FormatText Format = "text"
+	// FormatJSON is a single JSON document, see Report
+	FormatJSON Format = "json"
+)
+
+// ErrUnknownFormat is returned for formats other than text and json
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// Report is the JSON document describing a single run of a script
+type Report struct {
+	Script   string `json:"script"`
+	Network  string `json:"network,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Success  bool   `json:"success"`
+	Error    *Error `json:"error,omitempty"`
+	// Transactions are listed in the order that they were submitted
+	Transactions []Transaction `json:"transactions"`
+	// Entities holds the IDs of the entities created or used, by kind, e.g. `topic`
+	Entities map[string]string `json:"entities"`
+	// ExplorerURLs holds the HashScan URLs of Entities, by kind
+	ExplorerURLs map[string]string `json:"explorerUrls"`
+	// Mirror holds the results of the mirror node verification steps
+	Mirror []MirrorVerification `json:"mirror"`
+	// Values holds any other results of the script, e.g. a contract call result
+	Values map[string]any `json:"values,omitempty"`
+
+	format  *string
+	stdout  *os.File
+	profile *network.Profile
+}
+
+// Error describes why a script failed
+type Error struct {
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exitCode"`
+	Message  string `json:"message"`
+}
+
+// Transaction is a transaction submitted by a script
+type Transaction struct {
+	Name          string `json:"name"`
+	TransactionID string `json:"transactionId"`
+	Status        string `json:"status"`
+	ExplorerURL   string `json:"explorerUrl,omitempty"`
+	MirrorNodeURL string `json:"mirrorNodeUrl,omitempty"`
+	// ConsensusTimestamp and ChargedFee are taken from the mirror node, see FillFromMirror
+	ConsensusTimestamp string `json:"consensusTimestamp,omitempty"`
+	// ChargedFee is in tinybars
+	ChargedFee int64 `json:"chargedFee,omitempty"`
+
+	id hedera.TransactionID
+}
+
+// MirrorVerification is the result of reading data back from the mirror node
+type MirrorVerification struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+	Data     any    `json:"data,omitempty"`
+}
+
+// RegisterFlags adds the `-output` flag to fs,
+// and returns the Report for the script with the given name.
+func RegisterFlags(fs *flag.FlagSet, script string) *Report {
+	report := &Report{
+		Script:       script,
+		Transactions: []Transaction{},
+		Entities:     map[string]string{},
+		ExplorerURLs: map[string]string{},
+		Mirror:       []MirrorVerification{},
+	}
+	report.format = fs.String("output", string(FormatText), "output format: text or json")
+	return report
+}
+
+// Begin validates the output format, once the flags have been parsed.
+// In JSON mode, stdout is redirected to stderr from here on,
+// so that the progress lines do not mix with the JSON document.
+func (r *Report) Begin() error {
+	switch Format(*r.format) {
+	case FormatText:
+	case FormatJSON:
+		r.stdout = os.Stdout
+		os.Stdout = os.Stderr
+	default:
+		return failure.Wrap(failure.KindConfig, fmt.Errorf("-output: %w %q", ErrUnknownFormat, *r.format))
+	}
+	return nil
+}
+
+// JSON reports whether the JSON document will be written
+func (r *Report) JSON() bool {
+	return Format(*r.format) == FormatJSON
+}
+
+// SetOperator records the operator account and network,
+// which are also used to build the explorer and mirror node URLs
+func (r *Report) SetOperator(config *operator.Config) {
+	r.Operator = config.AccountID.String()
+	r.Network = config.Network.Name
+	r.profile = config.Network
+}
+
+// AddTransaction records a submitted transaction and its receipt status
+func (r *Report) AddTransaction(name string, txID hedera.TransactionID, status hedera.Status) {
+	transaction := Transaction{
+		Name:          name,
+		TransactionID: txID.String(),
+		Status:        status.String(),
+		id:            txID,
+	}
+	if r.profile != nil {
+		transaction.ExplorerURL = r.profile.ExplorerEntityURL("transaction", txID)
+		if id, err := txid.FromSDK(txID); err == nil {
+			transaction.MirrorNodeURL = r.profile.MirrorNodeAPIURL("transactions/%s", id.MirrorString())
+		}
+	}
+	r.Transactions = append(r.Transactions, transaction)
+}
+
+// AddEntity records the ID of an entity, and its HashScan URL,
+// where kind is one of `account`, `topic`, `token`, `contract`, or `file`
+func (r *Report) AddEntity(kind string, id fmt.Stringer) {
+	r.Entities[kind] = id.String()
+	// HashScan has no page for files
+	if r.profile != nil && kind != "file" {
+		r.ExplorerURLs[kind] = r.profile.ExplorerEntityURL(kind, id)
+	}
+}
+
+// AddMirrorVerification records the result of a mirror node verification step
+func (r *Report) AddMirrorVerification(name string, url string, data any, err error) {
+	verification := MirrorVerification{
+		Name:     name,
+		URL:      url,
+		Verified: err == nil,
+		Data:     data,
+	}
+	if err != nil {
+		verification.Error = err.Error()
+	}
+	r.Mirror = append(r.Mirror, verification)
+}
+
+// Set records any other result of the script
+func (r *Report) Set(key string, value any) {
+	if r.Values == nil {
+		r.Values = map[string]any{}
+	}
+	r.Values[key] = value
+}
+
+// FillFromMirror looks up each recorded transaction on the mirror node,
+// to add its consensus timestamp and the fee that was charged.
+// This only happens in JSON mode, as the text output does not show them.
+func (r *Report) FillFromMirror(ctx context.Context, mirrorClient *mirror.Client) error {
+	if !r.JSON() {
+		return nil
+	}
+	for i := range r.Transactions {
+		transaction := &r.Transactions[i]
+		transactionID, query, err := txid.ToMirror(transaction.id)
+		if err != nil {
+			return err
+		}
+		resp, err := mirrorClient.WaitForTransaction(ctx, mirror.DefaultBackoff, transactionID, query)
+		if err != nil {
+			return fmt.Errorf("fetching %s transaction: %w", transaction.Name, err)
+		}
+		transaction.ConsensusTimestamp = resp.Transactions[0].ConsensusTimestamp
+		transaction.ChargedFee = resp.Transactions[0].ChargedTxFee
+	}
+	return nil
+}
+
+// Finish writes the JSON document to stdout, in JSON mode,
+// recording err, if any, as the reason that the script failed
+func (r *Report) Finish(err error) {
+	if !r.JSON() {
+		return
+	}
+	r.Success = err == nil
+	if err != nil {
+		kind := failure.Classify(err)
+		r.Error = &Error{
+			Kind:     kind.String(),
+			ExitCode: kind.ExitCode(),
+			Message:  err.Error(),
+		}
+	}
+	encoder := json.NewEncoder(r.stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON output: %v\n", err)
+	}
+}
@@ -0,0 +1,102 @@
+package protected
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// Hedera keys are ED25519 or ECDSA secp256k1 keys, so the content key of a message is exchanged
+// with X25519 for ED25519 keys, which are converted to their X25519 form,
+// and with ECDH on secp256k1 for ECDSA keys
+
+// isEd25519 reports whether a public key is an ED25519 key, as its raw form is 32 bytes,
+// rather than the 33 bytes of a compressed secp256k1 point
+func isEd25519(publicKey hedera.PublicKey) bool {
+	return len(publicKey.BytesRaw()) == 32
+}
+
+// encapsulate generates an ephemeral key on the curve of the recipient key,
+// and returns its public key, and the secret it shares with the recipient
+func encapsulate(recipient hedera.PublicKey) (ephemeralKey []byte, sharedSecret []byte, err error) {
+	if isEd25519(recipient) {
+		recipientKey, err := x25519PublicKey(recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		sharedSecret, err := ephemeral.ECDH(recipientKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ephemeral.PublicKey().Bytes(), sharedSecret, nil
+	}
+	recipientKey, err := secp256k1.ParsePubKey(recipient.BytesRaw())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing secp256k1 public key: %w", err)
+	}
+	ephemeral, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ephemeral.PubKey().SerializeCompressed(), secp256k1.GenerateSharedSecret(ephemeral, recipientKey), nil
+}
+
+// decapsulate returns the secret that the ephemeral key of a message shares with the recipient private key
+func decapsulate(recipient hedera.PrivateKey, ephemeralKey []byte) ([]byte, error) {
+	if isEd25519(recipient.PublicKey()) {
+		// The X25519 private key is the scalar of the ED25519 key, which is derived from its seed
+		digest := sha512.Sum512(recipient.BytesRaw())
+		privateKey, err := ecdh.X25519().NewPrivateKey(digest[:32])
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := ecdh.X25519().NewPublicKey(ephemeralKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ephemeral key: %v", ErrMalformed, err)
+		}
+		return privateKey.ECDH(publicKey)
+	}
+	publicKey, err := secp256k1.ParsePubKey(ephemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ephemeral key: %v", ErrMalformed, err)
+	}
+	return secp256k1.GenerateSharedSecret(secp256k1.PrivKeyFromBytes(recipient.BytesRaw()), publicKey), nil
+}
+
+// fieldPrime is 2^255 - 19, the prime of the field of Curve25519 and Edwards25519
+var fieldPrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// x25519PublicKey converts an ED25519 public key, a point y on the Edwards curve,
+// to the X25519 public key of the same private key, the point u = (1 + y) / (1 - y) on the Montgomery curve
+func x25519PublicKey(publicKey hedera.PublicKey) (*ecdh.PublicKey, error) {
+	// Both are little-endian, and the top bit of an Edwards point is the sign of x, which u does not need
+	edwards := slices.Clone(publicKey.BytesRaw())
+	edwards[31] &= 0x7f
+	slices.Reverse(edwards)
+	y := new(big.Int).SetBytes(edwards)
+	if y.Cmp(fieldPrime) >= 0 {
+		return nil, errors.New("invalid ED25519 public key")
+	}
+	numerator := new(big.Int).Add(big.NewInt(1), y)
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, fieldPrime)
+	if denominator.Sign() == 0 {
+		return nil, errors.New("invalid ED25519 public key")
+	}
+	u := numerator.Mul(numerator, denominator.ModInverse(denominator, fieldPrime))
+	u.Mod(u, fieldPrime)
+	montgomery := u.FillBytes(make([]byte, 32))
+	slices.Reverse(montgomery)
+	return ecdh.X25519().NewPublicKey(montgomery)
+}
@@ -0,0 +1,313 @@
+// Package protected signs HCS message payloads with the private key of their sender,
+// so that readers can verify who wrote a message, whoever paid for the transaction that submitted it,
+// and optionally encrypts them to the public keys of a set of recipients, so that only they can read them.
+//
+// A protected message is a JSON object, marked with a `protected` field set to Version:
+//
+//	{"protected":"hfw/1","sender":"0.0.2","publicKey":"302a...","payload":"<base64>","signature":"<base64>"}
+//
+// An encrypted message has an `encryption` object instead of a payload. The payload is encrypted with AES-256-GCM
+// under a random content key, which is wrapped for each recipient with a key derived with HKDF-SHA256
+// from the secret that an ephemeral key shares with the recipient key:
+// with X25519 for ED25519 keys, and with ECDH on secp256k1 for ECDSA keys.
+//
+// The signature covers the topic, the sender, the public key, and the payload or its encryption,
+// so that a message cannot be replayed on another topic,
+// and the encryption is bound to the public key, so that another key cannot sign a message it cannot read as its own.
+package protected
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"golang.org/x/crypto/hkdf"
+
+	"lib/keys"
+	"lib/mirror"
+)
+
+// Version marks a protected message, and is the value of its `protected` field
+const Version = "hfw/1"
+
+// Algorithm names how the content key is exchanged and wrapped, and how the payload is encrypted
+const Algorithm = "ECDH-HKDF-SHA256-A256GCM"
+
+var (
+	// ErrNotProtected is returned for messages that are not protected messages
+	ErrNotProtected = errors.New("not a protected message")
+	// ErrMalformed is returned for protected messages that cannot be decoded
+	ErrMalformed = errors.New("malformed protected message")
+	// ErrInvalidSignature is returned when the signature is not by the public key of the message,
+	// or the message was changed after it was signed
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrNotRecipient is returned when none of the keys can decrypt an encrypted message
+	ErrNotRecipient = errors.New("not a recipient of the encrypted message")
+	// ErrSenderKeyMismatch is returned when the signing key does not satisfy the key of the sender account
+	ErrSenderKeyMismatch = errors.New("signing key does not satisfy the key of the sender account")
+)
+
+type message struct {
+	Protected  string      `json:"protected"`
+	Sender     string      `json:"sender,omitempty"`
+	PublicKey  string      `json:"publicKey"`
+	Payload    []byte      `json:"payload,omitempty"`
+	Encryption *encryption `json:"encryption,omitempty"`
+	Signature  []byte      `json:"signature"`
+}
+
+type encryption struct {
+	Algorithm  string      `json:"algorithm"`
+	Recipients []recipient `json:"recipients"`
+	Nonce      []byte      `json:"nonce"`
+	Ciphertext []byte      `json:"ciphertext"`
+}
+
+// recipient holds the content key, wrapped for a single recipient key
+type recipient struct {
+	PublicKey    string `json:"publicKey"`
+	EphemeralKey []byte `json:"ephemeralKey"`
+	WrappedKey   []byte `json:"wrappedKey"`
+}
+
+// Options are the optional parts of a protected message
+type Options struct {
+	// Sender is the account of the signing key, which readers can check the key against with VerifySender
+	Sender string
+	// Recipients are the public keys to encrypt the payload to.
+	// Without recipients, the payload is only signed, and anyone can read it.
+	Recipients []hedera.PublicKey
+}
+
+// Opened is a protected message whose signature has been verified
+type Opened struct {
+	// Sender is the account that the sender claims, which VerifySender checks
+	Sender    string
+	PublicKey hedera.PublicKey
+	// Payload is nil when the message is encrypted, but not to any of the keys it was opened with
+	Payload []byte
+	// Recipients are the public keys the payload was encrypted to, and empty when it was only signed
+	Recipients []hedera.PublicKey
+}
+
+// Encrypted reports whether the payload was encrypted
+func (o *Opened) Encrypted() bool {
+	return len(o.Recipients) > 0
+}
+
+// Seal signs a payload for a topic with key, and encrypts it to the recipients, if there are any
+func Seal(topicID string, key hedera.PrivateKey, payload []byte, opts Options) ([]byte, error) {
+	m := message{
+		Protected: Version,
+		Sender:    opts.Sender,
+		PublicKey: key.PublicKey().String(),
+	}
+	if len(opts.Recipients) == 0 {
+		m.Payload = payload
+	} else {
+		var err error
+		m.Encryption, err = encrypt(payload, opts.Recipients, additionalData(topicID, m.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting payload: %w", err)
+		}
+	}
+	m.Signature = key.Sign(m.digest(topicID))
+	return json.Marshal(m)
+}
+
+// Open verifies the signature of a protected message on a topic,
+// and decrypts it with whichever of the keys it was encrypted to.
+// An encrypted message that none of the keys can decrypt is returned without its payload, with ErrNotRecipient.
+func Open(topicID string, data []byte, keys ...hedera.PrivateKey) (*Opened, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, ErrNotProtected
+	}
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil || m.Protected != Version {
+		return nil, ErrNotProtected
+	}
+	publicKey, err := hedera.PublicKeyFromString(m.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: public key: %v", ErrMalformed, err)
+	}
+	if !verify(publicKey, m.digest(topicID), m.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	opened := &Opened{Sender: m.Sender, PublicKey: publicKey}
+	if m.Encryption == nil {
+		opened.Payload = m.Payload
+		return opened, nil
+	}
+	for _, r := range m.Encryption.Recipients {
+		recipientKey, err := hedera.PublicKeyFromString(r.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: recipient key: %v", ErrMalformed, err)
+		}
+		opened.Recipients = append(opened.Recipients, recipientKey)
+	}
+	opened.Payload, err = decrypt(m.Encryption, keys, additionalData(topicID, m.PublicKey))
+	if err != nil {
+		return opened, err
+	}
+	return opened, nil
+}
+
+// VerifySender checks on the mirror node that the signing key satisfies the key of the sender account,
+// that is, that the message was written by whoever controls the account
+func VerifySender(ctx context.Context, mirrorClient *mirror.Client, opened *Opened) error {
+	if opened.Sender == "" {
+		return fmt.Errorf("%w: the message does not name its sender account", ErrSenderKeyMismatch)
+	}
+	account, err := mirrorClient.GetAccount(ctx, opened.Sender)
+	if err != nil {
+		return fmt.Errorf("getting sender account %s: %w", opened.Sender, err)
+	}
+	accountKey, err := keys.FromMirror(account.Key)
+	if err != nil {
+		return fmt.Errorf("decoding key of sender account %s: %w", opened.Sender, err)
+	}
+	if accountKey == nil || !accountKey.SatisfiedBy([]hedera.PublicKey{opened.PublicKey}) {
+		return fmt.Errorf("%w: %s does not satisfy the key of %s", ErrSenderKeyMismatch, opened.PublicKey.StringRaw(), opened.Sender)
+	}
+	return nil
+}
+
+// digest returns the SHA-384 of every field of the message but the signature,
+// each prefixed with its length, so that no two messages have the same digest
+func (m *message) digest(topicID string) []byte {
+	h := sha512.New384()
+	write := func(field []byte) {
+		_ = binary.Write(h, binary.BigEndian, uint32(len(field)))
+		h.Write(field)
+	}
+	write([]byte(m.Protected))
+	write([]byte(topicID))
+	write([]byte(m.Sender))
+	write([]byte(m.PublicKey))
+	if m.Encryption == nil {
+		write([]byte("payload"))
+		write(m.Payload)
+		return h.Sum(nil)
+	}
+	write([]byte("encryption"))
+	write([]byte(m.Encryption.Algorithm))
+	for _, r := range m.Encryption.Recipients {
+		write([]byte(r.PublicKey))
+		write(r.EphemeralKey)
+		write(r.WrappedKey)
+	}
+	write(m.Encryption.Nonce)
+	write(m.Encryption.Ciphertext)
+	return h.Sum(nil)
+}
+
+// verify checks a signature made with PrivateKey.Sign.
+// ECDSA keys sign the Keccak-256 hash of a message, but verify a hash, so they are given the hash.
+func verify(publicKey hedera.PublicKey, message []byte, signature []byte) bool {
+	if isEd25519(publicKey) {
+		return publicKey.Verify(message, signature)
+	}
+	return publicKey.Verify(crypto.Keccak256(message), signature)
+}
+
+// additionalData binds the ciphertext to the topic and the signing key
+func additionalData(topicID string, publicKey string) []byte {
+	return []byte(Version + "\x00" + topicID + "\x00" + publicKey)
+}
+
+func encrypt(payload []byte, recipients []hedera.PublicKey, additionalData []byte) (*encryption, error) {
+	contentKey := make([]byte, 32)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	e := &encryption{Algorithm: Algorithm, Nonce: make([]byte, 12)}
+	for _, recipientKey := range recipients {
+		ephemeralKey, sharedSecret, err := encapsulate(recipientKey)
+		if err != nil {
+			return nil, fmt.Errorf("exchanging key with %s: %w", recipientKey.StringRaw(), err)
+		}
+		wrappingKey, err := gcm(deriveKey(sharedSecret, ephemeralKey, recipientKey))
+		if err != nil {
+			return nil, err
+		}
+		e.Recipients = append(e.Recipients, recipient{
+			PublicKey:    recipientKey.String(),
+			EphemeralKey: ephemeralKey,
+			// Each wrapping key is only used once, so a zero nonce is safe
+			WrappedKey: wrappingKey.Seal(nil, make([]byte, wrappingKey.NonceSize()), contentKey, nil),
+		})
+	}
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	content, err := gcm(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	e.Ciphertext = content.Seal(nil, e.Nonce, payload, additionalData)
+	return e, nil
+}
+
+func decrypt(e *encryption, keys []hedera.PrivateKey, additionalData []byte) ([]byte, error) {
+	if e.Algorithm != Algorithm {
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrMalformed, e.Algorithm)
+	}
+	for _, key := range keys {
+		publicKey := key.PublicKey()
+		for _, r := range e.Recipients {
+			if r.PublicKey != publicKey.String() {
+				continue
+			}
+			sharedSecret, err := decapsulate(key, r.EphemeralKey)
+			if err != nil {
+				return nil, err
+			}
+			wrappingKey, err := gcm(deriveKey(sharedSecret, r.EphemeralKey, publicKey))
+			if err != nil {
+				return nil, err
+			}
+			contentKey, err := wrappingKey.Open(nil, make([]byte, wrappingKey.NonceSize()), r.WrappedKey, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unwrapping content key: %v", ErrMalformed, err)
+			}
+			content, err := gcm(contentKey)
+			if err != nil {
+				return nil, fmt.Errorf("%w: content key: %v", ErrMalformed, err)
+			}
+			payload, err := content.Open(nil, e.Nonce, e.Ciphertext, additionalData)
+			if err != nil {
+				return nil, fmt.Errorf("%w: decrypting payload: %v", ErrMalformed, err)
+			}
+			return payload, nil
+		}
+	}
+	return nil, ErrNotRecipient
+}
+
+// deriveKey derives the key that wraps the content key for a recipient
+func deriveKey(sharedSecret []byte, ephemeralKey []byte, recipientKey hedera.PublicKey) []byte {
+	salt := append(bytes.Clone(ephemeralKey), recipientKey.BytesRaw()...)
+	key := make([]byte, 32)
+	_, _ = io.ReadFull(hkdf.New(func() hash.Hash { return sha256.New() }, sharedSecret, salt, []byte(Version+" content key")), key)
+	return key
+}
+
+func gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
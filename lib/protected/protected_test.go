@@ -0,0 +1,226 @@
+package protected
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+)
+
+const topicID = "0.0.1234"
+
+// generateKeys returns a new ED25519 key and a new ECDSA key, by their type
+func generateKeys(t *testing.T) map[string]hedera.PrivateKey {
+	t.Helper()
+	ed25519Key, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := hedera.PrivateKeyGenerateEcdsa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]hedera.PrivateKey{"ED25519": ed25519Key, "ECDSA": ecdsaKey}
+}
+
+func seal(t *testing.T, key hedera.PrivateKey, payload string, recipients ...hedera.PublicKey) []byte {
+	t.Helper()
+	data, err := Seal(topicID, key, []byte(payload), Options{Sender: "0.0.1001", Recipients: recipients})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// resign changes a sealed message, and signs it again with key, as someone who took the message might
+func resign(t *testing.T, data []byte, key hedera.PrivateKey, change func(m *message)) []byte {
+	t.Helper()
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	change(&m)
+	m.PublicKey = key.PublicKey().String()
+	m.Signature = key.Sign(m.digest(topicID))
+	resigned, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resigned
+}
+
+func TestSealAndOpen(t *testing.T) {
+	for name, signer := range generateKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			opened, err := Open(topicID, seal(t, signer, "Hello HCS"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(opened.Payload) != "Hello HCS" || opened.Sender != "0.0.1001" || opened.Encrypted() {
+				t.Errorf("Open = %+v, want the signed payload", opened)
+			}
+			if !bytes.Equal(opened.PublicKey.BytesRaw(), signer.PublicKey().BytesRaw()) {
+				t.Errorf("PublicKey = %s, want %s", opened.PublicKey, signer.PublicKey())
+			}
+
+			// The signature covers the topic
+			if _, err := Open("0.0.9999", seal(t, signer, "Hello HCS")); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Open on another topic = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestEncryptToRecipients(t *testing.T) {
+	recipients := generateKeys(t)
+	outsider, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, signer := range generateKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			data := seal(t, signer, "Hello recipients", recipients["ED25519"].PublicKey(), recipients["ECDSA"].PublicKey())
+			if bytes.Contains(data, []byte("Hello recipients")) {
+				t.Error("the sealed message holds the payload in the clear")
+			}
+			for recipientName, recipient := range recipients {
+				// The first key is not a recipient, and is skipped
+				opened, err := Open(topicID, data, outsider, recipient)
+				if err != nil {
+					t.Fatalf("Open with the %s recipient key: %v", recipientName, err)
+				}
+				if string(opened.Payload) != "Hello recipients" || len(opened.Recipients) != 2 {
+					t.Errorf("Open with the %s recipient key = %+v", recipientName, opened)
+				}
+			}
+
+			// Anyone can verify the signature, and see the recipients, but not read the payload
+			opened, err := Open(topicID, data, outsider)
+			if !errors.Is(err, ErrNotRecipient) {
+				t.Fatalf("Open with another key = %v, want ErrNotRecipient", err)
+			}
+			if opened == nil || opened.Payload != nil || len(opened.Recipients) != 2 || !opened.Encrypted() {
+				t.Errorf("Open with another key = %+v, want the recipients without the payload", opened)
+			}
+			if _, err := Open(topicID, data); !errors.Is(err, ErrNotRecipient) {
+				t.Errorf("Open without keys = %v, want ErrNotRecipient", err)
+			}
+		})
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	keys := generateKeys(t)
+	signer, recipient := keys["ECDSA"], keys["ED25519"]
+	attacker, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := seal(t, signer, "Hello HCS")
+	encrypted := seal(t, signer, "Hello recipient", recipient.PublicKey())
+
+	tamper := func(data []byte, change func(m map[string]any)) []byte {
+		var m map[string]any
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatal(err)
+		}
+		change(m)
+		tampered, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tampered
+	}
+	flip := func(m map[string]any, field string) {
+		value, err := base64.StdEncoding.DecodeString(m[field].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		value[len(value)-1] ^= 1
+		m[field] = value
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"payload", tamper(signed, func(m map[string]any) { m["payload"] = []byte("Goodbye HCS") }), ErrInvalidSignature},
+		{"signature", tamper(signed, func(m map[string]any) { flip(m, "signature") }), ErrInvalidSignature},
+		{"sender", tamper(signed, func(m map[string]any) { m["sender"] = "0.0.1002" }), ErrInvalidSignature},
+		{"public key", tamper(signed, func(m map[string]any) { m["publicKey"] = attacker.PublicKey().String() }), ErrInvalidSignature},
+		{"ciphertext", tamper(encrypted, func(m map[string]any) { flip(m["encryption"].(map[string]any), "ciphertext") }), ErrInvalidSignature},
+		// Signing the ciphertext with another key changes what the ciphertext is bound to
+		{"ciphertext signed by another key", resign(t, encrypted, attacker, func(m *message) {}), ErrMalformed},
+		{"ciphertext changed and signed again", resign(t, encrypted, signer, func(m *message) { m.Encryption.Ciphertext[0] ^= 1 }), ErrMalformed},
+		{"unknown algorithm", resign(t, encrypted, signer, func(m *message) { m.Encryption.Algorithm = "ROT13" }), ErrMalformed},
+		{"invalid public key", []byte(`{"protected":"hfw/1","publicKey":"not a key","payload":"","signature":""}`), ErrMalformed},
+		{"not protected", []byte("Hello HCS"), ErrNotProtected},
+		{"another version", []byte(`{"protected":"hfw/2"}`), ErrNotProtected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if opened, err := Open(topicID, tt.data, recipient); !errors.Is(err, tt.want) {
+				t.Errorf("Open = %+v, %v, want %v", opened, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySender(t *testing.T) {
+	keys := generateKeys(t)
+	accounts := map[string]mirror.Key{
+		"0.0.1001": {Type: "ED25519", Key: hex.EncodeToString(keys["ED25519"].PublicKey().BytesRaw())},
+		"0.0.1002": {Type: "ECDSA_SECP256K1", Key: hex.EncodeToString(keys["ECDSA"].PublicKey().BytesRaw())},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for accountID, key := range accounts {
+			if r.URL.Path == "/api/v1/accounts/"+accountID {
+				_ = json.NewEncoder(w).Encode(map[string]any{"account": accountID, "key": key})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"_status":{"messages":[{"message":"Not found"}]}}`)
+	}))
+	defer server.Close()
+	mirrorClient := mirror.NewClient(server.URL)
+
+	tests := []struct {
+		name   string
+		sender string
+		key    hedera.PrivateKey
+		want   error
+	}{
+		{"ED25519 account key", "0.0.1001", keys["ED25519"], nil},
+		{"ECDSA account key", "0.0.1002", keys["ECDSA"], nil},
+		{"key of another account", "0.0.1001", keys["ECDSA"], ErrSenderKeyMismatch},
+		{"no sender", "", keys["ED25519"], ErrSenderKeyMismatch},
+		{"unknown account", "0.0.1003", keys["ED25519"], mirror.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Seal(topicID, tt.key, []byte("Hello HCS"), Options{Sender: tt.sender})
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := Open(topicID, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifySender(context.Background(), mirrorClient, opened); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySender = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
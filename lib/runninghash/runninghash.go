@@ -0,0 +1,91 @@
+// Package runninghash computes the running hash of HCS topic messages.
+//
+// Every message of a topic gets a running hash, computed by the network from the running hash
+// of the message before it, and the message itself, so that the running hash of a message
+// commits to the whole history of the topic up to it, and no earlier message can be changed
+// without changing every later running hash. The mirror node returns it as `running_hash`.
+package runninghash
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+)
+
+// Version is the version of the running hash that the network computes for new messages
+const Version = 3
+
+var (
+	// ErrMismatch is returned for messages whose running hash is not the one computed from the message
+	ErrMismatch = errors.New("running hash mismatch")
+	// ErrUnsupportedVersion is returned for messages whose running hash is of another version than Version
+	ErrUnsupportedVersion = errors.New("unsupported running hash version")
+)
+
+// Initial returns the running hash before the first message of a topic, which is all zeros
+func Initial() []byte {
+	return make([]byte, sha512.Size384)
+}
+
+// Next computes the running hash of a message the way the network does for version 3:
+// the SHA-384 of the previous running hash, the version, the payer, the topic, the consensus timestamp,
+// the sequence number, and the SHA-384 of the message
+func Next(previous []byte, payer hedera.AccountID, topicID hedera.TopicID, consensusTimestamp time.Time, sequenceNumber uint64, contents []byte) []byte {
+	var b bytes.Buffer
+	b.Write(previous)
+	for _, v := range []any{
+		int64(Version),
+		int64(payer.Shard), int64(payer.Realm), int64(payer.Account),
+		int64(topicID.Shard), int64(topicID.Realm), int64(topicID.Topic),
+		consensusTimestamp.Unix(), int32(consensusTimestamp.Nanosecond()),
+		int64(sequenceNumber),
+	} {
+		// Writing fixed size integers to a bytes.Buffer cannot fail
+		_ = binary.Write(&b, binary.BigEndian, v)
+	}
+	messageHash := sha512.Sum384(contents)
+	b.Write(messageHash[:])
+	runningHash := sha512.Sum384(b.Bytes())
+	return runningHash[:]
+}
+
+// Verify checks the running hash of a message from the mirror node, given the running hash of the message before it.
+// Only version 3 running hashes can be checked.
+func Verify(previous []byte, message mirror.TopicMessage) error {
+	if message.RunningHashVersion != Version {
+		return fmt.Errorf("%w %d: topic message #%d", ErrUnsupportedVersion, message.RunningHashVersion, message.SequenceNumber)
+	}
+	payer, err := hedera.AccountIDFromString(message.PayerAccountID)
+	if err != nil {
+		return fmt.Errorf("parsing payer of topic message #%d: %w", message.SequenceNumber, err)
+	}
+	topicID, err := hedera.TopicIDFromString(message.TopicID)
+	if err != nil {
+		return fmt.Errorf("parsing topic of topic message #%d: %w", message.SequenceNumber, err)
+	}
+	consensusTimestamp, err := mirror.ParseTimestamp(message.ConsensusTimestamp)
+	if err != nil {
+		return fmt.Errorf("parsing consensus timestamp of topic message #%d: %w", message.SequenceNumber, err)
+	}
+	contents, err := message.Contents()
+	if err != nil {
+		return fmt.Errorf("decoding topic message #%d: %w", message.SequenceNumber, err)
+	}
+	runningHash, err := base64.StdEncoding.DecodeString(message.RunningHash)
+	if err != nil {
+		return fmt.Errorf("decoding running hash of topic message #%d: %w", message.SequenceNumber, err)
+	}
+	expected := Next(previous, payer, topicID, consensusTimestamp, uint64(message.SequenceNumber), contents)
+	if !bytes.Equal(runningHash, expected) {
+		return fmt.Errorf("%w: topic message #%d", ErrMismatch, message.SequenceNumber)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package signers collects the private keys that need to sign a transaction,
+// from the operator account and the other accounts configured in the `.env` file,
+// and explains INVALID_SIGNATURE failures account by account.
+//
+// The operator account always signs, as the fee payer.
+// Every other account that is debited needs to sign as well.
+package signers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+	"lib/operator"
+)
+
+// Keyring holds the private keys that transactions can be signed with.
+// Accounts are keyed by their ID without the checksum, as 0.0.5 and 0.0.5-vfmkw are the same account.
+type Keyring struct {
+	byAccount map[string]hedera.PrivateKey
+	// names are where each key came from, e.g. ACCOUNT_0, for the diagnosis
+	names map[string]string
+	// extra keys are not tied to an account, e.g. from the `-key` flag, and always sign
+	extra []hedera.PrivateKey
+}
+
+// New returns a keyring with the operator account, the configured accounts, and any extra keys
+func New(config *operator.Config, extra ...hedera.PrivateKey) *Keyring {
+	k := &Keyring{
+		byAccount: map[string]hedera.PrivateKey{config.AccountID.String(): config.PrivateKey},
+		names:     map[string]string{config.AccountID.String(): "operator"},
+		extra:     extra,
+	}
+	for _, account := range config.Accounts {
+		// The operator account may also be listed as one of the numbered accounts
+		if _, ok := k.byAccount[account.AccountID.String()]; !ok {
+			k.byAccount[account.AccountID.String()] = account.PrivateKey
+			k.names[account.AccountID.String()] = account.Name
+		}
+	}
+	return k
+}
+
+// Keys returns the keys to sign with for the given accounts, together with the extra keys,
+// and the accounts that do not have a key in the keyring
+func (k *Keyring) Keys(accounts []hedera.AccountID) ([]hedera.PrivateKey, []hedera.AccountID) {
+	keys := []hedera.PrivateKey{}
+	seen := map[string]bool{}
+	missing := []hedera.AccountID{}
+	add := func(key hedera.PrivateKey) {
+		if publicKey := key.PublicKey().String(); !seen[publicKey] {
+			seen[publicKey] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, accountId := range unique(accounts) {
+		key, ok := k.byAccount[accountId.String()]
+		if !ok {
+			missing = append(missing, accountId)
+			continue
+		}
+		add(key)
+	}
+	for _, key := range k.extra {
+		add(key)
+	}
+	return keys, missing
+}
+
+// IsInvalidSignature reports whether err is an INVALID_SIGNATURE failure,
+// from either the precheck or the receipt
+func IsInvalidSignature(err error) bool {
+	var precheckErr hedera.ErrHederaPreCheckStatus
+	if errors.As(err, &precheckErr) {
+		return precheckErr.Status == hedera.StatusInvalidSignature
+	}
+	var receiptErr hedera.ErrHederaReceiptStatus
+	if errors.As(err, &receiptErr) {
+		return receiptErr.Status == hedera.StatusInvalidSignature
+	}
+	return false
+}
+
+// Diagnosis is the signature state of a single required signer
+type Diagnosis struct {
+	AccountID hedera.AccountID
+	// Source is where the key came from, e.g. operator or ACCOUNT_0, empty if not in the keyring
+	Source string
+	// AccountKey is the key of the account on the mirror node, empty if it could not be fetched
+	AccountKey string
+	Signed     bool
+	Detail     string
+}
+
+// SignatureError explains an INVALID_SIGNATURE failure for each required signer.
+// It wraps the original error, so its failure kind is unchanged.
+type SignatureError struct {
+	Err      error
+	Accounts []Diagnosis
+}
+
+func (e *SignatureError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v\nINVALID_SIGNATURE diagnosis:", e.Err)
+	for _, d := range e.Accounts {
+		status := "signed"
+		if !d.Signed {
+			status = "NOT SIGNED"
+		}
+		fmt.Fprintf(&b, "\n  %-15s %-10s %s", d.AccountID.String(), status, d.Detail)
+	}
+	return b.String()
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnose returns err unchanged unless it is an INVALID_SIGNATURE failure.
+// Otherwise it compares the keys the transaction was signed with
+// to the key of each required signer on the mirror node,
+// and returns a SignatureError saying which signatures are missing, and why.
+func (k *Keyring) Diagnose(ctx context.Context, mirrorClient *mirror.Client, err error, accounts []hedera.AccountID) error {
+	if !IsInvalidSignature(err) {
+		return err
+	}
+	keys, _ := k.Keys(accounts)
+	signedWith := map[string]bool{}
+	for _, key := range keys {
+		signedWith[strings.ToLower(key.PublicKey().StringRaw())] = true
+	}
+
+	diagnoses := []Diagnosis{}
+	for _, accountId := range unique(accounts) {
+		d := Diagnosis{AccountID: accountId, Source: k.names[accountId.String()]}
+		account, mirrorErr := mirrorClient.GetAccount(ctx, accountId.String())
+		switch {
+		case mirrorErr != nil:
+			d.Detail = fmt.Sprintf("cannot fetch the account key from the mirror node: %v", mirrorErr)
+		case account.Key == nil:
+			d.Detail = "the account has no key, so it cannot sign"
+		case account.Key.Type == "ProtobufEncoded":
+			d.AccountKey = account.Key.Key
+			d.Detail = "the account has a key list or threshold key, which needs enough of its keys to sign"
+		default:
+			d.AccountKey = account.Key.Key
+			d.Signed = signedWith[strings.ToLower(account.Key.Key)]
+		}
+		if d.Detail == "" {
+			switch {
+			case d.Signed && d.Source != "":
+				d.Detail = fmt.Sprintf("with the %s key", d.Source)
+			case d.Signed:
+				d.Detail = "with a key passed with -key"
+			case d.Source != "":
+				d.Detail = fmt.Sprintf("the %s key does not match the account key %s", d.Source, d.AccountKey)
+			default:
+				d.Detail = "no key configured, set ACCOUNT_n_ID and ACCOUNT_n_PRIVATE_KEY in .env, or pass -key"
+			}
+		}
+		diagnoses = append(diagnoses, d)
+	}
+	return &SignatureError{Err: err, Accounts: diagnoses}
+}
+
+// unique removes repeated accounts, with or without a checksum, keeping the first of each
+func unique(accounts []hedera.AccountID) []hedera.AccountID {
+	seen := map[string]bool{}
+	result := []hedera.AccountID{}
+	for _, accountId := range accounts {
+		if !seen[accountId.String()] {
+			seen[accountId.String()] = true
+			result = append(result, accountId)
+		}
+	}
+	return result
+}
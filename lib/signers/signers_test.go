@@ -0,0 +1,196 @@
+package signers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/mirror"
+	"lib/operator"
+)
+
+func generateKey(t *testing.T) hedera.PrivateKey {
+	t.Helper()
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func accountID(t *testing.T, s string) hedera.AccountID {
+	t.Helper()
+	accountId, err := hedera.AccountIDFromString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accountId
+}
+
+// testKeyring has the operator account 0.0.1001, ACCOUNT_0 0.0.5 configured with a checksum,
+// ACCOUNT_1 0.0.6, and an extra key
+type testKeyring struct {
+	*Keyring
+	operatorKey, account0Key, account1Key, extraKey hedera.PrivateKey
+}
+
+func newKeyring(t *testing.T) testKeyring {
+	t.Helper()
+	k := testKeyring{operatorKey: generateKey(t), account0Key: generateKey(t), account1Key: generateKey(t), extraKey: generateKey(t)}
+	k.Keyring = New(&operator.Config{
+		AccountID:  accountID(t, "0.0.1001"),
+		PrivateKey: k.operatorKey,
+		Accounts: []operator.Account{
+			{Name: "ACCOUNT_0", AccountID: accountID(t, "0.0.5-vfmkw"), PrivateKey: k.account0Key},
+			{Name: "ACCOUNT_1", AccountID: accountID(t, "0.0.6"), PrivateKey: k.account1Key},
+			// The operator account listed again keeps the operator key
+			{Name: "ACCOUNT_2", AccountID: accountID(t, "0.0.1001"), PrivateKey: generateKey(t)},
+		},
+	}, k.extraKey)
+	return k
+}
+
+func TestKeys(t *testing.T) {
+	k := newKeyring(t)
+	tests := []struct {
+		name        string
+		accounts    []string
+		wantKeys    []hedera.PrivateKey
+		wantMissing []string
+	}{
+		{"operator", []string{"0.0.1001"}, []hedera.PrivateKey{k.operatorKey, k.extraKey}, nil},
+		{"configured with a checksum", []string{"0.0.1001", "0.0.5"}, []hedera.PrivateKey{k.operatorKey, k.account0Key, k.extraKey}, nil},
+		{"with and without a checksum", []string{"0.0.5-vfmkw", "0.0.5", "0.0.6"}, []hedera.PrivateKey{k.account0Key, k.account1Key, k.extraKey}, nil},
+		{"missing", []string{"0.0.1001", "0.0.7", "0.0.7"}, []hedera.PrivateKey{k.operatorKey, k.extraKey}, []string{"0.0.7"}},
+		{"none", nil, []hedera.PrivateKey{k.extraKey}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := []hedera.AccountID{}
+			for _, s := range tt.accounts {
+				accounts = append(accounts, accountID(t, s))
+			}
+			keys, missing := k.Keys(accounts)
+			if !slices.EqualFunc(keys, tt.wantKeys, func(a, b hedera.PrivateKey) bool { return a.String() == b.String() }) {
+				t.Errorf("Keys = %d keys, want %d keys in order", len(keys), len(tt.wantKeys))
+			}
+			missingIds := []string{}
+			for _, accountId := range missing {
+				missingIds = append(missingIds, accountId.String())
+			}
+			if !slices.Equal(missingIds, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missingIds, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestIsInvalidSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"precheck", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInvalidSignature}, true},
+		{"wrapped precheck", fmt.Errorf("executing TransferTransaction: %w", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInvalidSignature}), true},
+		{"receipt", hedera.ErrHederaReceiptStatus{Status: hedera.StatusInvalidSignature}, true},
+		{"wrapped receipt", fmt.Errorf("getting receipt: %w", hedera.ErrHederaReceiptStatus{Status: hedera.StatusInvalidSignature}), true},
+		{"other precheck status", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInsufficientPayerBalance}, false},
+		{"other receipt status", hedera.ErrHederaReceiptStatus{Status: hedera.StatusInvalidAccountAmounts}, false},
+		{"other error", errors.New("INVALID_SIGNATURE"), false},
+		{"no error", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsInvalidSignature(tt.err); got != tt.want {
+				t.Errorf("IsInvalidSignature(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// newMirror serves the accounts of the keyring, keyed by account ID, and 404 for every other account
+func newMirror(t *testing.T, accounts map[string]string) *mirror.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := accounts[strings.TrimPrefix(r.URL.Path, "/api/v1/accounts/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"_status":{"messages":[{"message":"Not found"}]}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return mirror.NewClient(server.URL + "/")
+}
+
+func TestDiagnose(t *testing.T) {
+	ctx := context.Background()
+	k := newKeyring(t)
+	otherKey := generateKey(t)
+	ed25519Key := func(key hedera.PrivateKey) string {
+		return fmt.Sprintf(`{"key":{"_type":"ED25519","key":%q}}`, key.PublicKey().StringRaw())
+	}
+	mirrorClient := newMirror(t, map[string]string{
+		"0.0.1001": ed25519Key(k.operatorKey),
+		"0.0.5":    ed25519Key(otherKey),
+		"0.0.6":    `{"key":{"_type":"ProtobufEncoded","key":"2a0a0a08"}}`,
+		"0.0.7":    `{"key":null}`,
+		"0.0.8":    ed25519Key(k.extraKey),
+	})
+	failed := fmt.Errorf("getting receipt: %w", hedera.ErrHederaReceiptStatus{Status: hedera.StatusInvalidSignature})
+	accounts := []hedera.AccountID{
+		accountID(t, "0.0.1001"), accountID(t, "0.0.5-vfmkw"), accountID(t, "0.0.6"),
+		accountID(t, "0.0.7"), accountID(t, "0.0.8"), accountID(t, "0.0.9"), accountID(t, "0.0.5"),
+	}
+
+	err := k.Diagnose(ctx, mirrorClient, failed, accounts)
+	var signatureErr *SignatureError
+	if !errors.As(err, &signatureErr) {
+		t.Fatalf("Diagnose = %v, want a SignatureError", err)
+	}
+	if !errors.Is(err, failed) || !IsInvalidSignature(err) {
+		t.Errorf("Diagnose = %v, want it to wrap %v", err, failed)
+	}
+	want := []struct {
+		account string
+		source  string
+		signed  bool
+		detail  string
+	}{
+		{"0.0.1001", "operator", true, "with the operator key"},
+		// 0.0.5 is listed twice, with and without a checksum, and diagnosed once
+		{"0.0.5", "ACCOUNT_0", false, "the ACCOUNT_0 key does not match the account key " + otherKey.PublicKey().StringRaw()},
+		{"0.0.6", "ACCOUNT_1", false, "key list or threshold key"},
+		{"0.0.7", "", false, "the account has no key"},
+		{"0.0.8", "", true, "with a key passed with -key"},
+		{"0.0.9", "", false, "cannot fetch the account key"},
+	}
+	if len(signatureErr.Accounts) != len(want) {
+		t.Fatalf("Diagnose = %d accounts, want %d:\n%v", len(signatureErr.Accounts), len(want), err)
+	}
+	for i, d := range signatureErr.Accounts {
+		w := want[i]
+		if d.AccountID.String() != w.account || d.Source != w.source || d.Signed != w.signed || !strings.Contains(d.Detail, w.detail) {
+			t.Errorf("Accounts[%d] = %+v, want %s from %q, signed %v, %q", i, d, w.account, w.source, w.signed, w.detail)
+		}
+	}
+	if !strings.Contains(err.Error(), "NOT SIGNED") {
+		t.Errorf("Error() = %q, want the unsigned accounts marked", err.Error())
+	}
+
+	// Other failures are returned unchanged, without querying the mirror node
+	other := fmt.Errorf("getting receipt: %w", hedera.ErrHederaReceiptStatus{Status: hedera.StatusInsufficientPayerBalance})
+	if got := k.Diagnose(ctx, mirror.NewClient("http://127.0.0.1:0/"), other, accounts); got != other {
+		t.Errorf("Diagnose = %v, want %v unchanged", got, other)
+	}
+}
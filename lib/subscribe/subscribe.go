@@ -0,0 +1,226 @@
+// Package subscribe streams the messages of a topic from the mirror node gRPC API,
+// with hedera.TopicMessageQuery, as they reach consensus.
+//
+// The SDK resubscribes on its own after some errors, but gives up after a few attempts,
+// and starts over without the messages it already delivered in mind.
+// Run resubscribes instead, from the consensus timestamp of the last message it delivered,
+// and skips any message with a sequence number that it already delivered,
+// so that a disconnect neither loses nor repeats a message.
+//
+// The SDK reassembles chunked messages within a single stream,
+// so a message whose chunks are split by a disconnect is only delivered
+// if the chunks before the disconnect come after the last delivered message.
+package subscribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultMaxReconnects is the number of reconnects in a row, without a message in between, before giving up
+	DefaultMaxReconnects = 10
+	// DefaultReconnectDelay is the delay before the first reconnect, which doubles with each reconnect in a row
+	DefaultReconnectDelay = 250 * time.Millisecond
+	// maxReconnectDelay caps the delay between reconnects, like the SDK does
+	maxReconnectDelay = 8 * time.Second
+)
+
+// ErrSubscriptionFailed is returned when the stream fails with a permanent error,
+// or keeps failing after MaxReconnects reconnects
+var ErrSubscriptionFailed = errors.New("topic subscription failed")
+
+// Options control where a subscription starts and ends, and how it reconnects.
+// Zero fields take their defaults.
+type Options struct {
+	// StartTime is the consensus timestamp to start at, and the zero time starts at the first message of the topic
+	StartTime time.Time
+	// EndTime ends the subscription before the first message at or after it, and the zero time never ends it
+	EndTime time.Time
+	// AfterSequenceNumber skips the messages up to and including this sequence number,
+	// e.g. the last message processed before a restart
+	AfterSequenceNumber uint64
+	// Limit ends the subscription after this many messages, and 0 never ends it
+	Limit uint64
+	// MaxReconnects is the number of reconnects in a row, without a message in between, before giving up
+	MaxReconnects int
+	// ReconnectDelay is the delay before the first reconnect, which doubles with each reconnect in a row
+	ReconnectDelay time.Duration
+	// OnReconnect is called before each reconnect, with the error that ended the stream,
+	// and the consensus timestamp the subscription resumes from
+	OnReconnect func(attempt int, resumeFrom time.Time, err error)
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxReconnects <= 0 {
+		o.MaxReconnects = DefaultMaxReconnects
+	}
+	if o.ReconnectDelay <= 0 {
+		o.ReconnectDelay = DefaultReconnectDelay
+	}
+	return o
+}
+
+// Position is the last message that a subscription delivered
+type Position struct {
+	SequenceNumber     uint64
+	ConsensusTimestamp time.Time
+	// Delivered is the number of messages delivered
+	Delivered uint64
+}
+
+// event is a message, the end of the stream, or an error, in the order the SDK reports them
+type event struct {
+	message *hedera.TopicMessage
+	err     error
+	done    bool
+}
+
+// Run subscribes to the topic, and calls handle for each message in consensus order,
+// until ctx is cancelled, the subscription ends, or handle returns an error.
+// It returns the position after the last delivered message, together with any error,
+// which is ctx.Err() when ctx is cancelled.
+func Run(ctx context.Context, client *hedera.Client, topicID hedera.TopicID, opts Options, handle func(hedera.TopicMessage) error) (Position, error) {
+	opts = opts.withDefaults()
+	position := Position{SequenceNumber: opts.AfterSequenceNumber}
+	reconnects := 0
+	for {
+		// Resume right after the last delivered message, or from the start time before the first one
+		start := opts.StartTime
+		if !position.ConsensusTimestamp.IsZero() {
+			start = position.ConsensusTimestamp.Add(time.Nanosecond)
+		}
+		events := make(chan event)
+		stop := make(chan struct{})
+		unsubscribe, err := subscribe(ctx, client, topicID, start, opts.EndTime, events, stop)
+		if err != nil {
+			return position, err
+		}
+
+		var streamErr error
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				unsubscribe()
+				return position, ctx.Err()
+			case ev := <-events:
+				switch {
+				case ev.done:
+					unsubscribe()
+					return position, nil
+				case ev.err != nil:
+					streamErr = ev.err
+					break stream
+				}
+				// Messages at or before the last delivered one are sent again after a reconnect
+				if ev.message.SequenceNumber <= position.SequenceNumber {
+					continue
+				}
+				if err := handle(*ev.message); err != nil {
+					unsubscribe()
+					return position, err
+				}
+				position.SequenceNumber = ev.message.SequenceNumber
+				position.ConsensusTimestamp = ev.message.ConsensusTimestamp
+				position.Delivered++
+				reconnects = 0
+				if opts.Limit > 0 && position.Delivered >= opts.Limit {
+					unsubscribe()
+					return position, nil
+				}
+			}
+		}
+		unsubscribe()
+
+		reconnects++
+		if !retryable(streamErr) || reconnects > opts.MaxReconnects {
+			return position, fmt.Errorf("%w after %d reconnects: %w", ErrSubscriptionFailed, reconnects-1, streamErr)
+		}
+		resumeFrom := opts.StartTime
+		if !position.ConsensusTimestamp.IsZero() {
+			resumeFrom = position.ConsensusTimestamp.Add(time.Nanosecond)
+		}
+		if opts.OnReconnect != nil {
+			opts.OnReconnect(reconnects, resumeFrom, streamErr)
+		}
+		delay := min(opts.ReconnectDelay<<(reconnects-1), maxReconnectDelay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return position, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// subscribe starts a single stream, which sends its events until stop is closed,
+// and returns the function that ends it
+func subscribe(ctx context.Context, client *hedera.Client, topicID hedera.TopicID, start time.Time, end time.Time, events chan<- event, stop chan struct{}) (func(), error) {
+	send := func(ev event) {
+		select {
+		case events <- ev:
+		case <-stop:
+		}
+	}
+	query := hedera.NewTopicMessageQuery().
+		SetTopicID(topicID).
+		// Run reconnects instead of the SDK, so that every error reaches the error handler
+		SetMaxAttempts(0).
+		SetErrorHandler(func(stat status.Status) { send(event{err: stat.Err()}) }).
+		SetCompletionHandler(func() { send(event{done: true}) })
+	if !start.IsZero() {
+		query.SetStartTime(start)
+	}
+	if !end.IsZero() {
+		query.SetEndTime(end)
+	}
+
+	// The first subscription of a client waits until it connects to the mirror node
+	type result struct {
+		handle hedera.SubscriptionHandle
+		err    error
+	}
+	subscribed := make(chan result, 1)
+	go func() {
+		handle, err := query.Subscribe(client, func(message hedera.TopicMessage) { send(event{message: &message}) })
+		subscribed <- result{handle, err}
+	}()
+	select {
+	case <-ctx.Done():
+		close(stop)
+		// End the stream once it has started, rather than leaving it open in the background
+		go func() {
+			if r := <-subscribed; r.err == nil {
+				r.handle.Unsubscribe()
+			}
+		}()
+		return nil, ctx.Err()
+	case r := <-subscribed:
+		if r.err != nil {
+			close(stop)
+			return nil, fmt.Errorf("%w: %w", ErrSubscriptionFailed, r.err)
+		}
+		return func() {
+			close(stop)
+			r.handle.Unsubscribe()
+		}, nil
+	}
+}
+
+// retryable reports whether a stream that failed with err may succeed when resubscribed.
+// NotFound is retried, since a topic that was just created may not be on the mirror node yet.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented, codes.FailedPrecondition:
+		return false
+	}
+	return true
+}
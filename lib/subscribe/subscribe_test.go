@@ -0,0 +1,215 @@
+package subscribe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"lib/fakemirror"
+	"lib/mirror"
+)
+
+// receiveTimeout is how long to wait for the subscription to deliver a message
+const receiveTimeout = 10 * time.Second
+
+var topicID = hedera.TopicID{Topic: 1001}
+
+// largeMessage is published in 3 chunks, which the SDK reassembles
+var largeMessage = []byte(strings.Repeat("Hello HCS in chunks! ", 100))
+
+// startServer starts a fake mirror node, and returns it with a client that subscribes to it
+func startServer(t *testing.T) (*fakemirror.Server, *hedera.Client) {
+	t.Helper()
+	server, err := fakemirror.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	// The client only talks to the mirror node, so its consensus node is never used
+	client := hedera.ClientForNetwork(map[string]hedera.AccountID{"127.0.0.1:50211": {Account: 3}})
+	t.Cleanup(func() { client.Close() })
+	client.SetMirrorNetwork([]string{server.GRPCAddress()})
+	return server, client
+}
+
+// publish publishes the messages, and returns their contents by the sequence number they are delivered with,
+// which is that of the last chunk for a chunked message
+func publish(server *fakemirror.Server, messages ...[]byte) map[uint64][]byte {
+	published := map[uint64][]byte{}
+	for _, message := range messages {
+		published[server.Publish(topicID, message)] = message
+	}
+	return published
+}
+
+// receive waits for count messages
+func receive(t *testing.T, received <-chan hedera.TopicMessage, count int) []hedera.TopicMessage {
+	t.Helper()
+	var got []hedera.TopicMessage
+	for len(got) < count {
+		select {
+		case message := <-received:
+			got = append(got, message)
+		case <-time.After(receiveTimeout):
+			t.Fatalf("received %d of %d messages", len(got), count)
+		}
+	}
+	return got
+}
+
+// checkMessages checks that the messages arrived in order, with the contents they were published with
+func checkMessages(t *testing.T, got []hedera.TopicMessage, published map[uint64][]byte, wantSequenceNumbers []uint64) {
+	t.Helper()
+	var sequenceNumbers []uint64
+	for _, message := range got {
+		sequenceNumbers = append(sequenceNumbers, message.SequenceNumber)
+		if !bytes.Equal(message.Contents, published[message.SequenceNumber]) {
+			t.Errorf("message #%d has %d bytes, want %d", message.SequenceNumber, len(message.Contents), len(published[message.SequenceNumber]))
+		}
+	}
+	if !slices.Equal(sequenceNumbers, wantSequenceNumbers) {
+		t.Errorf("sequence numbers = %v, want %v", sequenceNumbers, wantSequenceNumbers)
+	}
+}
+
+func TestRunResumesAfterDisconnect(t *testing.T) {
+	server, client := startServer(t)
+	published := publish(server, []byte("Hello HCS #1"), []byte("Hello HCS #2"), largeMessage, []byte("Hello HCS #3"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan hedera.TopicMessage)
+	reconnected := make(chan time.Time, 1)
+	opts := Options{
+		ReconnectDelay: 10 * time.Millisecond,
+		OnReconnect: func(attempt int, resumeFrom time.Time, err error) {
+			select {
+			case reconnected <- resumeFrom:
+			default:
+			}
+		},
+	}
+	type result struct {
+		position Position
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		position, err := Run(ctx, client, topicID, opts, func(message hedera.TopicMessage) error {
+			received <- message
+			return nil
+		})
+		done <- result{position, err}
+	}()
+	got := receive(t, received, 4)
+	checkMessages(t, got, published, []uint64{1, 2, 5, 6})
+
+	// The first message is altered while the subscription reconnects, and is not sent again
+	server.Disconnect()
+	go server.Tamper(topicID, 1, []byte("Altered"))
+	var resumeFrom time.Time
+	select {
+	case resumeFrom = <-reconnected:
+	case <-time.After(receiveTimeout):
+		t.Fatal("the subscription did not reconnect")
+	}
+	if want := got[3].ConsensusTimestamp.Add(time.Nanosecond); !resumeFrom.Equal(want) {
+		t.Errorf("resumed from %s, want %s", resumeFrom, want)
+	}
+	more := publish(server, []byte("Hello HCS #4"), []byte("Hello HCS #5"))
+	checkMessages(t, receive(t, received, 2), more, []uint64{7, 8})
+
+	subscriptions := server.Subscriptions()
+	if len(subscriptions) != 2 {
+		t.Fatalf("got %d subscriptions, want 2", len(subscriptions))
+	}
+	if !subscriptions[1].StartTime.Equal(resumeFrom) {
+		t.Errorf("second subscription from %s, want %s", subscriptions[1].StartTime, resumeFrom)
+	}
+
+	cancel()
+	r := <-done
+	if !errors.Is(r.err, context.Canceled) {
+		t.Errorf("Run = %v, want context.Canceled", r.err)
+	}
+	if r.position.SequenceNumber != 8 || r.position.Delivered != 6 {
+		t.Errorf("Position = %+v, want #8 after 6 messages", r.position)
+	}
+	select {
+	case message := <-received:
+		t.Errorf("message #%d was delivered again", message.SequenceNumber)
+	default:
+	}
+}
+
+func TestRunStartingPoints(t *testing.T) {
+	server, client := startServer(t)
+	published := publish(server, []byte("Hello HCS #1"), []byte("Hello HCS #2"), largeMessage, []byte("Hello HCS #3"))
+
+	// A sequence number is turned into a consensus timestamp with the REST API
+	topicMessage, err := mirror.NewClient(server.RESTURL()).GetTopicMessage(context.Background(), topicID.String(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondTimestamp, err := mirror.ParseTimestamp(topicMessage.ConsensusTimestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                string
+		opts                Options
+		wantSequenceNumbers []uint64
+	}{
+		{"first message", Options{Limit: 3}, []uint64{1, 2, 5}},
+		{"start time", Options{StartTime: secondTimestamp, Limit: 2}, []uint64{2, 5}},
+		{"after sequence number", Options{AfterSequenceNumber: 2, Limit: 2}, []uint64{5, 6}},
+		{"end time", Options{EndTime: secondTimestamp.Add(time.Nanosecond)}, []uint64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), receiveTimeout)
+			defer cancel()
+			var got []hedera.TopicMessage
+			position, err := Run(ctx, client, topicID, tt.opts, func(message hedera.TopicMessage) error {
+				got = append(got, message)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkMessages(t, got, published, tt.wantSequenceNumbers)
+			last := tt.wantSequenceNumbers[len(tt.wantSequenceNumbers)-1]
+			if position.SequenceNumber != last || position.Delivered != uint64(len(tt.wantSequenceNumbers)) {
+				t.Errorf("Position = %+v, want #%d after %d messages", position, last, len(tt.wantSequenceNumbers))
+			}
+		})
+	}
+}
+
+func TestRunHandleError(t *testing.T) {
+	server, client := startServer(t)
+	publish(server, []byte("Hello HCS #1"), []byte("Hello HCS #2"))
+
+	errStop := errors.New("stop")
+	ctx, cancel := context.WithTimeout(context.Background(), receiveTimeout)
+	defer cancel()
+	position, err := Run(ctx, client, topicID, Options{}, func(message hedera.TopicMessage) error {
+		if message.SequenceNumber == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("Run = %v, want the error of handle", err)
+	}
+	if position.SequenceNumber != 1 || position.Delivered != 1 {
+		t.Errorf("Position = %+v, want #1 after 1 message", position)
+	}
+}
@@ -0,0 +1,417 @@
+// Package transferlist builds HBAR transfer lists with any number of debits and credits,
+// from `0.0.x=amount` strings, CSV files, or JSON files.
+//
+// Amounts are in any `hedera.HbarUnits` unit, by name or by symbol, e.g. `1.5`,
+// `1.5 hbar`, `150 mℏ`, or `-20 tinybar`, and default to HBAR.
+// They are converted to tinybars exactly, without going through a float.
+//
+// A list must net to zero before it is frozen into a transaction.
+// Lists with more accounts than a single transaction accepts
+// are split into batches that each net to zero.
+package transferlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// MaxTransfers is the maximum number of HBAR account amounts in a single transfer transaction,
+// set by the `ledger.transfers.maxLen` network property
+const MaxTransfers = 10
+
+var (
+	// ErrInvalidAmount is returned for amounts that cannot be parsed,
+	// or that are not a whole number of tinybars
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidEntry is returned for entries that are not `0.0.x=amount`
+	ErrInvalidEntry = errors.New("invalid transfer")
+	// ErrEmpty is returned for lists without any transfers,
+	// or whose transfers all net to zero for each account
+	ErrEmpty = errors.New("empty transfer list")
+	// ErrOverflow is returned for lists whose debits or credits add up to more tinybars than an int64 holds
+	ErrOverflow = errors.New("transfer list total overflows")
+	// ErrNotBalanced is returned for lists whose debits and credits do not net to zero
+	ErrNotBalanced = errors.New("transfer list does not net to zero")
+	// ErrUnknownFormat is returned for files that are neither CSV nor JSON
+	ErrUnknownFormat = errors.New("unknown transfer file format")
+)
+
+// units are looked up by name and by symbol
+var units = []hedera.HbarUnit{
+	hedera.HbarUnits.Tinybar,
+	hedera.HbarUnits.Microbar,
+	hedera.HbarUnits.Millibar,
+	hedera.HbarUnits.Hbar,
+	hedera.HbarUnits.Kilobar,
+	hedera.HbarUnits.Megabar,
+	hedera.HbarUnits.Gigabar,
+}
+
+// Entry is a single debit (negative amount) or credit (positive amount)
+type Entry struct {
+	AccountID hedera.AccountID
+	Amount    hedera.Hbar
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s=%s", e.AccountID.String(), e.Amount.String())
+}
+
+// List is an HBAR transfer list
+type List []Entry
+
+// ParseUnit returns the unit with the given name, e.g. `millibar`, or symbol, e.g. `mℏ`.
+// Names are case insensitive; symbols are not, since `mℏ` and `Mℏ` are different units.
+func ParseUnit(s string) (hedera.HbarUnit, error) {
+	for _, unit := range units {
+		if s == unit.Symbol() || strings.EqualFold(s, unit.String()) {
+			return unit, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown unit %q", ErrInvalidAmount, s)
+}
+
+// ParseAmount parses an amount with an optional unit, e.g. `-1.5`, `250 tinybar`, or `3ℏ`
+func ParseAmount(s string) (hedera.Hbar, error) {
+	s = strings.TrimSpace(s)
+	// The number ends where the unit starts
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("+-.0123456789", r)
+	})
+	number, unitStr := s, ""
+	if end >= 0 {
+		number, unitStr = s[:end], strings.TrimSpace(s[end:])
+	}
+	unit := hedera.HbarUnits.Hbar
+	if unitStr != "" {
+		var err error
+		unit, err = ParseUnit(unitStr)
+		if err != nil {
+			return hedera.Hbar{}, err
+		}
+	}
+
+	value, ok := new(big.Rat).SetString(number)
+	if !ok || number == "" {
+		return hedera.Hbar{}, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	// Powers of ten up to a gigabar are exact as a float64
+	tinybarsPerUnit := hedera.HbarFrom(1, unit).AsTinybar()
+	value.Mul(value, new(big.Rat).SetInt64(tinybarsPerUnit))
+	if !value.IsInt() || !value.Num().IsInt64() {
+		return hedera.Hbar{}, fmt.Errorf("%w %q: not a whole number of tinybars", ErrInvalidAmount, s)
+	}
+	return hedera.HbarFromTinybar(value.Num().Int64()), nil
+}
+
+// ParseEntry parses a single `0.0.x=amount` transfer
+func ParseEntry(s string) (Entry, error) {
+	accountIdStr, amountStr, found := strings.Cut(s, "=")
+	if !found {
+		return Entry{}, fmt.Errorf("%w %q: expected 0.0.x=amount", ErrInvalidEntry, s)
+	}
+	return newEntry(accountIdStr, amountStr)
+}
+
+func newEntry(accountIdStr string, amountStr string) (Entry, error) {
+	accountId, err := hedera.AccountIDFromString(strings.TrimSpace(accountIdStr))
+	if err != nil {
+		return Entry{}, fmt.Errorf("%w: account %q: %v", ErrInvalidEntry, accountIdStr, err)
+	}
+	amount, err := ParseAmount(amountStr)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{AccountID: accountId, Amount: amount}, nil
+}
+
+// Parse parses a list of `0.0.x=amount` transfers
+func Parse(entries []string) (List, error) {
+	list := make(List, 0, len(entries))
+	for _, s := range entries {
+		entry, err := ParseEntry(s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+// ReadCSV reads `account,amount` rows.
+// A header row, blank lines, and lines starting with `#` are skipped.
+func ReadCSV(r io.Reader) (List, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	list := List{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return list, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading transfer CSV: %w", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "account") {
+			continue
+		}
+		entry, err := newEntry(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("transfer CSV row %d: %w", line, err)
+		}
+		list = append(list, entry)
+	}
+}
+
+// jsonEntry is an entry in a transfer JSON file.
+// The amount is either a string with an optional unit, or a number of HBAR.
+type jsonEntry struct {
+	Account string          `json:"account"`
+	Amount  json.RawMessage `json:"amount"`
+}
+
+// ReadJSON reads an array of `{"account": "0.0.x", "amount": "1.5 hbar"}` objects
+func ReadJSON(r io.Reader) (List, error) {
+	var entries []jsonEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("reading transfer JSON: %w", err)
+	}
+	list := make(List, 0, len(entries))
+	for i, e := range entries {
+		amountStr := string(e.Amount)
+		if bytes.HasPrefix(e.Amount, []byte(`"`)) {
+			if err := json.Unmarshal(e.Amount, &amountStr); err != nil {
+				return nil, fmt.Errorf("transfer JSON entry %d: %w", i, err)
+			}
+		}
+		entry, err := newEntry(e.Account, amountStr)
+		if err != nil {
+			return nil, fmt.Errorf("transfer JSON entry %d: %w", i, err)
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+// ReadFile reads a `.csv` or `.json` transfer file
+func ReadFile(path string) (List, error) {
+	var read func(io.Reader) (List, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		read = ReadCSV
+	case ".json":
+		read = ReadJSON
+	default:
+		return nil, fmt.Errorf("%w %q: expected .csv or .json", ErrUnknownFormat, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return read(file)
+}
+
+// String returns the list as comma separated `0.0.x=amount` transfers
+func (l *List) String() string {
+	entries := make([]string, len(*l))
+	for i, entry := range *l {
+		entries[i] = entry.String()
+	}
+	return strings.Join(entries, ",")
+}
+
+// Set appends a `0.0.x=amount` transfer,
+// so that a List can be used as a repeatable flag
+func (l *List) Set(s string) error {
+	entry, err := ParseEntry(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, entry)
+	return nil
+}
+
+// Total returns the sum of all amounts, which is zero for a valid list
+func (l List) Total() (hedera.Hbar, error) {
+	var total int64
+	for _, entry := range l {
+		var ok bool
+		total, ok = addTinybars(total, entry.Amount.AsTinybar())
+		if !ok {
+			return hedera.Hbar{}, ErrOverflow
+		}
+	}
+	return hedera.HbarFromTinybar(total), nil
+}
+
+// Validate checks that the list is not empty, that it nets to zero,
+// and that some account is left with a non-zero amount once the entries of each account are merged.
+// The network would otherwise reject the transaction with INVALID_ACCOUNT_AMOUNTS.
+func (l List) Validate() error {
+	if len(l) == 0 {
+		return ErrEmpty
+	}
+	var debits, credits int64
+	for _, entry := range l {
+		amount := entry.Amount.AsTinybar()
+		var ok bool
+		if amount < 0 {
+			// Debits are summed as negative amounts, and must not reach the most negative int64,
+			// which has no positive counterpart to compare with the credits
+			debits, ok = addTinybars(debits, amount)
+		} else {
+			credits, ok = addTinybars(credits, amount)
+		}
+		if !ok || debits == math.MinInt64 {
+			return fmt.Errorf("%w: more than %s", ErrOverflow, hedera.HbarFromTinybar(math.MaxInt64).String())
+		}
+	}
+	if -debits != credits {
+		return fmt.Errorf("%w: debits %s, credits %s",
+			ErrNotBalanced, hedera.HbarFromTinybar(-debits).String(), hedera.HbarFromTinybar(credits).String())
+	}
+	if len(l.Merge()) == 0 {
+		return fmt.Errorf("%w: every account nets to zero", ErrEmpty)
+	}
+	return nil
+}
+
+// addTinybars adds two amounts, and reports whether the sum fits in an int64
+func addTinybars(a int64, b int64) (int64, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// Merge combines the entries for the same account, in order of first appearance,
+// and drops the accounts that net to zero.
+// The sums cannot overflow for lists that pass Validate.
+func (l List) Merge() List {
+	// Accounts are keyed by their ID without the checksum, as 0.0.5 and 0.0.5-vfmkw are the same account,
+	// and keep the account ID of their first entry
+	totals := map[string]int64{}
+	order := []hedera.AccountID{}
+	for _, entry := range l {
+		if _, ok := totals[entry.AccountID.String()]; !ok {
+			order = append(order, entry.AccountID)
+		}
+		totals[entry.AccountID.String()] += entry.Amount.AsTinybar()
+	}
+	merged := List{}
+	for _, accountId := range order {
+		if total := totals[accountId.String()]; total != 0 {
+			merged = append(merged, Entry{AccountID: accountId, Amount: hedera.HbarFromTinybar(total)})
+		}
+	}
+	return merged
+}
+
+// Debited returns the accounts with a negative amount, which need to sign the transaction
+func (l List) Debited() []hedera.AccountID {
+	debited := []hedera.AccountID{}
+	for _, entry := range l.Merge() {
+		if entry.Amount.AsTinybar() < 0 {
+			debited = append(debited, entry.AccountID)
+		}
+	}
+	return debited
+}
+
+// Batches validates the list, and splits it into lists of at most max accounts,
+// that each net to zero, so that each can be submitted as its own transaction.
+// A list that already fits is returned as the only batch.
+//
+// Debits are matched to credits in order, so a debit or a credit
+// may be split across consecutive batches.
+func (l List) Batches(max int) ([]List, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	merged := l.Merge()
+	if len(merged) <= max {
+		return []List{merged}, nil
+	}
+	if max < 2 {
+		return nil, fmt.Errorf("cannot split a transfer list into batches of %d accounts", max)
+	}
+
+	var debits, credits List
+	for _, entry := range merged {
+		if entry.Amount.AsTinybar() < 0 {
+			debits = append(debits, Entry{AccountID: entry.AccountID, Amount: entry.Amount.Negated()})
+		} else {
+			credits = append(credits, entry)
+		}
+	}
+
+	batches := []List{}
+	batch := map[hedera.AccountID]int64{}
+	order := []hedera.AccountID{}
+	flush := func() {
+		list := List{}
+		for _, accountId := range order {
+			list = append(list, Entry{AccountID: accountId, Amount: hedera.HbarFromTinybar(batch[accountId])})
+		}
+		batches = append(batches, list)
+		batch = map[hedera.AccountID]int64{}
+		order = []hedera.AccountID{}
+	}
+	add := func(accountId hedera.AccountID, amount int64) {
+		if _, ok := batch[accountId]; !ok {
+			order = append(order, accountId)
+		}
+		batch[accountId] += amount
+	}
+	for len(debits) > 0 && len(credits) > 0 {
+		debit, credit := &debits[0], &credits[0]
+		// Each step adds at most the two accounts of the pair
+		newAccounts := 0
+		for _, accountId := range []hedera.AccountID{debit.AccountID, credit.AccountID} {
+			if _, ok := batch[accountId]; !ok {
+				newAccounts++
+			}
+		}
+		if len(order)+newAccounts > max {
+			flush()
+		}
+		amount := min(debit.Amount.AsTinybar(), credit.Amount.AsTinybar())
+		add(debit.AccountID, -amount)
+		add(credit.AccountID, amount)
+		debit.Amount = hedera.HbarFromTinybar(debit.Amount.AsTinybar() - amount)
+		credit.Amount = hedera.HbarFromTinybar(credit.Amount.AsTinybar() - amount)
+		if debit.Amount.AsTinybar() == 0 {
+			debits = debits[1:]
+		}
+		if credit.Amount.AsTinybar() == 0 {
+			credits = credits[1:]
+		}
+	}
+	flush()
+	return batches, nil
+}
+
+// Transaction returns a transfer transaction with the entries of the list,
+// ready to have its memo set and to be frozen
+func (l List) Transaction() *hedera.TransferTransaction {
+	transferTx := hedera.NewTransferTransaction()
+	for _, entry := range l {
+		transferTx.AddHbarTransfer(entry.AccountID, entry.Amount)
+	}
+	return transferTx
+}
@@ -0,0 +1,112 @@
+package transferlist
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr error
+	}{
+		{"balanced", []string{"0.0.2=-1", "0.0.3=0.5", "0.0.4=50000000 tinybar"}, nil},
+		{"empty", nil, ErrEmpty},
+		{"zero amount", []string{"0.0.2=0"}, ErrEmpty},
+		{"every account nets to zero", []string{"0.0.2=1", "0.0.2=-1", "0.0.3=2 tinybar", "0.0.3=-2 tinybar"}, ErrEmpty},
+		{"not balanced", []string{"0.0.2=-1", "0.0.3=2"}, ErrNotBalanced},
+		{"credits overflow", []string{"0.0.2=9223372036854775807 tinybar", "0.0.3=1 tinybar", "0.0.4=-1 tinybar"}, ErrOverflow},
+		{"debits overflow", []string{"0.0.2=-9223372036854775807 tinybar", "0.0.3=-1 tinybar", "0.0.4=1 tinybar"}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse(tt.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = list.Validate()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := list.Batches(MaxTransfers); tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Batches = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	list := List{
+		{AccountID: hedera.AccountID{Account: 2}, Amount: hedera.HbarFromTinybar(math.MaxInt64)},
+		{AccountID: hedera.AccountID{Account: 3}, Amount: hedera.HbarFromTinybar(1)},
+	}
+	if _, err := list.Total(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Total = %v, want ErrOverflow", err)
+	}
+	total, err := list[1:].Total()
+	if err != nil || total.AsTinybar() != 1 {
+		t.Errorf("Total = %v, %v, want 1 tinybar", total, err)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	var entries []string
+	for account := 10; account < 25; account++ {
+		entries = append(entries, hedera.AccountID{Account: uint64(account)}.String()+"=1")
+	}
+	entries = append(entries, "0.0.2=-15", "0.0.3=0")
+	list, err := Parse(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches, err := list.Batches(MaxTransfers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credited := 0
+	for _, batch := range batches {
+		if len(batch) > MaxTransfers {
+			t.Errorf("batch of %d accounts", len(batch))
+		}
+		if err := batch.Validate(); err != nil {
+			t.Errorf("batch %s: %v", batch.String(), err)
+		}
+		credited += len(batch) - 1
+	}
+	if credited != 15 {
+		t.Errorf("credited %d accounts, want 15", credited)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    string
+	}{
+		{"repeated account", []string{"0.0.2=-3", "0.0.3=1", "0.0.3=2"}, "0.0.2=-3 ℏ,0.0.3=3 ℏ"},
+		{"account that nets to zero", []string{"0.0.2=-1", "0.0.3=1", "0.0.4=1", "0.0.4=-1"}, "0.0.2=-1 ℏ,0.0.3=1 ℏ"},
+		// The checksum is not part of the account, whichever network it is for
+		{"account with and without a checksum", []string{"0.0.2=-2", "0.0.5-vfmkw=1", "0.0.5=1"}, "0.0.2=-2 ℏ,0.0.5=2 ℏ"},
+		{"account with a checksum that nets to zero", []string{"0.0.2=-1", "0.0.3=1", "0.0.5=1", "0.0.5-vfmkw=-1"}, "0.0.2=-1 ℏ,0.0.3=1 ℏ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse(tt.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merged := list.Merge()
+			if got := merged.String(); got != tt.want {
+				t.Errorf("Merge = %s, want %s", got, tt.want)
+			}
+			if debited := merged.Debited(); len(debited) != 1 || debited[0].String() != "0.0.2" {
+				t.Errorf("Debited = %v, want [0.0.2]", debited)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package txid converts transaction IDs between the SDK type,
+// the SDK string form `0.0.x@s.n`, and the mirror node form `0.0.x-s-n`.
+//
+// The scheduled flag and the nonce are preserved in every direction.
+// The SDK string form carries them as `?scheduled` and `/nonce` suffixes,
+// and the mirror node carries them as `scheduled` and `nonce` query parameters.
+package txid
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// ErrInvalid is returned for strings that are not transaction IDs in either form
+var ErrInvalid = errors.New("invalid transaction ID")
+
+// ID is a transaction ID
+type ID struct {
+	AccountID hedera.AccountID
+	// ValidStartSeconds and ValidStartNanos together are the valid start timestamp
+	ValidStartSeconds int64
+	ValidStartNanos   int64
+	// Scheduled is set for the transaction executed by a schedule
+	Scheduled bool
+	// Nonce is non-zero for child transactions
+	Nonce int32
+}
+
+// FromSDK converts an SDK transaction ID
+func FromSDK(txID hedera.TransactionID) (ID, error) {
+	if txID.AccountID == nil || txID.ValidStart == nil {
+		return ID{}, fmt.Errorf("%w: missing account ID or valid start", ErrInvalid)
+	}
+	return ID{
+		AccountID:         *txID.AccountID,
+		ValidStartSeconds: txID.ValidStart.Unix(),
+		ValidStartNanos:   int64(txID.ValidStart.Nanosecond()),
+		Scheduled:         txID.GetScheduled(),
+		Nonce:             txID.GetNonce(),
+	}, nil
+}
+
+// SDK returns the SDK transaction ID
+func (id ID) SDK() hedera.TransactionID {
+	txID := hedera.NewTransactionIDWithValidStart(id.AccountID, id.ValidStart()).
+		SetScheduled(id.Scheduled)
+	if id.Nonce != 0 {
+		txID = txID.SetNonce(id.Nonce)
+	}
+	return txID
+}
+
+// ValidStart returns the valid start timestamp
+func (id ID) ValidStart() time.Time {
+	return time.Unix(id.ValidStartSeconds, id.ValidStartNanos)
+}
+
+// Parse accepts either the SDK form, `0.0.x@s.n[?scheduled][/nonce]`,
+// or the mirror node form, `0.0.x-s-n[?scheduled=true][&nonce=1]`.
+func Parse(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "@") {
+		return parseSDK(s)
+	}
+	return parseMirror(s)
+}
+
+func parseSDK(s string) (ID, error) {
+	var id ID
+	rest, nonceStr, hasNonce := strings.Cut(s, "/")
+	if hasNonce {
+		nonce, err := strconv.ParseInt(nonceStr, 10, 32)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w %q: nonce: %v", ErrInvalid, s, err)
+		}
+		id.Nonce = int32(nonce)
+	}
+	rest, flag, hasFlag := strings.Cut(rest, "?")
+	if hasFlag {
+		if flag != "scheduled" {
+			return ID{}, fmt.Errorf("%w %q: unexpected suffix %q", ErrInvalid, s, flag)
+		}
+		id.Scheduled = true
+	}
+	accountIDStr, validStart, found := strings.Cut(rest, "@")
+	if !found {
+		return ID{}, fmt.Errorf("%w %q: expecting 0.0.x@seconds.nanos", ErrInvalid, s)
+	}
+	seconds, nanos, found := strings.Cut(validStart, ".")
+	if !found {
+		return ID{}, fmt.Errorf("%w %q: expecting 0.0.x@seconds.nanos", ErrInvalid, s)
+	}
+	return id, id.setParts(s, accountIDStr, seconds, nanos)
+}
+
+func parseMirror(s string) (ID, error) {
+	var id ID
+	path, rawQuery, _ := strings.Cut(s, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w %q: %v", ErrInvalid, s, err)
+	}
+	if scheduled := query.Get("scheduled"); scheduled != "" {
+		id.Scheduled, err = strconv.ParseBool(scheduled)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w %q: scheduled: %v", ErrInvalid, s, err)
+		}
+	}
+	if nonceStr := query.Get("nonce"); nonceStr != "" {
+		nonce, err := strconv.ParseInt(nonceStr, 10, 32)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w %q: nonce: %v", ErrInvalid, s, err)
+		}
+		id.Nonce = int32(nonce)
+	}
+	// The account ID itself contains dots, so split from the right
+	nanosIndex := strings.LastIndex(path, "-")
+	if nanosIndex < 0 {
+		return ID{}, fmt.Errorf("%w %q: expecting 0.0.x-seconds-nanos", ErrInvalid, s)
+	}
+	secondsIndex := strings.LastIndex(path[:nanosIndex], "-")
+	if secondsIndex < 0 {
+		return ID{}, fmt.Errorf("%w %q: expecting 0.0.x-seconds-nanos", ErrInvalid, s)
+	}
+	return id, id.setParts(s, path[:secondsIndex], path[secondsIndex+1:nanosIndex], path[nanosIndex+1:])
+}
+
+func (id *ID) setParts(s string, accountIDStr string, seconds string, nanos string) error {
+	accountID, err := hedera.AccountIDFromString(accountIDStr)
+	if err != nil {
+		return fmt.Errorf("%w %q: account ID: %v", ErrInvalid, s, err)
+	}
+	id.AccountID = accountID
+	id.ValidStartSeconds, err = strconv.ParseInt(seconds, 10, 64)
+	if err != nil || id.ValidStartSeconds < 0 {
+		return fmt.Errorf("%w %q: seconds %q", ErrInvalid, s, seconds)
+	}
+	id.ValidStartNanos, err = strconv.ParseInt(nanos, 10, 64)
+	if err != nil || id.ValidStartNanos < 0 || id.ValidStartNanos >= int64(time.Second) || len(nanos) > 9 {
+		return fmt.Errorf("%w %q: nanos %q", ErrInvalid, s, nanos)
+	}
+	return nil
+}
+
+// String returns the SDK form, `0.0.x@s.n[?scheduled][/nonce]`
+func (id ID) String() string {
+	s := fmt.Sprintf("%s@%d.%09d", id.AccountID.String(), id.ValidStartSeconds, id.ValidStartNanos)
+	if id.Scheduled {
+		s += "?scheduled"
+	}
+	if id.Nonce != 0 {
+		s += "/" + strconv.FormatInt(int64(id.Nonce), 10)
+	}
+	return s
+}
+
+// MirrorPath returns the mirror node form used in `/transactions/{id}`, `0.0.x-s-n`
+func (id ID) MirrorPath() string {
+	return fmt.Sprintf("%s-%d-%09d", id.AccountID.String(), id.ValidStartSeconds, id.ValidStartNanos)
+}
+
+// MirrorQuery returns the query parameters that select exactly this transaction
+// from `/transactions/{id}`, which otherwise also returns any scheduled
+// and child transactions that share the same ID.
+func (id ID) MirrorQuery() url.Values {
+	return url.Values{
+		"nonce":     {strconv.FormatInt(int64(id.Nonce), 10)},
+		"scheduled": {strconv.FormatBool(id.Scheduled)},
+	}
+}
+
+// MirrorString returns the mirror node form, with the query parameters
+func (id ID) MirrorString() string {
+	return id.MirrorPath() + "?" + id.MirrorQuery().Encode()
+}
+
+// ToMirror converts an SDK transaction ID into the mirror node path form and query parameters
+func ToMirror(txID hedera.TransactionID) (string, url.Values, error) {
+	id, err := FromSDK(txID)
+	if err != nil {
+		return "", nil, err
+	}
+	return id.MirrorPath(), id.MirrorQuery(), nil
+}
+
+// FromMirror converts the mirror node form into an SDK transaction ID
+func FromMirror(s string) (hedera.TransactionID, error) {
+	id, err := parseMirror(s)
+	if err != nil {
+		return hedera.TransactionID{}, err
+	}
+	return id.SDK(), nil
+}
@@ -0,0 +1,205 @@
+package txid
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+func TestParse(t *testing.T) {
+	account := hedera.AccountID{Account: 1234}
+	tests := []struct {
+		in         string
+		want       ID
+		wantString string
+		wantMirror string
+		wantQuery  url.Values
+	}{
+		{
+			in:         "0.0.1234@1712345678.000000001",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 1},
+			wantString: "0.0.1234@1712345678.000000001",
+			wantMirror: "0.0.1234-1712345678-000000001",
+			wantQuery:  url.Values{"nonce": {"0"}, "scheduled": {"false"}},
+		},
+		{
+			in:         "0.0.1234-1712345678-000000001",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 1},
+			wantString: "0.0.1234@1712345678.000000001",
+			wantMirror: "0.0.1234-1712345678-000000001",
+			wantQuery:  url.Values{"nonce": {"0"}, "scheduled": {"false"}},
+		},
+		{
+			in:         "0.0.1234@1712345678.123456789?scheduled",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 123456789, Scheduled: true},
+			wantString: "0.0.1234@1712345678.123456789?scheduled",
+			wantMirror: "0.0.1234-1712345678-123456789",
+			wantQuery:  url.Values{"nonce": {"0"}, "scheduled": {"true"}},
+		},
+		{
+			in:         "0.0.1234-1712345678-123456789?scheduled=true",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 123456789, Scheduled: true},
+			wantString: "0.0.1234@1712345678.123456789?scheduled",
+			wantMirror: "0.0.1234-1712345678-123456789",
+			wantQuery:  url.Values{"nonce": {"0"}, "scheduled": {"true"}},
+		},
+		{
+			in:         "0.0.1234@1712345678.000000100/2",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 100, Nonce: 2},
+			wantString: "0.0.1234@1712345678.000000100/2",
+			wantMirror: "0.0.1234-1712345678-000000100",
+			wantQuery:  url.Values{"nonce": {"2"}, "scheduled": {"false"}},
+		},
+		{
+			in:         "0.0.1234-1712345678-000000100?nonce=2",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, ValidStartNanos: 100, Nonce: 2},
+			wantString: "0.0.1234@1712345678.000000100/2",
+			wantMirror: "0.0.1234-1712345678-000000100",
+			wantQuery:  url.Values{"nonce": {"2"}, "scheduled": {"false"}},
+		},
+		{
+			in:         "0.0.1234@1712345678.000000000?scheduled/3",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, Scheduled: true, Nonce: 3},
+			wantString: "0.0.1234@1712345678.000000000?scheduled/3",
+			wantMirror: "0.0.1234-1712345678-000000000",
+			wantQuery:  url.Values{"nonce": {"3"}, "scheduled": {"true"}},
+		},
+		{
+			in:         "0.0.1234-1712345678-000000000?scheduled=true&nonce=3",
+			want:       ID{AccountID: account, ValidStartSeconds: 1712345678, Scheduled: true, Nonce: 3},
+			wantString: "0.0.1234@1712345678.000000000?scheduled/3",
+			wantMirror: "0.0.1234-1712345678-000000000",
+			wantQuery:  url.Values{"nonce": {"3"}, "scheduled": {"true"}},
+		},
+		{
+			in:         "  1.2.1234@1712345678.5  ",
+			want:       ID{AccountID: hedera.AccountID{Shard: 1, Realm: 2, Account: 1234}, ValidStartSeconds: 1712345678, ValidStartNanos: 5},
+			wantString: "1.2.1234@1712345678.000000005",
+			wantMirror: "1.2.1234-1712345678-000000005",
+			wantQuery:  url.Values{"nonce": {"0"}, "scheduled": {"false"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			id, err := Parse(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.want {
+				t.Errorf("Parse = %+v, want %+v", id, tt.want)
+			}
+			if got := id.String(); got != tt.wantString {
+				t.Errorf("String = %q, want %q", got, tt.wantString)
+			}
+			if got := id.MirrorPath(); got != tt.wantMirror {
+				t.Errorf("MirrorPath = %q, want %q", got, tt.wantMirror)
+			}
+			if got := id.MirrorQuery().Encode(); got != tt.wantQuery.Encode() {
+				t.Errorf("MirrorQuery = %q, want %q", got, tt.wantQuery.Encode())
+			}
+
+			// Both forms parse back into the same ID
+			for _, s := range []string{id.String(), id.MirrorString()} {
+				again, err := Parse(s)
+				if err != nil {
+					t.Fatalf("Parse(%q): %v", s, err)
+				}
+				if again != id {
+					t.Errorf("Parse(%q) = %+v, want %+v", s, again, id)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"0.0.1234",
+		"0.0.1234@1712345678",
+		"0.0.1234@1712345678.1000000000",
+		"0.0.1234@1712345678.0000000001",
+		"0.0.1234@-1.000000001",
+		"0.0.1234@1712345678.-1",
+		"0.0.1234@1712345678.000000001?pending",
+		"0.0.1234@1712345678.000000001/x",
+		"0.0.1234@1712345678.000000001/99999999999",
+		"0.0.x@1712345678.000000001",
+		"0.0.1234-1712345678",
+		"0.0.1234-1712345678-1000000000",
+		"0.0.1234-1712345678-000000001?scheduled=maybe",
+		"0.0.1234-1712345678-000000001?nonce=x",
+		"0.0.1234-1712345678-000000001?%zz",
+		"1712345678-000000001",
+	} {
+		t.Run(in, func(t *testing.T) {
+			if id, err := Parse(in); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse = %+v, %v, want ErrInvalid", id, err)
+			}
+		})
+	}
+}
+
+func TestToMirrorAndFromMirror(t *testing.T) {
+	validStart := time.Unix(1712345678, 1)
+	tests := []struct {
+		name      string
+		txID      hedera.TransactionID
+		wantPath  string
+		wantQuery string
+	}{
+		{
+			name:      "plain",
+			txID:      hedera.NewTransactionIDWithValidStart(hedera.AccountID{Account: 1234}, validStart),
+			wantPath:  "0.0.1234-1712345678-000000001",
+			wantQuery: "nonce=0&scheduled=false",
+		},
+		{
+			name:      "scheduled",
+			txID:      hedera.NewTransactionIDWithValidStart(hedera.AccountID{Account: 1234}, validStart).SetScheduled(true),
+			wantPath:  "0.0.1234-1712345678-000000001",
+			wantQuery: "nonce=0&scheduled=true",
+		},
+		{
+			name:      "child",
+			txID:      hedera.NewTransactionIDWithValidStart(hedera.AccountID{Account: 1234}, validStart).SetNonce(4),
+			wantPath:  "0.0.1234-1712345678-000000001",
+			wantQuery: "nonce=4&scheduled=false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, query, err := ToMirror(tt.txID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != tt.wantPath || query.Encode() != tt.wantQuery {
+				t.Errorf("ToMirror = %q %q, want %q %q", path, query.Encode(), tt.wantPath, tt.wantQuery)
+			}
+			txID, err := FromMirror(path + "?" + query.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if txID.String() != tt.txID.String() {
+				t.Errorf("FromMirror = %s, want %s", txID.String(), tt.txID.String())
+			}
+			if txID.GetScheduled() != tt.txID.GetScheduled() || txID.GetNonce() != tt.txID.GetNonce() {
+				t.Errorf("FromMirror lost the scheduled flag or the nonce: %s", txID.String())
+			}
+			if !txID.ValidStart.Equal(validStart) {
+				t.Errorf("FromMirror valid start %s, want %s", txID.ValidStart, validStart)
+			}
+		})
+	}
+
+	if _, _, err := ToMirror(hedera.TransactionID{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ToMirror of an empty ID: %v, want ErrInvalid", err)
+	}
+	// FromMirror only takes the mirror node form
+	if _, err := FromMirror("0.0.1234@1712345678.000000001"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("FromMirror of the SDK form: %v, want ErrInvalid", err)
+	}
+}
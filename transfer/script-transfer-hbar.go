@@ -1,174 +1,259 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"log"
-	"math"
-	"os"
-	"sort"
 	"strings"
-	"time"
 
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"github.com/imroc/req/v3"
-	"github.com/joho/godotenv"
+
+	"lib/failure"
+	"lib/mirror"
+	"lib/operator"
+	"lib/output"
+	"lib/signers"
+	"lib/transferlist"
+	"lib/txid"
 )
 
-type TransferMNAPIResponse struct {
-	Account string `json:"account"`
-	Amount  int64  `json:"amount"`
-}
-type TransferTransactionMNAPIResponse struct {
-	Transactions []struct {
-		Transfers []TransferMNAPIResponse `json:"transfers"`
-	} `json:"transactions"`
+var (
+	transfers    transferlist.List
+	transferFile = flag.String("file", "", "path of a .csv or .json file with the transfers")
+	signerKeys   operator.KeyList
+	memo         = flag.String("memo", "Hello Future World transfer - xyz", "transaction memo")
+)
+
+func init() {
+	flag.Var(&transfers, "transfer", "debit or credit, as `0.0.x=amount`, in any unit, e.g. 0.0.200=1.5ℏ, may be repeated")
+	flag.Var(&signerKeys, "key", "private key of a debited account that is not configured in .env, may be repeated")
 }
 
 func main() {
+	operatorOpts := operator.RegisterFlags(flag.CommandLine)
+	report := output.RegisterFlags(flag.CommandLine, "transfer-hbar")
+	flag.Parse()
+	if err := report.Begin(); err != nil {
+		failure.Exit(err)
+	}
+
 	fmt.Println("🏁 Hello Future World - Transfer Hbar - start")
 
-	// Load environment variables from .env file
-	err := godotenv.Load("../.env")
+	// With `-output json`, the results are written to stdout as a single JSON document
+	// Any error is printed together with its kind,
+	// and the process exits with the exit code for that kind
+	err := run(*operatorOpts, report)
+	report.Finish(err)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		failure.Exit(err)
 	}
 
-	// Initialize the operator account
-	operatorIdStr := os.Getenv("OPERATOR_ACCOUNT_ID")
-	operatorKeyStr := os.Getenv("OPERATOR_ACCOUNT_PRIVATE_KEY")
-	if operatorIdStr == "" || operatorKeyStr == "" {
-		log.Fatal("Must set OPERATOR_ACCOUNT_ID, OPERATOR_ACCOUNT_PRIVATE_KEY")
-	}
+	fmt.Println("🎉 Hello Future World - Transfer Hbar - complete")
+}
 
-	operatorId, _ := hedera.AccountIDFromString(operatorIdStr)
-	// Necessary because Go SDK v2.37.0 does not handle the `0x` prefix automatically
-	// Ref: https://github.com/hashgraph/hedera-sdk-go/issues/1057
-	operatorKeyStr = strings.TrimPrefix(operatorKeyStr, "0x")
-	operatorKey, _ := hedera.PrivateKeyFromStringECDSA(operatorKeyStr)
+func run(operatorOpts operator.Options, report *output.Report) error {
+	// Load the operator account from flags, environment variables, or the .env file
+	operatorConfig, err := operator.Load(operatorOpts)
+	if err != nil {
+		return fmt.Errorf("loading operator config: %w", err)
+	}
+	report.SetOperator(operatorConfig)
+	operatorId := operatorConfig.AccountID
+	operatorKey := operatorConfig.PrivateKey
 	fmt.Printf("Using account: %s\n", operatorId)
-	fmt.Printf("Using operatorKey: %s\n", operatorKeyStr)
+	fmt.Printf("Using operatorKey: %s\n", operatorKey.StringRaw())
+
+	// The transfer list is validated to net to zero before any transaction is frozen,
+	// and split into batches when it has more accounts than a single transaction accepts
+	transferList, err := loadTransferList(operatorId)
+	if err != nil {
+		return failure.Wrap(failure.KindConfig, err)
+	}
+	batches, err := transferList.Batches(transferlist.MaxTransfers)
+	if err != nil {
+		return failure.Wrap(failure.KindConfig, err)
+	}
+	fmt.Printf("Transferring %d debits and credits, in %d transaction(s)\n", len(transferList), len(batches))
 
 	// The client operator ID and key is the account that will be automatically set to pay for the transaction fees for each transaction
-	client := hedera.ClientForTestnet()
-	client.SetOperator(operatorId, operatorKey)
+	// The client also sets the default maximum transaction fee and query payment
+	client, err := operatorConfig.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+	// Close the client when done, including when returning early with an error
+	defer client.Close()
+
+	// The operator account and the ACCOUNT_0, ACCOUNT_1, ... accounts from the .env file
+	// sign for the accounts in the transfer list that they debit
+	keyring := signers.New(operatorConfig, signerKeys...)
+	mirrorClient := mirror.NewClient(operatorConfig.Network.MirrorNodeURL)
+
+	transferTxIds := make([]hedera.TransactionID, len(batches))
+	for i, batch := range batches {
+		transferTxIds[i], err = transferHbar(client, mirrorClient, batch, transferName(i, len(batches)), operatorId, keyring, report)
+		if err != nil {
+			return err
+		}
+	}
+
+	// NOTE: Query HBAR balance using AccountBalanceQuery
+	newAccountBalance, err := hedera.NewAccountBalanceQuery().
+		SetAccountID(operatorId).
+		Execute(client)
+	if err != nil {
+		return fmt.Errorf("executing AccountBalanceQuery: %w", err)
+	}
+	newHbarBalance := newAccountBalance.Hbars
+	fmt.Printf("The new account balance after the transfer: %s\n", newHbarBalance.String())
+	report.Set("newAccountBalance", newHbarBalance.AsTinybar())
 
-	// Set the default maximum transaction fee (in HBAR)
-	client.SetDefaultMaxTransactionFee(hedera.HbarFrom(100, hedera.HbarUnits.Hbar))
-	// Set the default maximum payment for queries (in HBAR)
-	client.SetDefaultMaxQueryPayment(hedera.HbarFrom(50, hedera.HbarUnits.Hbar))
+	// View the transaction in HashScan
+	fmt.Println("🟣 View the transfer transaction transaction in HashScan")
+	for _, transferTxId := range transferTxIds {
+		transferTxVerifyHashscanUrl :=
+			operatorConfig.Network.ExplorerEntityURL("transaction", transferTxId)
+		fmt.Printf("Copy and paste this URL in your browser: %s\n", transferTxVerifyHashscanUrl)
+	}
 
-	fmt.Println("🟣 Creating, signing, and submitting the transfer transaction")
+	for i, transferTxId := range transferTxIds {
+		err = verifyTransfer(mirrorClient, transferTxId, batches[i], transferName(i, len(batches)), report)
+		if err != nil {
+			return err
+		}
+	}
+	return report.FillFromMirror(context.Background(), mirrorClient)
+}
 
-	recipientAccount1, _ := hedera.AccountIDFromString("0.0.200")
-	recipientAccount2, _ := hedera.AccountIDFromString("0.0.201")
-	transferTx, _ := hedera.NewTransferTransaction().
-		SetTransactionMemo(fmt.Sprintf("Hello Future World transfer - xyz")).
+// loadTransferList reads the transfers from the `-transfer` flags and the `-file` file.
+// Without either, the operator account transfers 3 HBAR, split between accounts 0.0.200 and 0.0.201.
+func loadTransferList(operatorId hedera.AccountID) (transferlist.List, error) {
+	transferList := append(transferlist.List{}, transfers...)
+	if *transferFile != "" {
+		fileTransfers, err := transferlist.ReadFile(*transferFile)
+		if err != nil {
+			return nil, fmt.Errorf("-file: %w", err)
+		}
+		transferList = append(transferList, fileTransfers...)
+	}
+	if len(transferList) > 0 {
+		return transferList, nil
+	}
+	return transferlist.List{
 		// Debit  3 HBAR from the operator account (sender)
-		AddHbarTransfer(operatorId, hedera.HbarFrom(-3, hedera.HbarUnits.Hbar)).
+		{AccountID: operatorId, Amount: hedera.HbarFrom(-3, hedera.HbarUnits.Hbar)},
 		// Credit 1 HBAR to account 0.0.200 (1st recipient)
-		AddHbarTransfer(recipientAccount1, hedera.HbarFrom(1, hedera.HbarUnits.Hbar)).
+		{AccountID: hedera.AccountID{Account: 200}, Amount: hedera.HbarFrom(1, hedera.HbarUnits.Hbar)},
 		// Credit 2 HBAR to account 0.0.201 (2nd recipient)
-		AddHbarTransfer(recipientAccount2, hedera.HbarFrom(2, hedera.HbarUnits.Hbar)).
+		{AccountID: hedera.AccountID{Account: 201}, Amount: hedera.HbarFrom(2, hedera.HbarUnits.Hbar)},
+	}, nil
+}
+
+// transferName names each transaction in the report, numbering them when there is more than one batch
+func transferName(i int, count int) string {
+	if count == 1 {
+		return "transfer"
+	}
+	return fmt.Sprintf("transfer%d", i+1)
+}
+
+// transferHbar submits one batch of the transfer list, which nets to zero
+func transferHbar(client *hedera.Client, mirrorClient *mirror.Client, batch transferlist.List, name string, operatorId hedera.AccountID, keyring *signers.Keyring, report *output.Report) (hedera.TransactionID, error) {
+	fmt.Printf("🟣 Creating, signing, and submitting the %s transaction\n", name)
+	for _, entry := range batch {
+		fmt.Printf("  %-15s %s\n", entry.AccountID.String(), entry.Amount.String())
+	}
+
+	transferTx, err := batch.Transaction().
+		SetTransactionMemo(*memo).
 		// Freeze the transaction to prepare for signing
 		FreezeWith(client)
+	if err != nil {
+		return hedera.TransactionID{}, fmt.Errorf("freezing TransferTransaction: %w", err)
+	}
 
 	// Get the transaction ID for the transfer transaction
 	transferTxId := transferTx.GetTransactionID()
 	fmt.Printf("The transfer transaction ID: %s\n", transferTxId.String())
 
-	// Sign the transaction with the account that is being debited (operator account) and the transaction fee payer account (operator account)
-	// Since the account that is being debited and the account that is paying for the transaction are the same, only one account's signature is required
-	transferTxSigned := transferTx.Sign(operatorKey)
+	// Sign the transaction with the accounts that are being debited and the transaction fee payer account (operator account)
+	// When the operator account is the only account that is being debited, only one account's signature is required
+	requiredSigners := append([]hedera.AccountID{operatorId}, batch.Debited()...)
+	signingKeys, missingSigners := keyring.Keys(requiredSigners)
+	for _, accountId := range missingSigners {
+		fmt.Printf("⚠️ No key configured for the debited account %s, the transaction may fail with INVALID_SIGNATURE\n", accountId.String())
+	}
+	transferTxSigned := transferTx
+	for _, key := range signingKeys {
+		transferTxSigned = transferTxSigned.Sign(key)
+	}
 
 	// Submit the transaction to the Hedera Testnet
 	transferTxSubmitted, err := transferTxSigned.Execute(client)
 	if err != nil {
-		log.Fatalf("Error executing TransferTransaction: %v\n", err)
+		err = keyring.Diagnose(context.Background(), mirrorClient, err, requiredSigners)
+		return hedera.TransactionID{}, fmt.Errorf("executing TransferTransaction: %w", err)
 	}
 
 	// Get the transfer transaction receipt
 	transferTxReceipt, err := transferTxSubmitted.GetReceipt(client)
 	if err != nil {
-		log.Fatalf("Error getting receipt for TransferTransaction: %v\n", err)
+		// A missing signature of a debited account fails at consensus, not at precheck
+		err = keyring.Diagnose(context.Background(), mirrorClient, err, requiredSigners)
+		return hedera.TransactionID{}, fmt.Errorf("getting receipt for TransferTransaction: %w", err)
 	}
 	transactionStatus := transferTxReceipt.Status
 	fmt.Printf("The transfer transaction status is: %s\n", transactionStatus.String())
+	report.AddTransaction(name, transferTxId, transactionStatus)
+	return transferTxId, nil
+}
 
-	// NOTE: Query HBAR balance using AccountBalanceQuery
-	newAccountBalance, _ := hedera.NewAccountBalanceQuery().
-		SetAccountID(operatorId).
-		Execute(client)
-	newHbarBalance := newAccountBalance.Hbars
-	fmt.Printf("The new account balance after the transfer: %s\n", newHbarBalance.String())
-
-	client.Close()
-
-	// View the transaction in HashScan
-	fmt.Println("🟣 View the transfer transaction transaction in HashScan")
-	transferTxVerifyHashscanUrl :=
-		fmt.Sprintf("https://hashscan.io/testnet/transaction/%s", transferTxId.String())
-	fmt.Printf("Copy and paste this URL in your browser: %s\n", transferTxVerifyHashscanUrl)
-
-	fmt.Println("🟣 Get transfer transaction data from the Hedera Mirror Node")
-
-	// Wait for 6s for record files (blocks) to propagate to mirror nodes
-	time.Sleep(6 * time.Second)
+// verifyTransfer reads the transfer transaction back from the mirror node,
+// and prints the HBAR transfers of the accounts in the batch.
+// The amount of the fee payer account includes the transaction fee.
+func verifyTransfer(mirrorClient *mirror.Client, transferTxId hedera.TransactionID, batch transferlist.List, name string, report *output.Report) error {
+	fmt.Printf("🟣 Get %s transaction data from the Hedera Mirror Node\n", name)
 
 	// The transfer transaction mirror node API request
-	transferTxIdMirrorNodeFormat, _ :=
-		ConvertTransactionIDForMirrorNodeAPI(transferTxId)
+	// The transaction ID has to be converted to the correct format to pass in the mirror node query (0.0.x@x.x to 0.0.x-x-x)
+	// The scheduled flag and nonce are passed as query parameters
+	transferTxIdMirrorNodeFormat, transferTxVerifyMirrorNodeApiQuery, err :=
+		txid.ToMirror(transferTxId)
+	if err != nil {
+		return fmt.Errorf("converting transaction ID: %w", err)
+	}
 	transferTxVerifyMirrorNodeApiUrl :=
-		fmt.Sprintf("https://testnet.mirrornode.hedera.com/api/v1/transactions/%s?nonce=0", transferTxIdMirrorNodeFormat)
+		mirrorClient.URL(fmt.Sprintf("transactions/%s", transferTxIdMirrorNodeFormat), transferTxVerifyMirrorNodeApiQuery)
 	fmt.Printf("The transfer transaction Hedera Mirror Node API URL: %s\n", transferTxVerifyMirrorNodeApiUrl)
 
-	httpResp, err := req.R().Get(transferTxVerifyMirrorNodeApiUrl)
+	// Wait for record files (blocks) to propagate to mirror nodes,
+	// by polling until the transaction is visible
+	transferTxResp, err := mirrorClient.WaitForTransaction(context.Background(), mirror.DefaultBackoff, transferTxIdMirrorNodeFormat, transferTxVerifyMirrorNodeApiQuery)
 	if err != nil {
-		log.Fatalf("Failed to fetch account balance URL: %v", err)
-	}
-	var transferTxResp TransferTransactionMNAPIResponse
-	err = json.Unmarshal(httpResp.Bytes(), &transferTxResp)
-	if err != nil {
-		log.Fatalf("Failed to parse JSON of response fetched from account balance URL: %v", err)
+		report.AddMirrorVerification(name, transferTxVerifyMirrorNodeApiUrl, nil, err)
+		return fmt.Errorf("fetching transfer transaction: %w", err)
 	}
 	transferJsonAccountTransfers := transferTxResp.Transactions[0].Transfers
 
-	// Discard all entries whose values are less than 0.1 hbars
-	// as these are network fees
-	var filteredTransfers []TransferMNAPIResponse
+	// Discard the entries of accounts that are not in the batch,
+	// as these are network fees paid to the node and the fee collection accounts
+	batchAccounts := map[string]bool{}
+	for _, entry := range batch {
+		batchAccounts[entry.AccountID.String()] = true
+	}
+	var filteredTransfers []mirror.Transfer
 	for _, entry := range transferJsonAccountTransfers {
-		if math.Abs(float64(entry.Amount)) >= 100_000_00 {
+		if batchAccounts[entry.Account] {
 			filteredTransfers = append(filteredTransfers, entry)
 		}
 	}
-	sort.Slice(filteredTransfers, func(i, j int) bool {
-		return filteredTransfers[i].Amount < filteredTransfers[j].Amount
-	})
-	var transferJsonAccountTransfersFinalAmounts []map[string]string
-	for _, entry := range filteredTransfers {
-		transferJsonAccountTransfersFinalAmounts = append(transferJsonAccountTransfersFinalAmounts, map[string]string{
-			"AccountID": entry.Account,
-			"Amount":    hedera.HbarFromTinybar(entry.Amount).ToString(hedera.HbarUnits.Hbar),
-		})
-	}
+
+	report.AddMirrorVerification(name, transferTxVerifyMirrorNodeApiUrl, filteredTransfers, nil)
 
 	fmt.Printf("%-15s %-15s\n", "AccountID", "Amount")
 	fmt.Println(strings.Repeat("-", 30))
-	for _, entry := range transferJsonAccountTransfersFinalAmounts {
-		fmt.Printf("%-15s %-15s\n", entry["AccountID"], entry["Amount"])
-	}
-
-	fmt.Println("🎉 Hello Future World - Transfer Hbar - complete")
-}
-
-func ConvertTransactionIDForMirrorNodeAPI(txID hedera.TransactionID) (string, error) {
-	// The transaction ID has to be converted to the correct format to pass in the mirror node query (0.0.x@x.x to 0.0.x-x-x)
-	parts := strings.SplitN(txID.String(), "@", 2)
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid transaction ID format: %s", txID)
+	for _, entry := range filteredTransfers {
+		fmt.Printf("%-15s %-15s\n", entry.Account, hedera.HbarFromTinybar(entry.Amount).ToString(hedera.HbarUnits.Hbar))
 	}
-
-	convertedPart := strings.ReplaceAll(parts[1], ".", "-")
-
-	return fmt.Sprintf("%s-%s", parts[0], convertedPart), nil
+	return nil
 }
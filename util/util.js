@@ -633,14 +633,6 @@ function displayDuration(ms) {
   return out;
 }
 
-function convertTransactionIdForMirrorNodeApi(txId) {
-  // The transaction ID has to be converted to the correct format to pass in the mirror node query (0.0.x@x.x to 0.0.x-x-x)
-  let [txIdA, txIdB] = txId.toString().split('@');
-  txIdB = txIdB.replace('.', '-');
-  const txIdMirrorNodeFormat = `${txIdA}-${txIdB}`;
-  return txIdMirrorNodeFormat;
-}
-
 async function queryAccountByEvmAddress(evmAddress) {
   let accountId;
   let accountBalance;
@@ -873,7 +865,6 @@ module.exports = {
   writeLoggerFile,
   logMetricsSummary,
 
-  convertTransactionIdForMirrorNodeApi,
   queryAccountByEvmAddress,
   queryAccountByPrivateKey,
   isHexPrivateKey,